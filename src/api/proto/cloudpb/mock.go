@@ -18,4 +18,4 @@
 
 package cloudpb
 
-//go:generate mockgen -source=cloudapi.pb.go -destination=mock/cloudapi_mock.gen.go OrganizationServiceServer,ArtifactTrackerServer,VizierClusterInfoServer,VizierDeploymentKeyManagerServer,ScriptMgrServer,AutocompleteServiceServer,APIKeyManagerServer
+//go:generate mockgen -source=cloudapi.pb.go -destination=mock/cloudapi_mock.gen.go OrganizationServiceServer,ArtifactTrackerServer,VizierClusterInfoServer,VizierDeploymentKeyManagerServer,ScriptMgrServer,AutocompleteServiceServer,APIKeyManagerServer,APIHealthServer
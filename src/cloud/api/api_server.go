@@ -29,9 +29,11 @@ import (
 
 	"github.com/gorilla/handlers"
 	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"google.golang.org/grpc"
 
 	"px.dev/pixie/src/api/proto/cloudpb"
 	"px.dev/pixie/src/api/proto/vizierpb"
@@ -63,6 +65,9 @@ func init() {
 	pflag.String("elastic_username", "elastic", "Username for access to elastic cluster")
 	pflag.String("elastic_password", "", "Password for access to elastic")
 	pflag.String("allowed_origins", "", "The allowed origins for CORS")
+	pflag.Duration("artifact_tracker_timeout", 10*time.Second, "The timeout for each call to the artifact tracker")
+	pflag.Float64("autocomplete_org_rate_limit", 20, "The number of autocomplete requests per second allowed for each org")
+	pflag.Int("autocomplete_org_rate_limit_burst", 40, "The number of autocomplete requests an org may burst above its rate limit")
 }
 
 func main() {
@@ -148,6 +153,7 @@ func main() {
 	// This handles all the pprof endpoints.
 	mux.Handle("/debug/", http.DefaultServeMux)
 	healthz.RegisterDefaultChecks(mux)
+	mux.Handle("/metrics", promhttp.Handler())
 
 	// API service needs to convert any cookies into an augmented token in bearer auth.
 	serverOpts := &server.GRPCServerOptions{
@@ -159,10 +165,16 @@ func main() {
 			return controller.GetAugmentedTokenGRPC(ctx, apiEnv)
 		},
 		DisableAuth: map[string]bool{
-			"/px.cloudapi.ArtifactTracker/GetArtifactList": true,
-			"/px.cloudapi.ArtifactTracker/GetDownloadLink": true,
-			"/pl.cloudapi.ArtifactTracker/GetArtifactList": true,
-			"/pl.cloudapi.ArtifactTracker/GetDownloadLink": true,
+			"/px.cloudapi.ArtifactTracker/GetArtifactList":  true,
+			"/px.cloudapi.ArtifactTracker/GetDownloadLink":  true,
+			"/pl.cloudapi.ArtifactTracker/GetArtifactList":  true,
+			"/pl.cloudapi.ArtifactTracker/GetDownloadLink":  true,
+			"/px.cloudapi.ArtifactTracker/GetLatestVersion": true,
+			"/pl.cloudapi.ArtifactTracker/GetLatestVersion": true,
+		},
+		// Give each request a budget for retrying failed downstream calls.
+		GRPCServerOpts: []grpc.ServerOption{
+			grpc.ChainUnaryInterceptor(apienv.RetryBudgetUnaryServerInterceptor()),
 		},
 	}
 
@@ -171,13 +183,14 @@ func main() {
 	if viper.GetString("allowed_origins") != "" {
 		allowedOrigins = append(allowedOrigins, strings.Split(viper.GetString("allowed_origins"), ",")...)
 	}
-	s := server.NewPLServerWithOptions(env, handlers.CORS(services.DefaultCORSConfig(allowedOrigins)...)(mux), serverOpts)
+	s := server.NewPLServerWithOptions(env, handlers.CORS(services.DefaultCORSConfig(allowedOrigins)...)(apienv.RetryBudgetMiddleware(mux)), serverOpts)
 
 	imageAuthServer := &controller.VizierImageAuthServer{}
 	cloudpb.RegisterVizierImageAuthorizationServer(s.GRPCServer(), imageAuthServer)
 
 	artifactTrackerServer := controller.ArtifactTrackerServer{
 		ArtifactTrackerClient: at,
+		Timeout:               viper.GetDuration("artifact_tracker_timeout"),
 	}
 	cloudpb.RegisterArtifactTrackerServer(s.GRPCServer(), artifactTrackerServer)
 
@@ -235,7 +248,11 @@ func main() {
 	}()
 	defer close(quitCh)
 
-	as := &controller.AutocompleteServer{Suggester: esSuggester}
+	as := &controller.AutocompleteServer{
+		Suggester:         autocomplete.NewCachingSuggester(esSuggester, autocomplete.DefaultSuggestionCacheTTL),
+		OrgRateLimit:      viper.GetFloat64("autocomplete_org_rate_limit"),
+		OrgRateLimitBurst: viper.GetInt("autocomplete_org_rate_limit_burst"),
+	}
 	cloudpb.RegisterAutocompleteServiceServer(s.GRPCServer(), as)
 
 	profileServer := &controller.ProfileServer{ProfileServiceClient: pc}
@@ -247,6 +264,9 @@ func main() {
 	us := &controller.UserServiceServer{ProfileServiceClient: pc}
 	cloudpb.RegisterUserServiceServer(s.GRPCServer(), us)
 
+	hs := &controller.APIHealthServer{VzMgr: vc, ProfileServiceClient: pc, ArtifactTrackerClient: at}
+	cloudpb.RegisterAPIHealthServer(s.GRPCServer(), hs)
+
 	gqlEnv := controller.GraphQLEnv{
 		ArtifactTrackerServer: artifactTrackerServer,
 		VizierClusterInfo:     cis,
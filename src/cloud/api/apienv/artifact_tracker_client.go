@@ -24,7 +24,6 @@ import (
 	"google.golang.org/grpc"
 
 	"px.dev/pixie/src/cloud/artifact_tracker/artifacttrackerpb"
-	"px.dev/pixie/src/shared/services"
 )
 
 func init() {
@@ -33,7 +32,7 @@ func init() {
 
 // NewArtifactTrackerClient creates a new artifact tracker RPC client stub.
 func NewArtifactTrackerClient() (artifacttrackerpb.ArtifactTrackerClient, error) {
-	dialOpts, err := services.GetGRPCClientDialOpts()
+	dialOpts, err := getDownstreamDialOpts()
 	if err != nil {
 		return nil, err
 	}
@@ -24,7 +24,6 @@ import (
 	"google.golang.org/grpc"
 
 	"px.dev/pixie/src/cloud/profile/profilepb"
-	"px.dev/pixie/src/shared/services"
 )
 
 func init() {
@@ -33,7 +32,7 @@ func init() {
 
 // NewProfileServiceClient creates a new profile RPC client stub.
 func NewProfileServiceClient() (profilepb.ProfileServiceClient, error) {
-	dialOpts, err := services.GetGRPCClientDialOpts()
+	dialOpts, err := getDownstreamDialOpts()
 	if err != nil {
 		return nil, err
 	}
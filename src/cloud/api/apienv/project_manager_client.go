@@ -24,7 +24,6 @@ import (
 	"google.golang.org/grpc"
 
 	"px.dev/pixie/src/cloud/project_manager/projectmanagerpb"
-	"px.dev/pixie/src/shared/services"
 )
 
 func init() {
@@ -33,7 +32,7 @@ func init() {
 
 // NewProjectManagerServiceClient creates a new auth RPC client stub.
 func NewProjectManagerServiceClient() (projectmanagerpb.ProjectManagerServiceClient, error) {
-	dialOpts, err := services.GetGRPCClientDialOpts()
+	dialOpts, err := getDownstreamDialOpts()
 	if err != nil {
 		return nil, err
 	}
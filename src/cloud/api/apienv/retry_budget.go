@@ -0,0 +1,178 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package apienv
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"px.dev/pixie/src/shared/services"
+)
+
+func init() {
+	pflag.Int("downstream_retry_budget", 3, "The max number of retries shared by all downstream calls made while serving a single request. 0 disables retries")
+	pflag.Duration("downstream_retry_backoff", 50*time.Millisecond, "The base delay before retrying a downstream call, doubling on each subsequent retry")
+}
+
+// retryableMethods are the downstream methods that are safe to retry, because they only read state.
+// Methods that create or modify state are never retried, since the failed attempt may have been applied.
+var retryableMethods = map[string]bool{
+	"/px.services.ArtifactTracker/GetArtifactList":                     true,
+	"/px.services.ArtifactTracker/GetDownloadLink":                     true,
+	"/px.services.ProfileService/GetOrg":                               true,
+	"/px.services.ProfileService/GetOrgByDomain":                       true,
+	"/px.services.ProfileService/GetOrgs":                              true,
+	"/px.services.ProfileService/GetUser":                              true,
+	"/px.services.ProfileService/GetUserByEmail":                       true,
+	"/px.services.ProfileService/GetUserSettings":                      true,
+	"/px.services.ProfileService/GetUsersInOrg":                        true,
+	"/px.services.ProfileService/ListOrgInvites":                       true,
+	"/px.services.ProjectManagerService/GetProjectByName":              true,
+	"/px.services.ProjectManagerService/GetProjectForOrg":              true,
+	"/px.services.ProjectManagerService/IsProjectAvailable":            true,
+	"/px.services.ScriptMgrService/GetLiveViewContents":                true,
+	"/px.services.ScriptMgrService/GetLiveViews":                       true,
+	"/px.services.ScriptMgrService/GetScriptContents":                  true,
+	"/px.services.ScriptMgrService/GetScripts":                         true,
+	"/px.services.ScriptMgrService/SearchScripts":                      true,
+	"/px.services.internal.VZDeploymentKeyService/Get":                 true,
+	"/px.services.internal.VZDeploymentKeyService/GetDeployedClusters": true,
+	"/px.services.internal.VZDeploymentKeyService/List":                true,
+	"/px.services.internal.VZMgrService/GetVizierConnectionInfo":       true,
+	"/px.services.internal.VZMgrService/GetVizierInfo":                 true,
+	"/px.services.internal.VZMgrService/GetVizierInfos":                true,
+	"/px.services.internal.VZMgrService/GetViziersByOrg":               true,
+	"/px.services.internal.VZMgrService/GetViziersByShard":             true,
+}
+
+type retryBudgetKey struct{}
+
+// RetryBudget caps the number of retries made across all of the downstream calls for a single request,
+// so that retries on each downstream service can't compound into a retry storm during an incident.
+type RetryBudget struct {
+	remaining int64
+}
+
+// NewRetryBudget creates a retry budget that allows up to n retries.
+func NewRetryBudget(n int) *RetryBudget {
+	return &RetryBudget{remaining: int64(n)}
+}
+
+// TryAcquire takes a retry from the budget, returning false if the budget is exhausted.
+func (b *RetryBudget) TryAcquire() bool {
+	return atomic.AddInt64(&b.remaining, -1) >= 0
+}
+
+// Remaining returns the number of retries left in the budget.
+func (b *RetryBudget) Remaining() int {
+	r := atomic.LoadInt64(&b.remaining)
+	if r < 0 {
+		return 0
+	}
+	return int(r)
+}
+
+// ContextWithRetryBudget returns a context that carries the given retry budget.
+func ContextWithRetryBudget(ctx context.Context, b *RetryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, b)
+}
+
+// RetryBudgetFromContext returns the retry budget in the context, or nil if there is none.
+func RetryBudgetFromContext(ctx context.Context) *RetryBudget {
+	b, _ := ctx.Value(retryBudgetKey{}).(*RetryBudget)
+	return b
+}
+
+func newRetryBudgetFromFlags() *RetryBudget {
+	return NewRetryBudget(viper.GetInt("downstream_retry_budget"))
+}
+
+// RetryBudgetUnaryServerInterceptor attaches a new retry budget to each incoming GRPC request.
+func RetryBudgetUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(ContextWithRetryBudget(ctx, newRetryBudgetFromFlags()), req)
+	}
+}
+
+// RetryBudgetMiddleware attaches a new retry budget to each incoming HTTP request.
+func RetryBudgetMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(ContextWithRetryBudget(r.Context(), newRetryBudgetFromFlags())))
+	})
+}
+
+// retryDelay returns the jittered delay before the given retry (starting at 1), picked uniformly from
+// [d/2, d), where d doubles on each retry, so that clients retrying in lockstep spread out.
+func retryDelay(base time.Duration, retry int) time.Duration {
+	d := base << uint(retry-1)
+	if d <= 1 {
+		return d
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
+
+// waitForRetry waits for the given delay, returning false if the context is done first.
+func waitForRetry(ctx context.Context, delay time.Duration) bool {
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// RetryUnaryClientInterceptor retries read-only downstream calls that failed because the downstream service was
+// unavailable, with a jittered exponential backoff, for as long as the retry budget in the context allows.
+// Calls made without a retry budget, and calls to methods that aren't in retryableMethods, are never retried.
+func RetryUnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		budget := RetryBudgetFromContext(ctx)
+		if budget == nil || !retryableMethods[method] {
+			return err
+		}
+		backoff := viper.GetDuration("downstream_retry_backoff")
+		for retry := 1; status.Code(err) == codes.Unavailable && ctx.Err() == nil && budget.TryAcquire(); retry++ {
+			if !waitForRetry(ctx, retryDelay(backoff, retry)) {
+				return err
+			}
+			err = invoker(ctx, method, req, reply, cc, opts...)
+		}
+		return err
+	}
+}
+
+func getDownstreamDialOpts() ([]grpc.DialOption, error) {
+	dialOpts, err := services.GetGRPCClientDialOpts()
+	if err != nil {
+		return nil, err
+	}
+	return append(dialOpts, grpc.WithChainUnaryInterceptor(RetryUnaryClientInterceptor())), nil
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package apienv_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"px.dev/pixie/src/cloud/api/apienv"
+)
+
+// failingInvoker counts the calls made to a downstream service that always fails with the given code.
+type failingInvoker struct {
+	code  codes.Code
+	calls int
+}
+
+func (f *failingInvoker) invoke(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+	f.calls++
+	return status.Error(f.code, "downstream failed")
+}
+
+func TestRetryUnaryClientInterceptor_SharedBudget(t *testing.T) {
+	ctx := apienv.ContextWithRetryBudget(context.Background(), apienv.NewRetryBudget(2))
+	interceptor := apienv.RetryUnaryClientInterceptor()
+
+	vzmgr := &failingInvoker{code: codes.Unavailable}
+	err := interceptor(ctx, "/px.services.internal.VZMgrService/GetViziersByOrg", nil, nil, nil, vzmgr.invoke)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	// The original call, and two retries.
+	assert.Equal(t, 3, vzmgr.calls)
+
+	// The budget is exhausted, so calls to other downstream services should not be retried.
+	profile := &failingInvoker{code: codes.Unavailable}
+	err = interceptor(ctx, "/px.services.ProfileService/GetOrg", nil, nil, nil, profile.invoke)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 1, profile.calls)
+
+	artifacts := &failingInvoker{code: codes.Unavailable}
+	err = interceptor(ctx, "/px.services.ArtifactTracker/GetArtifactList", nil, nil, nil, artifacts.invoke)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 1, artifacts.calls)
+}
+
+func TestRetryUnaryClientInterceptor_RetriesUntilSuccess(t *testing.T) {
+	budget := apienv.NewRetryBudget(3)
+	ctx := apienv.ContextWithRetryBudget(context.Background(), budget)
+
+	calls := 0
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		if calls == 1 {
+			return status.Error(codes.Unavailable, "downstream failed")
+		}
+		return nil
+	}
+	err := apienv.RetryUnaryClientInterceptor()(ctx, "/px.services.internal.VZMgrService/GetViziersByOrg", nil, nil, nil, invoker)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 2, budget.Remaining())
+}
+
+func TestRetryUnaryClientInterceptor_NonRetryableError(t *testing.T) {
+	budget := apienv.NewRetryBudget(3)
+	ctx := apienv.ContextWithRetryBudget(context.Background(), budget)
+
+	invoker := &failingInvoker{code: codes.NotFound}
+	err := apienv.RetryUnaryClientInterceptor()(ctx, "/px.services.ProfileService/GetOrg", nil, nil, nil, invoker.invoke)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, 1, invoker.calls)
+	assert.Equal(t, 3, budget.Remaining())
+}
+
+func TestRetryUnaryClientInterceptor_NoBudget(t *testing.T) {
+	invoker := &failingInvoker{code: codes.Unavailable}
+	err := apienv.RetryUnaryClientInterceptor()(context.Background(), "/px.services.ProfileService/GetOrg", nil, nil, nil, invoker.invoke)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 1, invoker.calls)
+}
+
+func TestRetryUnaryClientInterceptor_NonRetryableMethod(t *testing.T) {
+	budget := apienv.NewRetryBudget(3)
+	ctx := apienv.ContextWithRetryBudget(context.Background(), budget)
+
+	// The update may have been applied before the connection failed, so it should not be retried.
+	invoker := &failingInvoker{code: codes.Unavailable}
+	err := apienv.RetryUnaryClientInterceptor()(ctx, "/px.services.internal.VZMgrService/UpdateOrInstallVizier", nil, nil, nil, invoker.invoke)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 1, invoker.calls)
+	assert.Equal(t, 3, budget.Remaining())
+}
+
+func TestRetryUnaryClientInterceptor_Backoff(t *testing.T) {
+	viper.Set("downstream_retry_backoff", 20*time.Millisecond)
+	defer viper.Set("downstream_retry_backoff", 0)
+
+	ctx := apienv.ContextWithRetryBudget(context.Background(), apienv.NewRetryBudget(2))
+	invoker := &failingInvoker{code: codes.Unavailable}
+
+	start := time.Now()
+	err := apienv.RetryUnaryClientInterceptor()(ctx, "/px.services.ProfileService/GetOrg", nil, nil, nil, invoker.invoke)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 3, invoker.calls)
+	// The retries wait at least half of the 20ms and 40ms backoffs.
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(30*time.Millisecond))
+}
+
+func TestRetryUnaryClientInterceptor_BackoffCanceled(t *testing.T) {
+	viper.Set("downstream_retry_backoff", time.Hour)
+	defer viper.Set("downstream_retry_backoff", 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	ctx = apienv.ContextWithRetryBudget(ctx, apienv.NewRetryBudget(2))
+	invoker := &failingInvoker{code: codes.Unavailable}
+
+	err := apienv.RetryUnaryClientInterceptor()(ctx, "/px.services.ProfileService/GetOrg", nil, nil, nil, invoker.invoke)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 1, invoker.calls)
+}
+
+func TestRetryBudgetUnaryServerInterceptor(t *testing.T) {
+	viper.Set("downstream_retry_budget", 5)
+	defer viper.Set("downstream_retry_budget", 3)
+
+	_, err := apienv.RetryBudgetUnaryServerInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			budget := apienv.RetryBudgetFromContext(ctx)
+			require.NotNil(t, budget)
+			assert.Equal(t, 5, budget.Remaining())
+			return nil, nil
+		})
+	require.NoError(t, err)
+}
+
+func TestRetryBudgetMiddleware(t *testing.T) {
+	viper.Set("downstream_retry_budget", 0)
+	defer viper.Set("downstream_retry_budget", 3)
+
+	var budget *apienv.RetryBudget
+	h := apienv.RetryBudgetMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		budget = apienv.RetryBudgetFromContext(r.Context())
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/graphql", nil))
+
+	require.NotNil(t, budget)
+	// A budget of 0 disables retries.
+	assert.False(t, budget.TryAcquire())
+}
@@ -24,7 +24,6 @@ import (
 	"google.golang.org/grpc"
 
 	"px.dev/pixie/src/cloud/scriptmgr/scriptmgrpb"
-	"px.dev/pixie/src/shared/services"
 )
 
 func init() {
@@ -33,7 +32,7 @@ func init() {
 
 // NewScriptMgrServiceClient creates a new scriptmgr RPC client stub.
 func NewScriptMgrServiceClient() (scriptmgrpb.ScriptMgrServiceClient, error) {
-	dialOpts, err := services.GetGRPCClientDialOpts()
+	dialOpts, err := getDownstreamDialOpts()
 	if err != nil {
 		return nil, err
 	}
@@ -24,7 +24,6 @@ import (
 	"google.golang.org/grpc"
 
 	"px.dev/pixie/src/cloud/vzmgr/vzmgrpb"
-	"px.dev/pixie/src/shared/services"
 )
 
 func init() {
@@ -33,7 +32,7 @@ func init() {
 
 // NewVZMgrServiceClients creates the vzmgr RPC client stubs.
 func NewVZMgrServiceClients() (vzmgrpb.VZMgrServiceClient, vzmgrpb.VZDeploymentKeyServiceClient, error) {
-	dialOpts, err := services.GetGRPCClientDialOpts()
+	dialOpts, err := getDownstreamDialOpts()
 	if err != nil {
 		return nil, nil, err
 	}
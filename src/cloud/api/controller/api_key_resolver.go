@@ -84,17 +84,24 @@ func apiKeyToResolver(key *cloudpb.APIKey) (*APIKeyResolver, error) {
 // APIKeys lists all of the API keys.
 func (q *QueryResolver) APIKeys(ctx context.Context) ([]*APIKeyResolver, error) {
 	grpcAPI := q.Env.APIKeyMgr
-	res, err := grpcAPI.List(ctx, &cloudpb.ListAPIKeyRequest{})
-	if err != nil {
-		return nil, err
-	}
 	var keys []*APIKeyResolver
-	for _, key := range res.Keys {
-		resolvedKey, err := apiKeyToResolver(key)
+	pageToken := ""
+	for {
+		res, err := grpcAPI.List(ctx, &cloudpb.ListAPIKeyRequest{PageToken: pageToken})
 		if err != nil {
 			return nil, err
 		}
-		keys = append(keys, resolvedKey)
+		for _, key := range res.Keys {
+			resolvedKey, err := apiKeyToResolver(key)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, resolvedKey)
+		}
+		if res.NextPageToken == "" {
+			break
+		}
+		pageToken = res.NextPageToken
 	}
 	// Sort by descending time
 	sort.Slice(keys, func(i, j int) bool { return keys[i].createdAtNs > keys[j].createdAtNs })
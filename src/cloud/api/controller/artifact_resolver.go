@@ -41,6 +41,8 @@ func artifactTypeToProto(a string) cloudpb.ArtifactType {
 		return cloudpb.AT_LINUX_AMD64
 	case "AT_DARWIN_AMD64":
 		return cloudpb.AT_DARWIN_AMD64
+	case "AT_DARWIN_ARM64":
+		return cloudpb.AT_DARWIN_ARM64
 	case "AT_CONTAINER_SET_YAMLS":
 		return cloudpb.AT_CONTAINER_SET_YAMLS
 	case "AT_CONTAINER_SET_LINUX_AMD64":
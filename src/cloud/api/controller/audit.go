@@ -0,0 +1,85 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"px.dev/pixie/src/shared/services/authcontext"
+)
+
+// The actions which are recorded in the audit trail.
+const (
+	AuditActionCreateAPIKey        = "api_key.create"
+	AuditActionDeleteAPIKey        = "api_key.delete"
+	AuditActionCreateDeploymentKey = "deployment_key.create"
+	AuditActionDeleteDeploymentKey = "deployment_key.delete"
+	AuditActionInviteUser          = "user.invite"
+	AuditActionResendInvite        = "user.resend_invite"
+)
+
+// AuditEvent is a record of a mutating call made through the API.
+type AuditEvent struct {
+	// The org and user that made the call. These are empty if the caller isn't a user.
+	OrgID  string
+	UserID string
+	Action string
+	// The ID of the object that the action was taken on. For invites, this is the email of the invited user.
+	TargetID string
+	Time     time.Time
+}
+
+// AuditHook is called with each audit event after the call it records succeeds.
+type AuditHook func(*AuditEvent)
+
+// LogAuditEvent is the default AuditHook, which writes the audit event to the log.
+func LogAuditEvent(e *AuditEvent) {
+	log.WithFields(log.Fields{
+		"audit":     true,
+		"org_id":    e.OrgID,
+		"user_id":   e.UserID,
+		"action":    e.Action,
+		"target_id": e.TargetID,
+		"time":      e.Time,
+	}).Info("Audit event")
+}
+
+// audit records an audit event for the action, taken by the user in the context on the target. If hook is nil, the
+// event is logged.
+func audit(ctx context.Context, hook AuditHook, action string, targetID string) {
+	e := &AuditEvent{
+		Action:   action,
+		TargetID: targetID,
+		Time:     time.Now(),
+	}
+	if sCtx, err := authcontext.FromContext(ctx); err == nil {
+		if claims := sCtx.Claims.GetUserClaims(); claims != nil {
+			e.OrgID = claims.OrgID
+			e.UserID = claims.UserID
+		}
+	}
+
+	if hook == nil {
+		hook = LogAuditEvent
+	}
+	hook(e)
+}
@@ -305,6 +305,11 @@ type ClusterConnectionInfoResolver struct {
 	Token     string
 }
 
+// String returns the connection info with the token redacted, so that it is safe to log.
+func (c *ClusterConnectionInfoResolver) String() string {
+	return RedactedConnectionInfo{IPAddress: c.IPAddress, Token: c.Token}.String()
+}
+
 // ClusterConnection resolves cluster connection information..
 func (q *QueryResolver) ClusterConnection(ctx context.Context, args *clusterArgs) (*ClusterConnectionInfoResolver, error) {
 	grpcAPI := q.Env.VizierClusterInfo
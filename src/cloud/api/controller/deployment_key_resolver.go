@@ -87,17 +87,24 @@ func deploymentKeyToResolver(key *cloudpb.DeploymentKey) (*DeploymentKeyResolver
 // DeploymentKeys lists all of the deployment keys.
 func (q *QueryResolver) DeploymentKeys(ctx context.Context) ([]*DeploymentKeyResolver, error) {
 	grpcAPI := q.Env.VizierDeployKeyMgr
-	res, err := grpcAPI.List(ctx, &cloudpb.ListDeploymentKeyRequest{})
-	if err != nil {
-		return nil, err
-	}
 	var keys []*DeploymentKeyResolver
-	for _, key := range res.Keys {
-		resolvedKey, err := deploymentKeyToResolver(key)
+	pageToken := ""
+	for {
+		res, err := grpcAPI.List(ctx, &cloudpb.ListDeploymentKeyRequest{PageToken: pageToken})
 		if err != nil {
 			return nil, err
 		}
-		keys = append(keys, resolvedKey)
+		for _, key := range res.Keys {
+			resolvedKey, err := deploymentKeyToResolver(key)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, resolvedKey)
+		}
+		if res.NextPageToken == "" {
+			break
+		}
+		pageToken = res.NextPageToken
 	}
 	// Sort by descending time
 	sort.Slice(keys, func(i, j int) bool { return keys[i].createdAtNs > keys[j].createdAtNs })
@@ -20,12 +20,20 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/mail"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/gogo/protobuf/types"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"google.golang.org/grpc/codes"
@@ -34,6 +42,8 @@ import (
 
 	"px.dev/pixie/src/api/proto/cloudpb"
 	"px.dev/pixie/src/api/proto/uuidpb"
+	"px.dev/pixie/src/api/proto/vispb"
+	"px.dev/pixie/src/cloud/api/apienv"
 	"px.dev/pixie/src/cloud/artifact_tracker/artifacttrackerpb"
 	"px.dev/pixie/src/cloud/auth/authpb"
 	"px.dev/pixie/src/cloud/autocomplete"
@@ -44,6 +54,7 @@ import (
 	"px.dev/pixie/src/shared/cvmsgspb"
 	"px.dev/pixie/src/shared/k8s/metadatapb"
 	"px.dev/pixie/src/shared/services/authcontext"
+	"px.dev/pixie/src/shared/services/jwtpb"
 	srvutils "px.dev/pixie/src/shared/services/utils"
 	"px.dev/pixie/src/utils"
 )
@@ -78,6 +89,28 @@ func (v VizierImageAuthServer) GetImageCredentials(context.Context, *cloudpb.Get
 // ArtifactTrackerServer is the GRPC server responsible for providing access to artifacts.
 type ArtifactTrackerServer struct {
 	ArtifactTrackerClient artifacttrackerpb.ArtifactTrackerClient
+	// Timeout is the timeout for each call to the artifact tracker. Defaults to defaultArtifactTrackerTimeout if unset.
+	Timeout time.Duration
+}
+
+const defaultArtifactTrackerTimeout = 10 * time.Second
+
+// withTimeout derives a context for a single call to the artifact tracker from the given context.
+func (a ArtifactTrackerServer) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := a.Timeout
+	if timeout <= 0 {
+		timeout = defaultArtifactTrackerTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// artifactTrackerError converts an error from a call to the artifact tracker made with the given context,
+// so that timeouts are reported as DeadlineExceeded.
+func artifactTrackerError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, "timed out waiting for the artifact tracker")
+	}
+	return err
 }
 
 func getArtifactTypeFromCloudProto(a cloudpb.ArtifactType) versionspb.ArtifactType {
@@ -86,6 +119,8 @@ func getArtifactTypeFromCloudProto(a cloudpb.ArtifactType) versionspb.ArtifactTy
 		return versionspb.AT_LINUX_AMD64
 	case cloudpb.AT_DARWIN_AMD64:
 		return versionspb.AT_DARWIN_AMD64
+	case cloudpb.AT_DARWIN_ARM64:
+		return versionspb.AT_DARWIN_ARM64
 	case cloudpb.AT_CONTAINER_SET_YAMLS:
 		return versionspb.AT_CONTAINER_SET_YAMLS
 	case cloudpb.AT_CONTAINER_SET_LINUX_AMD64:
@@ -103,6 +138,8 @@ func getArtifactTypeFromVersionsProto(a versionspb.ArtifactType) cloudpb.Artifac
 		return cloudpb.AT_LINUX_AMD64
 	case versionspb.AT_DARWIN_AMD64:
 		return cloudpb.AT_DARWIN_AMD64
+	case versionspb.AT_DARWIN_ARM64:
+		return cloudpb.AT_DARWIN_ARM64
 	case versionspb.AT_CONTAINER_SET_YAMLS:
 		return cloudpb.AT_CONTAINER_SET_YAMLS
 	case versionspb.AT_CONTAINER_SET_LINUX_AMD64:
@@ -134,9 +171,11 @@ func (a ArtifactTrackerServer) GetArtifactList(ctx context.Context, req *cloudpb
 	ctx = metadata.AppendToOutgoingContext(ctx, "authorization",
 		fmt.Sprintf("bearer %s", serviceAuthToken))
 
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
 	resp, err := a.ArtifactTrackerClient.GetArtifactList(ctx, atReq)
 	if err != nil {
-		return nil, err
+		return nil, artifactTrackerError(ctx, err)
 	}
 
 	cloudpbArtifacts := make([]*cloudpb.Artifact, len(resp.Artifact))
@@ -160,12 +199,95 @@ func (a ArtifactTrackerServer) GetArtifactList(ctx context.Context, req *cloudpb
 	}, nil
 }
 
+// maxArtifactListWorkers is the maximum number of concurrent artifact tracker lookups in GetArtifactLists.
+const maxArtifactListWorkers = 10
+
+// GetArtifactLists gets the sets of artifact versions for several artifacts.
+func (a ArtifactTrackerServer) GetArtifactLists(ctx context.Context, req *cloudpb.GetArtifactListsRequest) (*cloudpb.GetArtifactListsResponse, error) {
+	// The results are keyed by artifact name, so each name can only be requested once.
+	seen := make(map[string]bool)
+	for _, r := range req.Requests {
+		if seen[r.ArtifactName] {
+			return nil, status.Errorf(codes.InvalidArgument, "artifact %q is requested more than once", r.ArtifactName)
+		}
+		seen[r.ArtifactName] = true
+	}
+
+	resp := &cloudpb.GetArtifactListsResponse{
+		Results: make(map[string]*cloudpb.ArtifactListResult),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxArtifactListWorkers)
+
+	for _, r := range req.Requests {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(r *cloudpb.GetArtifactListRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := &cloudpb.ArtifactListResult{}
+			set, err := a.GetArtifactList(ctx, r)
+			if err != nil {
+				result.Error = status.Convert(err).Message()
+			} else {
+				result.ArtifactSet = set
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			resp.Results[r.ArtifactName] = result
+		}(r)
+	}
+	wg.Wait()
+
+	return resp, nil
+}
+
+// GetLatestVersion gets the latest version of the given artifact.
+func (a ArtifactTrackerServer) GetLatestVersion(ctx context.Context, req *cloudpb.GetLatestVersionRequest) (*cloudpb.GetLatestVersionResponse, error) {
+	atReq := &artifacttrackerpb.GetArtifactListRequest{
+		ArtifactType:      getArtifactTypeFromCloudProto(req.ArtifactType),
+		ArtifactName:      req.ArtifactName,
+		Limit:             1,
+		IncludePrerelease: req.IncludePrerelease,
+	}
+
+	serviceAuthToken, err := getServiceCredentials(viper.GetString("jwt_signing_key"))
+	if err != nil {
+		return nil, err
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization",
+		fmt.Sprintf("bearer %s", serviceAuthToken))
+
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+	resp, err := a.ArtifactTrackerClient.GetArtifactList(ctx, atReq)
+	if err != nil {
+		return nil, artifactTrackerError(ctx, err)
+	}
+	if len(resp.Artifact) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no versions found for artifact %s", req.ArtifactName)
+	}
+
+	return &cloudpb.GetLatestVersionResponse{
+		VersionStr: resp.Artifact[0].VersionStr,
+	}, nil
+}
+
 // GetDownloadLink gets the download link for the given artifact.
 func (a ArtifactTrackerServer) GetDownloadLink(ctx context.Context, req *cloudpb.GetDownloadLinkRequest) (*cloudpb.GetDownloadLinkResponse, error) {
+	var preferredTypes []versionspb.ArtifactType
+	for _, at := range req.PreferredTypes {
+		preferredTypes = append(preferredTypes, getArtifactTypeFromCloudProto(at))
+	}
+
 	atReq := &artifacttrackerpb.GetDownloadLinkRequest{
-		ArtifactName: req.ArtifactName,
-		VersionStr:   req.VersionStr,
-		ArtifactType: getArtifactTypeFromCloudProto(req.ArtifactType),
+		ArtifactName:   req.ArtifactName,
+		VersionStr:     req.VersionStr,
+		ArtifactType:   getArtifactTypeFromCloudProto(req.ArtifactType),
+		PreferredTypes: preferredTypes,
 	}
 
 	serviceAuthToken, err := getServiceCredentials(viper.GetString("jwt_signing_key"))
@@ -175,15 +297,21 @@ func (a ArtifactTrackerServer) GetDownloadLink(ctx context.Context, req *cloudpb
 	ctx = metadata.AppendToOutgoingContext(ctx, "authorization",
 		fmt.Sprintf("bearer %s", serviceAuthToken))
 
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
 	resp, err := a.ArtifactTrackerClient.GetDownloadLink(ctx, atReq)
 	if err != nil {
-		return nil, err
+		return nil, artifactTrackerError(ctx, err)
+	}
+	if resp == nil || resp.Url == "" {
+		return nil, status.Errorf(codes.NotFound, "no download link found for %s version %s", req.ArtifactName, req.VersionStr)
 	}
 
 	return &cloudpb.GetDownloadLinkResponse{
-		Url:        resp.Url,
-		SHA256:     resp.SHA256,
-		ValidUntil: resp.ValidUntil,
+		Url:          resp.Url,
+		SHA256:       resp.SHA256,
+		ValidUntil:   resp.ValidUntil,
+		ArtifactType: getArtifactTypeFromVersionsProto(resp.ArtifactType),
 	}, nil
 }
 
@@ -191,6 +319,28 @@ func (a ArtifactTrackerServer) GetDownloadLink(ctx context.Context, req *cloudpb
 type VizierClusterInfo struct {
 	VzMgr                 vzmgrpb.VZMgrServiceClient
 	ArtifactTrackerClient artifacttrackerpb.ArtifactTrackerClient
+	// Now returns the current time, used to compute pod ages. Defaults to time.Now if unset.
+	Now func() time.Time
+	// ClusterInfoPollInterval is how often StreamClusterInfo polls for cluster changes.
+	// Defaults to defaultClusterInfoPollInterval if unset.
+	ClusterInfoPollInterval time.Duration
+	// VzMgrRetryBackoff is the delay before the first retry of a transient vzmgr error, doubling on each
+	// subsequent retry. Defaults to defaultVzMgrRetryBackoff if unset.
+	VzMgrRetryBackoff time.Duration
+}
+
+const (
+	defaultClusterInfoPollInterval = 5 * time.Second
+	defaultVzMgrRetryBackoff       = 100 * time.Millisecond
+	// vzMgrMaxAttempts is the max number of times a vzmgr call is attempted before giving up.
+	vzMgrMaxAttempts = 3
+)
+
+func (v *VizierClusterInfo) now() time.Time {
+	if v.Now == nil {
+		return time.Now()
+	}
+	return v.Now()
 }
 
 func contextWithAuthToken(ctx context.Context) (context.Context, error) {
@@ -202,13 +352,132 @@ func contextWithAuthToken(ctx context.Context) (context.Context, error) {
 		fmt.Sprintf("bearer %s", sCtx.AuthToken)), nil
 }
 
+const (
+	// orgIDOverrideMetadataKey is the request metadata key that admins use to act on behalf of another org.
+	orgIDOverrideMetadataKey = "px-org-id-override"
+)
+
+func hasScope(claims *jwtpb.JWTClaims, scope string) bool {
+	for _, s := range claims.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// contextWithOrgOverride returns a context scoped to the org specified in the request metadata, if any.
+// Only callers with the admin scope, which is given to Pixie support accounts, may specify an org. The auth token is re-signed for the
+// specified org, so that downstream services scope their requests to it as well.
+func contextWithOrgOverride(ctx context.Context) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx, nil
+	}
+	overrides := md.Get(orgIDOverrideMetadataKey)
+	if len(overrides) == 0 {
+		return ctx, nil
+	}
+
+	sCtx, err := authcontext.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userClaims := sCtx.Claims.GetUserClaims()
+	if userClaims == nil || !hasScope(sCtx.Claims, srvutils.AdminScope) {
+		return nil, status.Error(codes.PermissionDenied, "only admins may override the org of a request")
+	}
+	orgID, err := uuid.FromString(overrides[0])
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid org ID override")
+	}
+
+	claims := srvutils.GenerateJWTForUser(userClaims.UserID, orgID.String(), userClaims.Email,
+		time.Unix(sCtx.Claims.ExpiresAt, 0), sCtx.Claims.Audience)
+	claims.Scopes = sCtx.Claims.Scopes
+	token, err := srvutils.SignJWTClaims(claims, viper.GetString("jwt_signing_key"))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to sign token for org override")
+	}
+	log.WithField("user", userClaims.UserID).WithField("org", orgID.String()).Info("Admin acting on behalf of org")
+
+	overrideCtx := authcontext.New()
+	overrideCtx.Claims = claims
+	overrideCtx.AuthToken = token
+	overrideCtx.Path = sCtx.Path
+	return authcontext.NewContext(ctx, overrideCtx), nil
+}
+
 // CreateCluster creates a cluster for the current org.
 func (v *VizierClusterInfo) CreateCluster(ctx context.Context, request *cloudpb.CreateClusterRequest) (*cloudpb.CreateClusterResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "Deprecated. Please use `px deploy`")
 }
 
+// wrapVzMgrError adds the failed vzmgr operation and the clusters it was called for to the given error,
+// preserving the original status code.
+func wrapVzMgrError(err error, op string, clusterIDs ...*uuidpb.UUID) error {
+	s := status.Convert(err)
+	if len(clusterIDs) == 0 {
+		return status.Errorf(s.Code(), "%s failed: %s", op, s.Message())
+	}
+
+	ids := make([]string, len(clusterIDs))
+	for i, id := range clusterIDs {
+		ids[i] = utils.ProtoToUUIDStr(id)
+	}
+	return status.Errorf(s.Code(), "%s failed for cluster %s: %s", op, strings.Join(ids, ", "), s.Message())
+}
+
+func isTransientVzMgrError(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryVzMgr calls fn until it succeeds, returns a non-transient error, or runs out of attempts,
+// backing off exponentially between attempts. Each retry is taken from the request's retry budget,
+// so calls made without a retry budget, or after it is exhausted, are never retried. It gives up
+// early if the next attempt would start after the context's deadline.
+func (v *VizierClusterInfo) retryVzMgr(ctx context.Context, fn func() error) error {
+	backoff := v.VzMgrRetryBackoff
+	if backoff <= 0 {
+		backoff = defaultVzMgrRetryBackoff
+	}
+	budget := apienv.RetryBudgetFromContext(ctx)
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || !isTransientVzMgrError(err) || attempt >= vzMgrMaxAttempts {
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(backoff).After(deadline) {
+			return err
+		}
+		if budget == nil || !budget.TryAcquire() {
+			return err
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		backoff *= 2
+	}
+}
+
 // GetClusterInfo returns information about Vizier clusters.
 func (v *VizierClusterInfo) GetClusterInfo(ctx context.Context, request *cloudpb.GetClusterInfoRequest) (*cloudpb.GetClusterInfoResponse, error) {
+	ctx, err := contextWithOrgOverride(ctx)
+	if err != nil {
+		return nil, err
+	}
 	sCtx, err := authcontext.FromContext(ctx)
 	if err != nil {
 		return nil, err
@@ -228,28 +497,169 @@ func (v *VizierClusterInfo) GetClusterInfo(ctx context.Context, request *cloudpb
 	if request.ID != nil {
 		vzIDs = append(vzIDs, request.ID)
 	} else {
-		viziers, err := v.VzMgr.GetViziersByOrg(ctx, utils.ProtoFromUUID(orgID))
+		var viziers *vzmgrpb.GetViziersByOrgResponse
+		err := v.retryVzMgr(ctx, func() (err error) {
+			viziers, err = v.VzMgr.GetViziersByOrg(ctx, utils.ProtoFromUUID(orgID))
+			return err
+		})
 		if err != nil {
-			return nil, err
+			return nil, wrapVzMgrError(err, "GetViziersByOrg")
 		}
 		vzIDs = viziers.VizierIDs
 	}
 
-	return v.getClusterInfoForViziers(ctx, vzIDs)
+	resp, err := v.getClusterInfoForViziers(ctx, vzIDs, request.IncludeRecentErrorEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	// VzMgr can't look up viziers by cluster UID, so filter the org's viziers instead.
+	if request.ID == nil && request.ClusterUID != "" {
+		clusters := make([]*cloudpb.ClusterInfo, 0)
+		for _, c := range resp.Clusters {
+			if c.ClusterUID == request.ClusterUID {
+				clusters = append(clusters, c)
+			}
+		}
+		resp.Clusters = clusters
+	}
+
+	if request.OnlyUnhealthyPods {
+		for _, c := range resp.Clusters {
+			for podName, podStatus := range c.ControlPlanePodStatuses {
+				if podStatus.Status == cloudpb.RUNNING {
+					delete(c.ControlPlanePodStatuses, podName)
+				}
+			}
+		}
+	}
+	return resp, nil
+}
+
+// StreamClusterInfo polls for the info of the current org's clusters, sending a cluster's info whenever its
+// status changes.
+func (v *VizierClusterInfo) StreamClusterInfo(req *cloudpb.StreamClusterInfoRequest, srv cloudpb.VizierClusterInfo_StreamClusterInfoServer) error {
+	ctx := srv.Context()
+	interval := v.ClusterInfoPollInterval
+	if interval <= 0 {
+		interval = defaultClusterInfoPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// The status of each cluster in the last poll, keyed by cluster ID.
+	lastStatuses := make(map[string]cloudpb.ClusterStatus)
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		resp, err := v.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{ID: req.ID})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		statuses := make(map[string]cloudpb.ClusterStatus)
+		for _, c := range resp.Clusters {
+			id := utils.ProtoToUUIDStr(c.ID)
+			statuses[id] = c.Status
+			if lastStatus, ok := lastStatuses[id]; ok && lastStatus == c.Status {
+				continue
+			}
+			err = srv.Send(c)
+			if err != nil {
+				return err
+			}
+		}
+		lastStatuses = statuses
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetClusterHealthSummary returns the number of clusters in each status for the current org.
+func (v *VizierClusterInfo) GetClusterHealthSummary(ctx context.Context, request *cloudpb.GetClusterHealthSummaryRequest) (*cloudpb.GetClusterHealthSummaryResponse, error) {
+	ctx, err := contextWithOrgOverride(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sCtx, err := authcontext.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	orgID, err := uuid.FromString(sCtx.Claims.GetUserClaims().OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = contextWithAuthToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var viziers *vzmgrpb.GetViziersByOrgResponse
+	err = v.retryVzMgr(ctx, func() (err error) {
+		viziers, err = v.VzMgr.GetViziersByOrg(ctx, utils.ProtoFromUUID(orgID))
+		return err
+	})
+	if err != nil {
+		return nil, wrapVzMgrError(err, "GetViziersByOrg")
+	}
+	resp := &cloudpb.GetClusterHealthSummaryResponse{}
+	if len(viziers.VizierIDs) == 0 {
+		return resp, nil
+	}
+
+	var vzInfoResp *vzmgrpb.GetVizierInfosResponse
+	err = v.retryVzMgr(ctx, func() (err error) {
+		vzInfoResp, err = v.VzMgr.GetVizierInfos(ctx, &vzmgrpb.GetVizierInfosRequest{
+			VizierIDs: viziers.VizierIDs,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, wrapVzMgrError(err, "GetVizierInfos", viziers.VizierIDs...)
+	}
+
+	for _, vzInfo := range vzInfoResp.VizierInfos {
+		if vzInfo == nil || vzInfo.VizierID == nil {
+			continue
+		}
+		switch vzStatusToClusterStatus(vzInfo.Status) {
+		case cloudpb.CS_HEALTHY:
+			resp.NumHealthy++
+		case cloudpb.CS_UNHEALTHY:
+			resp.NumUnhealthy++
+		case cloudpb.CS_DISCONNECTED:
+			resp.NumDisconnected++
+		case cloudpb.CS_UPDATING:
+			resp.NumUpdating++
+		default:
+			resp.NumOther++
+		}
+	}
+	return resp, nil
 }
 
-func convertContainerState(cs metadatapb.ContainerState) cloudpb.ContainerState {
+func convertContainerState(cs metadatapb.ContainerState) (cloudpb.ContainerState, error) {
 	switch cs {
 	case metadatapb.CONTAINER_STATE_RUNNING:
-		return cloudpb.CONTAINER_STATE_RUNNING
+		return cloudpb.CONTAINER_STATE_RUNNING, nil
 	case metadatapb.CONTAINER_STATE_TERMINATED:
-		return cloudpb.CONTAINER_STATE_TERMINATED
+		return cloudpb.CONTAINER_STATE_TERMINATED, nil
 	case metadatapb.CONTAINER_STATE_WAITING:
-		return cloudpb.CONTAINER_STATE_WAITING
+		return cloudpb.CONTAINER_STATE_WAITING, nil
 	case metadatapb.CONTAINER_STATE_UNKNOWN:
-		return cloudpb.CONTAINER_STATE_UNKNOWN
+		return cloudpb.CONTAINER_STATE_UNKNOWN, nil
 	default:
-		return cloudpb.CONTAINER_STATE_UNKNOWN
+		return cloudpb.CONTAINER_STATE_UNKNOWN, fmt.Errorf("invalid container state %d", cs)
 	}
 }
 
@@ -270,16 +680,218 @@ func convertPodPhase(p metadatapb.PodPhase) cloudpb.PodPhase {
 	}
 }
 
-func (v *VizierClusterInfo) getClusterInfoForViziers(ctx context.Context, ids []*uuidpb.UUID) (*cloudpb.GetClusterInfoResponse, error) {
+// podAgeSeconds returns the number of seconds between the pod's creation and now, or 0 if
+// the creation time is unknown.
+func podAgeSeconds(createdAt *types.Timestamp, now time.Time) int64 {
+	if createdAt == nil {
+		return 0
+	}
+	created, err := types.TimestampFromProto(createdAt)
+	if err != nil || created.After(now) {
+		return 0
+	}
+	return int64(now.Sub(created) / time.Second)
+}
+
+// maxRecentErrorEvents is the max number of events returned in a cluster's RecentErrorEvents.
+const maxRecentErrorEvents = 10
+
+func isErrorEvent(ev *cloudpb.K8SEvent) bool {
+	return strings.EqualFold(ev.Type, "Warning") || strings.EqualFold(ev.Type, "Error")
+}
+
+// recentErrorEvents returns the most recent warning and error events across all of the given pods,
+// newest first.
+func recentErrorEvents(podStatuses map[string]*cloudpb.PodStatus) []*cloudpb.K8SEvent {
+	events := make([]*cloudpb.K8SEvent, 0)
+	for _, status := range podStatuses {
+		for _, ev := range status.Events {
+			if isErrorEvent(ev) {
+				events = append(events, ev)
+			}
+		}
+	}
+
+	lastTimeNanos := func(ev *cloudpb.K8SEvent) int64 {
+		if ev.LastTime == nil {
+			return 0
+		}
+		return ev.LastTime.Seconds*NanosPerSecond + int64(ev.LastTime.Nanos)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return lastTimeNanos(events[i]) > lastTimeNanos(events[j])
+	})
+	if len(events) > maxRecentErrorEvents {
+		events = events[:maxRecentErrorEvents]
+	}
+	return events
+}
+
+func podPhaseDescription(p cloudpb.PodPhase) string {
+	switch p {
+	case cloudpb.PENDING:
+		return "pending"
+	case cloudpb.FAILED:
+		return "failed"
+	default:
+		return "in an unknown state"
+	}
+}
+
+// podUnhealthyReason returns a human-readable description of what is wrong with the pod, or an empty string
+// if the pod looks healthy.
+func podUnhealthyReason(pod *cloudpb.PodStatus) string {
+	for _, c := range pod.Containers {
+		if c.Reason == "" {
+			continue
+		}
+		switch c.State {
+		case cloudpb.CONTAINER_STATE_WAITING:
+			if c.Reason == "CrashLoopBackOff" {
+				return fmt.Sprintf("%s crashlooping", pod.Name)
+			}
+			return fmt.Sprintf("%s container %s waiting: %s", pod.Name, c.Name, c.Reason)
+		case cloudpb.CONTAINER_STATE_TERMINATED:
+			return fmt.Sprintf("%s container %s terminated: %s", pod.Name, c.Name, c.Reason)
+		}
+	}
+
+	if pod.TranslationError != "" || pod.Status == cloudpb.RUNNING || pod.Status == cloudpb.SUCCEEDED {
+		return ""
+	}
+	reason := fmt.Sprintf("%s %s", pod.Name, podPhaseDescription(pod.Status))
+	if pod.Reason != "" {
+		reason = fmt.Sprintf("%s: %s", reason, pod.Reason)
+	} else if pod.StatusMessage != "" {
+		reason = fmt.Sprintf("%s: %s", reason, pod.StatusMessage)
+	}
+	return reason
+}
+
+// clusterStatusReason returns a human-readable reason for why an unhealthy cluster is unhealthy, based on
+// the statuses of its control plane pods.
+func clusterStatusReason(status cloudpb.ClusterStatus, podStatuses map[string]*cloudpb.PodStatus) string {
+	if status != cloudpb.CS_UNHEALTHY {
+		return ""
+	}
+	podNames := make([]string, 0, len(podStatuses))
+	for name := range podStatuses {
+		podNames = append(podNames, name)
+	}
+	sort.Strings(podNames)
+
+	var reasons []string
+	for _, name := range podNames {
+		if reason := podUnhealthyReason(podStatuses[name]); reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// instrumentationCoverage returns the fraction of nodes that are instrumented, or 0 if there are no nodes.
+func instrumentationCoverage(numInstrumentedNodes, numNodes int32) float32 {
+	if numNodes <= 0 {
+		return 0
+	}
+	return float32(numInstrumentedNodes) / float32(numNodes)
+}
+
+func convertPodStatus(status *cvmsgspb.PodStatus, now time.Time) (*cloudpb.PodStatus, error) {
+	var containers []*cloudpb.ContainerStatus
+	for _, container := range status.Containers {
+		state, err := convertContainerState(container.State)
+		if err != nil {
+			return nil, fmt.Errorf("container %s: %w", container.Name, err)
+		}
+		containers = append(containers, &cloudpb.ContainerStatus{
+			Name:         container.Name,
+			State:        state,
+			Message:      container.Message,
+			Reason:       container.Reason,
+			CreatedAt:    container.CreatedAt,
+			RestartCount: container.RestartCount,
+		})
+	}
+	var events []*cloudpb.K8SEvent
+	for _, ev := range status.Events {
+		events = append(events, &cloudpb.K8SEvent{
+			Message:   ev.Message,
+			LastTime:  ev.LastTime,
+			FirstTime: ev.FirstTime,
+			Type:      ev.Type,
+		})
+	}
+
+	return &cloudpb.PodStatus{
+		Name:          status.Name,
+		Status:        convertPodPhase(status.Status),
+		StatusMessage: status.StatusMessage,
+		Reason:        status.Reason,
+		Containers:    containers,
+		CreatedAt:     status.CreatedAt,
+		Events:        events,
+		AgeSeconds:    podAgeSeconds(status.CreatedAt, now),
+	}, nil
+}
+
+// convertVizierConfig converts the config from vzmgr. A missing config is converted to the zero config,
+// rather than nil, so that clients can always read it.
+func convertVizierConfig(config *cvmsgspb.VizierConfig) *cloudpb.VizierConfig {
+	if config == nil {
+		return &cloudpb.VizierConfig{}
+	}
+	return &cloudpb.VizierConfig{
+		PassthroughEnabled: config.PassthroughEnabled,
+		AutoUpdateEnabled:  config.AutoUpdateEnabled,
+		UpdateChannel:      config.UpdateChannel,
+	}
+}
+
+func convertPluginStatuses(statuses []*cvmsgspb.PluginStatus) []*cloudpb.PluginStatus {
+	if len(statuses) == 0 {
+		return nil
+	}
+	res := make([]*cloudpb.PluginStatus, 0, len(statuses))
+	for _, ps := range statuses {
+		if ps == nil {
+			continue
+		}
+		res = append(res, &cloudpb.PluginStatus{
+			Name:    ps.Name,
+			Healthy: ps.Healthy,
+			Message: ps.Message,
+		})
+	}
+	return res
+}
+
+// safeConvertPodStatus converts the pod status, turning any panic caused by a malformed status
+// into an error.
+func safeConvertPodStatus(status *cvmsgspb.PodStatus, now time.Time) (podStatus *cloudpb.PodStatus, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			podStatus = nil
+			err = fmt.Errorf("panic while translating pod status: %v", r)
+		}
+	}()
+	return convertPodStatus(status, now)
+}
+
+func (v *VizierClusterInfo) getClusterInfoForViziers(ctx context.Context, ids []*uuidpb.UUID, includeRecentErrorEvents bool) (*cloudpb.GetClusterInfoResponse, error) {
 	resp := &cloudpb.GetClusterInfoResponse{}
+	now := v.now()
 
 	cNames := make(map[string]int)
-	vzInfoResp, err := v.VzMgr.GetVizierInfos(ctx, &vzmgrpb.GetVizierInfosRequest{
-		VizierIDs: ids,
+	var vzInfoResp *vzmgrpb.GetVizierInfosResponse
+	err := v.retryVzMgr(ctx, func() (err error) {
+		vzInfoResp, err = v.VzMgr.GetVizierInfos(ctx, &vzmgrpb.GetVizierInfosRequest{
+			VizierIDs: ids,
+		})
+		return err
 	})
-
 	if err != nil {
-		return nil, err
+		return nil, wrapVzMgrError(err, "GetVizierInfos", ids...)
 	}
 
 	for _, vzInfo := range vzInfoResp.VizierInfos {
@@ -288,34 +900,19 @@ func (v *VizierClusterInfo) getClusterInfoForViziers(ctx context.Context, ids []
 		}
 		podStatuses := make(map[string]*cloudpb.PodStatus)
 		for podName, status := range vzInfo.ControlPlanePodStatuses {
-			var containers []*cloudpb.ContainerStatus
-			for _, container := range status.Containers {
-				containers = append(containers, &cloudpb.ContainerStatus{
-					Name:      container.Name,
-					State:     convertContainerState(container.State),
-					Message:   container.Message,
-					Reason:    container.Reason,
-					CreatedAt: container.CreatedAt,
-				})
-			}
-			var events []*cloudpb.K8SEvent
-			for _, ev := range status.Events {
-				events = append(events, &cloudpb.K8SEvent{
-					Message:   ev.Message,
-					LastTime:  ev.LastTime,
-					FirstTime: ev.FirstTime,
-				})
+			if status == nil {
+				continue
 			}
-
-			podStatuses[podName] = &cloudpb.PodStatus{
-				Name:          status.Name,
-				Status:        convertPodPhase(status.Status),
-				StatusMessage: status.StatusMessage,
-				Reason:        status.Reason,
-				Containers:    containers,
-				CreatedAt:     status.CreatedAt,
-				Events:        events,
+			podStatus, err := safeConvertPodStatus(status, now)
+			if err != nil {
+				// Return the rest of the cluster's info, rather than failing because of a single bad pod.
+				log.WithError(err).WithField("pod", podName).Error("Failed to translate pod status")
+				podStatus = &cloudpb.PodStatus{
+					Name:             podName,
+					TranslationError: err.Error(),
+				}
 			}
+			podStatuses[podName] = podStatus
 		}
 
 		s := vzStatusToClusterStatus(vzInfo.Status)
@@ -327,14 +924,18 @@ func (v *VizierClusterInfo) getClusterInfoForViziers(ctx context.Context, ids []
 			cNames[prettyName] = 1
 		}
 
+		config := convertVizierConfig(vzInfo.Config)
+
+		var errorEvents []*cloudpb.K8SEvent
+		if includeRecentErrorEvents {
+			errorEvents = recentErrorEvents(podStatuses)
+		}
+
 		resp.Clusters = append(resp.Clusters, &cloudpb.ClusterInfo{
-			ID:              vzInfo.VizierID,
-			Status:          s,
-			LastHeartbeatNs: vzInfo.LastHeartbeatNs,
-			Config: &cloudpb.VizierConfig{
-				PassthroughEnabled: vzInfo.Config.PassthroughEnabled,
-				AutoUpdateEnabled:  vzInfo.Config.AutoUpdateEnabled,
-			},
+			ID:                      vzInfo.VizierID,
+			Status:                  s,
+			LastHeartbeatNs:         vzInfo.LastHeartbeatNs,
+			Config:                  config,
 			ClusterUID:              vzInfo.ClusterUID,
 			ClusterName:             vzInfo.ClusterName,
 			PrettyClusterName:       prettyName,
@@ -343,6 +944,12 @@ func (v *VizierClusterInfo) getClusterInfoForViziers(ctx context.Context, ids []
 			ControlPlanePodStatuses: podStatuses,
 			NumNodes:                vzInfo.NumNodes,
 			NumInstrumentedNodes:    vzInfo.NumInstrumentedNodes,
+			RecentErrorEvents:       errorEvents,
+			PluginStatuses:          convertPluginStatuses(vzInfo.PluginStatuses),
+			LastScriptRunAt:         vzInfo.LastScriptRunAt,
+			AutoUpdateInProgress:    vzInfo.AutoUpdateInProgress,
+			StatusReason:            clusterStatusReason(s, podStatuses),
+			InstrumentationCoverage: instrumentationCoverage(vzInfo.NumInstrumentedNodes, vzInfo.NumNodes),
 		})
 	}
 
@@ -353,21 +960,33 @@ func (v *VizierClusterInfo) getClusterInfoForViziers(ctx context.Context, ids []
 		}
 	}
 
+	// VzMgr returns the viziers in an arbitrary order, so sort them by ID to keep the order stable across calls.
+	sort.SliceStable(resp.Clusters, func(i, j int) bool {
+		return utils.UUIDFromProtoOrNil(resp.Clusters[i].ID).String() < utils.UUIDFromProtoOrNil(resp.Clusters[j].ID).String()
+	})
+
 	return resp, nil
 }
 
 // GetClusterConnectionInfo returns information about connections to Vizier cluster.
 func (v *VizierClusterInfo) GetClusterConnectionInfo(ctx context.Context, request *cloudpb.GetClusterConnectionInfoRequest) (*cloudpb.GetClusterConnectionInfoResponse, error) {
 	id := request.ID
-	ctx, err := contextWithAuthToken(ctx)
+	ctx, err := contextWithOrgOverride(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err = contextWithAuthToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	ci, err := v.VzMgr.GetVizierConnectionInfo(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, wrapVzMgrError(err, "GetVizierConnectionInfo", id)
 	}
+	log.WithField("cluster", utils.ProtoToUUIDStr(id)).
+		WithField("connectionInfo", RedactedConnectionInfo{IPAddress: ci.IPAddress, Token: ci.Token}).
+		Trace("Got cluster connection info")
 
 	return &cloudpb.GetClusterConnectionInfoResponse{
 		IPAddress: ci.IPAddress,
@@ -375,64 +994,251 @@ func (v *VizierClusterInfo) GetClusterConnectionInfo(ctx context.Context, reques
 	}, nil
 }
 
+// connectionTokenPrefixLen is the number of characters of a connection token that are shown when it is logged.
+const connectionTokenPrefixLen = 4
+
+// RedactedConnectionInfo wraps cluster connection info so that it can be logged without leaking the token.
+type RedactedConnectionInfo struct {
+	IPAddress string
+	Token     string
+}
+
+// String returns the connection info, with all but a short prefix of the token removed.
+func (r RedactedConnectionInfo) String() string {
+	token := "..."
+	if len(r.Token) > connectionTokenPrefixLen {
+		token = r.Token[:connectionTokenPrefixLen] + token
+	}
+	return fmt.Sprintf("{IPAddress: %s, Token: %s}", r.IPAddress, token)
+}
+
+// maxConnectionInfoWorkers is the maximum number of concurrent vzmgr lookups in GetClusterConnectionInfos.
+const maxConnectionInfoWorkers = 10
+
+// GetClusterConnectionInfos returns information about connections to several Vizier clusters.
+func (v *VizierClusterInfo) GetClusterConnectionInfos(ctx context.Context, request *cloudpb.GetClusterConnectionInfosRequest) (*cloudpb.GetClusterConnectionInfosResponse, error) {
+	ctx, err := contextWithOrgOverride(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err = contextWithAuthToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &cloudpb.GetClusterConnectionInfosResponse{
+		Results: make(map[string]*cloudpb.ClusterConnectionInfoResult),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConnectionInfoWorkers)
+	// Tracks the IDs that have been looked up, so duplicate IDs are only looked up once.
+	seen := make(map[string]bool)
+
+	for _, id := range request.IDs {
+		idStr := utils.ProtoToUUIDStr(id)
+		if seen[idStr] {
+			continue
+		}
+		seen[idStr] = true
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id *uuidpb.UUID, idStr string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := &cloudpb.ClusterConnectionInfoResult{}
+			ci, err := v.VzMgr.GetVizierConnectionInfo(ctx, id)
+			if err != nil {
+				result.Error = status.Convert(wrapVzMgrError(err, "GetVizierConnectionInfo", id)).Message()
+			} else {
+				result.Info = &cloudpb.GetClusterConnectionInfoResponse{
+					IPAddress: ci.IPAddress,
+					Token:     ci.Token,
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			resp.Results[idStr] = result
+		}(id, idStr)
+	}
+	wg.Wait()
+
+	return resp, nil
+}
+
+// validUpdateChannels are the release channels that a cluster can be updated from.
+var validUpdateChannels = map[string]bool{
+	"stable": true,
+	"beta":   true,
+}
+
 // UpdateClusterVizierConfig supports updates of VizierConfig for a cluster
 func (v *VizierClusterInfo) UpdateClusterVizierConfig(ctx context.Context, req *cloudpb.UpdateClusterVizierConfigRequest) (*cloudpb.UpdateClusterVizierConfigResponse, error) {
-	ctx, err := contextWithAuthToken(ctx)
+	ctx, err := contextWithOrgOverride(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err = contextWithAuthToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = v.VzMgr.UpdateVizierConfig(ctx, &cvmsgspb.UpdateVizierConfigRequest{
-		VizierID: req.ID,
-		ConfigUpdate: &cvmsgspb.VizierConfigUpdate{
+	// Only the fields which are set in the update are applied by vzmgr, so unset fields must be forwarded as nil
+	// to keep their current values.
+	var configUpdate *cvmsgspb.VizierConfigUpdate
+	if req.ConfigUpdate != nil {
+		if req.ConfigUpdate.UpdateChannel != nil && !validUpdateChannels[req.ConfigUpdate.UpdateChannel.Value] {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid update channel %q", req.ConfigUpdate.UpdateChannel.Value)
+		}
+		configUpdate = &cvmsgspb.VizierConfigUpdate{
 			PassthroughEnabled: req.ConfigUpdate.PassthroughEnabled,
 			AutoUpdateEnabled:  req.ConfigUpdate.AutoUpdateEnabled,
-		},
+			UpdateChannel:      req.ConfigUpdate.UpdateChannel,
+		}
+	}
+
+	_, err = v.VzMgr.UpdateVizierConfig(ctx, &cvmsgspb.UpdateVizierConfigRequest{
+		VizierID:     req.ID,
+		ConfigUpdate: configUpdate,
 	})
 	if err != nil {
-		return nil, err
+		return nil, wrapVzMgrError(err, "UpdateVizierConfig", req.ID)
 	}
 
 	return &cloudpb.UpdateClusterVizierConfigResponse{}, nil
 }
 
+// GetClusterVizierConfig gets the VizierConfig for a cluster.
+func (v *VizierClusterInfo) GetClusterVizierConfig(ctx context.Context, req *cloudpb.GetClusterVizierConfigRequest) (*cloudpb.GetClusterVizierConfigResponse, error) {
+	ctx, err := contextWithOrgOverride(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err = contextWithAuthToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var vzInfo *cvmsgspb.VizierInfo
+	err = v.retryVzMgr(ctx, func() (err error) {
+		vzInfo, err = v.VzMgr.GetVizierInfo(ctx, req.ID)
+		return err
+	})
+	if err != nil {
+		return nil, wrapVzMgrError(err, "GetVizierInfo", req.ID)
+	}
+
+	return &cloudpb.GetClusterVizierConfigResponse{
+		Config: convertVizierConfig(vzInfo.Config),
+	}, nil
+}
+
 // UpdateOrInstallCluster updates or installs the given vizier cluster to the specified version.
 func (v *VizierClusterInfo) UpdateOrInstallCluster(ctx context.Context, req *cloudpb.UpdateOrInstallClusterRequest) (*cloudpb.UpdateOrInstallClusterResponse, error) {
-	if req.Version == "" {
-		return nil, status.Errorf(codes.InvalidArgument, "version cannot be empty")
+	ctx, err := contextWithAuthToken(ctx)
+	if err != nil {
+		return nil, err
 	}
 
+	// An empty version is resolved to the latest version by vzmgr.
+	if req.Version != "" {
+		err = v.validateVizierVersion(ctx, req.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := v.updateOrInstallVizier(ctx, req.ClusterID, req.Version, req.RedeployEtcd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cloudpb.UpdateOrInstallClusterResponse{
+		UpdateStarted: resp.UpdateStarted,
+	}, nil
+}
+
+// RollbackCluster rolls the given vizier cluster back to the version it ran before its last update.
+func (v *VizierClusterInfo) RollbackCluster(ctx context.Context, req *cloudpb.RollbackClusterRequest) (*cloudpb.RollbackClusterResponse, error) {
 	ctx, err := contextWithAuthToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// Validate version.
-	atReq := &artifacttrackerpb.GetDownloadLinkRequest{
-		ArtifactName: "vizier",
-		VersionStr:   req.Version,
-		ArtifactType: versionspb.AT_CONTAINER_SET_YAMLS,
+	version, err := v.previousVizierVersion(ctx, req.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	if version == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "no previous vizier version known for cluster %s", utils.ProtoToUUIDStr(req.ClusterID))
 	}
 
-	_, err = v.ArtifactTrackerClient.GetDownloadLink(ctx, atReq)
+	err = v.validateVizierVersion(ctx, version)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid version")
+		return nil, err
 	}
 
-	resp, err := v.VzMgr.UpdateOrInstallVizier(ctx, &cvmsgspb.UpdateOrInstallVizierRequest{
-		VizierID:     req.ClusterID,
-		Version:      req.Version,
-		RedeployEtcd: req.RedeployEtcd,
-	})
+	resp, err := v.updateOrInstallVizier(ctx, req.ClusterID, version, false)
 	if err != nil {
 		return nil, err
 	}
 
-	return &cloudpb.UpdateOrInstallClusterResponse{
+	return &cloudpb.RollbackClusterResponse{
 		UpdateStarted: resp.UpdateStarted,
+		Version:       version,
 	}, nil
 }
 
+// updateOrInstallVizier starts an update of the given cluster to the version. vzmgr records the version the cluster
+// is currently running, so that the update can be rolled back.
+func (v *VizierClusterInfo) updateOrInstallVizier(ctx context.Context, clusterID *uuidpb.UUID, version string, redeployEtcd bool) (*cvmsgspb.UpdateOrInstallVizierResponse, error) {
+	resp, err := v.VzMgr.UpdateOrInstallVizier(ctx, &cvmsgspb.UpdateOrInstallVizierRequest{
+		VizierID:     clusterID,
+		Version:      version,
+		RedeployEtcd: redeployEtcd,
+	})
+	if err != nil {
+		return nil, wrapVzMgrError(err, "UpdateOrInstallVizier", clusterID)
+	}
+	return resp, nil
+}
+
+// previousVizierVersion returns the Vizier version the given cluster ran before its last update, or an empty string
+// if it isn't known.
+func (v *VizierClusterInfo) previousVizierVersion(ctx context.Context, clusterID *uuidpb.UUID) (string, error) {
+	var vzInfoResp *vzmgrpb.GetVizierInfosResponse
+	err := v.retryVzMgr(ctx, func() (err error) {
+		vzInfoResp, err = v.VzMgr.GetVizierInfos(ctx, &vzmgrpb.GetVizierInfosRequest{
+			VizierIDs: []*uuidpb.UUID{clusterID},
+		})
+		return err
+	})
+	if err != nil {
+		return "", wrapVzMgrError(err, "GetVizierInfos", clusterID)
+	}
+	if len(vzInfoResp.VizierInfos) == 0 || vzInfoResp.VizierInfos[0] == nil {
+		return "", nil
+	}
+	return vzInfoResp.VizierInfos[0].PreviousVizierVersion, nil
+}
+
+// validateVizierVersion checks that the given Vizier version exists, returning a NotFound error if it doesn't.
+// The version is looked up directly, rather than in the artifact list, which also covers pre-release versions.
+func (v *VizierClusterInfo) validateVizierVersion(ctx context.Context, version string) error {
+	_, err := v.ArtifactTrackerClient.GetDownloadLink(ctx, &artifacttrackerpb.GetDownloadLinkRequest{
+		ArtifactName: "vizier",
+		VersionStr:   version,
+		ArtifactType: versionspb.AT_CONTAINER_SET_YAMLS,
+	})
+	if status.Code(err) == codes.NotFound {
+		return status.Errorf(codes.NotFound, "vizier version %s not found", version)
+	}
+	return err
+}
+
 func vzStatusToClusterStatus(s cvmsgspb.VizierStatus) cloudpb.ClusterStatus {
 	switch s {
 	case cvmsgspb.VZ_ST_HEALTHY:
@@ -455,6 +1261,84 @@ func vzStatusToClusterStatus(s cvmsgspb.VizierStatus) cloudpb.ClusterStatus {
 // VizierDeploymentKeyServer is the server that implements the VizierDeploymentKeyManager gRPC service.
 type VizierDeploymentKeyServer struct {
 	VzDeploymentKey vzmgrpb.VZDeploymentKeyServiceClient
+	// AuditHook is called when a deploy key is created or deleted. Defaults to LogAuditEvent if unset.
+	AuditHook AuditHook
+}
+
+const (
+	defaultKeyPageSize = 50
+	maxKeyPageSize     = 1000
+)
+
+// keySortFields are the fields of an API or deployment key that keys can be sorted by.
+type keySortFields struct {
+	id        string
+	createdAt *types.Timestamp
+	desc      string
+}
+
+// keyLess orders keys by the given field. Ties are broken by creation time then ID, so that the order is
+// stable across pages.
+func keyLess(sortBy cloudpb.KeySortBy, a, b keySortFields) bool {
+	if sortBy == cloudpb.KSB_DESC && a.desc != b.desc {
+		return a.desc < b.desc
+	}
+	if a.createdAt.GetSeconds() != b.createdAt.GetSeconds() {
+		return a.createdAt.GetSeconds() < b.createdAt.GetSeconds()
+	}
+	if a.createdAt.GetNanos() != b.createdAt.GetNanos() {
+		return a.createdAt.GetNanos() < b.createdAt.GetNanos()
+	}
+	return a.id < b.id
+}
+
+// keysPage returns the range of the page of numKeys sorted keys selected by the page size and token, along with the
+// token for the following page. The page token is the offset of the first key in the page.
+func keysPage(numKeys int, pageSize int32, pageToken string) (start, end int, nextPageToken string, err error) {
+	if pageSize < 0 {
+		return 0, 0, "", status.Error(codes.InvalidArgument, "page size must not be negative")
+	}
+	size := int(pageSize)
+	if size == 0 {
+		size = defaultKeyPageSize
+	}
+	if size > maxKeyPageSize {
+		size = maxKeyPageSize
+	}
+
+	if pageToken != "" {
+		start, err = strconv.Atoi(pageToken)
+		if err != nil || start < 0 {
+			return 0, 0, "", status.Error(codes.InvalidArgument, "invalid page token")
+		}
+	}
+	if start > numKeys {
+		start = numKeys
+	}
+	end = start + size
+	if end < numKeys {
+		nextPageToken = strconv.Itoa(end)
+	} else {
+		end = numKeys
+	}
+	return start, end, nextPageToken, nil
+}
+
+// keyCreator returns the ID of the user who created a key, or nil if the creator is unknown.
+func keyCreator(createdBy *uuidpb.UUID) *uuidpb.UUID {
+	if utils.IsNilUUIDProto(createdBy) {
+		return nil
+	}
+	return createdBy
+}
+
+// keyCreatorFromContext returns the ID of the authenticated user, who a newly created key is attributed to.
+func keyCreatorFromContext(ctx context.Context) *uuidpb.UUID {
+	sCtx, err := authcontext.FromContext(ctx)
+	if err != nil {
+		return nil
+	}
+	return keyCreator(utils.ProtoFromUUIDStrOrNil(sCtx.Claims.GetUserClaims().UserID))
 }
 
 func deployKeyToCloudAPI(key *vzmgrpb.DeploymentKey) *cloudpb.DeploymentKey {
@@ -463,6 +1347,7 @@ func deployKeyToCloudAPI(key *vzmgrpb.DeploymentKey) *cloudpb.DeploymentKey {
 		Key:       key.Key,
 		CreatedAt: key.CreatedAt,
 		Desc:      key.Desc,
+		CreatedBy: keyCreator(key.CreatedBy),
 	}
 }
 
@@ -477,7 +1362,12 @@ func (v *VizierDeploymentKeyServer) Create(ctx context.Context, req *cloudpb.Cre
 	if err != nil {
 		return nil, err
 	}
-	return deployKeyToCloudAPI(resp), nil
+	audit(ctx, v.AuditHook, AuditActionCreateDeploymentKey, utils.ProtoToUUIDStr(resp.ID))
+	key := deployKeyToCloudAPI(resp)
+	if key.CreatedBy == nil {
+		key.CreatedBy = keyCreatorFromContext(ctx)
+	}
+	return key, nil
 }
 
 // List lists all of the deploy keys in vzmgr.
@@ -487,6 +1377,7 @@ func (v *VizierDeploymentKeyServer) List(ctx context.Context, req *cloudpb.ListD
 		return nil, err
 	}
 
+	// vzmgr doesn't support pagination, so all of the keys are fetched and then sorted and paged here.
 	resp, err := v.VzDeploymentKey.List(ctx, &vzmgrpb.ListDeploymentKeyRequest{})
 	if err != nil {
 		return nil, err
@@ -495,8 +1386,20 @@ func (v *VizierDeploymentKeyServer) List(ctx context.Context, req *cloudpb.ListD
 	for _, key := range resp.Keys {
 		keys = append(keys, deployKeyToCloudAPI(key))
 	}
+
+	fields := func(k *cloudpb.DeploymentKey) keySortFields {
+		return keySortFields{id: utils.ProtoToUUIDStr(k.ID), createdAt: k.CreatedAt, desc: k.Desc}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keyLess(req.SortBy, fields(keys[i]), fields(keys[j]))
+	})
+	start, end, nextPageToken, err := keysPage(len(keys), req.PageSize, req.PageToken)
+	if err != nil {
+		return nil, err
+	}
 	return &cloudpb.ListDeploymentKeyResponse{
-		Keys: keys,
+		Keys:          keys[start:end],
+		NextPageToken: nextPageToken,
 	}, nil
 }
 
@@ -524,12 +1427,46 @@ func (v *VizierDeploymentKeyServer) Delete(ctx context.Context, uuid *uuidpb.UUI
 	if err != nil {
 		return nil, err
 	}
-	return v.VzDeploymentKey.Delete(ctx, uuid)
+	resp, err := v.VzDeploymentKey.Delete(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+	audit(ctx, v.AuditHook, AuditActionDeleteDeploymentKey, utils.ProtoToUUIDStr(uuid))
+	return resp, nil
+}
+
+// GetDeployedClusters fetches the clusters that were registered using a specific deploy key in vzmgr.
+func (v *VizierDeploymentKeyServer) GetDeployedClusters(ctx context.Context, req *cloudpb.GetDeployedClustersRequest) (*cloudpb.GetDeployedClustersResponse, error) {
+	ctx, err := contextWithAuthToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.VzDeploymentKey.GetDeployedClusters(ctx, &vzmgrpb.GetDeployedClustersRequest{
+		KeyID: req.KeyID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	clusters := make([]*cloudpb.DeployedCluster, len(resp.Clusters))
+	for i, c := range resp.Clusters {
+		clusters[i] = &cloudpb.DeployedCluster{
+			ID:          c.ID,
+			ClusterName: c.ClusterName,
+			ClusterUID:  c.ClusterUID,
+			CreatedAt:   c.CreatedAt,
+		}
+	}
+	return &cloudpb.GetDeployedClustersResponse{
+		Clusters: clusters,
+	}, nil
 }
 
 // APIKeyServer is the server that implements the APIKeyManager gRPC service.
 type APIKeyServer struct {
 	APIKeyClient authpb.APIKeyServiceClient
+	// AuditHook is called when an API key is created or deleted. Defaults to LogAuditEvent if unset.
+	AuditHook AuditHook
 }
 
 func apiKeyToCloudAPI(key *authpb.APIKey) *cloudpb.APIKey {
@@ -538,6 +1475,7 @@ func apiKeyToCloudAPI(key *authpb.APIKey) *cloudpb.APIKey {
 		Key:       key.Key,
 		CreatedAt: key.CreatedAt,
 		Desc:      key.Desc,
+		CreatedBy: keyCreator(key.CreatedBy),
 	}
 }
 
@@ -552,7 +1490,12 @@ func (v *APIKeyServer) Create(ctx context.Context, req *cloudpb.CreateAPIKeyRequ
 	if err != nil {
 		return nil, err
 	}
-	return apiKeyToCloudAPI(resp), nil
+	audit(ctx, v.AuditHook, AuditActionCreateAPIKey, utils.ProtoToUUIDStr(resp.ID))
+	key := apiKeyToCloudAPI(resp)
+	if key.CreatedBy == nil {
+		key.CreatedBy = keyCreatorFromContext(ctx)
+	}
+	return key, nil
 }
 
 // List lists all of the API keys in vzmgr.
@@ -562,6 +1505,7 @@ func (v *APIKeyServer) List(ctx context.Context, req *cloudpb.ListAPIKeyRequest)
 		return nil, err
 	}
 
+	// The auth service doesn't support pagination, so all of the keys are fetched and then sorted and paged here.
 	resp, err := v.APIKeyClient.List(ctx, &authpb.ListAPIKeyRequest{})
 	if err != nil {
 		return nil, err
@@ -570,8 +1514,20 @@ func (v *APIKeyServer) List(ctx context.Context, req *cloudpb.ListAPIKeyRequest)
 	for _, key := range resp.Keys {
 		keys = append(keys, apiKeyToCloudAPI(key))
 	}
+
+	fields := func(k *cloudpb.APIKey) keySortFields {
+		return keySortFields{id: utils.ProtoToUUIDStr(k.ID), createdAt: k.CreatedAt, desc: k.Desc}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keyLess(req.SortBy, fields(keys[i]), fields(keys[j]))
+	})
+	start, end, nextPageToken, err := keysPage(len(keys), req.PageSize, req.PageToken)
+	if err != nil {
+		return nil, err
+	}
 	return &cloudpb.ListAPIKeyResponse{
-		Keys: keys,
+		Keys:          keys[start:end],
+		NextPageToken: nextPageToken,
 	}, nil
 }
 
@@ -599,16 +1555,59 @@ func (v *APIKeyServer) Delete(ctx context.Context, uuid *uuidpb.UUID) (*types.Em
 	if err != nil {
 		return nil, err
 	}
-	return v.APIKeyClient.Delete(ctx, uuid)
+	resp, err := v.APIKeyClient.Delete(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+	audit(ctx, v.AuditHook, AuditActionDeleteAPIKey, utils.ProtoToUUIDStr(uuid))
+	return resp, nil
 }
 
 // AutocompleteServer is the server that implements the Autocomplete gRPC service.
 type AutocompleteServer struct {
 	Suggester autocomplete.Suggester
+	// OrgRateLimit is the sustained number of autocomplete requests per second allowed for each org.
+	// Defaults to defaultAutocompleteOrgRateLimit if unset.
+	OrgRateLimit float64
+	// OrgRateLimitBurst is the number of autocomplete requests an org may make in a burst above OrgRateLimit.
+	// Defaults to defaultAutocompleteOrgRateLimitBurst if unset.
+	OrgRateLimitBurst int
+	// Now returns the current time, used to refill the rate limiter. Defaults to time.Now if unset.
+	Now func() time.Time
+
+	limiter orgRateLimiter
+}
+
+const (
+	defaultAutocompleteOrgRateLimit      = 20
+	defaultAutocompleteOrgRateLimitBurst = 40
+)
+
+// checkRateLimit returns ResourceExhausted if the org has exceeded its autocomplete rate, so a single misbehaving
+// client can't overload the suggester for everyone else.
+func (a *AutocompleteServer) checkRateLimit(orgID string) error {
+	rate := a.OrgRateLimit
+	if rate <= 0 {
+		rate = defaultAutocompleteOrgRateLimit
+	}
+	burst := a.OrgRateLimitBurst
+	if burst <= 0 {
+		burst = defaultAutocompleteOrgRateLimitBurst
+	}
+	now := time.Now()
+	if a.Now != nil {
+		now = a.Now()
+	}
+	if !a.limiter.allow(orgID, rate, burst, now) {
+		return status.Errorf(codes.ResourceExhausted, "autocomplete rate limit of %v requests per second exceeded for org", rate)
+	}
+	return nil
 }
 
 // Autocomplete returns a formatted string and autocomplete suggestions.
-func (a *AutocompleteServer) Autocomplete(ctx context.Context, req *cloudpb.AutocompleteRequest) (*cloudpb.AutocompleteResponse, error) {
+func (a *AutocompleteServer) Autocomplete(ctx context.Context, req *cloudpb.AutocompleteRequest) (resp *cloudpb.AutocompleteResponse, err error) {
+	defer func() { recordAutocompleteRequest(autocompleteEndpoint, err) }()
+
 	sCtx, err := authcontext.FromContext(ctx)
 	if err != nil {
 		return nil, err
@@ -618,21 +1617,106 @@ func (a *AutocompleteServer) Autocomplete(ctx context.Context, req *cloudpb.Auto
 	if err != nil {
 		return nil, err
 	}
+	if err := a.checkRateLimit(orgID.String()); err != nil {
+		return nil, err
+	}
 
-	fmtString, executable, suggestions, err := autocomplete.Autocomplete(req.Input, int(req.CursorPos), req.Action, a.Suggester, orgID, req.ClusterUID)
+	// The cursor splits the input into the token being edited, so it must fall within the input.
+	if req.CursorPos < 0 || int(req.CursorPos) > len(req.Input) {
+		return nil, status.Errorf(codes.InvalidArgument, "cursor position %d is outside of input of length %d", req.CursorPos, len(req.Input))
+	}
+
+	fmtString, executable, blockReason, suggestions, tabStops, err := autocomplete.Autocomplete(req.Input, int(req.CursorPos), req.Action, timedSuggester{a.Suggester, autocompleteEndpoint}, orgID, req.ClusterUID)
 	if err != nil {
 		return nil, err
 	}
 
 	return &cloudpb.AutocompleteResponse{
-		FormattedInput: fmtString,
-		IsExecutable:   executable,
-		TabSuggestions: suggestions,
+		FormattedInput:        fmtString,
+		IsExecutable:          executable,
+		ExecutableBlockReason: blockReason,
+		TabSuggestions:        suggestions,
+		TabStops:              tabStops,
 	}, nil
 }
 
 // AutocompleteField returns suggestions for a single field.
-func (a *AutocompleteServer) AutocompleteField(ctx context.Context, req *cloudpb.AutocompleteFieldRequest) (*cloudpb.AutocompleteFieldResponse, error) {
+func (a *AutocompleteServer) AutocompleteField(ctx context.Context, req *cloudpb.AutocompleteFieldRequest) (resp *cloudpb.AutocompleteFieldResponse, err error) {
+	defer func() { recordAutocompleteRequest(autocompleteFieldEndpoint, err) }()
+
+	suggestionReq, err := fieldSuggestionRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.checkRateLimit(suggestionReq.OrgID.String()); err != nil {
+		return nil, err
+	}
+
+	suggestions, err := timedSuggester{a.Suggester, autocompleteFieldEndpoint}.GetSuggestions([]*autocomplete.SuggestionRequest{suggestionReq})
+	if err != nil {
+		return nil, err
+	}
+	if len(suggestions) != 1 {
+		return nil, status.Error(codes.Internal, "failed to get autocomplete suggestions")
+	}
+
+	result := suggestions[0]
+	// Suggesters which don't count their matches return all of them as suggestions.
+	totalCount := result.TotalCount
+	if totalCount < int64(len(result.Suggestions)) {
+		totalCount = int64(len(result.Suggestions))
+	}
+
+	resp = &cloudpb.AutocompleteFieldResponse{
+		TotalCount: totalCount,
+	}
+	if !req.CountOnly {
+		sortSuggestionsByState(result.Suggestions)
+		resp.Suggestions = toAutocompleteSuggestions(result.Suggestions)
+	}
+	return resp, nil
+}
+
+// StreamAutocompleteField streams suggestions for a single field as they are scored. If the suggester
+// can't stream suggestions, all of the suggestions are sent in a single batch.
+func (a *AutocompleteServer) StreamAutocompleteField(req *cloudpb.AutocompleteFieldRequest, srv cloudpb.AutocompleteService_StreamAutocompleteFieldServer) (err error) {
+	defer func() { recordAutocompleteRequest(streamAutocompleteFieldEndpoint, err) }()
+
+	ctx := srv.Context()
+	suggestionReq, err := fieldSuggestionRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+	if err := a.checkRateLimit(suggestionReq.OrgID.String()); err != nil {
+		return err
+	}
+
+	send := func(suggestions []*autocomplete.Suggestion) error {
+		// Stop as soon as the client goes away, rather than scoring suggestions that won't be read.
+		if ctx.Err() != nil {
+			return status.Error(codes.Canceled, ctx.Err().Error())
+		}
+		return srv.Send(&cloudpb.AutocompleteFieldResponse{
+			Suggestions: toAutocompleteSuggestions(suggestions),
+		})
+	}
+
+	if s, ok := a.Suggester.(autocomplete.StreamingSuggester); ok {
+		defer observeSuggestionLatency(streamAutocompleteFieldEndpoint, time.Now())
+		return s.StreamSuggestions(ctx, suggestionReq, send)
+	}
+
+	suggestions, err := timedSuggester{a.Suggester, streamAutocompleteFieldEndpoint}.GetSuggestions([]*autocomplete.SuggestionRequest{suggestionReq})
+	if err != nil {
+		return err
+	}
+	if len(suggestions) != 1 {
+		return status.Error(codes.Internal, "failed to get autocomplete suggestions")
+	}
+	return send(suggestions[0].Suggestions)
+}
+
+func fieldSuggestionRequest(ctx context.Context, req *cloudpb.AutocompleteFieldRequest) (*autocomplete.SuggestionRequest, error) {
 	sCtx, err := authcontext.FromContext(ctx)
 	if err != nil {
 		return nil, err
@@ -648,37 +1732,57 @@ func (a *AutocompleteServer) AutocompleteField(ctx context.Context, req *cloudpb
 		allowedArgs = req.RequiredArgTypes
 	}
 
-	suggestionReq := []*autocomplete.SuggestionRequest{
-		{
-			OrgID:        orgID,
-			Input:        req.Input,
-			AllowedKinds: []cloudpb.AutocompleteEntityKind{req.FieldType},
-			AllowedArgs:  allowedArgs,
-			ClusterUID:   req.ClusterUID,
-		},
-	}
-	suggestions, err := a.Suggester.GetSuggestions(suggestionReq)
-	if err != nil {
-		return nil, err
-	}
-	if len(suggestions) != 1 {
-		return nil, status.Error(codes.Internal, "failed to get autocomplete suggestions")
+	return &autocomplete.SuggestionRequest{
+		OrgID:        orgID,
+		Input:        req.Input,
+		AllowedKinds: []cloudpb.AutocompleteEntityKind{req.FieldType},
+		AllowedArgs:  allowedArgs,
+		ClusterUID:   req.ClusterUID,
+		Fuzzy:        req.Fuzzy,
+	}, nil
+}
+
+// entityStateRank ranks suggestions by their entity state, lowest first. Running entities come first, since users
+// almost always want live entities, and terminated entities come last.
+func entityStateRank(state cloudpb.AutocompleteEntityState) int {
+	switch state {
+	case cloudpb.AES_RUNNING:
+		return 0
+	case cloudpb.AES_TERMINATED:
+		return 2
+	default:
+		return 1
 	}
+}
+
+// sortSuggestionsByState sorts the suggestions by score, breaking ties by the entity state of the suggestion.
+// Suggestions with the same score and state keep their relative order.
+func sortSuggestionsByState(suggestions []*autocomplete.Suggestion) {
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return entityStateRank(suggestions[i].State) < entityStateRank(suggestions[j].State)
+	})
+}
 
-	acSugg := make([]*cloudpb.AutocompleteSuggestion, len(suggestions[0].Suggestions))
-	for j, s := range suggestions[0].Suggestions {
+func toAutocompleteSuggestions(suggestions []*autocomplete.Suggestion) []*cloudpb.AutocompleteSuggestion {
+	acSugg := make([]*cloudpb.AutocompleteSuggestion, len(suggestions))
+	for j, s := range suggestions {
+		matchType := cloudpb.AMT_EXACT
+		if s.Fuzzy {
+			matchType = cloudpb.AMT_FUZZY
+		}
 		acSugg[j] = &cloudpb.AutocompleteSuggestion{
 			Kind:           s.Kind,
 			Name:           s.Name,
 			Description:    s.Desc,
 			MatchedIndexes: s.MatchedIndexes,
 			State:          s.State,
+			MatchType:      matchType,
 		}
 	}
-
-	return &cloudpb.AutocompleteFieldResponse{
-		Suggestions: acSugg,
-	}, nil
+	return acSugg
 }
 
 // ScriptMgrServer is the server that implements the ScriptMgr gRPC service.
@@ -686,13 +1790,15 @@ type ScriptMgrServer struct {
 	ScriptMgr scriptmgrpb.ScriptMgrServiceClient
 }
 
-// GetLiveViews returns a list of all available live views.
+// GetLiveViews returns a list of all available live views, or only the live views referencing a script.
 func (s *ScriptMgrServer) GetLiveViews(ctx context.Context, req *cloudpb.GetLiveViewsReq) (*cloudpb.GetLiveViewsResp, error) {
 	ctx, err := contextWithAuthToken(ctx)
 	if err != nil {
 		return nil, err
 	}
-	smReq := &scriptmgrpb.GetLiveViewsReq{}
+	smReq := &scriptmgrpb.GetLiveViewsReq{
+		ReferencedScript: req.ReferencedScript,
+	}
 	smResp, err := s.ScriptMgr.GetLiveViews(ctx, smReq)
 	if err != nil {
 		return nil, err
@@ -712,20 +1818,25 @@ func (s *ScriptMgrServer) GetLiveViews(ctx context.Context, req *cloudpb.GetLive
 
 // GetLiveViewContents returns the pxl script, vis info, and metdata for a live view.
 func (s *ScriptMgrServer) GetLiveViewContents(ctx context.Context, req *cloudpb.GetLiveViewContentsReq) (*cloudpb.GetLiveViewContentsResp, error) {
-	ctx, err := contextWithAuthToken(ctx)
+	liveViewID, err := uuid.FromString(req.LiveViewID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid live view ID")
+	}
+
+	ctx, err = contextWithAuthToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	smReq := &scriptmgrpb.GetLiveViewContentsReq{
-		LiveViewID: utils.ProtoFromUUIDStrOrNil(req.LiveViewID),
+		LiveViewID: utils.ProtoFromUUID(liveViewID),
 	}
 	smResp, err := s.ScriptMgr.GetLiveViewContents(ctx, smReq)
 	if err != nil {
 		return nil, err
 	}
 
-	return &cloudpb.GetLiveViewContentsResp{
+	resp := &cloudpb.GetLiveViewContentsResp{
 		Metadata: &cloudpb.LiveViewMetadata{
 			ID:   req.LiveViewID,
 			Name: smResp.Metadata.Name,
@@ -733,44 +1844,70 @@ func (s *ScriptMgrServer) GetLiveViewContents(ctx context.Context, req *cloudpb.
 		},
 		PxlContents: smResp.PxlContents,
 		Vis:         smResp.Vis,
-	}, nil
+	}
+	if req.ValidateVis {
+		resp.VisWarnings = visWarnings(smResp.Vis)
+	}
+	return resp, nil
+}
+
+// visWarnings returns a warning for each widget in the vis spec which is missing its func, or whose
+// display spec is missing or can't be parsed.
+func visWarnings(vis *vispb.Vis) []string {
+	var warnings []string
+	for i, w := range vis.GetWidgets() {
+		name := w.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+		if w.FuncOrRef == nil {
+			warnings = append(warnings, fmt.Sprintf("widget %s has no func or global func output name", name))
+		}
+		if w.DisplaySpec == nil {
+			warnings = append(warnings, fmt.Sprintf("widget %s has no display spec", name))
+			continue
+		}
+		var displaySpec types.DynamicAny
+		if err := types.UnmarshalAny(w.DisplaySpec, &displaySpec); err != nil {
+			warnings = append(warnings, fmt.Sprintf("widget %s has an invalid display spec: %v", name, err))
+		}
+	}
+	return warnings
 }
 
-// GetScripts returns a list of all available scripts.
+// GetScripts returns a list of all available scripts, or only the scripts carrying all tags in the tag filter.
 func (s *ScriptMgrServer) GetScripts(ctx context.Context, req *cloudpb.GetScriptsReq) (*cloudpb.GetScriptsResp, error) {
 	ctx, err := contextWithAuthToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	smReq := &scriptmgrpb.GetScriptsReq{}
+	smReq := &scriptmgrpb.GetScriptsReq{
+		TagFilter: req.TagFilter,
+	}
 	smResp, err := s.ScriptMgr.GetScripts(ctx, smReq)
 	if err != nil {
 		return nil, err
 	}
-	resp := &cloudpb.GetScriptsResp{
-		Scripts: make([]*cloudpb.ScriptMetadata, len(smResp.Scripts)),
-	}
-	for i, script := range smResp.Scripts {
-		resp.Scripts[i] = &cloudpb.ScriptMetadata{
-			ID:          utils.UUIDFromProtoOrNil(script.ID).String(),
-			Name:        script.Name,
-			Desc:        script.Desc,
-			HasLiveView: script.HasLiveView,
-		}
-	}
-	return resp, nil
+	return &cloudpb.GetScriptsResp{
+		Scripts: toCloudScriptMetadata(smResp.Scripts),
+	}, nil
 }
 
 // GetScriptContents returns the pxl string of the script.
 func (s *ScriptMgrServer) GetScriptContents(ctx context.Context, req *cloudpb.GetScriptContentsReq) (*cloudpb.GetScriptContentsResp, error) {
-	ctx, err := contextWithAuthToken(ctx)
+	scriptID, err := uuid.FromString(req.ScriptID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid script ID")
+	}
+
+	ctx, err = contextWithAuthToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	smReq := &scriptmgrpb.GetScriptContentsReq{
-		ScriptID: utils.ProtoFromUUIDStrOrNil(req.ScriptID),
+		ScriptID: utils.ProtoFromUUID(scriptID),
 	}
 	smResp, err := s.ScriptMgr.GetScriptContents(ctx, smReq)
 	if err != nil {
@@ -782,11 +1919,81 @@ func (s *ScriptMgrServer) GetScriptContents(ctx context.Context, req *cloudpb.Ge
 			Name:        smResp.Metadata.Name,
 			Desc:        smResp.Metadata.Desc,
 			HasLiveView: smResp.Metadata.HasLiveView,
+			Tags:        smResp.Metadata.Tags,
 		},
 		Contents: smResp.Contents,
 	}, nil
 }
 
+func toCloudScriptMetadata(scripts []*scriptmgrpb.ScriptMetadata) []*cloudpb.ScriptMetadata {
+	res := make([]*cloudpb.ScriptMetadata, len(scripts))
+	for i, script := range scripts {
+		res[i] = &cloudpb.ScriptMetadata{
+			ID:          utils.UUIDFromProtoOrNil(script.ID).String(),
+			Name:        script.Name,
+			Desc:        script.Desc,
+			HasLiveView: script.HasLiveView,
+			Tags:        script.Tags,
+		}
+	}
+	return res
+}
+
+// SearchScripts returns the scripts whose name or description matches the query, most relevant first.
+// If the scriptmgr service doesn't support searching, the scripts are filtered by substring here instead,
+// with scripts whose name matches ranked above scripts where only the description matches.
+func (s *ScriptMgrServer) SearchScripts(ctx context.Context, req *cloudpb.SearchScriptsReq) (*cloudpb.SearchScriptsResp, error) {
+	ctx, err := contextWithAuthToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	smResp, err := s.ScriptMgr.SearchScripts(ctx, &scriptmgrpb.SearchScriptsReq{
+		Query:       req.Query,
+		HasLiveView: req.HasLiveView,
+	})
+	if status.Code(err) == codes.Unimplemented {
+		return s.searchScriptsFallback(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cloudpb.SearchScriptsResp{
+		Scripts: toCloudScriptMetadata(smResp.Scripts),
+	}, nil
+}
+
+func (s *ScriptMgrServer) searchScriptsFallback(ctx context.Context, req *cloudpb.SearchScriptsReq) (*cloudpb.SearchScriptsResp, error) {
+	smResp, err := s.ScriptMgr.GetScripts(ctx, &scriptmgrpb.GetScriptsReq{})
+	if err != nil {
+		return nil, err
+	}
+
+	query := strings.ToLower(strings.TrimSpace(req.Query))
+	var nameMatches, descMatches []*scriptmgrpb.ScriptMetadata
+	for _, script := range smResp.Scripts {
+		if req.HasLiveView != nil && req.HasLiveView.Value != script.HasLiveView {
+			continue
+		}
+		if strings.Contains(strings.ToLower(script.Name), query) {
+			nameMatches = append(nameMatches, script)
+		} else if strings.Contains(strings.ToLower(script.Desc), query) {
+			descMatches = append(descMatches, script)
+		}
+	}
+	byName := func(scripts []*scriptmgrpb.ScriptMetadata) {
+		sort.Slice(scripts, func(i, j int) bool {
+			return scripts[i].Name < scripts[j].Name
+		})
+	}
+	byName(nameMatches)
+	byName(descMatches)
+
+	return &cloudpb.SearchScriptsResp{
+		Scripts: toCloudScriptMetadata(append(nameMatches, descMatches...)),
+	}, nil
+}
+
 // ProfileServer provides info about users and orgs.
 type ProfileServer struct {
 	ProfileServiceClient profilepb.ProfileServiceClient
@@ -820,13 +2027,64 @@ func (p *ProfileServer) GetOrgInfo(ctx context.Context, req *uuidpb.UUID) (*clou
 	}, nil
 }
 
+// UpdateOrgInfo renames the org with the given ID.
+func (p *ProfileServer) UpdateOrgInfo(ctx context.Context, req *cloudpb.UpdateOrgInfoRequest) (*cloudpb.OrgInfo, error) {
+	if strings.TrimSpace(req.OrgName) == "" {
+		return nil, status.Error(codes.InvalidArgument, "org name cannot be empty")
+	}
+
+	ctx, err := contextWithAuthToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sCtx, err := authcontext.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claimsOrgID := sCtx.Claims.GetUserClaims().OrgID
+	orgID := utils.UUIDFromProtoOrNil(req.ID)
+	if claimsOrgID != orgID.String() {
+		return nil, status.Error(codes.PermissionDenied, "Unable to update org info")
+	}
+
+	resp, err := p.ProfileServiceClient.UpdateOrg(ctx, &profilepb.UpdateOrgRequest{
+		ID:      req.ID,
+		OrgName: &types.StringValue{Value: req.OrgName},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &cloudpb.OrgInfo{
+		ID:      resp.ID,
+		OrgName: resp.OrgName,
+	}, nil
+}
+
 // OrganizationServiceServer is the server that implements the OrganizationService gRPC service.
 type OrganizationServiceServer struct {
 	ProfileServiceClient profilepb.ProfileServiceClient
+	// AuditHook is called when a user is invited, or their invite is resent. Defaults to LogAuditEvent if unset.
+	AuditHook AuditHook
+}
+
+// validateInviteEmail checks that email is a single bare address, such as "bob@example.com", rather than
+// a name and address or a list of addresses.
+func validateInviteEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return status.Errorf(codes.InvalidArgument, "invalid email address %q", email)
+	}
+	return nil
 }
 
 // InviteUser creates and returns an invite link for the org for the specified user info.
 func (o *OrganizationServiceServer) InviteUser(ctx context.Context, externalReq *cloudpb.InviteUserRequest) (*cloudpb.InviteUserResponse, error) {
+	if err := validateInviteEmail(externalReq.Email); err != nil {
+		return nil, err
+	}
+
 	ctx, err := contextWithAuthToken(ctx)
 	if err != nil {
 		return nil, err
@@ -848,18 +2106,211 @@ func (o *OrganizationServiceServer) InviteUser(ctx context.Context, externalReq
 		FirstName:      externalReq.FirstName,
 		LastName:       externalReq.LastName,
 		MustCreateUser: true,
+		TTL:            externalReq.TTL,
 	}
 	resp, err := o.ProfileServiceClient.InviteUser(ctx, internalReq)
 	if err != nil {
 		return nil, err
 	}
+	audit(ctx, o.AuditHook, AuditActionInviteUser, resp.Email)
+
+	return &cloudpb.InviteUserResponse{
+		Email:       resp.Email,
+		InviteLink:  resp.InviteLink,
+		ExpiresAt:   resp.ExpiresAt,
+		UserCreated: resp.UserCreated,
+	}, nil
+}
+
+// maxInvitesPerRequest is the max number of users that can be invited in a single InviteUsers request.
+const maxInvitesPerRequest = 100
+
+// InviteUsers invites each of the requested users, continuing on to the remaining users if an invite fails.
+func (o *OrganizationServiceServer) InviteUsers(ctx context.Context, req *cloudpb.InviteUsersRequest) (*cloudpb.InviteUsersResponse, error) {
+	if len(req.Users) > maxInvitesPerRequest {
+		return nil, status.Errorf(codes.InvalidArgument, "cannot invite more than %d users at once", maxInvitesPerRequest)
+	}
+
+	results := make([]*cloudpb.InviteUserResult, len(req.Users))
+	for i, u := range req.Users {
+		results[i] = &cloudpb.InviteUserResult{Email: u.Email}
+		resp, err := o.InviteUser(ctx, u)
+		if err != nil {
+			results[i].Error = status.Convert(err).Message()
+			continue
+		}
+		results[i].Success = true
+		results[i].InviteLink = resp.InviteLink
+		results[i].ExpiresAt = resp.ExpiresAt
+	}
+	return &cloudpb.InviteUsersResponse{
+		Results: results,
+	}, nil
+}
+
+// ResendInvite regenerates the Invite Link for a user who has been invited to the current org, but has
+// not yet accepted their invite.
+func (o *OrganizationServiceServer) ResendInvite(ctx context.Context, externalReq *cloudpb.ResendInviteRequest) (*cloudpb.InviteUserResponse, error) {
+	ctx, err := contextWithAuthToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sCtx, err := authcontext.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claimsOrgID := sCtx.Claims.GetUserClaims().OrgID
+	orgIDPb := utils.ProtoFromUUIDStrOrNil(claimsOrgID)
+	if orgIDPb == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Could not identify user's org")
+	}
+
+	resp, err := o.ProfileServiceClient.ResendInvite(ctx, &profilepb.ResendInviteRequest{
+		OrgID: orgIDPb,
+		Email: externalReq.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+	audit(ctx, o.AuditHook, AuditActionResendInvite, resp.Email)
 
 	return &cloudpb.InviteUserResponse{
-		Email:      resp.Email,
-		InviteLink: resp.InviteLink,
+		Email:       resp.Email,
+		InviteLink:  resp.InviteLink,
+		UserCreated: resp.UserCreated,
+	}, nil
+}
+
+// ListOrgInvites lists the invites to the user's org that have not yet been accepted.
+func (o *OrganizationServiceServer) ListOrgInvites(ctx context.Context, req *cloudpb.ListOrgInvitesRequest) (*cloudpb.ListOrgInvitesResponse, error) {
+	ctx, err := contextWithAuthToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sCtx, err := authcontext.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claimsOrgID := sCtx.Claims.GetUserClaims().OrgID
+	orgIDPb := utils.ProtoFromUUIDStrOrNil(claimsOrgID)
+	if orgIDPb == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Could not identify user's org")
+	}
+
+	resp, err := o.ProfileServiceClient.ListOrgInvites(ctx, &profilepb.ListOrgInvitesRequest{
+		OrgID: orgIDPb,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invites := make([]*cloudpb.OrgInvite, len(resp.Invites))
+	for i, inv := range resp.Invites {
+		invites[i] = &cloudpb.OrgInvite{
+			Email:        inv.Email,
+			InviterEmail: inv.InviterEmail,
+			CreatedAt:    inv.CreatedAt,
+			ExpiresAt:    inv.ExpiresAt,
+		}
+	}
+	return &cloudpb.ListOrgInvitesResponse{
+		Invites: invites,
+	}, nil
+}
+
+func orgUserFromProfileUser(user *profilepb.UserInfo) *cloudpb.OrgUser {
+	role := cloudpb.OUR_MEMBER
+	if user.IsOrgAdmin {
+		role = cloudpb.OUR_ADMIN
+	} else if !user.IsApproved {
+		role = cloudpb.OUR_PENDING_APPROVAL
+	}
+	return &cloudpb.OrgUser{
+		ID:    user.ID,
+		Name:  strings.TrimSpace(fmt.Sprintf("%s %s", user.FirstName, user.LastName)),
+		Email: user.Email,
+		Role:  role,
+	}
+}
+
+// ListOrgUsers lists the users in the user's org, along with their role in the org.
+func (o *OrganizationServiceServer) ListOrgUsers(ctx context.Context, req *cloudpb.ListOrgUsersRequest) (*cloudpb.ListOrgUsersResponse, error) {
+	ctx, err := contextWithAuthToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sCtx, err := authcontext.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claimsOrgID := sCtx.Claims.GetUserClaims().OrgID
+	orgIDPb := utils.ProtoFromUUIDStrOrNil(claimsOrgID)
+	if orgIDPb == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Could not identify user's org")
+	}
+
+	resp, err := o.ProfileServiceClient.GetUsersInOrg(ctx, &profilepb.GetUsersInOrgRequest{
+		OrgID: orgIDPb,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*cloudpb.OrgUser, 0, len(resp.Users))
+	for _, user := range resp.Users {
+		// Never return users from outside of the user's org.
+		if utils.UUIDFromProtoOrNil(user.OrgID).String() != claimsOrgID {
+			continue
+		}
+		users = append(users, orgUserFromProfileUser(user))
+	}
+	return &cloudpb.ListOrgUsersResponse{
+		Users: users,
+	}, nil
+}
+
+// RemoveUserFromOrg removes a user from the user's org, revoking their access.
+func (o *OrganizationServiceServer) RemoveUserFromOrg(ctx context.Context, req *cloudpb.RemoveUserFromOrgRequest) (*cloudpb.RemoveUserFromOrgResponse, error) {
+	ctx, err := contextWithAuthToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := o.ProfileServiceClient.RemoveUserFromOrg(ctx, &profilepb.RemoveUserFromOrgRequest{
+		UserID: req.UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cloudpb.RemoveUserFromOrgResponse{
+		Success: resp.Success,
 	}, nil
 }
 
+// UpdateUserRole changes the role of a user in the user's org.
+func (o *OrganizationServiceServer) UpdateUserRole(ctx context.Context, req *cloudpb.UpdateUserRoleRequest) (*cloudpb.OrgUser, error) {
+	if req.Role != cloudpb.OUR_MEMBER && req.Role != cloudpb.OUR_ADMIN {
+		return nil, status.Errorf(codes.InvalidArgument, "role must be either member or admin")
+	}
+
+	ctx, err := contextWithAuthToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := o.ProfileServiceClient.UpdateUserRole(ctx, &profilepb.UpdateUserRoleRequest{
+		UserID:     req.UserID,
+		IsOrgAdmin: req.Role == cloudpb.OUR_ADMIN,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orgUserFromProfileUser(resp), nil
+}
+
 // GetOrg will retrieve org based on uuid.
 func (o *OrganizationServiceServer) GetOrg(ctx context.Context, req *uuidpb.UUID) (*cloudpb.OrgInfo, error) {
 	ctx, err := contextWithAuthToken(ctx)
@@ -1074,3 +2525,99 @@ func (u *UserServiceServer) UpdateUser(ctx context.Context, req *cloudpb.UpdateU
 		IsApproved:     resp.IsApproved,
 	}, nil
 }
+
+// APIHealthServer checks that the API and the services it depends on are reachable.
+type APIHealthServer struct {
+	VzMgr                 vzmgrpb.VZMgrServiceClient
+	ProfileServiceClient  profilepb.ProfileServiceClient
+	ArtifactTrackerClient artifacttrackerpb.ArtifactTrackerClient
+	// ProbeTimeout is how long each dependency has to respond to its probe. Defaults to defaultHealthProbeTimeout
+	// if unset.
+	ProbeTimeout time.Duration
+}
+
+const defaultHealthProbeTimeout = 2 * time.Second
+
+// HealthCheck probes the requested dependencies concurrently, and reports whether each of them is up.
+func (h *APIHealthServer) HealthCheck(ctx context.Context, req *cloudpb.HealthCheckRequest) (*cloudpb.HealthCheckResponse, error) {
+	ctx, err := contextWithAuthToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	probes := make([]func(context.Context) error, len(req.Dependencies))
+	for i, d := range req.Dependencies {
+		probes[i] = h.probe(d)
+		if probes[i] == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown dependency %s", d.String())
+		}
+	}
+
+	timeout := h.ProbeTimeout
+	if timeout == 0 {
+		timeout = defaultHealthProbeTimeout
+	}
+
+	resp := &cloudpb.HealthCheckResponse{
+		Dependencies: make([]*cloudpb.DependencyHealth, len(req.Dependencies)),
+	}
+	var wg sync.WaitGroup
+	for i, d := range req.Dependencies {
+		wg.Add(1)
+		go func(i int, d cloudpb.APIDependency) {
+			defer wg.Done()
+
+			probeCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := probes[i](probeCtx)
+			health := &cloudpb.DependencyHealth{
+				Dependency: d,
+				Up:         err == nil,
+				LatencyNs:  time.Since(start).Nanoseconds(),
+			}
+			if err != nil {
+				health.Error = status.Convert(err).Message()
+			}
+			// Each probe writes to its own index, so this doesn't need a lock.
+			resp.Dependencies[i] = health
+		}(i, d)
+	}
+	wg.Wait()
+
+	return resp, nil
+}
+
+// probe returns a function which makes a cheap call to the given dependency, which doesn't depend on the caller's
+// org. It returns nil if the dependency is unknown.
+func (h *APIHealthServer) probe(d cloudpb.APIDependency) func(context.Context) error {
+	switch d {
+	case cloudpb.AD_VZMGR:
+		return func(ctx context.Context) error {
+			// Getting the info for no viziers returns without querying the database.
+			_, err := h.VzMgr.GetVizierInfos(ctx, &vzmgrpb.GetVizierInfosRequest{})
+			return err
+		}
+	case cloudpb.AD_PROFILE:
+		return func(ctx context.Context) error {
+			// Whether or not there is an org without a domain, profile has responded.
+			_, err := h.ProfileServiceClient.GetOrgByDomain(ctx, &profilepb.GetOrgByDomainRequest{})
+			if status.Code(err) == codes.NotFound {
+				return nil
+			}
+			return err
+		}
+	case cloudpb.AD_ARTIFACT_TRACKER:
+		return func(ctx context.Context) error {
+			_, err := h.ArtifactTrackerClient.GetArtifactList(ctx, &artifacttrackerpb.GetArtifactListRequest{
+				ArtifactName: "cli",
+				ArtifactType: versionspb.AT_LINUX_AMD64,
+				Limit:        1,
+			})
+			return err
+		}
+	default:
+		return nil
+	}
+}
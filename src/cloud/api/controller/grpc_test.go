@@ -20,19 +20,32 @@ package controller_test
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 	"github.com/golang/mock/gomock"
+	"github.com/olivere/elastic/v7"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"px.dev/pixie/src/api/proto/cloudpb"
+	mock_cloudpb "px.dev/pixie/src/api/proto/cloudpb/mock"
 	"px.dev/pixie/src/api/proto/uuidpb"
 	"px.dev/pixie/src/api/proto/vispb"
+	"px.dev/pixie/src/cloud/api/apienv"
 	"px.dev/pixie/src/cloud/api/controller"
 	"px.dev/pixie/src/cloud/api/controller/testutils"
 	"px.dev/pixie/src/cloud/artifact_tracker/artifacttrackerpb"
@@ -46,6 +59,8 @@ import (
 	"px.dev/pixie/src/shared/artifacts/versionspb"
 	"px.dev/pixie/src/shared/cvmsgspb"
 	"px.dev/pixie/src/shared/k8s/metadatapb"
+	"px.dev/pixie/src/shared/services/authcontext"
+	svcutils "px.dev/pixie/src/shared/services/utils"
 	"px.dev/pixie/src/utils"
 )
 
@@ -85,6 +100,257 @@ func TestArtifactTracker_GetArtifactList(t *testing.T) {
 	assert.Equal(t, 1, len(resp.Artifact))
 }
 
+func TestArtifactTracker_GetArtifactListDarwinARM64(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	mockClients.MockArtifact.EXPECT().GetArtifactList(gomock.Any(),
+		&artifacttrackerpb.GetArtifactListRequest{
+			ArtifactName: "cli",
+			Limit:        1,
+			ArtifactType: versionspb.AT_DARWIN_ARM64,
+		}).
+		Return(&versionspb.ArtifactSet{
+			Name: "cli",
+			Artifact: []*versionspb.Artifact{{
+				VersionStr:         "test",
+				AvailableArtifacts: []versionspb.ArtifactType{versionspb.AT_DARWIN_AMD64, versionspb.AT_DARWIN_ARM64},
+			}},
+		}, nil)
+
+	artifactTrackerServer := &controller.ArtifactTrackerServer{
+		ArtifactTrackerClient: mockClients.MockArtifact,
+	}
+
+	resp, err := artifactTrackerServer.GetArtifactList(ctx, &cloudpb.GetArtifactListRequest{
+		ArtifactName: "cli",
+		Limit:        1,
+		ArtifactType: cloudpb.AT_DARWIN_ARM64,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, 1, len(resp.Artifact))
+	assert.Equal(t, []cloudpb.ArtifactType{cloudpb.AT_DARWIN_AMD64, cloudpb.AT_DARWIN_ARM64}, resp.Artifact[0].AvailableArtifacts)
+}
+
+func TestArtifactTracker_GetArtifactLists(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	mockClients.MockArtifact.EXPECT().GetArtifactList(gomock.Any(),
+		&artifacttrackerpb.GetArtifactListRequest{
+			ArtifactName: "cli",
+			Limit:        1,
+			ArtifactType: versionspb.AT_LINUX_AMD64,
+		}).
+		Return(&versionspb.ArtifactSet{
+			Name: "cli",
+			Artifact: []*versionspb.Artifact{{
+				VersionStr: "test",
+			}},
+		}, nil)
+	mockClients.MockArtifact.EXPECT().GetArtifactList(gomock.Any(),
+		&artifacttrackerpb.GetArtifactListRequest{
+			ArtifactName: "vizier",
+			Limit:        5,
+			ArtifactType: versionspb.AT_CONTAINER_SET_YAMLS,
+		}).
+		Return(nil, status.Error(codes.Unavailable, "artifact tracker is down"))
+
+	artifactTrackerServer := &controller.ArtifactTrackerServer{
+		ArtifactTrackerClient: mockClients.MockArtifact,
+	}
+
+	resp, err := artifactTrackerServer.GetArtifactLists(ctx, &cloudpb.GetArtifactListsRequest{
+		Requests: []*cloudpb.GetArtifactListRequest{
+			{
+				ArtifactName: "cli",
+				Limit:        1,
+				ArtifactType: cloudpb.AT_LINUX_AMD64,
+			},
+			{
+				ArtifactName: "vizier",
+				Limit:        5,
+				ArtifactType: cloudpb.AT_CONTAINER_SET_YAMLS,
+			},
+		},
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, 2, len(resp.Results))
+
+	cliResult := resp.Results["cli"]
+	require.NotNil(t, cliResult)
+	assert.Empty(t, cliResult.Error)
+	require.NotNil(t, cliResult.ArtifactSet)
+	assert.Equal(t, "cli", cliResult.ArtifactSet.Name)
+	assert.Equal(t, 1, len(cliResult.ArtifactSet.Artifact))
+
+	vizierResult := resp.Results["vizier"]
+	require.NotNil(t, vizierResult)
+	assert.Nil(t, vizierResult.ArtifactSet)
+	assert.Equal(t, "artifact tracker is down", vizierResult.Error)
+}
+
+func TestArtifactTracker_GetArtifactListsDuplicateName(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	artifactTrackerServer := &controller.ArtifactTrackerServer{
+		ArtifactTrackerClient: mockClients.MockArtifact,
+	}
+
+	_, err := artifactTrackerServer.GetArtifactLists(ctx, &cloudpb.GetArtifactListsRequest{
+		Requests: []*cloudpb.GetArtifactListRequest{
+			{ArtifactName: "cli", ArtifactType: cloudpb.AT_LINUX_AMD64},
+			{ArtifactName: "cli", ArtifactType: cloudpb.AT_DARWIN_AMD64},
+		},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestArtifactTracker_GetLatestVersion(t *testing.T) {
+	tests := []struct {
+		name              string
+		includePrerelease bool
+		latestVersion     string
+	}{
+		{
+			name:              "excludes prereleases by default",
+			includePrerelease: false,
+			latestVersion:     "0.1.30",
+		},
+		{
+			name:              "includes prereleases when requested",
+			includePrerelease: true,
+			latestVersion:     "0.1.31-pre-main.0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+			defer cleanup()
+			ctx := context.Background()
+
+			mockClients.MockArtifact.EXPECT().GetArtifactList(gomock.Any(),
+				&artifacttrackerpb.GetArtifactListRequest{
+					ArtifactName:      "cli",
+					Limit:             1,
+					ArtifactType:      versionspb.AT_LINUX_AMD64,
+					IncludePrerelease: tc.includePrerelease,
+				}).
+				Return(&versionspb.ArtifactSet{
+					Name: "cli",
+					Artifact: []*versionspb.Artifact{{
+						VersionStr: tc.latestVersion,
+					}},
+				}, nil)
+
+			artifactTrackerServer := &controller.ArtifactTrackerServer{
+				ArtifactTrackerClient: mockClients.MockArtifact,
+			}
+
+			resp, err := artifactTrackerServer.GetLatestVersion(ctx, &cloudpb.GetLatestVersionRequest{
+				ArtifactName:      "cli",
+				ArtifactType:      cloudpb.AT_LINUX_AMD64,
+				IncludePrerelease: tc.includePrerelease,
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.latestVersion, resp.VersionStr)
+		})
+	}
+}
+
+func TestArtifactTracker_GetLatestVersion_NoVersions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	mockClients.MockArtifact.EXPECT().GetArtifactList(gomock.Any(),
+		&artifacttrackerpb.GetArtifactListRequest{
+			ArtifactName: "cli",
+			Limit:        1,
+			ArtifactType: versionspb.AT_LINUX_AMD64,
+		}).
+		Return(&versionspb.ArtifactSet{
+			Name:     "cli",
+			Artifact: []*versionspb.Artifact{},
+		}, nil)
+
+	artifactTrackerServer := &controller.ArtifactTrackerServer{
+		ArtifactTrackerClient: mockClients.MockArtifact,
+	}
+
+	resp, err := artifactTrackerServer.GetLatestVersion(ctx, &cloudpb.GetLatestVersionRequest{
+		ArtifactName: "cli",
+		ArtifactType: cloudpb.AT_LINUX_AMD64,
+	})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestArtifactTracker_Timeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// The artifact tracker blocks until the request is cancelled.
+	mockClients.MockArtifact.EXPECT().GetArtifactList(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req *artifacttrackerpb.GetArtifactListRequest, opts ...grpc.CallOption) (*versionspb.ArtifactSet, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+	mockClients.MockArtifact.EXPECT().GetDownloadLink(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req *artifacttrackerpb.GetDownloadLinkRequest, opts ...grpc.CallOption) (*artifacttrackerpb.GetDownloadLinkResponse, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+	artifactTrackerServer := &controller.ArtifactTrackerServer{
+		ArtifactTrackerClient: mockClients.MockArtifact,
+		Timeout:               10 * time.Millisecond,
+	}
+
+	listResp, err := artifactTrackerServer.GetArtifactList(ctx, &cloudpb.GetArtifactListRequest{
+		ArtifactName: "cli",
+		ArtifactType: cloudpb.AT_LINUX_AMD64,
+	})
+	assert.Nil(t, listResp)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	linkResp, err := artifactTrackerServer.GetDownloadLink(ctx, &cloudpb.GetDownloadLinkRequest{
+		ArtifactName: "cli",
+		VersionStr:   "version",
+		ArtifactType: cloudpb.AT_LINUX_AMD64,
+	})
+	assert.Nil(t, linkResp)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
 func TestArtifactTracker_GetDownloadLink(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -119,6 +385,128 @@ func TestArtifactTracker_GetDownloadLink(t *testing.T) {
 	assert.Equal(t, "sha", resp.SHA256)
 }
 
+func TestArtifactTracker_GetDownloadLinkNotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		resp *artifacttrackerpb.GetDownloadLinkResponse
+	}{
+		{
+			name: "nil response",
+			resp: nil,
+		},
+		{
+			name: "empty url",
+			resp: &artifacttrackerpb.GetDownloadLinkResponse{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+			defer cleanup()
+			ctx := context.Background()
+
+			mockClients.MockArtifact.EXPECT().GetDownloadLink(gomock.Any(),
+				&artifacttrackerpb.GetDownloadLinkRequest{
+					ArtifactName: "cli",
+					VersionStr:   "0.0.0",
+					ArtifactType: versionspb.AT_LINUX_AMD64,
+				}).
+				Return(test.resp, nil)
+
+			artifactTrackerServer := &controller.ArtifactTrackerServer{
+				ArtifactTrackerClient: mockClients.MockArtifact,
+			}
+
+			resp, err := artifactTrackerServer.GetDownloadLink(ctx, &cloudpb.GetDownloadLinkRequest{
+				ArtifactName: "cli",
+				VersionStr:   "0.0.0",
+				ArtifactType: cloudpb.AT_LINUX_AMD64,
+			})
+
+			assert.Nil(t, resp)
+			assert.Equal(t, codes.NotFound, status.Code(err))
+			msg := status.Convert(err).Message()
+			assert.Contains(t, msg, "cli")
+			assert.Contains(t, msg, "0.0.0")
+		})
+	}
+}
+
+func TestArtifactTracker_GetDownloadLinkDarwinARM64(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	mockClients.MockArtifact.EXPECT().GetDownloadLink(gomock.Any(),
+		&artifacttrackerpb.GetDownloadLinkRequest{
+			ArtifactName: "cli",
+			VersionStr:   "version",
+			ArtifactType: versionspb.AT_DARWIN_ARM64,
+		}).
+		Return(&artifacttrackerpb.GetDownloadLinkResponse{
+			Url:          "http://localhost",
+			SHA256:       "sha",
+			ArtifactType: versionspb.AT_DARWIN_ARM64,
+		}, nil)
+
+	artifactTrackerServer := &controller.ArtifactTrackerServer{
+		ArtifactTrackerClient: mockClients.MockArtifact,
+	}
+
+	resp, err := artifactTrackerServer.GetDownloadLink(ctx, &cloudpb.GetDownloadLinkRequest{
+		ArtifactName: "cli",
+		VersionStr:   "version",
+		ArtifactType: cloudpb.AT_DARWIN_ARM64,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost", resp.Url)
+	assert.Equal(t, cloudpb.AT_DARWIN_ARM64, resp.ArtifactType)
+}
+
+func TestArtifactTracker_GetDownloadLinkPreferredTypes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	mockClients.MockArtifact.EXPECT().GetDownloadLink(gomock.Any(),
+		&artifacttrackerpb.GetDownloadLinkRequest{
+			ArtifactName:   "vizier",
+			VersionStr:     "version",
+			ArtifactType:   versionspb.AT_UNKNOWN,
+			PreferredTypes: []versionspb.ArtifactType{versionspb.AT_CONTAINER_SET_YAMLS, versionspb.AT_CONTAINER_SET_TEMPLATE_YAMLS},
+		}).
+		Return(&artifacttrackerpb.GetDownloadLinkResponse{
+			Url:          "http://localhost",
+			SHA256:       "sha",
+			ArtifactType: versionspb.AT_CONTAINER_SET_TEMPLATE_YAMLS,
+		}, nil)
+
+	artifactTrackerServer := &controller.ArtifactTrackerServer{
+		ArtifactTrackerClient: mockClients.MockArtifact,
+	}
+
+	resp, err := artifactTrackerServer.GetDownloadLink(ctx, &cloudpb.GetDownloadLinkRequest{
+		ArtifactName:   "vizier",
+		VersionStr:     "version",
+		PreferredTypes: []cloudpb.ArtifactType{cloudpb.AT_CONTAINER_SET_YAMLS, cloudpb.AT_CONTAINER_SET_TEMPLATE_YAMLS},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost", resp.Url)
+	assert.Equal(t, cloudpb.AT_CONTAINER_SET_TEMPLATE_YAMLS, resp.ArtifactType)
+}
+
 func TestVizierClusterInfo_GetClusterConnectionInfo(t *testing.T) {
 	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
 
@@ -144,10 +532,39 @@ func TestVizierClusterInfo_GetClusterConnectionInfo(t *testing.T) {
 	assert.Equal(t, "hello", resp.Token)
 }
 
-func TestVizierClusterInfo_GetClusterInfo(t *testing.T) {
-	orgID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+func TestRedactedConnectionInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    string
+		expected string
+	}{
+		{
+			name:     "long token",
+			token:    "abcdefghijklmnop",
+			expected: "{IPAddress: 127.0.0.1, Token: abcd...}",
+		},
+		{
+			name:     "short token",
+			token:    "abcd",
+			expected: "{IPAddress: 127.0.0.1, Token: ...}",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			info := controller.RedactedConnectionInfo{IPAddress: "127.0.0.1", Token: tc.token}
+			assert.Equal(t, tc.expected, fmt.Sprintf("%v", info))
+			assert.NotContains(t, info.String(), tc.token)
+
+			resolver := &controller.ClusterConnectionInfoResolver{IPAddress: "127.0.0.1", Token: tc.token}
+			assert.Equal(t, tc.expected, fmt.Sprintf("%v", resolver))
+		})
+	}
+}
+
+func TestVizierClusterInfo_GetClusterConnectionInfos(t *testing.T) {
 	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
-	assert.NotNil(t, clusterID)
+	otherClusterID := utils.ProtoFromUUIDStrOrNil("8ba7b810-9dad-11d1-80b4-00c04fd430c8")
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -156,23 +573,64 @@ func TestVizierClusterInfo_GetClusterInfo(t *testing.T) {
 	defer cleanup()
 	ctx := CreateTestContext()
 
-	mockClients.MockVzMgr.EXPECT().GetViziersByOrg(gomock.Any(), orgID).Return(&vzmgrpb.GetViziersByOrgResponse{
-		VizierIDs: []*uuidpb.UUID{clusterID},
+	mockClients.MockVzMgr.EXPECT().GetVizierConnectionInfo(gomock.Any(), clusterID).Return(&cvmsgspb.VizierConnectionInfo{
+		IPAddress: "127.0.0.1",
+		Token:     "hello",
 	}, nil)
+	mockClients.MockVzMgr.EXPECT().GetVizierConnectionInfo(gomock.Any(), otherClusterID).
+		Return(nil, status.Error(codes.NotFound, "vizier not found"))
 
-	mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
-		VizierIDs: []*uuidpb.UUID{clusterID},
-	}).Return(&vzmgrpb.GetVizierInfosResponse{
-		VizierInfos: []*cvmsgspb.VizierInfo{{
-			VizierID:        clusterID,
-			Status:          cvmsgspb.VZ_ST_HEALTHY,
-			LastHeartbeatNs: int64(1305646598000000000),
-			Config: &cvmsgspb.VizierConfig{
-				PassthroughEnabled: false,
-				AutoUpdateEnabled:  true,
-			},
-			VizierVersion:  "1.2.3",
-			ClusterUID:     "a UID",
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr: mockClients.MockVzMgr,
+	}
+
+	resp, err := vzClusterInfoServer.GetClusterConnectionInfos(ctx, &cloudpb.GetClusterConnectionInfosRequest{
+		IDs: []*uuidpb.UUID{clusterID, otherClusterID},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(resp.Results))
+
+	result := resp.Results["7ba7b810-9dad-11d1-80b4-00c04fd430c8"]
+	require.NotNil(t, result)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, "127.0.0.1", result.Info.IPAddress)
+	assert.Equal(t, "hello", result.Info.Token)
+
+	otherResult := resp.Results["8ba7b810-9dad-11d1-80b4-00c04fd430c8"]
+	require.NotNil(t, otherResult)
+	assert.Nil(t, otherResult.Info)
+	assert.Contains(t, otherResult.Error, "vizier not found")
+}
+
+func TestVizierClusterInfo_GetClusterInfo(t *testing.T) {
+	orgID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	assert.NotNil(t, clusterID)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	mockClients.MockVzMgr.EXPECT().GetViziersByOrg(gomock.Any(), orgID).Return(&vzmgrpb.GetViziersByOrgResponse{
+		VizierIDs: []*uuidpb.UUID{clusterID},
+	}, nil)
+
+	mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
+		VizierIDs: []*uuidpb.UUID{clusterID},
+	}).Return(&vzmgrpb.GetVizierInfosResponse{
+		VizierInfos: []*cvmsgspb.VizierInfo{{
+			VizierID:        clusterID,
+			Status:          cvmsgspb.VZ_ST_HEALTHY,
+			LastHeartbeatNs: int64(1305646598000000000),
+			Config: &cvmsgspb.VizierConfig{
+				PassthroughEnabled: false,
+				AutoUpdateEnabled:  true,
+			},
+			VizierVersion:  "1.2.3",
+			ClusterUID:     "a UID",
 			ClusterName:    "gke_pl-dev-infra_us-west1-a_dev-cluster-zasgar-3",
 			ClusterVersion: "5.6.7",
 			ControlPlanePodStatuses: map[string]*cvmsgspb.PodStatus{
@@ -211,6 +669,9 @@ func TestVizierClusterInfo_GetClusterInfo(t *testing.T) {
 
 	vzClusterInfoServer := &controller.VizierClusterInfo{
 		VzMgr: mockClients.MockVzMgr,
+		Now: func() time.Time {
+			return time.Unix(1561230721, 0)
+		},
 	}
 
 	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{})
@@ -238,11 +699,13 @@ func TestVizierClusterInfo_GetClusterInfo(t *testing.T) {
 			StatusMessage: "pod message",
 			Reason:        "pod reason",
 			CreatedAt:     &types.Timestamp{Seconds: 1561230621},
+			AgeSeconds:    100,
 		},
 		"vizier-query-broker": {
-			Name:      "vizier-query-broker",
-			Status:    cloudpb.RUNNING,
-			CreatedAt: nil,
+			Name:       "vizier-query-broker",
+			Status:     cloudpb.RUNNING,
+			CreatedAt:  nil,
+			AgeSeconds: 0,
 		},
 	}
 
@@ -264,11 +727,8 @@ func TestVizierClusterInfo_GetClusterInfo(t *testing.T) {
 	assert.Equal(t, int32(3), cluster.NumInstrumentedNodes)
 }
 
-func TestVizierClusterInfo_GetClusterInfoDuplicates(t *testing.T) {
-	orgID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+func TestVizierClusterInfo_GetClusterInfoRecentErrorEvents(t *testing.T) {
 	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
-	assert.NotNil(t, clusterID)
-	clusterID2 := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c9")
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -277,61 +737,83 @@ func TestVizierClusterInfo_GetClusterInfoDuplicates(t *testing.T) {
 	defer cleanup()
 	ctx := CreateTestContext()
 
-	mockClients.MockVzMgr.EXPECT().GetViziersByOrg(gomock.Any(), orgID).Return(&vzmgrpb.GetViziersByOrgResponse{
-		VizierIDs: []*uuidpb.UUID{clusterID, clusterID2},
-	}, nil)
+	// The proxy has a warning event every second, interleaved with normal events.
+	var proxyEvents []*cvmsgspb.K8SEvent
+	for i := 0; i < 12; i++ {
+		proxyEvents = append(proxyEvents, &cvmsgspb.K8SEvent{
+			Message:  fmt.Sprintf("warning %d", i),
+			LastTime: &types.Timestamp{Seconds: int64(1561230600 + i)},
+			Type:     "Warning",
+		}, &cvmsgspb.K8SEvent{
+			Message:  fmt.Sprintf("normal %d", i),
+			LastTime: &types.Timestamp{Seconds: int64(1561230600 + i)},
+			Type:     "Normal",
+		})
+	}
 
 	mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
-		VizierIDs: []*uuidpb.UUID{clusterID, clusterID2},
+		VizierIDs: []*uuidpb.UUID{clusterID},
 	}).Return(&vzmgrpb.GetVizierInfosResponse{
 		VizierInfos: []*cvmsgspb.VizierInfo{{
-			VizierID:        clusterID,
-			Status:          cvmsgspb.VZ_ST_HEALTHY,
-			LastHeartbeatNs: int64(1305646598000000000),
-			Config: &cvmsgspb.VizierConfig{
-				PassthroughEnabled: false,
-				AutoUpdateEnabled:  true,
-			},
-			VizierVersion:        "1.2.3",
-			ClusterUID:           "a UID",
-			ClusterName:          "gke_pl-dev-infra_us-west1-a_dev-cluster-zasgar",
-			ClusterVersion:       "5.6.7",
-			NumNodes:             5,
-			NumInstrumentedNodes: 3,
-		},
-			{
-				VizierID:        clusterID,
-				Status:          cvmsgspb.VZ_ST_HEALTHY,
-				LastHeartbeatNs: int64(1305646598000000000),
-				Config: &cvmsgspb.VizierConfig{
-					PassthroughEnabled: false,
-					AutoUpdateEnabled:  true,
+			VizierID: clusterID,
+			Status:   cvmsgspb.VZ_ST_HEALTHY,
+			Config:   &cvmsgspb.VizierConfig{},
+			ControlPlanePodStatuses: map[string]*cvmsgspb.PodStatus{
+				"vizier-proxy": {
+					Name:   "vizier-proxy",
+					Status: metadatapb.RUNNING,
+					Events: proxyEvents,
+				},
+				"vizier-query-broker": {
+					Name:   "vizier-query-broker",
+					Status: metadatapb.FAILED,
+					Events: []*cvmsgspb.K8SEvent{
+						{
+							Message:  "newest error",
+							LastTime: &types.Timestamp{Seconds: 1561230700},
+							Type:     "Error",
+						},
+						{
+							Message:  "newest normal",
+							LastTime: &types.Timestamp{Seconds: 1561230701},
+							Type:     "Normal",
+						},
+					},
 				},
-				VizierVersion:        "1.2.3",
-				ClusterUID:           "a UID2",
-				ClusterName:          "gke_pl-pixies_us-west1-a_dev-cluster-zasgar",
-				ClusterVersion:       "5.6.7",
-				NumNodes:             5,
-				NumInstrumentedNodes: 3,
 			},
-		},
-	}, nil)
+		}},
+	}, nil).Times(2)
 
 	vzClusterInfoServer := &controller.VizierClusterInfo{
 		VzMgr: mockClients.MockVzMgr,
 	}
 
-	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{})
+	// Events shouldn't be returned unless requested.
+	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{ID: clusterID})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(resp.Clusters))
+	assert.Empty(t, resp.Clusters[0].RecentErrorEvents)
 
+	resp, err = vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{
+		ID:                       clusterID,
+		IncludeRecentErrorEvents: true,
+	})
 	require.NoError(t, err)
-	assert.Equal(t, 2, len(resp.Clusters))
-	assert.Equal(t, "gke:dev-cluster-zasgar (pl-dev-infra)", resp.Clusters[0].PrettyClusterName)
-	assert.Equal(t, "gke:dev-cluster-zasgar (pl-pixies)", resp.Clusters[1].PrettyClusterName)
+	require.Equal(t, 1, len(resp.Clusters))
+
+	expectedMessages := []string{"newest error"}
+	for i := 11; i > 2; i-- {
+		expectedMessages = append(expectedMessages, fmt.Sprintf("warning %d", i))
+	}
+	var messages []string
+	for _, ev := range resp.Clusters[0].RecentErrorEvents {
+		messages = append(messages, ev.Message)
+	}
+	assert.Equal(t, expectedMessages, messages)
 }
 
-func TestVizierClusterInfo_GetClusterInfoWithID(t *testing.T) {
+func TestVizierClusterInfo_GetClusterInfoMalformedPodStatus(t *testing.T) {
 	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
-	assert.NotNil(t, clusterID)
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -344,42 +826,69 @@ func TestVizierClusterInfo_GetClusterInfoWithID(t *testing.T) {
 		VizierIDs: []*uuidpb.UUID{clusterID},
 	}).Return(&vzmgrpb.GetVizierInfosResponse{
 		VizierInfos: []*cvmsgspb.VizierInfo{{
-			VizierID:        clusterID,
-			Status:          cvmsgspb.VZ_ST_HEALTHY,
-			LastHeartbeatNs: int64(1305646598000000000),
-			Config: &cvmsgspb.VizierConfig{
-				PassthroughEnabled: false,
-				AutoUpdateEnabled:  true,
+			VizierID:    clusterID,
+			Status:      cvmsgspb.VZ_ST_HEALTHY,
+			ClusterName: "test-cluster",
+			Config:      &cvmsgspb.VizierConfig{},
+			ControlPlanePodStatuses: map[string]*cvmsgspb.PodStatus{
+				"vizier-proxy": {
+					Name:   "vizier-proxy",
+					Status: metadatapb.RUNNING,
+					Containers: []*cvmsgspb.ContainerStatus{
+						{
+							Name:  "my-proxy-container",
+							State: metadatapb.CONTAINER_STATE_RUNNING,
+						},
+					},
+				},
+				"vizier-query-broker": {
+					Name:   "vizier-query-broker",
+					Status: metadatapb.RUNNING,
+					Containers: []*cvmsgspb.ContainerStatus{
+						{
+							Name:  "my-qb-container",
+							State: metadatapb.ContainerState(100),
+						},
+					},
+				},
+				"vizier-metadata": {
+					Name:       "vizier-metadata",
+					Status:     metadatapb.RUNNING,
+					Containers: []*cvmsgspb.ContainerStatus{nil},
+				},
 			},
-			VizierVersion:  "1.2.3",
-			ClusterUID:     "a UID",
-			ClusterName:    "some cluster",
-			ClusterVersion: "5.6.7",
-		},
-		},
+		}},
 	}, nil)
 
 	vzClusterInfoServer := &controller.VizierClusterInfo{
 		VzMgr: mockClients.MockVzMgr,
 	}
 
-	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{
-		ID: clusterID,
-	})
-
+	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{ID: clusterID})
 	require.NoError(t, err)
-	assert.Equal(t, 1, len(resp.Clusters))
+	require.Equal(t, 1, len(resp.Clusters))
 	cluster := resp.Clusters[0]
-	assert.Equal(t, cluster.ID, clusterID)
-	assert.Equal(t, cluster.Status, cloudpb.CS_HEALTHY)
-	assert.Equal(t, cluster.LastHeartbeatNs, int64(1305646598000000000))
-	assert.Equal(t, cluster.Config.PassthroughEnabled, false)
-	assert.Equal(t, cluster.Config.AutoUpdateEnabled, true)
+	assert.Equal(t, "test-cluster", cluster.ClusterName)
+	require.Equal(t, 3, len(cluster.ControlPlanePodStatuses))
+
+	proxy := cluster.ControlPlanePodStatuses["vizier-proxy"]
+	assert.Equal(t, cloudpb.RUNNING, proxy.Status)
+	assert.Empty(t, proxy.TranslationError)
+	require.Equal(t, 1, len(proxy.Containers))
+	assert.Equal(t, cloudpb.CONTAINER_STATE_RUNNING, proxy.Containers[0].State)
+
+	qb := cluster.ControlPlanePodStatuses["vizier-query-broker"]
+	assert.Equal(t, "vizier-query-broker", qb.Name)
+	assert.Equal(t, cloudpb.PHASE_UNKNOWN, qb.Status)
+	assert.Contains(t, qb.TranslationError, "invalid container state")
+
+	md := cluster.ControlPlanePodStatuses["vizier-metadata"]
+	assert.Equal(t, "vizier-metadata", md.Name)
+	assert.Contains(t, md.TranslationError, "panic")
 }
 
-func TestVizierClusterInfo_UpdateClusterVizierConfig(t *testing.T) {
+func TestVizierClusterInfo_GetClusterInfoMissingOptionalFields(t *testing.T) {
 	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
-	assert.NotNil(t, clusterID)
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -388,35 +897,36 @@ func TestVizierClusterInfo_UpdateClusterVizierConfig(t *testing.T) {
 	defer cleanup()
 	ctx := CreateTestContext()
 
-	updateReq := &cvmsgspb.UpdateVizierConfigRequest{
-		VizierID: clusterID,
-		ConfigUpdate: &cvmsgspb.VizierConfigUpdate{
-			PassthroughEnabled: &types.BoolValue{Value: true},
-			AutoUpdateEnabled:  &types.BoolValue{Value: false},
-		},
-	}
-
-	mockClients.MockVzMgr.EXPECT().UpdateVizierConfig(gomock.Any(), updateReq).Return(&cvmsgspb.UpdateVizierConfigResponse{}, nil)
+	mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
+		VizierIDs: []*uuidpb.UUID{clusterID},
+	}).Return(&vzmgrpb.GetVizierInfosResponse{
+		VizierInfos: []*cvmsgspb.VizierInfo{{
+			VizierID:                clusterID,
+			Status:                  cvmsgspb.VZ_ST_UPDATING,
+			ClusterName:             "test-cluster",
+			Config:                  nil,
+			ControlPlanePodStatuses: nil,
+		}},
+	}, nil)
 
 	vzClusterInfoServer := &controller.VizierClusterInfo{
 		VzMgr: mockClients.MockVzMgr,
 	}
 
-	resp, err := vzClusterInfoServer.UpdateClusterVizierConfig(ctx, &cloudpb.UpdateClusterVizierConfigRequest{
-		ID: clusterID,
-		ConfigUpdate: &cloudpb.VizierConfigUpdate{
-			PassthroughEnabled: &types.BoolValue{Value: true},
-			AutoUpdateEnabled:  &types.BoolValue{Value: false},
-		},
-	})
-
+	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{ID: clusterID})
 	require.NoError(t, err)
-	assert.NotNil(t, resp)
+	require.Equal(t, 1, len(resp.Clusters))
+	cluster := resp.Clusters[0]
+	assert.Equal(t, "test-cluster", cluster.ClusterName)
+	assert.Equal(t, cloudpb.CS_UPDATING, cluster.Status)
+	assert.Equal(t, &cloudpb.VizierConfig{}, cluster.Config)
+	assert.False(t, cluster.AutoUpdateInProgress)
+	assert.Empty(t, cluster.ControlPlanePodStatuses)
+	assert.Empty(t, cluster.StatusReason)
 }
 
-func TestVizierClusterInfo_UpdateOrInstallCluster(t *testing.T) {
+func TestVizierClusterInfo_GetClusterInfoPluginStatuses(t *testing.T) {
 	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
-	assert.NotNil(t, clusterID)
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -425,36 +935,53 @@ func TestVizierClusterInfo_UpdateOrInstallCluster(t *testing.T) {
 	defer cleanup()
 	ctx := CreateTestContext()
 
-	updateReq := &cvmsgspb.UpdateOrInstallVizierRequest{
-		VizierID: clusterID,
-		Version:  "0.1.30",
-	}
-
-	mockClients.MockVzMgr.EXPECT().UpdateOrInstallVizier(gomock.Any(), updateReq).Return(&cvmsgspb.UpdateOrInstallVizierResponse{UpdateStarted: true}, nil)
-
-	mockClients.MockArtifact.EXPECT().
-		GetDownloadLink(gomock.Any(), &artifacttrackerpb.GetDownloadLinkRequest{
-			ArtifactName: "vizier",
-			VersionStr:   "0.1.30",
-			ArtifactType: versionspb.AT_CONTAINER_SET_YAMLS,
-		}).
-		Return(nil, nil)
+	mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
+		VizierIDs: []*uuidpb.UUID{clusterID},
+	}).Return(&vzmgrpb.GetVizierInfosResponse{
+		VizierInfos: []*cvmsgspb.VizierInfo{{
+			VizierID:    clusterID,
+			Status:      cvmsgspb.VZ_ST_HEALTHY,
+			ClusterName: "test-cluster",
+			Config:      &cvmsgspb.VizierConfig{},
+			PluginStatuses: []*cvmsgspb.PluginStatus{
+				{
+					Name:    "otel-export",
+					Healthy: true,
+				},
+				{
+					Name:    "prometheus-export",
+					Healthy: false,
+					Message: "remote write failed",
+				},
+			},
+		}},
+	}, nil)
 
 	vzClusterInfoServer := &controller.VizierClusterInfo{
-		VzMgr:                 mockClients.MockVzMgr,
-		ArtifactTrackerClient: mockClients.MockArtifact,
+		VzMgr: mockClients.MockVzMgr,
 	}
 
-	resp, err := vzClusterInfoServer.UpdateOrInstallCluster(ctx, &cloudpb.UpdateOrInstallClusterRequest{
-		ClusterID: clusterID,
-		Version:   "0.1.30",
-	})
-
+	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{ID: clusterID})
 	require.NoError(t, err)
-	assert.NotNil(t, resp)
+	require.Equal(t, 1, len(resp.Clusters))
+	assert.Equal(t, []*cloudpb.PluginStatus{
+		{
+			Name:    "otel-export",
+			Healthy: true,
+		},
+		{
+			Name:    "prometheus-export",
+			Healthy: false,
+			Message: "remote write failed",
+		},
+	}, resp.Clusters[0].PluginStatuses)
 }
 
-func TestVizierDeploymentKeyServer_Create(t *testing.T) {
+func TestVizierClusterInfo_GetClusterInfoLastScriptRunAt(t *testing.T) {
+	orgID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	idleClusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c9")
+
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -462,28 +989,46 @@ func TestVizierDeploymentKeyServer_Create(t *testing.T) {
 	defer cleanup()
 	ctx := CreateTestContext()
 
-	vzreq := &vzmgrpb.CreateDeploymentKeyRequest{Desc: "test key"}
-	vzresp := &vzmgrpb.DeploymentKey{
-		ID:        utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
-		Key:       "foobar",
-		CreatedAt: types.TimestampNow(),
-	}
-	mockClients.MockVzDeployKey.EXPECT().
-		Create(gomock.Any(), vzreq).Return(vzresp, nil)
+	mockClients.MockVzMgr.EXPECT().GetViziersByOrg(gomock.Any(), orgID).Return(&vzmgrpb.GetViziersByOrgResponse{
+		VizierIDs: []*uuidpb.UUID{clusterID, idleClusterID},
+	}, nil)
+	mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
+		VizierIDs: []*uuidpb.UUID{clusterID, idleClusterID},
+	}).Return(&vzmgrpb.GetVizierInfosResponse{
+		VizierInfos: []*cvmsgspb.VizierInfo{
+			{
+				VizierID:        clusterID,
+				Status:          cvmsgspb.VZ_ST_HEALTHY,
+				ClusterName:     "active-cluster",
+				Config:          &cvmsgspb.VizierConfig{},
+				LastScriptRunAt: &types.Timestamp{Seconds: 1561230620},
+			},
+			{
+				VizierID:    idleClusterID,
+				Status:      cvmsgspb.VZ_ST_HEALTHY,
+				ClusterName: "idle-cluster",
+				Config:      &cvmsgspb.VizierConfig{},
+			},
+		},
+	}, nil)
 
-	vzDeployKeyServer := &controller.VizierDeploymentKeyServer{
-		VzDeploymentKey: mockClients.MockVzDeployKey,
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr: mockClients.MockVzMgr,
 	}
 
-	resp, err := vzDeployKeyServer.Create(ctx, &cloudpb.CreateDeploymentKeyRequest{Desc: "test key"})
+	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{})
 	require.NoError(t, err)
-	assert.NotNil(t, resp)
-	assert.Equal(t, resp.ID, vzresp.ID)
-	assert.Equal(t, resp.Key, vzresp.Key)
-	assert.Equal(t, resp.CreatedAt, vzresp.CreatedAt)
+	require.Equal(t, 2, len(resp.Clusters))
+	assert.Equal(t, &types.Timestamp{Seconds: 1561230620}, resp.Clusters[0].LastScriptRunAt)
+	assert.Nil(t, resp.Clusters[1].LastScriptRunAt)
 }
 
-func TestVizierDeploymentKeyServer_List(t *testing.T) {
+func TestVizierClusterInfo_GetClusterInfoAutoUpdateInProgress(t *testing.T) {
+	orgID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	updatingID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	healthyID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c9")
+	manualUpdateID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430ca")
+
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -491,36 +1036,127 @@ func TestVizierDeploymentKeyServer_List(t *testing.T) {
 	defer cleanup()
 	ctx := CreateTestContext()
 
-	vzreq := &vzmgrpb.ListDeploymentKeyRequest{}
-	vzresp := &vzmgrpb.ListDeploymentKeyResponse{
-		Keys: []*vzmgrpb.DeploymentKey{
+	mockClients.MockVzMgr.EXPECT().GetViziersByOrg(gomock.Any(), orgID).Return(&vzmgrpb.GetViziersByOrgResponse{
+		VizierIDs: []*uuidpb.UUID{updatingID, healthyID, manualUpdateID},
+	}, nil)
+	mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
+		VizierIDs: []*uuidpb.UUID{updatingID, healthyID, manualUpdateID},
+	}).Return(&vzmgrpb.GetVizierInfosResponse{
+		VizierInfos: []*cvmsgspb.VizierInfo{
 			{
-				ID:        utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
-				Key:       "foobar",
-				CreatedAt: types.TimestampNow(),
-				Desc:      "this is a key",
+				VizierID:             updatingID,
+				Status:               cvmsgspb.VZ_ST_UPDATING,
+				ClusterName:          "updating-cluster",
+				Config:               &cvmsgspb.VizierConfig{AutoUpdateEnabled: true},
+				AutoUpdateInProgress: true,
+			},
+			{
+				VizierID:    healthyID,
+				Status:      cvmsgspb.VZ_ST_HEALTHY,
+				ClusterName: "healthy-cluster",
+				Config:      &cvmsgspb.VizierConfig{AutoUpdateEnabled: true},
+			},
+			// A user requested an update of a cluster that has auto-update enabled.
+			{
+				VizierID:    manualUpdateID,
+				Status:      cvmsgspb.VZ_ST_UPDATING,
+				ClusterName: "manual-update-cluster",
+				Config:      &cvmsgspb.VizierConfig{AutoUpdateEnabled: true},
 			},
 		},
-	}
-	mockClients.MockVzDeployKey.EXPECT().
-		List(gomock.Any(), vzreq).Return(vzresp, nil)
+	}, nil)
 
-	vzDeployKeyServer := &controller.VizierDeploymentKeyServer{
-		VzDeploymentKey: mockClients.MockVzDeployKey,
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr: mockClients.MockVzMgr,
 	}
 
-	resp, err := vzDeployKeyServer.List(ctx, &cloudpb.ListDeploymentKeyRequest{})
+	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{})
 	require.NoError(t, err)
-	assert.NotNil(t, resp)
-	for i, key := range resp.Keys {
-		assert.Equal(t, key.ID, vzresp.Keys[i].ID)
-		assert.Equal(t, key.Key, vzresp.Keys[i].Key)
-		assert.Equal(t, key.CreatedAt, vzresp.Keys[i].CreatedAt)
-		assert.Equal(t, key.Desc, vzresp.Keys[i].Desc)
+	require.Equal(t, 3, len(resp.Clusters))
+	assert.True(t, resp.Clusters[0].AutoUpdateInProgress)
+	assert.False(t, resp.Clusters[1].AutoUpdateInProgress)
+	assert.False(t, resp.Clusters[2].AutoUpdateInProgress)
+}
+
+func TestVizierClusterInfo_GetClusterInfoOnlyUnhealthyPods(t *testing.T) {
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	tests := []struct {
+		name              string
+		onlyUnhealthyPods bool
+		expectedPods      []string
+	}{
+		{
+			name:              "all pods",
+			onlyUnhealthyPods: false,
+			expectedPods:      []string{"vizier-metadata", "vizier-proxy", "vizier-query-broker"},
+		},
+		{
+			name:              "only unhealthy pods",
+			onlyUnhealthyPods: true,
+			expectedPods:      []string{"vizier-metadata", "vizier-proxy"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+			defer cleanup()
+			ctx := CreateTestContext()
+
+			mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
+				VizierIDs: []*uuidpb.UUID{clusterID},
+			}).Return(&vzmgrpb.GetVizierInfosResponse{
+				VizierInfos: []*cvmsgspb.VizierInfo{
+					{
+						VizierID:    clusterID,
+						Status:      cvmsgspb.VZ_ST_UNHEALTHY,
+						ClusterName: "test-cluster",
+						Config:      &cvmsgspb.VizierConfig{},
+						ControlPlanePodStatuses: map[string]*cvmsgspb.PodStatus{
+							"vizier-query-broker": {
+								Name:   "vizier-query-broker",
+								Status: metadatapb.RUNNING,
+							},
+							"vizier-metadata": {
+								Name:   "vizier-metadata",
+								Status: metadatapb.PENDING,
+							},
+							"vizier-proxy": {
+								Name:   "vizier-proxy",
+								Status: metadatapb.FAILED,
+							},
+						},
+					},
+				},
+			}, nil)
+
+			vzClusterInfoServer := &controller.VizierClusterInfo{
+				VzMgr: mockClients.MockVzMgr,
+			}
+
+			resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{
+				ID:                clusterID,
+				OnlyUnhealthyPods: tc.onlyUnhealthyPods,
+			})
+			require.NoError(t, err)
+			require.Equal(t, 1, len(resp.Clusters))
+
+			pods := make([]string, 0)
+			for podName := range resp.Clusters[0].ControlPlanePodStatuses {
+				pods = append(pods, podName)
+			}
+			assert.ElementsMatch(t, tc.expectedPods, pods)
+		})
 	}
 }
 
-func TestVizierDeploymentKeyServer_Get(t *testing.T) {
+func TestVizierClusterInfo_GetClusterInfoRestartCount(t *testing.T) {
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -528,58 +1164,194 @@ func TestVizierDeploymentKeyServer_Get(t *testing.T) {
 	defer cleanup()
 	ctx := CreateTestContext()
 
-	id := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
-	vzreq := &vzmgrpb.GetDeploymentKeyRequest{
-		ID: id,
+	mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
+		VizierIDs: []*uuidpb.UUID{clusterID},
+	}).Return(&vzmgrpb.GetVizierInfosResponse{
+		VizierInfos: []*cvmsgspb.VizierInfo{
+			{
+				VizierID:    clusterID,
+				Status:      cvmsgspb.VZ_ST_HEALTHY,
+				ClusterName: "test-cluster",
+				Config:      &cvmsgspb.VizierConfig{},
+				ControlPlanePodStatuses: map[string]*cvmsgspb.PodStatus{
+					"vizier-query-broker": {
+						Name:   "vizier-query-broker",
+						Status: metadatapb.RUNNING,
+						Containers: []*cvmsgspb.ContainerStatus{
+							{
+								Name:         "app",
+								State:        metadatapb.CONTAINER_STATE_RUNNING,
+								RestartCount: 3,
+							},
+							{
+								Name:  "sidecar",
+								State: metadatapb.CONTAINER_STATE_RUNNING,
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil)
+
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr: mockClients.MockVzMgr,
 	}
-	vzresp := &vzmgrpb.GetDeploymentKeyResponse{
-		Key: &vzmgrpb.DeploymentKey{
-			ID:        id,
-			Key:       "foobar",
-			CreatedAt: types.TimestampNow(),
-			Desc:      "this is a key",
+
+	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{ID: clusterID})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(resp.Clusters))
+	containers := resp.Clusters[0].ControlPlanePodStatuses["vizier-query-broker"].Containers
+	require.Equal(t, 2, len(containers))
+	assert.Equal(t, int32(3), containers[0].RestartCount)
+	assert.Equal(t, int32(0), containers[1].RestartCount)
+}
+
+func TestVizierClusterInfo_GetClusterInfoInstrumentationCoverage(t *testing.T) {
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	tests := []struct {
+		name                 string
+		numNodes             int32
+		numInstrumentedNodes int32
+		expectedCoverage     float32
+	}{
+		{
+			name:                 "partial coverage",
+			numNodes:             4,
+			numInstrumentedNodes: 3,
+			expectedCoverage:     0.75,
+		},
+		{
+			name:                 "zero nodes",
+			numNodes:             0,
+			numInstrumentedNodes: 0,
+			expectedCoverage:     0,
+		},
+		{
+			name:                 "full coverage",
+			numNodes:             5,
+			numInstrumentedNodes: 5,
+			expectedCoverage:     1,
 		},
 	}
-	mockClients.MockVzDeployKey.EXPECT().
-		Get(gomock.Any(), vzreq).Return(vzresp, nil)
 
-	vzDeployKeyServer := &controller.VizierDeploymentKeyServer{
-		VzDeploymentKey: mockClients.MockVzDeployKey,
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+			defer cleanup()
+			ctx := CreateTestContext()
+
+			mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
+				VizierIDs: []*uuidpb.UUID{clusterID},
+			}).Return(&vzmgrpb.GetVizierInfosResponse{
+				VizierInfos: []*cvmsgspb.VizierInfo{
+					{
+						VizierID:             clusterID,
+						Status:               cvmsgspb.VZ_ST_HEALTHY,
+						ClusterName:          "test-cluster",
+						Config:               &cvmsgspb.VizierConfig{},
+						NumNodes:             tc.numNodes,
+						NumInstrumentedNodes: tc.numInstrumentedNodes,
+					},
+				},
+			}, nil)
+
+			vzClusterInfoServer := &controller.VizierClusterInfo{
+				VzMgr: mockClients.MockVzMgr,
+			}
+
+			resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{ID: clusterID})
+			require.NoError(t, err)
+			require.Equal(t, 1, len(resp.Clusters))
+			assert.Equal(t, tc.expectedCoverage, resp.Clusters[0].InstrumentationCoverage)
+		})
 	}
-	resp, err := vzDeployKeyServer.Get(ctx, &cloudpb.GetDeploymentKeyRequest{
-		ID: id,
-	})
-	require.NoError(t, err)
-	assert.NotNil(t, resp)
-	assert.Equal(t, resp.Key.ID, vzresp.Key.ID)
-	assert.Equal(t, resp.Key.Key, vzresp.Key.Key)
-	assert.Equal(t, resp.Key.CreatedAt, vzresp.Key.CreatedAt)
-	assert.Equal(t, resp.Key.Desc, vzresp.Key.Desc)
 }
 
-func TestVizierDeploymentKeyServer_Delete(t *testing.T) {
+func TestVizierClusterInfo_StreamClusterInfo(t *testing.T) {
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
 	defer cleanup()
-	ctx := CreateTestContext()
+	ctx, cancel := context.WithCancel(CreateTestContext())
+	defer cancel()
 
-	id := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
-	vzresp := &types.Empty{}
-	mockClients.MockVzDeployKey.EXPECT().
-		Delete(gomock.Any(), id).Return(vzresp, nil)
+	vzInfosReq := &vzmgrpb.GetVizierInfosRequest{
+		VizierIDs: []*uuidpb.UUID{clusterID},
+	}
+	vzInfosResp := func(status cvmsgspb.VizierStatus) *vzmgrpb.GetVizierInfosResponse {
+		return &vzmgrpb.GetVizierInfosResponse{
+			VizierInfos: []*cvmsgspb.VizierInfo{
+				{
+					VizierID:    clusterID,
+					Status:      status,
+					ClusterName: "test-cluster",
+					Config:      &cvmsgspb.VizierConfig{},
+				},
+			},
+		}
+	}
+	gomock.InOrder(
+		mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), vzInfosReq).
+			Return(vzInfosResp(cvmsgspb.VZ_ST_HEALTHY), nil),
+		mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), vzInfosReq).
+			Return(vzInfosResp(cvmsgspb.VZ_ST_HEALTHY), nil),
+		mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), vzInfosReq).
+			Return(vzInfosResp(cvmsgspb.VZ_ST_UNHEALTHY), nil),
+	)
+
+	var sent []*cloudpb.ClusterInfo
+	srv := mock_cloudpb.NewMockVizierClusterInfo_StreamClusterInfoServer(ctrl)
+	srv.EXPECT().Context().Return(ctx).AnyTimes()
+	srv.EXPECT().Send(gomock.Any()).DoAndReturn(func(c *cloudpb.ClusterInfo) error {
+		sent = append(sent, c)
+		// Stop the stream once the status change has been sent.
+		if len(sent) == 2 {
+			cancel()
+		}
+		return nil
+	}).Times(2)
 
-	vzDeployKeyServer := &controller.VizierDeploymentKeyServer{
-		VzDeploymentKey: mockClients.MockVzDeployKey,
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr:                   mockClients.MockVzMgr,
+		ClusterInfoPollInterval: time.Millisecond,
 	}
-	resp, err := vzDeployKeyServer.Delete(ctx, id)
+
+	err := vzClusterInfoServer.StreamClusterInfo(&cloudpb.StreamClusterInfoRequest{ID: clusterID}, srv)
 	require.NoError(t, err)
-	assert.NotNil(t, resp)
-	assert.Equal(t, resp, vzresp)
+
+	// The initial info is sent, then only the status change.
+	require.Equal(t, 2, len(sent))
+	assert.Equal(t, cloudpb.CS_HEALTHY, sent[0].Status)
+	assert.Equal(t, cloudpb.CS_UNHEALTHY, sent[1].Status)
 }
 
-func TestAPIKeyServer_Create(t *testing.T) {
+func TestVizierClusterInfo_GetClusterHealthSummary(t *testing.T) {
+	orgID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	ids := []*uuidpb.UUID{
+		utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c1"),
+		utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c2"),
+		utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c3"),
+		utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c4"),
+		utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c5"),
+		utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c6"),
+	}
+	statuses := []cvmsgspb.VizierStatus{
+		cvmsgspb.VZ_ST_HEALTHY,
+		cvmsgspb.VZ_ST_HEALTHY,
+		cvmsgspb.VZ_ST_UNHEALTHY,
+		cvmsgspb.VZ_ST_DISCONNECTED,
+		cvmsgspb.VZ_ST_UPDATING,
+		cvmsgspb.VZ_ST_UPDATE_FAILED,
+	}
+
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -587,28 +1359,44 @@ func TestAPIKeyServer_Create(t *testing.T) {
 	defer cleanup()
 	ctx := CreateTestContext()
 
-	vzreq := &authpb.CreateAPIKeyRequest{Desc: "test key"}
-	vzresp := &authpb.APIKey{
-		ID:        utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
-		Key:       "foobar",
-		CreatedAt: types.TimestampNow(),
+	vzInfos := make([]*cvmsgspb.VizierInfo, len(ids))
+	for i, id := range ids {
+		vzInfos[i] = &cvmsgspb.VizierInfo{
+			VizierID: id,
+			Status:   statuses[i],
+			Config:   &cvmsgspb.VizierConfig{},
+		}
 	}
-	mockClients.MockAPIKey.EXPECT().
-		Create(gomock.Any(), vzreq).Return(vzresp, nil)
 
-	vzAPIKeyServer := &controller.APIKeyServer{
-		APIKeyClient: mockClients.MockAPIKey,
+	mockClients.MockVzMgr.EXPECT().GetViziersByOrg(gomock.Any(), orgID).Return(&vzmgrpb.GetViziersByOrgResponse{
+		VizierIDs: ids,
+	}, nil)
+	mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
+		VizierIDs: ids,
+	}).Return(&vzmgrpb.GetVizierInfosResponse{
+		VizierInfos: vzInfos,
+	}, nil)
+
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr: mockClients.MockVzMgr,
 	}
 
-	resp, err := vzAPIKeyServer.Create(ctx, &cloudpb.CreateAPIKeyRequest{Desc: "test key"})
+	resp, err := vzClusterInfoServer.GetClusterHealthSummary(ctx, &cloudpb.GetClusterHealthSummaryRequest{})
 	require.NoError(t, err)
-	assert.NotNil(t, resp)
-	assert.Equal(t, resp.ID, vzresp.ID)
-	assert.Equal(t, resp.Key, vzresp.Key)
-	assert.Equal(t, resp.CreatedAt, vzresp.CreatedAt)
+	assert.Equal(t, &cloudpb.GetClusterHealthSummaryResponse{
+		NumHealthy:      2,
+		NumUnhealthy:    1,
+		NumDisconnected: 1,
+		NumUpdating:     1,
+		NumOther:        1,
+	}, resp)
 }
 
-func TestAPIKeyServer_List(t *testing.T) {
+func TestVizierClusterInfo_GetClusterInfoStatusReason(t *testing.T) {
+	orgID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	unhealthyID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	healthyID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c9")
+
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -616,36 +1404,80 @@ func TestAPIKeyServer_List(t *testing.T) {
 	defer cleanup()
 	ctx := CreateTestContext()
 
-	vzreq := &authpb.ListAPIKeyRequest{}
-	vzresp := &authpb.ListAPIKeyResponse{
-		Keys: []*authpb.APIKey{
+	mockClients.MockVzMgr.EXPECT().GetViziersByOrg(gomock.Any(), orgID).Return(&vzmgrpb.GetViziersByOrgResponse{
+		VizierIDs: []*uuidpb.UUID{unhealthyID, healthyID},
+	}, nil)
+	mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
+		VizierIDs: []*uuidpb.UUID{unhealthyID, healthyID},
+	}).Return(&vzmgrpb.GetVizierInfosResponse{
+		VizierInfos: []*cvmsgspb.VizierInfo{
 			{
-				ID:        utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
-				Key:       "foobar",
-				CreatedAt: types.TimestampNow(),
-				Desc:      "this is a key",
+				VizierID:    unhealthyID,
+				Status:      cvmsgspb.VZ_ST_UNHEALTHY,
+				ClusterName: "unhealthy-cluster",
+				Config:      &cvmsgspb.VizierConfig{},
+				ControlPlanePodStatuses: map[string]*cvmsgspb.PodStatus{
+					"vizier-query-broker": {
+						Name:   "vizier-query-broker",
+						Status: metadatapb.RUNNING,
+						Containers: []*cvmsgspb.ContainerStatus{
+							{
+								Name:   "app",
+								State:  metadatapb.CONTAINER_STATE_WAITING,
+								Reason: "CrashLoopBackOff",
+							},
+						},
+					},
+					"vizier-metadata": {
+						Name:   "vizier-metadata",
+						Status: metadatapb.PENDING,
+						Reason: "Unschedulable",
+					},
+					"vizier-proxy": {
+						Name:   "vizier-proxy",
+						Status: metadatapb.RUNNING,
+						Containers: []*cvmsgspb.ContainerStatus{
+							{
+								Name:  "app",
+								State: metadatapb.CONTAINER_STATE_RUNNING,
+							},
+						},
+					},
+				},
+			},
+			{
+				VizierID:    healthyID,
+				Status:      cvmsgspb.VZ_ST_HEALTHY,
+				ClusterName: "healthy-cluster",
+				Config:      &cvmsgspb.VizierConfig{},
+				ControlPlanePodStatuses: map[string]*cvmsgspb.PodStatus{
+					"vizier-query-broker": {
+						Name:   "vizier-query-broker",
+						Status: metadatapb.RUNNING,
+					},
+				},
 			},
 		},
-	}
-	mockClients.MockAPIKey.EXPECT().
-		List(gomock.Any(), vzreq).Return(vzresp, nil)
+	}, nil)
 
-	vzAPIKeyServer := &controller.APIKeyServer{
-		APIKeyClient: mockClients.MockAPIKey,
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr: mockClients.MockVzMgr,
 	}
 
-	resp, err := vzAPIKeyServer.List(ctx, &cloudpb.ListAPIKeyRequest{})
+	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{})
 	require.NoError(t, err)
-	assert.NotNil(t, resp)
-	for i, key := range resp.Keys {
-		assert.Equal(t, key.ID, vzresp.Keys[i].ID)
-		assert.Equal(t, key.Key, vzresp.Keys[i].Key)
-		assert.Equal(t, key.CreatedAt, vzresp.Keys[i].CreatedAt)
-		assert.Equal(t, key.Desc, vzresp.Keys[i].Desc)
-	}
+	require.Equal(t, 2, len(resp.Clusters))
+	assert.Equal(t, cloudpb.CS_UNHEALTHY, resp.Clusters[0].Status)
+	assert.Equal(t, "vizier-metadata pending: Unschedulable; vizier-query-broker crashlooping", resp.Clusters[0].StatusReason)
+	assert.Equal(t, cloudpb.CS_HEALTHY, resp.Clusters[1].Status)
+	assert.Equal(t, "", resp.Clusters[1].StatusReason)
 }
 
-func TestAPIKeyServer_Get(t *testing.T) {
+func TestVizierClusterInfo_GetClusterInfoWithClusterUID(t *testing.T) {
+	orgID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	otherClusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c9")
+
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -653,36 +1485,46 @@ func TestAPIKeyServer_Get(t *testing.T) {
 	defer cleanup()
 	ctx := CreateTestContext()
 
-	id := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
-	vzreq := &authpb.GetAPIKeyRequest{
-		ID: id,
-	}
-	vzresp := &authpb.GetAPIKeyResponse{
-		Key: &authpb.APIKey{
-			ID:        id,
-			Key:       "foobar",
-			CreatedAt: types.TimestampNow(),
-			Desc:      "this is a key",
+	mockClients.MockVzMgr.EXPECT().GetViziersByOrg(gomock.Any(), orgID).Return(&vzmgrpb.GetViziersByOrgResponse{
+		VizierIDs: []*uuidpb.UUID{clusterID, otherClusterID},
+	}, nil)
+	mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
+		VizierIDs: []*uuidpb.UUID{clusterID, otherClusterID},
+	}).Return(&vzmgrpb.GetVizierInfosResponse{
+		VizierInfos: []*cvmsgspb.VizierInfo{
+			{
+				VizierID:    clusterID,
+				Status:      cvmsgspb.VZ_ST_HEALTHY,
+				ClusterUID:  "a UID",
+				ClusterName: "cluster",
+				Config:      &cvmsgspb.VizierConfig{},
+			},
+			{
+				VizierID:    otherClusterID,
+				Status:      cvmsgspb.VZ_ST_HEALTHY,
+				ClusterUID:  "another UID",
+				ClusterName: "other-cluster",
+				Config:      &cvmsgspb.VizierConfig{},
+			},
 		},
-	}
-	mockClients.MockAPIKey.EXPECT().
-		Get(gomock.Any(), vzreq).Return(vzresp, nil)
+	}, nil)
 
-	vzAPIKeyServer := &controller.APIKeyServer{
-		APIKeyClient: mockClients.MockAPIKey,
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr: mockClients.MockVzMgr,
 	}
-	resp, err := vzAPIKeyServer.Get(ctx, &cloudpb.GetAPIKeyRequest{
-		ID: id,
+
+	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{
+		ClusterUID: "a UID",
 	})
 	require.NoError(t, err)
-	assert.NotNil(t, resp)
-	assert.Equal(t, resp.Key.ID, vzresp.Key.ID)
-	assert.Equal(t, resp.Key.Key, vzresp.Key.Key)
-	assert.Equal(t, resp.Key.CreatedAt, vzresp.Key.CreatedAt)
-	assert.Equal(t, resp.Key.Desc, vzresp.Key.Desc)
+	require.Equal(t, 1, len(resp.Clusters))
+	assert.Equal(t, clusterID, resp.Clusters[0].ID)
+	assert.Equal(t, "a UID", resp.Clusters[0].ClusterUID)
 }
 
-func TestAPIKeyServer_Delete(t *testing.T) {
+func TestVizierClusterInfo_GetClusterInfoWithIDAndClusterUID(t *testing.T) {
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -690,413 +1532,3384 @@ func TestAPIKeyServer_Delete(t *testing.T) {
 	defer cleanup()
 	ctx := CreateTestContext()
 
-	id := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
-	vzresp := &types.Empty{}
-	mockClients.MockAPIKey.EXPECT().
-		Delete(gomock.Any(), id).Return(vzresp, nil)
+	mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
+		VizierIDs: []*uuidpb.UUID{clusterID},
+	}).Return(&vzmgrpb.GetVizierInfosResponse{
+		VizierInfos: []*cvmsgspb.VizierInfo{
+			{
+				VizierID:    clusterID,
+				Status:      cvmsgspb.VZ_ST_HEALTHY,
+				ClusterUID:  "a UID",
+				ClusterName: "cluster",
+				Config:      &cvmsgspb.VizierConfig{},
+			},
+		},
+	}, nil)
 
-	vzAPIKeyServer := &controller.APIKeyServer{
-		APIKeyClient: mockClients.MockAPIKey,
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr: mockClients.MockVzMgr,
 	}
-	resp, err := vzAPIKeyServer.Delete(ctx, id)
+
+	// The ID takes precedence over the cluster UID.
+	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{
+		ID:         clusterID,
+		ClusterUID: "another UID",
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(resp.Clusters))
+	assert.Equal(t, clusterID, resp.Clusters[0].ID)
+}
+
+func TestVizierClusterInfo_GetClusterInfoStableOrder(t *testing.T) {
+	orgID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	clusterID1 := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	clusterID2 := utils.ProtoFromUUIDStrOrNil("8ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	clusterID3 := utils.ProtoFromUUIDStrOrNil("9ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	vzInfo := func(id *uuidpb.UUID, name string) *cvmsgspb.VizierInfo {
+		return &cvmsgspb.VizierInfo{
+			VizierID:    id,
+			Status:      cvmsgspb.VZ_ST_HEALTHY,
+			ClusterName: name,
+			Config:      &cvmsgspb.VizierConfig{},
+		}
+	}
+
+	// VzMgr returns the same viziers in a different order on each call.
+	mockClients.MockVzMgr.EXPECT().GetViziersByOrg(gomock.Any(), orgID).Return(&vzmgrpb.GetViziersByOrgResponse{
+		VizierIDs: []*uuidpb.UUID{clusterID3, clusterID1, clusterID2},
+	}, nil).Times(2)
+	gomock.InOrder(
+		mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), gomock.Any()).Return(&vzmgrpb.GetVizierInfosResponse{
+			VizierInfos: []*cvmsgspb.VizierInfo{
+				vzInfo(clusterID3, "cluster3"),
+				vzInfo(clusterID1, "cluster1"),
+				vzInfo(clusterID2, "cluster2"),
+			},
+		}, nil),
+		mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), gomock.Any()).Return(&vzmgrpb.GetVizierInfosResponse{
+			VizierInfos: []*cvmsgspb.VizierInfo{
+				vzInfo(clusterID2, "cluster2"),
+				vzInfo(clusterID3, "cluster3"),
+				vzInfo(clusterID1, "cluster1"),
+			},
+		}, nil),
+	)
+
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr: mockClients.MockVzMgr,
+	}
+
+	resp1, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{})
+	require.NoError(t, err)
+	resp2, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{})
+	require.NoError(t, err)
+
+	assert.Equal(t, resp1, resp2)
+	require.Equal(t, 3, len(resp1.Clusters))
+	assert.Equal(t, clusterID1, resp1.Clusters[0].ID)
+	assert.Equal(t, clusterID2, resp1.Clusters[1].ID)
+	assert.Equal(t, clusterID3, resp1.Clusters[2].ID)
+}
+
+func TestVizierClusterInfo_GetClusterInfoAdminOrgOverride(t *testing.T) {
+	viper.Set("jwt_signing_key", "jwt-key")
+	overrideOrgID := utils.ProtoFromUUIDStrOrNil("8ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+
+	sCtx := authcontext.New()
+	sCtx.Claims = svcutils.GenerateJWTForSupportUser(uuid.Nil.String(), "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "test@pixie.support", time.Now().Add(time.Hour), "pixie")
+	ctx := authcontext.NewContext(context.Background(), sCtx)
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("px-org-id-override", "8ba7b810-9dad-11d1-80b4-00c04fd430c8"))
+
+	// The downstream request should be made on behalf of the overridden org.
+	mockClients.MockVzMgr.EXPECT().GetViziersByOrg(gomock.Any(), overrideOrgID).
+		DoAndReturn(func(ctx context.Context, orgID *uuidpb.UUID) (*vzmgrpb.GetViziersByOrgResponse, error) {
+			md, ok := metadata.FromOutgoingContext(ctx)
+			require.True(t, ok)
+			auth := md.Get("authorization")
+			require.Equal(t, 1, len(auth))
+			aCtx := authcontext.New()
+			require.NoError(t, aCtx.UseJWTAuth("jwt-key", auth[0][len("bearer "):], "pixie"))
+			assert.Equal(t, "8ba7b810-9dad-11d1-80b4-00c04fd430c8", aCtx.Claims.GetUserClaims().OrgID)
+			return &vzmgrpb.GetViziersByOrgResponse{
+				VizierIDs: []*uuidpb.UUID{clusterID},
+			}, nil
+		})
+	mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
+		VizierIDs: []*uuidpb.UUID{clusterID},
+	}).Return(&vzmgrpb.GetVizierInfosResponse{
+		VizierInfos: []*cvmsgspb.VizierInfo{{
+			VizierID:    clusterID,
+			Status:      cvmsgspb.VZ_ST_HEALTHY,
+			ClusterName: "other-org-cluster",
+			Config:      &cvmsgspb.VizierConfig{},
+		}},
+	}, nil)
+
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr: mockClients.MockVzMgr,
+	}
+
+	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(resp.Clusters))
+	assert.Equal(t, "other-org-cluster", resp.Clusters[0].ClusterName)
+}
+
+func TestVizierClusterInfo_GetClusterInfoNonAdminOrgOverride(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := metadata.NewIncomingContext(CreateTestContext(), metadata.Pairs("px-org-id-override", "8ba7b810-9dad-11d1-80b4-00c04fd430c8"))
+
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr: mockClients.MockVzMgr,
+	}
+
+	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{})
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestVizierClusterInfo_GetClusterInfoDuplicates(t *testing.T) {
+	orgID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	assert.NotNil(t, clusterID)
+	clusterID2 := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c9")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	mockClients.MockVzMgr.EXPECT().GetViziersByOrg(gomock.Any(), orgID).Return(&vzmgrpb.GetViziersByOrgResponse{
+		VizierIDs: []*uuidpb.UUID{clusterID, clusterID2},
+	}, nil)
+
+	mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
+		VizierIDs: []*uuidpb.UUID{clusterID, clusterID2},
+	}).Return(&vzmgrpb.GetVizierInfosResponse{
+		VizierInfos: []*cvmsgspb.VizierInfo{{
+			VizierID:        clusterID,
+			Status:          cvmsgspb.VZ_ST_HEALTHY,
+			LastHeartbeatNs: int64(1305646598000000000),
+			Config: &cvmsgspb.VizierConfig{
+				PassthroughEnabled: false,
+				AutoUpdateEnabled:  true,
+			},
+			VizierVersion:        "1.2.3",
+			ClusterUID:           "a UID",
+			ClusterName:          "gke_pl-dev-infra_us-west1-a_dev-cluster-zasgar",
+			ClusterVersion:       "5.6.7",
+			NumNodes:             5,
+			NumInstrumentedNodes: 3,
+		},
+			{
+				VizierID:        clusterID,
+				Status:          cvmsgspb.VZ_ST_HEALTHY,
+				LastHeartbeatNs: int64(1305646598000000000),
+				Config: &cvmsgspb.VizierConfig{
+					PassthroughEnabled: false,
+					AutoUpdateEnabled:  true,
+				},
+				VizierVersion:        "1.2.3",
+				ClusterUID:           "a UID2",
+				ClusterName:          "gke_pl-pixies_us-west1-a_dev-cluster-zasgar",
+				ClusterVersion:       "5.6.7",
+				NumNodes:             5,
+				NumInstrumentedNodes: 3,
+			},
+		},
+	}, nil)
+
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr: mockClients.MockVzMgr,
+	}
+
+	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(resp.Clusters))
+	assert.Equal(t, "gke:dev-cluster-zasgar (pl-dev-infra)", resp.Clusters[0].PrettyClusterName)
+	assert.Equal(t, "gke:dev-cluster-zasgar (pl-pixies)", resp.Clusters[1].PrettyClusterName)
+}
+
+func TestVizierClusterInfo_GetClusterInfoWithID(t *testing.T) {
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	assert.NotNil(t, clusterID)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
+		VizierIDs: []*uuidpb.UUID{clusterID},
+	}).Return(&vzmgrpb.GetVizierInfosResponse{
+		VizierInfos: []*cvmsgspb.VizierInfo{{
+			VizierID:        clusterID,
+			Status:          cvmsgspb.VZ_ST_HEALTHY,
+			LastHeartbeatNs: int64(1305646598000000000),
+			Config: &cvmsgspb.VizierConfig{
+				PassthroughEnabled: false,
+				AutoUpdateEnabled:  true,
+			},
+			VizierVersion:  "1.2.3",
+			ClusterUID:     "a UID",
+			ClusterName:    "some cluster",
+			ClusterVersion: "5.6.7",
+		},
+		},
+	}, nil)
+
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr: mockClients.MockVzMgr,
+	}
+
+	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{
+		ID: clusterID,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(resp.Clusters))
+	cluster := resp.Clusters[0]
+	assert.Equal(t, cluster.ID, clusterID)
+	assert.Equal(t, cluster.Status, cloudpb.CS_HEALTHY)
+	assert.Equal(t, cluster.LastHeartbeatNs, int64(1305646598000000000))
+	assert.Equal(t, cluster.Config.PassthroughEnabled, false)
+	assert.Equal(t, cluster.Config.AutoUpdateEnabled, true)
+}
+
+func TestVizierClusterInfo_GetClusterInfoVzMgrError(t *testing.T) {
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	assert.NotNil(t, clusterID)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{
+		VizierIDs: []*uuidpb.UUID{clusterID},
+	}).Return(nil, status.Error(codes.Unavailable, "vzmgr is down")).Times(3)
+
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr:             mockClients.MockVzMgr,
+		VzMgrRetryBackoff: time.Millisecond,
+	}
+
+	ctx = apienv.ContextWithRetryBudget(ctx, apienv.NewRetryBudget(5))
+	resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{ID: clusterID})
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	msg := status.Convert(err).Message()
+	assert.Contains(t, msg, "GetVizierInfos")
+	assert.Contains(t, msg, "7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	assert.Contains(t, msg, "vzmgr is down")
+}
+
+func TestVizierClusterInfo_GetClusterInfoVzMgrRetry(t *testing.T) {
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	assert.NotNil(t, clusterID)
+
+	tests := []struct {
+		name          string
+		errs          []error
+		budget        *apienv.RetryBudget
+		expectedCode  codes.Code
+		expectedCalls int
+	}{
+		{
+			name: "succeeds after transient errors",
+			errs: []error{
+				status.Error(codes.Unavailable, "vzmgr is down"),
+				status.Error(codes.Unavailable, "vzmgr is down"),
+			},
+			budget:        apienv.NewRetryBudget(3),
+			expectedCode:  codes.OK,
+			expectedCalls: 3,
+		},
+		{
+			name: "deadline exceeded is retried",
+			errs: []error{
+				status.Error(codes.DeadlineExceeded, "timed out"),
+			},
+			budget:        apienv.NewRetryBudget(3),
+			expectedCode:  codes.OK,
+			expectedCalls: 2,
+		},
+		{
+			name: "not found is not retried",
+			errs: []error{
+				status.Error(codes.NotFound, "no such cluster"),
+			},
+			budget:        apienv.NewRetryBudget(3),
+			expectedCode:  codes.NotFound,
+			expectedCalls: 1,
+		},
+		{
+			name: "invalid argument is not retried",
+			errs: []error{
+				status.Error(codes.InvalidArgument, "bad request"),
+			},
+			budget:        apienv.NewRetryBudget(3),
+			expectedCode:  codes.InvalidArgument,
+			expectedCalls: 1,
+		},
+		{
+			name: "stops when the retry budget is exhausted",
+			errs: []error{
+				status.Error(codes.Unavailable, "vzmgr is down"),
+				status.Error(codes.Unavailable, "vzmgr is down"),
+			},
+			budget:        apienv.NewRetryBudget(1),
+			expectedCode:  codes.Unavailable,
+			expectedCalls: 2,
+		},
+		{
+			name: "not retried without a retry budget",
+			errs: []error{
+				status.Error(codes.Unavailable, "vzmgr is down"),
+			},
+			expectedCode:  codes.Unavailable,
+			expectedCalls: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+			defer cleanup()
+			ctx := CreateTestContext()
+			if test.budget != nil {
+				ctx = apienv.ContextWithRetryBudget(ctx, test.budget)
+			}
+
+			req := &vzmgrpb.GetVizierInfosRequest{
+				VizierIDs: []*uuidpb.UUID{clusterID},
+			}
+			calls := 0
+			mockClients.MockVzMgr.EXPECT().GetVizierInfos(gomock.Any(), req).
+				DoAndReturn(func(ctx context.Context, req *vzmgrpb.GetVizierInfosRequest, opts ...grpc.CallOption) (*vzmgrpb.GetVizierInfosResponse, error) {
+					calls++
+					if calls <= len(test.errs) {
+						return nil, test.errs[calls-1]
+					}
+					return &vzmgrpb.GetVizierInfosResponse{
+						VizierInfos: []*cvmsgspb.VizierInfo{{
+							VizierID:    clusterID,
+							Status:      cvmsgspb.VZ_ST_HEALTHY,
+							ClusterName: "test",
+							Config:      &cvmsgspb.VizierConfig{},
+						}},
+					}, nil
+				}).AnyTimes()
+
+			vzClusterInfoServer := &controller.VizierClusterInfo{
+				VzMgr:             mockClients.MockVzMgr,
+				VzMgrRetryBackoff: time.Millisecond,
+			}
+
+			resp, err := vzClusterInfoServer.GetClusterInfo(ctx, &cloudpb.GetClusterInfoRequest{ID: clusterID})
+			assert.Equal(t, test.expectedCode, status.Code(err))
+			assert.Equal(t, test.expectedCalls, calls)
+			if test.expectedCode != codes.OK {
+				assert.Nil(t, resp)
+				return
+			}
+			require.NotNil(t, resp)
+			assert.Equal(t, 1, len(resp.Clusters))
+		})
+	}
+}
+
+func TestVizierClusterInfo_UpdateClusterVizierConfig(t *testing.T) {
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	assert.NotNil(t, clusterID)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	updateReq := &cvmsgspb.UpdateVizierConfigRequest{
+		VizierID: clusterID,
+		ConfigUpdate: &cvmsgspb.VizierConfigUpdate{
+			PassthroughEnabled: &types.BoolValue{Value: true},
+			AutoUpdateEnabled:  &types.BoolValue{Value: false},
+		},
+	}
+
+	mockClients.MockVzMgr.EXPECT().UpdateVizierConfig(gomock.Any(), updateReq).Return(&cvmsgspb.UpdateVizierConfigResponse{}, nil)
+
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr: mockClients.MockVzMgr,
+	}
+
+	resp, err := vzClusterInfoServer.UpdateClusterVizierConfig(ctx, &cloudpb.UpdateClusterVizierConfigRequest{
+		ID: clusterID,
+		ConfigUpdate: &cloudpb.VizierConfigUpdate{
+			PassthroughEnabled: &types.BoolValue{Value: true},
+			AutoUpdateEnabled:  &types.BoolValue{Value: false},
+		},
+	})
+
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+}
+
+func TestVizierClusterInfo_GetClusterVizierConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	unknownID := utils.ProtoFromUUIDStrOrNil("8ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	mockClients.MockVzMgr.EXPECT().
+		GetVizierInfo(gomock.Any(), clusterID).
+		Return(&cvmsgspb.VizierInfo{
+			VizierID: clusterID,
+			Status:   cvmsgspb.VZ_ST_HEALTHY,
+			Config: &cvmsgspb.VizierConfig{
+				PassthroughEnabled: true,
+				AutoUpdateEnabled:  false,
+				UpdateChannel:      "beta",
+			},
+		}, nil)
+	mockClients.MockVzMgr.EXPECT().
+		GetVizierInfo(gomock.Any(), unknownID).
+		Return(nil, status.Error(codes.NotFound, "invalid cluster ID for org"))
+
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr: mockClients.MockVzMgr,
+	}
+
+	resp, err := vzClusterInfoServer.GetClusterVizierConfig(ctx, &cloudpb.GetClusterVizierConfigRequest{
+		ID: clusterID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &cloudpb.VizierConfig{
+		PassthroughEnabled: true,
+		AutoUpdateEnabled:  false,
+		UpdateChannel:      "beta",
+	}, resp.Config)
+
+	resp, err = vzClusterInfoServer.GetClusterVizierConfig(ctx, &cloudpb.GetClusterVizierConfigRequest{
+		ID: unknownID,
+	})
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestVizierClusterInfo_UpdateClusterVizierConfigPartial(t *testing.T) {
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	tests := []struct {
+		name     string
+		update   *cloudpb.VizierConfigUpdate
+		expected *cvmsgspb.VizierConfigUpdate
+	}{
+		{
+			name:     "passthrough only",
+			update:   &cloudpb.VizierConfigUpdate{PassthroughEnabled: &types.BoolValue{Value: true}},
+			expected: &cvmsgspb.VizierConfigUpdate{PassthroughEnabled: &types.BoolValue{Value: true}},
+		},
+		{
+			name:     "auto update only",
+			update:   &cloudpb.VizierConfigUpdate{AutoUpdateEnabled: &types.BoolValue{Value: false}},
+			expected: &cvmsgspb.VizierConfigUpdate{AutoUpdateEnabled: &types.BoolValue{Value: false}},
+		},
+		{
+			name:     "update channel only",
+			update:   &cloudpb.VizierConfigUpdate{UpdateChannel: &types.StringValue{Value: "stable"}},
+			expected: &cvmsgspb.VizierConfigUpdate{UpdateChannel: &types.StringValue{Value: "stable"}},
+		},
+		{
+			name:     "no update",
+			update:   nil,
+			expected: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+			defer cleanup()
+			ctx := CreateTestContext()
+
+			// The unset fields should be forwarded as nil, so that vzmgr leaves them untouched.
+			mockClients.MockVzMgr.EXPECT().
+				UpdateVizierConfig(gomock.Any(), &cvmsgspb.UpdateVizierConfigRequest{
+					VizierID:     clusterID,
+					ConfigUpdate: tc.expected,
+				}).
+				Return(&cvmsgspb.UpdateVizierConfigResponse{}, nil)
+
+			vzClusterInfoServer := &controller.VizierClusterInfo{
+				VzMgr: mockClients.MockVzMgr,
+			}
+
+			_, err := vzClusterInfoServer.UpdateClusterVizierConfig(ctx, &cloudpb.UpdateClusterVizierConfigRequest{
+				ID:           clusterID,
+				ConfigUpdate: tc.update,
+			})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestVizierClusterInfo_UpdateClusterVizierConfigUpdateChannel(t *testing.T) {
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	tests := []struct {
+		name          string
+		channel       string
+		expectErrCode codes.Code
+	}{
+		{
+			name:          "valid channel",
+			channel:       "beta",
+			expectErrCode: codes.OK,
+		},
+		{
+			name:          "invalid channel",
+			channel:       "nightly",
+			expectErrCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+			defer cleanup()
+			ctx := CreateTestContext()
+
+			if tc.expectErrCode == codes.OK {
+				mockClients.MockVzMgr.EXPECT().
+					UpdateVizierConfig(gomock.Any(), &cvmsgspb.UpdateVizierConfigRequest{
+						VizierID: clusterID,
+						ConfigUpdate: &cvmsgspb.VizierConfigUpdate{
+							UpdateChannel: &types.StringValue{Value: tc.channel},
+						},
+					}).
+					Return(&cvmsgspb.UpdateVizierConfigResponse{}, nil)
+			}
+
+			vzClusterInfoServer := &controller.VizierClusterInfo{
+				VzMgr: mockClients.MockVzMgr,
+			}
+
+			resp, err := vzClusterInfoServer.UpdateClusterVizierConfig(ctx, &cloudpb.UpdateClusterVizierConfigRequest{
+				ID: clusterID,
+				ConfigUpdate: &cloudpb.VizierConfigUpdate{
+					UpdateChannel: &types.StringValue{Value: tc.channel},
+				},
+			})
+			if tc.expectErrCode != codes.OK {
+				assert.Nil(t, resp)
+				assert.Equal(t, tc.expectErrCode, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, resp)
+		})
+	}
+}
+
+func TestVizierClusterInfo_UpdateOrInstallCluster(t *testing.T) {
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	assert.NotNil(t, clusterID)
+
+	tests := []struct {
+		name    string
+		version string
+		// Whether the version should be looked up by getting its download link.
+		expectLinkLookup bool
+		linkErr          error
+		expectUpdate     bool
+		expectErrCode    codes.Code
+	}{
+		{
+			name:             "valid version",
+			version:          "0.1.30",
+			expectLinkLookup: true,
+			expectUpdate:     true,
+			expectErrCode:    codes.OK,
+		},
+		{
+			name:             "missing version",
+			version:          "0.1.29",
+			expectLinkLookup: true,
+			linkErr:          status.Error(codes.NotFound, "artifact not found"),
+			expectUpdate:     false,
+			expectErrCode:    codes.NotFound,
+		},
+		{
+			name:          "latest version",
+			version:       "",
+			expectUpdate:  true,
+			expectErrCode: codes.OK,
+		},
+		{
+			name:             "valid prerelease version",
+			version:          "0.1.32-pre-main.0",
+			expectLinkLookup: true,
+			expectUpdate:     true,
+			expectErrCode:    codes.OK,
+		},
+		{
+			name:             "missing prerelease version",
+			version:          "0.1.32-pre-main.1",
+			expectLinkLookup: true,
+			linkErr:          status.Error(codes.NotFound, "artifact not found"),
+			expectUpdate:     false,
+			expectErrCode:    codes.NotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+			defer cleanup()
+			ctx := CreateTestContext()
+
+			if tc.expectLinkLookup {
+				mockClients.MockArtifact.EXPECT().
+					GetDownloadLink(gomock.Any(), &artifacttrackerpb.GetDownloadLinkRequest{
+						ArtifactName: "vizier",
+						VersionStr:   tc.version,
+						ArtifactType: versionspb.AT_CONTAINER_SET_YAMLS,
+					}).
+					Return(nil, tc.linkErr)
+			}
+			if tc.expectUpdate {
+				mockClients.MockVzMgr.EXPECT().
+					UpdateOrInstallVizier(gomock.Any(), &cvmsgspb.UpdateOrInstallVizierRequest{
+						VizierID: clusterID,
+						Version:  tc.version,
+					}).
+					Return(&cvmsgspb.UpdateOrInstallVizierResponse{UpdateStarted: true}, nil)
+			}
+
+			vzClusterInfoServer := &controller.VizierClusterInfo{
+				VzMgr:                 mockClients.MockVzMgr,
+				ArtifactTrackerClient: mockClients.MockArtifact,
+			}
+
+			resp, err := vzClusterInfoServer.UpdateOrInstallCluster(ctx, &cloudpb.UpdateOrInstallClusterRequest{
+				ClusterID: clusterID,
+				Version:   tc.version,
+			})
+			if tc.expectErrCode != codes.OK {
+				assert.Nil(t, resp)
+				assert.Equal(t, tc.expectErrCode, status.Code(err))
+				assert.Contains(t, status.Convert(err).Message(), tc.version)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, resp.UpdateStarted)
+		})
+	}
+}
+
+func TestVizierClusterInfo_RollbackCluster(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	// vzmgr recorded 0.1.30 as the version the cluster ran before its last update.
+	gomock.InOrder(
+		mockClients.MockVzMgr.EXPECT().
+			GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{VizierIDs: []*uuidpb.UUID{clusterID}}).
+			Return(&vzmgrpb.GetVizierInfosResponse{
+				VizierInfos: []*cvmsgspb.VizierInfo{{
+					VizierID:              clusterID,
+					VizierVersion:         "0.1.31",
+					PreviousVizierVersion: "0.1.30",
+				}},
+			}, nil),
+		mockClients.MockArtifact.EXPECT().
+			GetDownloadLink(gomock.Any(), &artifacttrackerpb.GetDownloadLinkRequest{
+				ArtifactName: "vizier",
+				VersionStr:   "0.1.30",
+				ArtifactType: versionspb.AT_CONTAINER_SET_YAMLS,
+			}).
+			Return(&artifacttrackerpb.GetDownloadLinkResponse{}, nil),
+		mockClients.MockVzMgr.EXPECT().
+			UpdateOrInstallVizier(gomock.Any(), &cvmsgspb.UpdateOrInstallVizierRequest{
+				VizierID: clusterID,
+				Version:  "0.1.30",
+			}).
+			Return(&cvmsgspb.UpdateOrInstallVizierResponse{UpdateStarted: true}, nil),
+	)
+
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr:                 mockClients.MockVzMgr,
+		ArtifactTrackerClient: mockClients.MockArtifact,
+	}
+
+	resp, err := vzClusterInfoServer.RollbackCluster(ctx, &cloudpb.RollbackClusterRequest{
+		ClusterID: clusterID,
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.UpdateStarted)
+	assert.Equal(t, "0.1.30", resp.Version)
+}
+
+func TestVizierClusterInfo_RollbackClusterNoPreviousVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	clusterID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	vzClusterInfoServer := &controller.VizierClusterInfo{
+		VzMgr:                 mockClients.MockVzMgr,
+		ArtifactTrackerClient: mockClients.MockArtifact,
+	}
+
+	mockClients.MockVzMgr.EXPECT().
+		GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{VizierIDs: []*uuidpb.UUID{clusterID}}).
+		Return(&vzmgrpb.GetVizierInfosResponse{
+			VizierInfos: []*cvmsgspb.VizierInfo{{VizierID: clusterID, VizierVersion: "0.1.31"}},
+		}, nil)
+
+	// The cluster was never updated, so there is nothing to roll back to.
+	resp, err := vzClusterInfoServer.RollbackCluster(ctx, &cloudpb.RollbackClusterRequest{
+		ClusterID: clusterID,
+	})
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestVizierDeploymentKeyServer_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	vzreq := &vzmgrpb.CreateDeploymentKeyRequest{Desc: "test key"}
+	vzresp := &vzmgrpb.DeploymentKey{
+		ID:        utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+		Key:       "foobar",
+		CreatedAt: types.TimestampNow(),
+	}
+	mockClients.MockVzDeployKey.EXPECT().
+		Create(gomock.Any(), vzreq).Return(vzresp, nil)
+
+	vzDeployKeyServer := &controller.VizierDeploymentKeyServer{
+		VzDeploymentKey: mockClients.MockVzDeployKey,
+	}
+
+	resp, err := vzDeployKeyServer.Create(ctx, &cloudpb.CreateDeploymentKeyRequest{Desc: "test key"})
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+	assert.Equal(t, resp.ID, vzresp.ID)
+	assert.Equal(t, resp.Key, vzresp.Key)
+	assert.Equal(t, resp.CreatedAt, vzresp.CreatedAt)
+	// The key is attributed to the user in the context.
+	assert.Equal(t, utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c9"), resp.CreatedBy)
+}
+
+func TestVizierDeploymentKeyServer_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	vzreq := &vzmgrpb.ListDeploymentKeyRequest{}
+	vzresp := &vzmgrpb.ListDeploymentKeyResponse{
+		Keys: []*vzmgrpb.DeploymentKey{
+			{
+				ID:        utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+				Key:       "foobar",
+				CreatedAt: types.TimestampNow(),
+				Desc:      "this is a key",
+			},
+		},
+	}
+	mockClients.MockVzDeployKey.EXPECT().
+		List(gomock.Any(), vzreq).Return(vzresp, nil)
+
+	vzDeployKeyServer := &controller.VizierDeploymentKeyServer{
+		VzDeploymentKey: mockClients.MockVzDeployKey,
+	}
+
+	resp, err := vzDeployKeyServer.List(ctx, &cloudpb.ListDeploymentKeyRequest{})
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+	for i, key := range resp.Keys {
+		assert.Equal(t, key.ID, vzresp.Keys[i].ID)
+		assert.Equal(t, key.Key, vzresp.Keys[i].Key)
+		assert.Equal(t, key.CreatedAt, vzresp.Keys[i].CreatedAt)
+		assert.Equal(t, key.Desc, vzresp.Keys[i].Desc)
+		assert.Nil(t, key.CreatedBy)
+	}
+}
+
+func TestVizierDeploymentKeyServer_ListPaged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	vzresp := &vzmgrpb.ListDeploymentKeyResponse{
+		Keys: []*vzmgrpb.DeploymentKey{
+			{
+				ID:        utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c1"),
+				CreatedAt: &types.Timestamp{Seconds: 1},
+				Desc:      "charlie",
+			},
+			{
+				ID:        utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c2"),
+				CreatedAt: &types.Timestamp{Seconds: 2},
+				Desc:      "alpha",
+			},
+			{
+				ID:        utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c3"),
+				CreatedAt: &types.Timestamp{Seconds: 3},
+				Desc:      "bravo",
+			},
+		},
+	}
+	mockClients.MockVzDeployKey.EXPECT().
+		List(gomock.Any(), &vzmgrpb.ListDeploymentKeyRequest{}).Return(vzresp, nil).Times(2)
+
+	vzDeployKeyServer := &controller.VizierDeploymentKeyServer{
+		VzDeploymentKey: mockClients.MockVzDeployKey,
+	}
+
+	resp, err := vzDeployKeyServer.List(ctx, &cloudpb.ListDeploymentKeyRequest{
+		PageSize: 2,
+		SortBy:   cloudpb.KSB_DESC,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(resp.Keys))
+	assert.Equal(t, "alpha", resp.Keys[0].Desc)
+	assert.Equal(t, "bravo", resp.Keys[1].Desc)
+	require.NotEmpty(t, resp.NextPageToken)
+
+	resp, err = vzDeployKeyServer.List(ctx, &cloudpb.ListDeploymentKeyRequest{
+		PageSize:  2,
+		PageToken: resp.NextPageToken,
+		SortBy:    cloudpb.KSB_DESC,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(resp.Keys))
+	assert.Equal(t, "charlie", resp.Keys[0].Desc)
+	assert.Empty(t, resp.NextPageToken)
+}
+
+func TestVizierDeploymentKeyServer_Get(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	id := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	vzreq := &vzmgrpb.GetDeploymentKeyRequest{
+		ID: id,
+	}
+	vzresp := &vzmgrpb.GetDeploymentKeyResponse{
+		Key: &vzmgrpb.DeploymentKey{
+			ID:        id,
+			Key:       "foobar",
+			CreatedAt: types.TimestampNow(),
+			Desc:      "this is a key",
+			CreatedBy: utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c9"),
+		},
+	}
+	mockClients.MockVzDeployKey.EXPECT().
+		Get(gomock.Any(), vzreq).Return(vzresp, nil)
+
+	vzDeployKeyServer := &controller.VizierDeploymentKeyServer{
+		VzDeploymentKey: mockClients.MockVzDeployKey,
+	}
+	resp, err := vzDeployKeyServer.Get(ctx, &cloudpb.GetDeploymentKeyRequest{
+		ID: id,
+	})
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+	assert.Equal(t, resp.Key.ID, vzresp.Key.ID)
+	assert.Equal(t, resp.Key.Key, vzresp.Key.Key)
+	assert.Equal(t, resp.Key.CreatedAt, vzresp.Key.CreatedAt)
+	assert.Equal(t, resp.Key.Desc, vzresp.Key.Desc)
+	assert.Equal(t, resp.Key.CreatedBy, vzresp.Key.CreatedBy)
+}
+
+func TestVizierDeploymentKeyServer_Delete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	id := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	vzresp := &types.Empty{}
+	mockClients.MockVzDeployKey.EXPECT().
+		Delete(gomock.Any(), id).Return(vzresp, nil)
+
+	vzDeployKeyServer := &controller.VizierDeploymentKeyServer{
+		VzDeploymentKey: mockClients.MockVzDeployKey,
+	}
+	resp, err := vzDeployKeyServer.Delete(ctx, id)
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+	assert.Equal(t, resp, vzresp)
+}
+
+func TestVizierDeploymentKeyServer_GetDeployedClusters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	keyID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	createdAt := types.TimestampNow()
+	mockClients.MockVzDeployKey.EXPECT().
+		GetDeployedClusters(gomock.Any(), &vzmgrpb.GetDeployedClustersRequest{KeyID: keyID}).
+		Return(&vzmgrpb.GetDeployedClustersResponse{
+			Clusters: []*vzmgrpb.DeployedCluster{
+				{
+					ID:          utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+					ClusterName: "cluster_1",
+					ClusterUID:  "uid_1",
+					CreatedAt:   createdAt,
+				},
+				{
+					ID:          utils.ProtoFromUUIDStrOrNil("8ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+					ClusterName: "cluster_2",
+					ClusterUID:  "uid_2",
+					CreatedAt:   createdAt,
+				},
+			},
+		}, nil)
+
+	vzDeployKeyServer := &controller.VizierDeploymentKeyServer{
+		VzDeploymentKey: mockClients.MockVzDeployKey,
+	}
+	resp, err := vzDeployKeyServer.GetDeployedClusters(ctx, &cloudpb.GetDeployedClustersRequest{
+		KeyID: keyID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*cloudpb.DeployedCluster{
+		{
+			ID:          utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+			ClusterName: "cluster_1",
+			ClusterUID:  "uid_1",
+			CreatedAt:   createdAt,
+		},
+		{
+			ID:          utils.ProtoFromUUIDStrOrNil("8ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+			ClusterName: "cluster_2",
+			ClusterUID:  "uid_2",
+			CreatedAt:   createdAt,
+		},
+	}, resp.Clusters)
+}
+
+func TestVizierDeploymentKeyServer_GetDeployedClustersUnownedKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	keyID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	mockClients.MockVzDeployKey.EXPECT().
+		GetDeployedClusters(gomock.Any(), &vzmgrpb.GetDeployedClustersRequest{KeyID: keyID}).
+		Return(nil, status.Error(codes.NotFound, "No such deployment key"))
+
+	vzDeployKeyServer := &controller.VizierDeploymentKeyServer{
+		VzDeploymentKey: mockClients.MockVzDeployKey,
+	}
+	resp, err := vzDeployKeyServer.GetDeployedClusters(ctx, &cloudpb.GetDeployedClustersRequest{
+		KeyID: keyID,
+	})
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestAPIKeyServer_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	vzreq := &authpb.CreateAPIKeyRequest{Desc: "test key"}
+	vzresp := &authpb.APIKey{
+		ID:        utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+		Key:       "foobar",
+		CreatedAt: types.TimestampNow(),
+	}
+	mockClients.MockAPIKey.EXPECT().
+		Create(gomock.Any(), vzreq).Return(vzresp, nil)
+
+	vzAPIKeyServer := &controller.APIKeyServer{
+		APIKeyClient: mockClients.MockAPIKey,
+	}
+
+	resp, err := vzAPIKeyServer.Create(ctx, &cloudpb.CreateAPIKeyRequest{Desc: "test key"})
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+	assert.Equal(t, resp.ID, vzresp.ID)
+	assert.Equal(t, resp.Key, vzresp.Key)
+	assert.Equal(t, resp.CreatedAt, vzresp.CreatedAt)
+	// The key is attributed to the user in the context.
+	assert.Equal(t, utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c9"), resp.CreatedBy)
+}
+
+func TestAPIKeyServer_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	vzreq := &authpb.ListAPIKeyRequest{}
+	vzresp := &authpb.ListAPIKeyResponse{
+		Keys: []*authpb.APIKey{
+			{
+				ID:        utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+				Key:       "foobar",
+				CreatedAt: types.TimestampNow(),
+				Desc:      "this is a key",
+			},
+		},
+	}
+	mockClients.MockAPIKey.EXPECT().
+		List(gomock.Any(), vzreq).Return(vzresp, nil)
+
+	vzAPIKeyServer := &controller.APIKeyServer{
+		APIKeyClient: mockClients.MockAPIKey,
+	}
+
+	resp, err := vzAPIKeyServer.List(ctx, &cloudpb.ListAPIKeyRequest{})
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+	for i, key := range resp.Keys {
+		assert.Equal(t, key.ID, vzresp.Keys[i].ID)
+		assert.Equal(t, key.Key, vzresp.Keys[i].Key)
+		assert.Equal(t, key.CreatedAt, vzresp.Keys[i].CreatedAt)
+		assert.Equal(t, key.Desc, vzresp.Keys[i].Desc)
+		assert.Nil(t, key.CreatedBy)
+	}
+}
+
+func TestAPIKeyServer_ListPaged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	vzresp := &authpb.ListAPIKeyResponse{
+		Keys: []*authpb.APIKey{
+			{
+				ID:        utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c1"),
+				CreatedAt: &types.Timestamp{Seconds: 3},
+				Desc:      "newest",
+			},
+			{
+				ID:        utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c2"),
+				CreatedAt: &types.Timestamp{Seconds: 1},
+				Desc:      "oldest",
+			},
+			{
+				ID:        utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c3"),
+				CreatedAt: &types.Timestamp{Seconds: 2},
+				Desc:      "middle",
+			},
+		},
+	}
+	mockClients.MockAPIKey.EXPECT().
+		List(gomock.Any(), &authpb.ListAPIKeyRequest{}).Return(vzresp, nil).Times(3)
+
+	vzAPIKeyServer := &controller.APIKeyServer{
+		APIKeyClient: mockClients.MockAPIKey,
+	}
+
+	resp, err := vzAPIKeyServer.List(ctx, &cloudpb.ListAPIKeyRequest{
+		PageSize: 2,
+		SortBy:   cloudpb.KSB_CREATED_AT,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(resp.Keys))
+	assert.Equal(t, "oldest", resp.Keys[0].Desc)
+	assert.Equal(t, "middle", resp.Keys[1].Desc)
+	require.NotEmpty(t, resp.NextPageToken)
+
+	resp, err = vzAPIKeyServer.List(ctx, &cloudpb.ListAPIKeyRequest{
+		PageSize:  2,
+		PageToken: resp.NextPageToken,
+		SortBy:    cloudpb.KSB_CREATED_AT,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(resp.Keys))
+	assert.Equal(t, "newest", resp.Keys[0].Desc)
+	assert.Empty(t, resp.NextPageToken)
+
+	_, err = vzAPIKeyServer.List(ctx, &cloudpb.ListAPIKeyRequest{
+		PageToken: "not a token",
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAPIKeyServer_Get(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	id := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	vzreq := &authpb.GetAPIKeyRequest{
+		ID: id,
+	}
+	vzresp := &authpb.GetAPIKeyResponse{
+		Key: &authpb.APIKey{
+			ID:        id,
+			Key:       "foobar",
+			CreatedAt: types.TimestampNow(),
+			Desc:      "this is a key",
+			CreatedBy: utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c9"),
+		},
+	}
+	mockClients.MockAPIKey.EXPECT().
+		Get(gomock.Any(), vzreq).Return(vzresp, nil)
+
+	vzAPIKeyServer := &controller.APIKeyServer{
+		APIKeyClient: mockClients.MockAPIKey,
+	}
+	resp, err := vzAPIKeyServer.Get(ctx, &cloudpb.GetAPIKeyRequest{
+		ID: id,
+	})
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+	assert.Equal(t, resp.Key.ID, vzresp.Key.ID)
+	assert.Equal(t, resp.Key.Key, vzresp.Key.Key)
+	assert.Equal(t, resp.Key.CreatedAt, vzresp.Key.CreatedAt)
+	assert.Equal(t, resp.Key.Desc, vzresp.Key.Desc)
+	assert.Equal(t, resp.Key.CreatedBy, vzresp.Key.CreatedBy)
+}
+
+func TestAPIKeyServer_Delete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	id := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	vzresp := &types.Empty{}
+	mockClients.MockAPIKey.EXPECT().
+		Delete(gomock.Any(), id).Return(vzresp, nil)
+
+	vzAPIKeyServer := &controller.APIKeyServer{
+		APIKeyClient: mockClients.MockAPIKey,
+	}
+	resp, err := vzAPIKeyServer.Delete(ctx, id)
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+	assert.Equal(t, resp, vzresp)
+}
+
+func TestScriptMgr_InvalidIDs(t *testing.T) {
+	testCases := []struct {
+		name string
+		id   string
+	}{
+		{
+			name: "empty ID",
+			id:   "",
+		},
+		{
+			name: "non-UUID ID",
+			id:   "not-a-uuid",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// No calls to the scriptmgr service are expected.
+			mockScriptMgr := mock_scriptmgr.NewMockScriptMgrServiceClient(ctrl)
+			ctx := CreateTestContext()
+
+			scriptMgrServer := &controller.ScriptMgrServer{
+				ScriptMgr: mockScriptMgr,
+			}
+
+			scriptResp, err := scriptMgrServer.GetScriptContents(ctx, &cloudpb.GetScriptContentsReq{ScriptID: tc.id})
+			assert.Nil(t, scriptResp)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+			liveViewResp, err := scriptMgrServer.GetLiveViewContents(ctx, &cloudpb.GetLiveViewContentsReq{LiveViewID: tc.id})
+			assert.Nil(t, liveViewResp)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
+
+func TestScriptMgr_SearchScriptsFallback(t *testing.T) {
+	ID1 := uuid.Must(uuid.NewV4())
+	ID2 := uuid.Must(uuid.NewV4())
+	ID3 := uuid.Must(uuid.NewV4())
+	scripts := []*scriptmgrpb.ScriptMetadata{
+		{
+			ID:          utils.ProtoFromUUID(ID1),
+			Name:        "px/service_stats",
+			Desc:        "Overview of the HTTP traffic of a service",
+			HasLiveView: true,
+		},
+		{
+			ID:          utils.ProtoFromUUID(ID2),
+			Name:        "px/http_data",
+			Desc:        "Recent HTTP requests",
+			HasLiveView: false,
+		},
+		{
+			ID:          utils.ProtoFromUUID(ID3),
+			Name:        "px/cluster",
+			Desc:        "Cluster overview",
+			HasLiveView: true,
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		req         *cloudpb.SearchScriptsReq
+		expectedIDs []string
+	}{
+		{
+			name:        "name match",
+			req:         &cloudpb.SearchScriptsReq{Query: "cluster"},
+			expectedIDs: []string{ID3.String()},
+		},
+		{
+			name:        "desc match ranks below name match",
+			req:         &cloudpb.SearchScriptsReq{Query: "HTTP"},
+			expectedIDs: []string{ID2.String(), ID1.String()},
+		},
+		{
+			name: "live view filter",
+			req: &cloudpb.SearchScriptsReq{
+				Query:       "http",
+				HasLiveView: &types.BoolValue{Value: true},
+			},
+			expectedIDs: []string{ID1.String()},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockScriptMgr := mock_scriptmgr.NewMockScriptMgrServiceClient(ctrl)
+			ctx := CreateTestContext()
+
+			mockScriptMgr.EXPECT().
+				SearchScripts(gomock.Any(), gomock.Any()).
+				Return(nil, status.Error(codes.Unimplemented, "unknown method SearchScripts"))
+			mockScriptMgr.EXPECT().
+				GetScripts(gomock.Any(), &scriptmgrpb.GetScriptsReq{}).
+				Return(&scriptmgrpb.GetScriptsResp{Scripts: scripts}, nil)
+
+			scriptMgrServer := &controller.ScriptMgrServer{
+				ScriptMgr: mockScriptMgr,
+			}
+
+			resp, err := scriptMgrServer.SearchScripts(ctx, tc.req)
+			require.NoError(t, err)
+			ids := make([]string, len(resp.Scripts))
+			for i, script := range resp.Scripts {
+				ids[i] = script.ID
+			}
+			assert.Equal(t, tc.expectedIDs, ids)
+		})
+	}
+}
+
+func TestAutocompleteService_Autocomplete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orgID, err := uuid.FromString("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	require.NoError(t, err)
+	ctx := CreateTestContext()
+
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+
+	requests := [][]*autocomplete.SuggestionRequest{
+		{
+			{
+				OrgID:        orgID,
+				ClusterUID:   "test",
+				Input:        "px/svc_info",
+				AllowedKinds: []cloudpb.AutocompleteEntityKind{cloudpb.AEK_POD, cloudpb.AEK_SVC, cloudpb.AEK_NAMESPACE, cloudpb.AEK_SCRIPT},
+				AllowedArgs:  []cloudpb.AutocompleteEntityKind{},
+			},
+			{
+				OrgID:        orgID,
+				ClusterUID:   "test",
+				Input:        "pl/test",
+				AllowedKinds: []cloudpb.AutocompleteEntityKind{cloudpb.AEK_POD, cloudpb.AEK_SVC, cloudpb.AEK_NAMESPACE, cloudpb.AEK_SCRIPT},
+				AllowedArgs:  []cloudpb.AutocompleteEntityKind{},
+			},
+		},
+	}
+
+	responses := [][]*autocomplete.SuggestionResult{
+		{
+			{
+				Suggestions: []*autocomplete.Suggestion{
+					{
+						Name:     "px/svc_info",
+						Score:    1,
+						ArgNames: []string{"svc_name"},
+						ArgKinds: []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SVC},
+					},
+				},
+				ExactMatch: true,
+			},
+			{
+				Suggestions: []*autocomplete.Suggestion{
+					{
+						Name:  "px/test",
+						Score: 1,
+					},
+				},
+				ExactMatch: true,
+			},
+		},
+	}
+
+	suggestionCalls := 0
+	s.EXPECT().
+		GetSuggestions(gomock.Any()).
+		DoAndReturn(func(req []*autocomplete.SuggestionRequest) ([]*autocomplete.SuggestionResult, error) {
+			assert.ElementsMatch(t, requests[suggestionCalls], req)
+			resp := responses[suggestionCalls]
+			suggestionCalls++
+			return resp, nil
+		}).
+		Times(len(requests))
+
+	autocompleteServer := &controller.AutocompleteServer{
+		Suggester: s,
+	}
+
+	resp, err := autocompleteServer.Autocomplete(ctx, &cloudpb.AutocompleteRequest{
+		Input:      "px/svc_info pl/test",
+		CursorPos:  0,
+		Action:     cloudpb.AAT_EDIT,
+		ClusterUID: "test",
+	})
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+	assert.Equal(t, "${2:$0px/svc_info} ${1:pl/test}", resp.FormattedInput)
+	assert.False(t, resp.IsExecutable)
+	assert.Equal(t, 2, len(resp.TabSuggestions))
+	assert.Equal(t, []*cloudpb.TabStop{
+		{TabIndex: 2, Text: "px/svc_info", ContainsCursor: true},
+		{TabIndex: 1, Text: "pl/test", ContainsCursor: false},
+	}, resp.TabStops)
+}
+
+func TestAutocompleteService_AutocompleteCursorInToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orgID, err := uuid.FromString("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	require.NoError(t, err)
+	ctx := CreateTestContext()
+
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+
+	allowedKinds := []cloudpb.AutocompleteEntityKind{cloudpb.AEK_POD, cloudpb.AEK_SVC, cloudpb.AEK_NAMESPACE, cloudpb.AEK_SCRIPT}
+	s.EXPECT().
+		GetSuggestions(gomock.Any()).
+		DoAndReturn(func(req []*autocomplete.SuggestionRequest) ([]*autocomplete.SuggestionResult, error) {
+			// The cursor marker should not leak into the search term of the token being edited.
+			assert.ElementsMatch(t, []*autocomplete.SuggestionRequest{
+				{OrgID: orgID, ClusterUID: "test", Input: "px/svc_info", AllowedKinds: allowedKinds, AllowedArgs: []cloudpb.AutocompleteEntityKind{}},
+				{OrgID: orgID, ClusterUID: "test", Input: "pl/test", AllowedKinds: allowedKinds, AllowedArgs: []cloudpb.AutocompleteEntityKind{}},
+			}, req)
+			return []*autocomplete.SuggestionResult{
+				{
+					Suggestions: []*autocomplete.Suggestion{{Name: "px/svc_info", Score: 1}},
+					ExactMatch:  true,
+				},
+				{
+					Suggestions: []*autocomplete.Suggestion{
+						{Name: "pl/test", Score: 1, Kind: cloudpb.AEK_POD},
+						{Name: "pl/test2", Score: 0.5, Kind: cloudpb.AEK_POD},
+					},
+				},
+			}, nil
+		})
+
+	autocompleteServer := &controller.AutocompleteServer{
+		Suggester: s,
+	}
+
+	// Place the cursor in the middle of the second token: "px/svc_info pl/|test".
+	resp, err := autocompleteServer.Autocomplete(ctx, &cloudpb.AutocompleteRequest{
+		Input:      "px/svc_info pl/test",
+		CursorPos:  15,
+		Action:     cloudpb.AAT_EDIT,
+		ClusterUID: "test",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "${1:px/svc_info} ${2:pl/$0test}", resp.FormattedInput)
+	assert.False(t, resp.IsExecutable)
+	assert.Equal(t, []*cloudpb.TabStop{
+		{TabIndex: 1, Text: "px/svc_info", ContainsCursor: false},
+		{TabIndex: 2, Text: "pl/test", ContainsCursor: true},
+	}, resp.TabStops)
+
+	// The suggestions for the tab stop under the cursor should be the ones for the second token.
+	require.Equal(t, 2, len(resp.TabSuggestions))
+	var cursorSuggestions *cloudpb.TabSuggestion
+	for _, ts := range resp.TabSuggestions {
+		if ts.TabIndex == 2 {
+			cursorSuggestions = ts
+		}
+	}
+	require.NotNil(t, cursorSuggestions)
+	names := make([]string, len(cursorSuggestions.Suggestions))
+	for i, sugg := range cursorSuggestions.Suggestions {
+		names[i] = sugg.Name
+	}
+	assert.Equal(t, []string{"pl/test", "pl/test2"}, names)
+}
+
+func TestAutocompleteService_AutocompleteInvalidCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := CreateTestContext()
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+	autocompleteServer := &controller.AutocompleteServer{
+		Suggester: s,
+	}
+
+	for _, pos := range []int64{-1, 20} {
+		_, err := autocompleteServer.Autocomplete(ctx, &cloudpb.AutocompleteRequest{
+			Input:      "px/svc_info pl/test",
+			CursorPos:  pos,
+			Action:     cloudpb.AAT_EDIT,
+			ClusterUID: "test",
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestAutocompleteService_AutocompleteExecutableBlockReason(t *testing.T) {
+	tests := []struct {
+		name               string
+		input              string
+		expectedExecutable bool
+		expectedReason     cloudpb.ExecutableBlockReason
+	}{
+		{
+			name:               "executable",
+			input:              "script:px/svc_info svc:pl/test",
+			expectedExecutable: true,
+			expectedReason:     cloudpb.EBR_NONE,
+		},
+		{
+			name:               "missing script",
+			input:              "svc:pl/test",
+			expectedExecutable: false,
+			expectedReason:     cloudpb.EBR_MISSING_SCRIPT,
+		},
+		{
+			name:               "unknown script",
+			input:              "script:px/unknown svc:pl/test",
+			expectedExecutable: false,
+			expectedReason:     cloudpb.EBR_UNKNOWN_SCRIPT,
+		},
+		{
+			name:               "invalid arg",
+			input:              "script:px/svc_info svc:pl/unknown",
+			expectedExecutable: false,
+			expectedReason:     cloudpb.EBR_INVALID_ARG,
+		},
+		{
+			name:               "unexpected arg",
+			input:              "script:px/svc_info svc:pl/test pl/other",
+			expectedExecutable: false,
+			expectedReason:     cloudpb.EBR_UNEXPECTED_ARG,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := CreateTestContext()
+
+			// Only px/svc_info and pl/test exist.
+			results := map[string]*autocomplete.SuggestionResult{
+				"px/svc_info": {
+					Suggestions: []*autocomplete.Suggestion{
+						{
+							Name:     "px/svc_info",
+							Score:    1,
+							Kind:     cloudpb.AEK_SCRIPT,
+							ArgNames: []string{"svc_name"},
+							ArgKinds: []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SVC},
+						},
+					},
+					ExactMatch: true,
+				},
+				"pl/test": {
+					Suggestions: []*autocomplete.Suggestion{{Name: "pl/test", Score: 1, Kind: cloudpb.AEK_SVC}},
+					ExactMatch:  true,
+				},
+			}
+
+			s := mock_autocomplete.NewMockSuggester(ctrl)
+			s.EXPECT().
+				GetSuggestions(gomock.Any()).
+				DoAndReturn(func(reqs []*autocomplete.SuggestionRequest) ([]*autocomplete.SuggestionResult, error) {
+					resp := make([]*autocomplete.SuggestionResult, len(reqs))
+					for i, req := range reqs {
+						if res, ok := results[req.Input]; ok {
+							resp[i] = res
+						} else {
+							resp[i] = &autocomplete.SuggestionResult{}
+						}
+					}
+					return resp, nil
+				}).
+				AnyTimes()
+
+			autocompleteServer := &controller.AutocompleteServer{
+				Suggester: s,
+			}
+
+			resp, err := autocompleteServer.Autocomplete(ctx, &cloudpb.AutocompleteRequest{
+				Input:      test.input,
+				CursorPos:  int64(len(test.input)),
+				Action:     cloudpb.AAT_EDIT,
+				ClusterUID: "test",
+			})
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedExecutable, resp.IsExecutable)
+			assert.Equal(t, test.expectedReason, resp.ExecutableBlockReason)
+		})
+	}
+}
+
+func TestAutocompleteService_AutocompleteField(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orgID, err := uuid.FromString("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	require.NoError(t, err)
+	ctx := CreateTestContext()
+
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+
+	requests := [][]*autocomplete.SuggestionRequest{
+		{
+			{
+				OrgID:        orgID,
+				ClusterUID:   "test",
+				Input:        "px/svc_info",
+				AllowedKinds: []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SVC},
+				AllowedArgs:  []cloudpb.AutocompleteEntityKind{},
+			},
+		},
+	}
+
+	responses := []*autocomplete.SuggestionResult{
+		{
+			Suggestions: []*autocomplete.Suggestion{
+				{
+					Name:  "px/svc_info",
+					Score: 1,
+					State: cloudpb.AES_RUNNING,
+				},
+				{
+					Name:  "px/svc_info2",
+					Score: 1,
+					State: cloudpb.AES_TERMINATED,
+				},
+			},
+			ExactMatch: true,
+		},
+	}
+
+	s.EXPECT().
+		GetSuggestions(gomock.Any()).
+		DoAndReturn(func(req []*autocomplete.SuggestionRequest) ([]*autocomplete.SuggestionResult, error) {
+			assert.ElementsMatch(t, requests[0], req)
+			return responses, nil
+		})
+
+	autocompleteServer := &controller.AutocompleteServer{
+		Suggester: s,
+	}
+
+	resp, err := autocompleteServer.AutocompleteField(ctx, &cloudpb.AutocompleteFieldRequest{
+		Input:      "px/svc_info",
+		FieldType:  cloudpb.AEK_SVC,
+		ClusterUID: "test",
+	})
 	require.NoError(t, err)
 	assert.NotNil(t, resp)
-	assert.Equal(t, resp, vzresp)
+	assert.Equal(t, 2, len(resp.Suggestions))
+}
+
+func TestAutocompleteService_AutocompleteFieldRunningFirst(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := CreateTestContext()
+
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+	s.EXPECT().
+		GetSuggestions(gomock.Any()).
+		Return([]*autocomplete.SuggestionResult{
+			{
+				Suggestions: []*autocomplete.Suggestion{
+					{Name: "pl/svc_best", Score: 2, State: cloudpb.AES_TERMINATED},
+					{Name: "pl/svc_terminated", Score: 1, State: cloudpb.AES_TERMINATED},
+					{Name: "pl/svc_pending", Score: 1, State: cloudpb.AES_PENDING},
+					{Name: "pl/svc_running", Score: 1, State: cloudpb.AES_RUNNING},
+					{Name: "pl/svc_running2", Score: 1, State: cloudpb.AES_RUNNING},
+					{Name: "pl/svc_worst", Score: 0.5, State: cloudpb.AES_RUNNING},
+				},
+			},
+		}, nil)
+
+	autocompleteServer := &controller.AutocompleteServer{
+		Suggester: s,
+	}
+
+	resp, err := autocompleteServer.AutocompleteField(ctx, &cloudpb.AutocompleteFieldRequest{
+		Input:      "pl/svc",
+		FieldType:  cloudpb.AEK_SVC,
+		ClusterUID: "test",
+	})
+	require.NoError(t, err)
+	names := make([]string, len(resp.Suggestions))
+	for i, sugg := range resp.Suggestions {
+		names[i] = sugg.Name
+	}
+	assert.Equal(t, []string{
+		"pl/svc_best",
+		"pl/svc_running",
+		"pl/svc_running2",
+		"pl/svc_pending",
+		"pl/svc_terminated",
+		"pl/svc_worst",
+	}, names)
+}
+
+func TestAutocompleteService_StreamAutocompleteField(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orgID, err := uuid.FromString("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	require.NoError(t, err)
+	ctx := CreateTestContext()
+
+	batches := [][]*autocomplete.Suggestion{
+		{
+			{Name: "pl/svc1", Kind: cloudpb.AEK_SVC, State: cloudpb.AES_RUNNING},
+			{Name: "pl/svc2", Kind: cloudpb.AEK_SVC, State: cloudpb.AES_RUNNING},
+		},
+		{
+			{Name: "pl/svc3", Kind: cloudpb.AEK_SVC, State: cloudpb.AES_TERMINATED, Fuzzy: true},
+		},
+	}
+
+	s := mock_autocomplete.NewMockStreamingSuggester(ctrl)
+	s.EXPECT().
+		StreamSuggestions(gomock.Any(), &autocomplete.SuggestionRequest{
+			OrgID:        orgID,
+			ClusterUID:   "test",
+			Input:        "pl/svc",
+			AllowedKinds: []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SVC},
+			AllowedArgs:  []cloudpb.AutocompleteEntityKind{},
+		}, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req *autocomplete.SuggestionRequest, send func([]*autocomplete.Suggestion) error) error {
+			for _, b := range batches {
+				if err := send(b); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	var sent []*cloudpb.AutocompleteFieldResponse
+	srv := mock_cloudpb.NewMockAutocompleteService_StreamAutocompleteFieldServer(ctrl)
+	srv.EXPECT().Context().Return(ctx).AnyTimes()
+	srv.EXPECT().Send(gomock.Any()).DoAndReturn(func(resp *cloudpb.AutocompleteFieldResponse) error {
+		sent = append(sent, resp)
+		return nil
+	}).Times(2)
+
+	autocompleteServer := &controller.AutocompleteServer{
+		Suggester: s,
+	}
+
+	err = autocompleteServer.StreamAutocompleteField(&cloudpb.AutocompleteFieldRequest{
+		Input:      "pl/svc",
+		FieldType:  cloudpb.AEK_SVC,
+		ClusterUID: "test",
+	}, srv)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, len(sent))
+	require.Equal(t, 2, len(sent[0].Suggestions))
+	assert.Equal(t, "pl/svc1", sent[0].Suggestions[0].Name)
+	assert.Equal(t, "pl/svc2", sent[0].Suggestions[1].Name)
+	assert.Equal(t, cloudpb.AMT_EXACT, sent[0].Suggestions[1].MatchType)
+	require.Equal(t, 1, len(sent[1].Suggestions))
+	assert.Equal(t, "pl/svc3", sent[1].Suggestions[0].Name)
+	assert.Equal(t, cloudpb.AES_TERMINATED, sent[1].Suggestions[0].State)
+	assert.Equal(t, cloudpb.AMT_FUZZY, sent[1].Suggestions[0].MatchType)
+}
+
+func TestAutocompleteService_StreamAutocompleteFieldCancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(CreateTestContext())
+
+	s := mock_autocomplete.NewMockStreamingSuggester(ctrl)
+	s.EXPECT().
+		StreamSuggestions(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req *autocomplete.SuggestionRequest, send func([]*autocomplete.Suggestion) error) error {
+			if err := send([]*autocomplete.Suggestion{{Name: "pl/svc1"}}); err != nil {
+				return err
+			}
+			// The client goes away after the first batch, so the rest shouldn't be sent.
+			cancel()
+			if err := send([]*autocomplete.Suggestion{{Name: "pl/svc2"}}); err != nil {
+				return err
+			}
+			return send([]*autocomplete.Suggestion{{Name: "pl/svc3"}})
+		})
+
+	srv := mock_cloudpb.NewMockAutocompleteService_StreamAutocompleteFieldServer(ctrl)
+	srv.EXPECT().Context().Return(ctx).AnyTimes()
+	srv.EXPECT().Send(gomock.Any()).Return(nil).Times(1)
+
+	autocompleteServer := &controller.AutocompleteServer{
+		Suggester: s,
+	}
+
+	err := autocompleteServer.StreamAutocompleteField(&cloudpb.AutocompleteFieldRequest{
+		Input:     "pl/svc",
+		FieldType: cloudpb.AEK_SVC,
+	}, srv)
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
+
+func TestAutocompleteService_StreamAutocompleteFieldNonStreamingSuggester(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := CreateTestContext()
+
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+	s.EXPECT().
+		GetSuggestions(gomock.Any()).
+		Return([]*autocomplete.SuggestionResult{
+			{
+				Suggestions: []*autocomplete.Suggestion{
+					{Name: "pl/svc1"},
+					{Name: "pl/svc2"},
+				},
+			},
+		}, nil)
+
+	var sent []*cloudpb.AutocompleteFieldResponse
+	srv := mock_cloudpb.NewMockAutocompleteService_StreamAutocompleteFieldServer(ctrl)
+	srv.EXPECT().Context().Return(ctx).AnyTimes()
+	srv.EXPECT().Send(gomock.Any()).DoAndReturn(func(resp *cloudpb.AutocompleteFieldResponse) error {
+		sent = append(sent, resp)
+		return nil
+	}).Times(1)
+
+	autocompleteServer := &controller.AutocompleteServer{
+		Suggester: s,
+	}
+
+	err := autocompleteServer.StreamAutocompleteField(&cloudpb.AutocompleteFieldRequest{
+		Input:     "pl/svc",
+		FieldType: cloudpb.AEK_SVC,
+	}, srv)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(sent))
+	assert.Equal(t, 2, len(sent[0].Suggestions))
+}
+
+func TestAutocompleteService_StreamAutocompleteFieldElasticSuggester(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := CreateTestContext()
+
+	// A fake elastic server, which finds a single service.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/_msearch" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"responses": [{"hits": {"total": {"value": 1}, "hits": [{
+			"_score": 1.5,
+			"_source": {"kind": "service", "name": "svc1", "ns": "pl", "state": 2},
+			"highlight": {"name": ["<em>svc</em>1"]}
+		}]}}]}`)
+	}))
+	defer ts.Close()
+	es, err := elastic.NewClient(elastic.SetURL(ts.URL), elastic.SetSniff(false), elastic.SetHealthcheck(false))
+	require.NoError(t, err)
+
+	// Use the same suggesters as the API server.
+	esSuggester, err := autocomplete.NewElasticSuggester(es, "scripts", nil)
+	require.NoError(t, err)
+	autocompleteServer := &controller.AutocompleteServer{
+		Suggester: autocomplete.NewCachingSuggester(esSuggester, autocomplete.DefaultSuggestionCacheTTL),
+	}
+
+	var sent []*cloudpb.AutocompleteFieldResponse
+	srv := mock_cloudpb.NewMockAutocompleteService_StreamAutocompleteFieldServer(ctrl)
+	srv.EXPECT().Context().Return(ctx).AnyTimes()
+	srv.EXPECT().Send(gomock.Any()).DoAndReturn(func(resp *cloudpb.AutocompleteFieldResponse) error {
+		sent = append(sent, resp)
+		return nil
+	}).Times(1)
+
+	err = autocompleteServer.StreamAutocompleteField(&cloudpb.AutocompleteFieldRequest{
+		Input:     "pl/svc",
+		FieldType: cloudpb.AEK_SVC,
+	}, srv)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(sent))
+	require.Equal(t, 1, len(sent[0].Suggestions))
+	assert.Equal(t, "pl/svc1", sent[0].Suggestions[0].Name)
+	assert.Equal(t, cloudpb.AEK_SVC, sent[0].Suggestions[0].Kind)
+	assert.Equal(t, cloudpb.AES_RUNNING, sent[0].Suggestions[0].State)
+	assert.Equal(t, []int64{3, 4, 5}, sent[0].Suggestions[0].MatchedIndexes)
+}
+
+func TestAutocompleteService_AutocompleteFieldCountOnly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := CreateTestContext()
+
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+
+	s.EXPECT().
+		GetSuggestions(gomock.Any()).
+		Return([]*autocomplete.SuggestionResult{
+			{
+				Suggestions: []*autocomplete.Suggestion{
+					{
+						Name:  "pl/svc1",
+						Score: 1,
+						Kind:  cloudpb.AEK_SVC,
+					},
+					{
+						Name:  "pl/svc2",
+						Score: 1,
+						Kind:  cloudpb.AEK_SVC,
+					},
+				},
+				TotalCount: 42,
+			},
+		}, nil)
+
+	autocompleteServer := &controller.AutocompleteServer{
+		Suggester: s,
+	}
+
+	resp, err := autocompleteServer.AutocompleteField(ctx, &cloudpb.AutocompleteFieldRequest{
+		Input:      "pl/svc",
+		FieldType:  cloudpb.AEK_SVC,
+		ClusterUID: "test",
+		CountOnly:  true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), resp.TotalCount)
+	assert.Empty(t, resp.Suggestions)
+}
+
+func TestAutocompleteService_AutocompleteFieldFuzzy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orgID, err := uuid.FromString("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	require.NoError(t, err)
+	ctx := CreateTestContext()
+
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+
+	s.EXPECT().
+		GetSuggestions(gomock.Any()).
+		DoAndReturn(func(req []*autocomplete.SuggestionRequest) ([]*autocomplete.SuggestionResult, error) {
+			assert.ElementsMatch(t, []*autocomplete.SuggestionRequest{
+				{
+					OrgID:        orgID,
+					ClusterUID:   "test",
+					Input:        "px/svc_ifno",
+					AllowedKinds: []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SCRIPT},
+					AllowedArgs:  []cloudpb.AutocompleteEntityKind{},
+					Fuzzy:        true,
+				},
+			}, req)
+			return []*autocomplete.SuggestionResult{
+				{
+					Suggestions: []*autocomplete.Suggestion{
+						{
+							Name:  "px/svc_info",
+							Score: 1,
+							Kind:  cloudpb.AEK_SCRIPT,
+							Fuzzy: true,
+						},
+						{
+							Name:  "px/svc_ifno_exact",
+							Score: 1,
+							Kind:  cloudpb.AEK_SCRIPT,
+						},
+					},
+				},
+			}, nil
+		})
+
+	autocompleteServer := &controller.AutocompleteServer{
+		Suggester: s,
+	}
+
+	resp, err := autocompleteServer.AutocompleteField(ctx, &cloudpb.AutocompleteFieldRequest{
+		Input:      "px/svc_ifno",
+		FieldType:  cloudpb.AEK_SCRIPT,
+		ClusterUID: "test",
+		Fuzzy:      true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(resp.Suggestions))
+	assert.Equal(t, "px/svc_info", resp.Suggestions[0].Name)
+	assert.Equal(t, cloudpb.AMT_FUZZY, resp.Suggestions[0].MatchType)
+	assert.Equal(t, "px/svc_ifno_exact", resp.Suggestions[1].Name)
+	assert.Equal(t, cloudpb.AMT_EXACT, resp.Suggestions[1].MatchType)
+}
+
+func TestAutocompleteService_RateLimitPerOrg(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orgACtx := CreateTestContext()
+	sCtx := authcontext.New()
+	sCtx.Claims = svcutils.GenerateJWTForUser("6ba7b810-9dad-11d1-80b4-00c04fd430c9", "7ba7b810-9dad-11d1-80b4-00c04fd430c8", "test@test.com", time.Now(), "pixie")
+	orgBCtx := authcontext.NewContext(context.Background(), sCtx)
+
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+	s.EXPECT().
+		GetSuggestions(gomock.Any()).
+		Return([]*autocomplete.SuggestionResult{
+			{
+				Suggestions: []*autocomplete.Suggestion{
+					{
+						Name:  "pl/svc1",
+						Score: 1,
+						Kind:  cloudpb.AEK_SVC,
+					},
+				},
+			},
+		}, nil).
+		AnyTimes()
+
+	now := time.Unix(0, 0)
+	autocompleteServer := &controller.AutocompleteServer{
+		Suggester:         s,
+		OrgRateLimit:      1,
+		OrgRateLimitBurst: 3,
+		Now:               func() time.Time { return now },
+	}
+
+	autocompleteField := func(ctx context.Context) error {
+		_, err := autocompleteServer.AutocompleteField(ctx, &cloudpb.AutocompleteFieldRequest{
+			Input:      "pl/svc",
+			FieldType:  cloudpb.AEK_SVC,
+			ClusterUID: "test",
+		})
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, autocompleteField(orgACtx))
+	}
+	err := autocompleteField(orgACtx)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	_, err = autocompleteServer.Autocomplete(orgACtx, &cloudpb.AutocompleteRequest{
+		Input:      "px/svc_info",
+		CursorPos:  0,
+		Action:     cloudpb.AAT_EDIT,
+		ClusterUID: "test",
+	})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// Another org has its own limit, and isn't affected by the first org.
+	for i := 0; i < 3; i++ {
+		require.NoError(t, autocompleteField(orgBCtx))
+	}
+
+	// The first org's bucket refills over time.
+	now = now.Add(time.Second)
+	require.NoError(t, autocompleteField(orgACtx))
+	err = autocompleteField(orgACtx)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+// metricValue returns the value of the counter, or the sample count of the histogram, with the given name and labels.
+func metricValue(t *testing.T, name string, labels map[string]string) float64 {
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, mf := range mfs {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			matched := 0
+			for _, l := range m.GetLabel() {
+				if v, ok := labels[l.GetName()]; ok && v == l.GetValue() {
+					matched++
+				}
+			}
+			if matched != len(labels) {
+				continue
+			}
+			if m.GetHistogram() != nil {
+				return float64(m.GetHistogram().GetSampleCount())
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestAutocompleteService_Metrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := CreateTestContext()
+
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+	s.EXPECT().
+		GetSuggestions(gomock.Any()).
+		Return([]*autocomplete.SuggestionResult{
+			{
+				Suggestions: []*autocomplete.Suggestion{
+					{
+						Name:  "pl/svc1",
+						Score: 1,
+						Kind:  cloudpb.AEK_SVC,
+					},
+				},
+			},
+		}, nil).
+		Times(2)
+
+	autocompleteServer := &controller.AutocompleteServer{
+		Suggester: s,
+	}
+
+	fieldOKLabels := map[string]string{"endpoint": "AutocompleteField", "code": "OK"}
+	fieldLatencyLabels := map[string]string{"endpoint": "AutocompleteField"}
+	invalidLabels := map[string]string{"endpoint": "Autocomplete", "code": "InvalidArgument"}
+	fieldOKBefore := metricValue(t, "autocomplete_requests_total", fieldOKLabels)
+	fieldLatencyBefore := metricValue(t, "autocomplete_get_suggestions_duration_seconds", fieldLatencyLabels)
+	invalidBefore := metricValue(t, "autocomplete_requests_total", invalidLabels)
+
+	for i := 0; i < 2; i++ {
+		_, err := autocompleteServer.AutocompleteField(ctx, &cloudpb.AutocompleteFieldRequest{
+			Input:      "pl/svc",
+			FieldType:  cloudpb.AEK_SVC,
+			ClusterUID: "test",
+		})
+		require.NoError(t, err)
+	}
+	_, err := autocompleteServer.Autocomplete(ctx, &cloudpb.AutocompleteRequest{
+		Input:      "px/svc_info",
+		CursorPos:  100,
+		Action:     cloudpb.AAT_EDIT,
+		ClusterUID: "test",
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, fieldOKBefore+2, metricValue(t, "autocomplete_requests_total", fieldOKLabels))
+	assert.Equal(t, fieldLatencyBefore+2, metricValue(t, "autocomplete_get_suggestions_duration_seconds", fieldLatencyLabels))
+	assert.Equal(t, invalidBefore+1, metricValue(t, "autocomplete_requests_total", invalidLabels))
+}
+
+func toAny(t *testing.T, msg proto.Message) *types.Any {
+	any, err := types.MarshalAny(msg)
+	require.NoError(t, err)
+	return any
+}
+
+func TestScriptMgr(t *testing.T) {
+	var testVis = &vispb.Vis{
+		Widgets: []*vispb.Widget{
+			{
+				FuncOrRef: &vispb.Widget_Func_{
+					Func: &vispb.Widget_Func{
+						Name: "my_func",
+					},
+				},
+				DisplaySpec: toAny(t, &vispb.VegaChart{
+					Spec: "{}",
+				}),
+			},
+		},
+	}
+
+	ID1 := uuid.Must(uuid.NewV4())
+	ID2 := uuid.Must(uuid.NewV4())
+	testCases := []struct {
+		name         string
+		endpoint     string
+		smReq        proto.Message
+		smResp       proto.Message
+		req          proto.Message
+		expectedResp proto.Message
+	}{
+		{
+			name:     "GetLiveViews correctly translates from scriptmgrpb to cloudpb.",
+			endpoint: "GetLiveViews",
+			smReq:    &scriptmgrpb.GetLiveViewsReq{},
+			smResp: &scriptmgrpb.GetLiveViewsResp{
+				LiveViews: []*scriptmgrpb.LiveViewMetadata{
+					{
+						ID:   utils.ProtoFromUUID(ID1),
+						Name: "liveview1",
+						Desc: "liveview1 desc",
+					},
+					{
+						ID:   utils.ProtoFromUUID(ID2),
+						Name: "liveview2",
+						Desc: "liveview2 desc",
+					},
+				},
+			},
+			req: &cloudpb.GetLiveViewsReq{},
+			expectedResp: &cloudpb.GetLiveViewsResp{
+				LiveViews: []*cloudpb.LiveViewMetadata{
+					{
+						ID:   ID1.String(),
+						Name: "liveview1",
+						Desc: "liveview1 desc",
+					},
+					{
+						ID:   ID2.String(),
+						Name: "liveview2",
+						Desc: "liveview2 desc",
+					},
+				},
+			},
+		},
+		{
+			name:     "GetLiveViews forwards the referenced script filter.",
+			endpoint: "GetLiveViews",
+			smReq: &scriptmgrpb.GetLiveViewsReq{
+				ReferencedScript: "px.service_stats",
+			},
+			smResp: &scriptmgrpb.GetLiveViewsResp{
+				LiveViews: []*scriptmgrpb.LiveViewMetadata{
+					{
+						ID:   utils.ProtoFromUUID(ID2),
+						Name: "liveview2",
+						Desc: "liveview2 desc",
+					},
+				},
+			},
+			req: &cloudpb.GetLiveViewsReq{
+				ReferencedScript: "px.service_stats",
+			},
+			expectedResp: &cloudpb.GetLiveViewsResp{
+				LiveViews: []*cloudpb.LiveViewMetadata{
+					{
+						ID:   ID2.String(),
+						Name: "liveview2",
+						Desc: "liveview2 desc",
+					},
+				},
+			},
+		},
+		{
+			name:     "GetLiveViewContents correctly translates between scriptmgr and cloudpb.",
+			endpoint: "GetLiveViewContents",
+			smReq: &scriptmgrpb.GetLiveViewContentsReq{
+				LiveViewID: utils.ProtoFromUUID(ID1),
+			},
+			smResp: &scriptmgrpb.GetLiveViewContentsResp{
+				Metadata: &scriptmgrpb.LiveViewMetadata{
+					ID:   utils.ProtoFromUUID(ID1),
+					Name: "liveview1",
+					Desc: "liveview1 desc",
+				},
+				PxlContents: "liveview1 pxl",
+				Vis:         testVis,
+			},
+			req: &cloudpb.GetLiveViewContentsReq{
+				LiveViewID: ID1.String(),
+			},
+			expectedResp: &cloudpb.GetLiveViewContentsResp{
+				Metadata: &cloudpb.LiveViewMetadata{
+					ID:   ID1.String(),
+					Name: "liveview1",
+					Desc: "liveview1 desc",
+				},
+				PxlContents: "liveview1 pxl",
+				Vis:         testVis,
+			},
+		},
+		{
+			name:     "GetScripts correctly translates between scriptmgr and cloudpb.",
+			endpoint: "GetScripts",
+			smReq:    &scriptmgrpb.GetScriptsReq{},
+			smResp: &scriptmgrpb.GetScriptsResp{
+				Scripts: []*scriptmgrpb.ScriptMetadata{
+					{
+						ID:          utils.ProtoFromUUID(ID1),
+						Name:        "script1",
+						Desc:        "script1 desc",
+						HasLiveView: false,
+					},
+					{
+						ID:          utils.ProtoFromUUID(ID2),
+						Name:        "liveview1",
+						Desc:        "liveview1 desc",
+						HasLiveView: true,
+					},
+				},
+			},
+			req: &cloudpb.GetScriptsReq{},
+			expectedResp: &cloudpb.GetScriptsResp{
+				Scripts: []*cloudpb.ScriptMetadata{
+					{
+						ID:          ID1.String(),
+						Name:        "script1",
+						Desc:        "script1 desc",
+						HasLiveView: false,
+					},
+					{
+						ID:          ID2.String(),
+						Name:        "liveview1",
+						Desc:        "liveview1 desc",
+						HasLiveView: true,
+					},
+				},
+			},
+		},
+		{
+			name:     "GetScripts forwards the tag filter and translates tags.",
+			endpoint: "GetScripts",
+			smReq: &scriptmgrpb.GetScriptsReq{
+				TagFilter: []string{"network", "http"},
+			},
+			smResp: &scriptmgrpb.GetScriptsResp{
+				Scripts: []*scriptmgrpb.ScriptMetadata{
+					{
+						ID:   utils.ProtoFromUUID(ID1),
+						Name: "script1",
+						Desc: "script1 desc",
+						Tags: []string{"network", "http"},
+					},
+				},
+			},
+			req: &cloudpb.GetScriptsReq{
+				TagFilter: []string{"network", "http"},
+			},
+			expectedResp: &cloudpb.GetScriptsResp{
+				Scripts: []*cloudpb.ScriptMetadata{
+					{
+						ID:   ID1.String(),
+						Name: "script1",
+						Desc: "script1 desc",
+						Tags: []string{"network", "http"},
+					},
+				},
+			},
+		},
+		{
+			name:     "SearchScripts correctly translates between scriptmgr and cloudpb.",
+			endpoint: "SearchScripts",
+			smReq: &scriptmgrpb.SearchScriptsReq{
+				Query:       "script",
+				HasLiveView: &types.BoolValue{Value: false},
+			},
+			smResp: &scriptmgrpb.SearchScriptsResp{
+				Scripts: []*scriptmgrpb.ScriptMetadata{
+					{
+						ID:          utils.ProtoFromUUID(ID1),
+						Name:        "script1",
+						Desc:        "script1 desc",
+						HasLiveView: false,
+					},
+				},
+			},
+			req: &cloudpb.SearchScriptsReq{
+				Query:       "script",
+				HasLiveView: &types.BoolValue{Value: false},
+			},
+			expectedResp: &cloudpb.SearchScriptsResp{
+				Scripts: []*cloudpb.ScriptMetadata{
+					{
+						ID:          ID1.String(),
+						Name:        "script1",
+						Desc:        "script1 desc",
+						HasLiveView: false,
+					},
+				},
+			},
+		},
+		{
+			name:     "GetScriptContents correctly translates between scriptmgr and cloudpb.",
+			endpoint: "GetScriptContents",
+			smReq: &scriptmgrpb.GetScriptContentsReq{
+				ScriptID: utils.ProtoFromUUID(ID1),
+			},
+			smResp: &scriptmgrpb.GetScriptContentsResp{
+				Metadata: &scriptmgrpb.ScriptMetadata{
+					ID:          utils.ProtoFromUUID(ID1),
+					Name:        "Script1",
+					Desc:        "Script1 desc",
+					HasLiveView: false,
+				},
+				Contents: "Script1 pxl",
+			},
+			req: &cloudpb.GetScriptContentsReq{
+				ScriptID: ID1.String(),
+			},
+			expectedResp: &cloudpb.GetScriptContentsResp{
+				Metadata: &cloudpb.ScriptMetadata{
+					ID:          ID1.String(),
+					Name:        "Script1",
+					Desc:        "Script1 desc",
+					HasLiveView: false,
+				},
+				Contents: "Script1 pxl",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockScriptMgr := mock_scriptmgr.NewMockScriptMgrServiceClient(ctrl)
+			ctx := CreateTestContext()
+
+			reflect.ValueOf(mockScriptMgr.EXPECT()).
+				MethodByName(tc.endpoint).
+				Call([]reflect.Value{
+					reflect.ValueOf(gomock.Any()),
+					reflect.ValueOf(tc.smReq),
+				})[0].Interface().(*gomock.Call).
+				Return(tc.smResp, nil)
+
+			scriptMgrServer := &controller.ScriptMgrServer{
+				ScriptMgr: mockScriptMgr,
+			}
+
+			returnVals := reflect.ValueOf(scriptMgrServer).
+				MethodByName(tc.endpoint).
+				Call([]reflect.Value{
+					reflect.ValueOf(ctx),
+					reflect.ValueOf(tc.req),
+				})
+			assert.Nil(t, returnVals[1].Interface())
+			resp := returnVals[0].Interface().(proto.Message)
+
+			assert.Equal(t, tc.expectedResp, resp)
+		})
+	}
+}
+
+func TestScriptMgr_GetLiveViewContentsVisWarnings(t *testing.T) {
+	vis := &vispb.Vis{
+		Widgets: []*vispb.Widget{
+			{
+				Name: "valid",
+				FuncOrRef: &vispb.Widget_Func_{
+					Func: &vispb.Widget_Func{
+						Name: "my_func",
+					},
+				},
+				DisplaySpec: toAny(t, &vispb.Table{}),
+			},
+			{
+				Name:        "no_func",
+				DisplaySpec: toAny(t, &vispb.Table{}),
+			},
+			{
+				FuncOrRef: &vispb.Widget_GlobalFuncOutputName{
+					GlobalFuncOutputName: "my_output",
+				},
+				DisplaySpec: &types.Any{TypeUrl: "types.px.dev/px.vispb.NotAChart"},
+			},
+		},
+	}
+	ID1 := uuid.Must(uuid.NewV4())
+
+	testCases := []struct {
+		name             string
+		validateVis      bool
+		expectedWarnings []string
+	}{
+		{
+			name:             "warnings are only returned when requested",
+			validateVis:      false,
+			expectedWarnings: nil,
+		},
+		{
+			name:        "broken widgets are reported",
+			validateVis: true,
+			expectedWarnings: []string{
+				"widget no_func has no func or global func output name",
+				"widget #2 has an invalid display spec",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockScriptMgr := mock_scriptmgr.NewMockScriptMgrServiceClient(ctrl)
+			ctx := CreateTestContext()
+
+			mockScriptMgr.EXPECT().
+				GetLiveViewContents(gomock.Any(), &scriptmgrpb.GetLiveViewContentsReq{
+					LiveViewID: utils.ProtoFromUUID(ID1),
+				}).
+				Return(&scriptmgrpb.GetLiveViewContentsResp{
+					Metadata: &scriptmgrpb.LiveViewMetadata{
+						ID:   utils.ProtoFromUUID(ID1),
+						Name: "liveview1",
+					},
+					PxlContents: "liveview1 pxl",
+					Vis:         vis,
+				}, nil)
+
+			scriptMgrServer := &controller.ScriptMgrServer{
+				ScriptMgr: mockScriptMgr,
+			}
+			resp, err := scriptMgrServer.GetLiveViewContents(ctx, &cloudpb.GetLiveViewContentsReq{
+				LiveViewID:  ID1.String(),
+				ValidateVis: tc.validateVis,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, vis, resp.Vis)
+			require.Len(t, resp.VisWarnings, len(tc.expectedWarnings))
+			for i, warning := range tc.expectedWarnings {
+				assert.Contains(t, resp.VisWarnings[i], warning)
+			}
+		})
+	}
+}
+
+func TestProfileServer_GetOrgInfo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	orgID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	mockClients.MockProfile.EXPECT().GetOrg(gomock.Any(), orgID).
+		Return(&profilepb.OrgInfo{
+			OrgName: "someOrg",
+			ID:      orgID,
+		}, nil)
+
+	profileServer := &controller.ProfileServer{mockClients.MockProfile}
+
+	resp, err := profileServer.GetOrgInfo(ctx, orgID)
+
+	require.NoError(t, err)
+	assert.Equal(t, "someOrg", resp.OrgName)
+	assert.Equal(t, orgID, resp.ID)
+}
+
+func TestProfileServer_UpdateOrgInfo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	orgID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	mockClients.MockProfile.EXPECT().UpdateOrg(gomock.Any(), &profilepb.UpdateOrgRequest{
+		ID:      orgID,
+		OrgName: &types.StringValue{Value: "newOrg"},
+	}).Return(&profilepb.OrgInfo{
+		OrgName: "newOrg",
+		ID:      orgID,
+	}, nil)
+
+	profileServer := &controller.ProfileServer{mockClients.MockProfile}
+
+	resp, err := profileServer.UpdateOrgInfo(ctx, &cloudpb.UpdateOrgInfoRequest{
+		ID:      orgID,
+		OrgName: "newOrg",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "newOrg", resp.OrgName)
+	assert.Equal(t, orgID, resp.ID)
+}
+
+func TestProfileServer_UpdateOrgInfo_OtherOrg(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	profileServer := &controller.ProfileServer{mockClients.MockProfile}
+
+	resp, err := profileServer.UpdateOrgInfo(ctx, &cloudpb.UpdateOrgInfoRequest{
+		ID:      utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c7"),
+		OrgName: "newOrg",
+	})
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestProfileServer_UpdateOrgInfo_EmptyName(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	profileServer := &controller.ProfileServer{mockClients.MockProfile}
+
+	resp, err := profileServer.UpdateOrgInfo(ctx, &cloudpb.UpdateOrgInfoRequest{
+		ID:      utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+		OrgName: "",
+	})
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestOrganizationServiceServer_InviteUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+	mockReq := &profilepb.InviteUserRequest{
+		OrgID:          utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+		MustCreateUser: true,
+		Email:          "bobloblaw@lawblog.law",
+		FirstName:      "bob",
+		LastName:       "loblaw",
+	}
+
+	mockClients.MockProfile.EXPECT().InviteUser(gomock.Any(), mockReq).
+		Return(&profilepb.InviteUserResponse{
+			Email:       "bobloblaw@lawblog.law",
+			InviteLink:  "withpixie.ai/invite&id=abcd",
+			UserCreated: true,
+		}, nil)
+
+	os := &controller.OrganizationServiceServer{ProfileServiceClient: mockClients.MockProfile}
+
+	resp, err := os.InviteUser(ctx, &cloudpb.InviteUserRequest{
+		Email:     "bobloblaw@lawblog.law",
+		FirstName: "bob",
+		LastName:  "loblaw",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, mockReq.Email, resp.Email)
+	assert.Equal(t, "withpixie.ai/invite&id=abcd", resp.InviteLink)
+	assert.True(t, resp.UserCreated)
 }
 
-func TestAutocompleteService_Autocomplete(t *testing.T) {
+func TestOrganizationServiceServer_InviteUserInvalidEmail(t *testing.T) {
+	tests := []struct {
+		name  string
+		email string
+	}{
+		{
+			name:  "empty",
+			email: "",
+		},
+		{
+			name:  "missing domain",
+			email: "bobloblaw@",
+		},
+		{
+			name:  "missing @",
+			email: "bobloblaw.lawblog.law",
+		},
+		{
+			name:  "name and address",
+			email: "Bob Loblaw <bobloblaw@lawblog.law>",
+		},
+		{
+			name:  "multiple addresses",
+			email: "bobloblaw@lawblog.law, lindsay@bluth.com",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+			defer cleanup()
+			ctx := CreateTestContext()
+
+			// The profile service should not be called for an invalid email.
+			os := &controller.OrganizationServiceServer{ProfileServiceClient: mockClients.MockProfile}
+			resp, err := os.InviteUser(ctx, &cloudpb.InviteUserRequest{
+				Email:     tc.email,
+				FirstName: "bob",
+				LastName:  "loblaw",
+			})
+			assert.Nil(t, resp)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+			assert.Contains(t, status.Convert(err).Message(), "invalid email address")
+		})
+	}
+}
+
+func TestOrganizationServiceServer_InviteUserNoName(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	orgID, err := uuid.FromString("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
-	require.NoError(t, err)
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
 	ctx := CreateTestContext()
 
-	s := mock_autocomplete.NewMockSuggester(ctrl)
+	mockClients.MockProfile.EXPECT().InviteUser(gomock.Any(), &profilepb.InviteUserRequest{
+		OrgID:          utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+		MustCreateUser: true,
+		Email:          "bob.loblaw+invite@law-blog.co.uk",
+	}).Return(&profilepb.InviteUserResponse{
+		Email:       "bob.loblaw+invite@law-blog.co.uk",
+		InviteLink:  "withpixie.ai/invite&id=abcd",
+		UserCreated: true,
+	}, nil)
 
-	requests := [][]*autocomplete.SuggestionRequest{
+	os := &controller.OrganizationServiceServer{ProfileServiceClient: mockClients.MockProfile}
+	resp, err := os.InviteUser(ctx, &cloudpb.InviteUserRequest{
+		Email: "bob.loblaw+invite@law-blog.co.uk",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "withpixie.ai/invite&id=abcd", resp.InviteLink)
+}
+
+func TestOrganizationServiceServer_InviteUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+	orgID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	gomock.InOrder(
+		mockClients.MockProfile.EXPECT().InviteUser(gomock.Any(), &profilepb.InviteUserRequest{
+			OrgID:          orgID,
+			MustCreateUser: true,
+			Email:          "bobloblaw@lawblog.law",
+			FirstName:      "bob",
+			LastName:       "loblaw",
+		}).Return(&profilepb.InviteUserResponse{
+			Email:      "bobloblaw@lawblog.law",
+			InviteLink: "withpixie.ai/invite&id=abcd",
+		}, nil),
+		// The user was already invited above, so inviting them again fails.
+		mockClients.MockProfile.EXPECT().InviteUser(gomock.Any(), &profilepb.InviteUserRequest{
+			OrgID:          orgID,
+			MustCreateUser: true,
+			Email:          "bobloblaw@lawblog.law",
+			FirstName:      "bob",
+			LastName:       "loblaw",
+		}).Return(nil, status.Error(codes.Unknown, "cannot invite a user that already exists")),
+		mockClients.MockProfile.EXPECT().InviteUser(gomock.Any(), &profilepb.InviteUserRequest{
+			OrgID:          orgID,
+			MustCreateUser: true,
+			Email:          "lindsay@bluth.com",
+			FirstName:      "lindsay",
+			LastName:       "bluth",
+		}).Return(&profilepb.InviteUserResponse{
+			Email:      "lindsay@bluth.com",
+			InviteLink: "withpixie.ai/invite&id=efgh",
+		}, nil),
+	)
+
+	os := &controller.OrganizationServiceServer{ProfileServiceClient: mockClients.MockProfile}
+
+	resp, err := os.InviteUsers(ctx, &cloudpb.InviteUsersRequest{
+		Users: []*cloudpb.InviteUserRequest{
+			{Email: "bobloblaw@lawblog.law", FirstName: "bob", LastName: "loblaw"},
+			{Email: "bobloblaw@lawblog.law", FirstName: "bob", LastName: "loblaw"},
+			{Email: "lindsay@bluth.com", FirstName: "lindsay", LastName: "bluth"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*cloudpb.InviteUserResult{
+		{
+			Email:      "bobloblaw@lawblog.law",
+			Success:    true,
+			InviteLink: "withpixie.ai/invite&id=abcd",
+		},
 		{
+			Email: "bobloblaw@lawblog.law",
+			Error: "cannot invite a user that already exists",
+		},
+		{
+			Email:      "lindsay@bluth.com",
+			Success:    true,
+			InviteLink: "withpixie.ai/invite&id=efgh",
+		},
+	}, resp.Results)
+}
+
+func TestOrganizationServiceServer_InviteUsersTooMany(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	users := make([]*cloudpb.InviteUserRequest, 101)
+	for i := range users {
+		users[i] = &cloudpb.InviteUserRequest{Email: fmt.Sprintf("user%d@lawblog.law", i)}
+	}
+
+	os := &controller.OrganizationServiceServer{ProfileServiceClient: mockClients.MockProfile}
+	resp, err := os.InviteUsers(ctx, &cloudpb.InviteUsersRequest{Users: users})
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestOrganizationServiceServer_InviteUserWithTTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	ttl := types.DurationProto(24 * time.Hour)
+	expiresAt := types.TimestampNow()
+	mockClients.MockProfile.EXPECT().InviteUser(gomock.Any(), &profilepb.InviteUserRequest{
+		OrgID:          utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+		MustCreateUser: true,
+		Email:          "bobloblaw@lawblog.law",
+		TTL:            ttl,
+	}).Return(&profilepb.InviteUserResponse{
+		Email:      "bobloblaw@lawblog.law",
+		InviteLink: "withpixie.ai/invite&id=abcd",
+		ExpiresAt:  expiresAt,
+	}, nil)
+
+	os := &controller.OrganizationServiceServer{ProfileServiceClient: mockClients.MockProfile}
+
+	resp, err := os.InviteUser(ctx, &cloudpb.InviteUserRequest{
+		Email: "bobloblaw@lawblog.law",
+		TTL:   ttl,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "withpixie.ai/invite&id=abcd", resp.InviteLink)
+	require.NotNil(t, resp.ExpiresAt)
+	assert.Equal(t, expiresAt, resp.ExpiresAt)
+}
+
+func TestOrganizationServiceServer_ListOrgInvites(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	createdAt1 := &types.Timestamp{Seconds: 1600000000}
+	createdAt2 := &types.Timestamp{Seconds: 1600000100}
+	expiresAt := &types.Timestamp{Seconds: 1600086500}
+	mockClients.MockProfile.EXPECT().ListOrgInvites(gomock.Any(), &profilepb.ListOrgInvitesRequest{
+		OrgID: utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+	}).Return(&profilepb.ListOrgInvitesResponse{
+		Invites: []*profilepb.OrgInvite{
 			{
-				OrgID:        orgID,
-				ClusterUID:   "test",
-				Input:        "px/svc_info",
-				AllowedKinds: []cloudpb.AutocompleteEntityKind{cloudpb.AEK_POD, cloudpb.AEK_SVC, cloudpb.AEK_NAMESPACE, cloudpb.AEK_SCRIPT},
-				AllowedArgs:  []cloudpb.AutocompleteEntityKind{},
+				Email:        "bobloblaw@lawblog.law",
+				InviterEmail: "test@test.com",
+				CreatedAt:    createdAt2,
+				ExpiresAt:    expiresAt,
 			},
 			{
-				OrgID:        orgID,
-				ClusterUID:   "test",
-				Input:        "pl/test",
-				AllowedKinds: []cloudpb.AutocompleteEntityKind{cloudpb.AEK_POD, cloudpb.AEK_SVC, cloudpb.AEK_NAMESPACE, cloudpb.AEK_SCRIPT},
-				AllowedArgs:  []cloudpb.AutocompleteEntityKind{},
+				Email:     "lindsay@bluth.com",
+				CreatedAt: createdAt1,
 			},
 		},
-	}
+	}, nil)
 
-	responses := [][]*autocomplete.SuggestionResult{
+	os := &controller.OrganizationServiceServer{ProfileServiceClient: mockClients.MockProfile}
+
+	resp, err := os.ListOrgInvites(ctx, &cloudpb.ListOrgInvitesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []*cloudpb.OrgInvite{
+		{
+			Email:        "bobloblaw@lawblog.law",
+			InviterEmail: "test@test.com",
+			CreatedAt:    createdAt2,
+			ExpiresAt:    expiresAt,
+		},
 		{
+			Email:     "lindsay@bluth.com",
+			CreatedAt: createdAt1,
+		},
+	}, resp.Invites)
+}
+
+func TestOrganizationServiceServer_ListOrgUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	orgID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	user1 := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c9")
+	user2 := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c9")
+	user3 := utils.ProtoFromUUIDStrOrNil("8ba7b810-9dad-11d1-80b4-00c04fd430c9")
+	mockClients.MockProfile.EXPECT().GetUsersInOrg(gomock.Any(), &profilepb.GetUsersInOrgRequest{
+		OrgID: orgID,
+	}).Return(&profilepb.GetUsersInOrgResponse{
+		Users: []*profilepb.UserInfo{
 			{
-				Suggestions: []*autocomplete.Suggestion{
-					{
-						Name:     "px/svc_info",
-						Score:    1,
-						ArgNames: []string{"svc_name"},
-						ArgKinds: []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SVC},
-					},
-				},
-				ExactMatch: true,
+				ID:         user1,
+				OrgID:      orgID,
+				FirstName:  "Test",
+				LastName:   "User",
+				Email:      "test@test.com",
+				IsApproved: true,
+				IsOrgAdmin: true,
 			},
 			{
-				Suggestions: []*autocomplete.Suggestion{
-					{
-						Name:  "px/test",
-						Score: 1,
-					},
-				},
-				ExactMatch: true,
+				ID:         user2,
+				OrgID:      orgID,
+				FirstName:  "Lindsay",
+				Email:      "lindsay@bluth.com",
+				IsApproved: false,
+			},
+			{
+				ID:         user3,
+				OrgID:      utils.ProtoFromUUIDStrOrNil("223e4567-e89b-12d3-a456-426655440000"),
+				FirstName:  "Bob",
+				LastName:   "Loblaw",
+				Email:      "bobloblaw@lawblog.law",
+				IsApproved: true,
 			},
 		},
-	}
+	}, nil)
 
-	suggestionCalls := 0
-	s.EXPECT().
-		GetSuggestions(gomock.Any()).
-		DoAndReturn(func(req []*autocomplete.SuggestionRequest) ([]*autocomplete.SuggestionResult, error) {
-			assert.ElementsMatch(t, requests[suggestionCalls], req)
-			resp := responses[suggestionCalls]
-			suggestionCalls++
-			return resp, nil
-		}).
-		Times(len(requests))
+	os := &controller.OrganizationServiceServer{ProfileServiceClient: mockClients.MockProfile}
 
-	autocompleteServer := &controller.AutocompleteServer{
-		Suggester: s,
-	}
+	resp, err := os.ListOrgUsers(ctx, &cloudpb.ListOrgUsersRequest{})
+	require.NoError(t, err)
+	// Users outside of the requesting user's org should never be returned.
+	assert.Equal(t, []*cloudpb.OrgUser{
+		{
+			ID:    user1,
+			Name:  "Test User",
+			Email: "test@test.com",
+			Role:  cloudpb.OUR_ADMIN,
+		},
+		{
+			ID:    user2,
+			Name:  "Lindsay",
+			Email: "lindsay@bluth.com",
+			Role:  cloudpb.OUR_PENDING_APPROVAL,
+		},
+	}, resp.Users)
+}
 
-	resp, err := autocompleteServer.Autocomplete(ctx, &cloudpb.AutocompleteRequest{
-		Input:      "px/svc_info pl/test",
-		CursorPos:  0,
-		Action:     cloudpb.AAT_EDIT,
-		ClusterUID: "test",
+func TestOrganizationServiceServer_RemoveUserFromOrg(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	userID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c9")
+	mockClients.MockProfile.EXPECT().RemoveUserFromOrg(gomock.Any(), &profilepb.RemoveUserFromOrgRequest{
+		UserID: userID,
+	}).Return(&profilepb.RemoveUserFromOrgResponse{Success: true}, nil)
+
+	os := &controller.OrganizationServiceServer{ProfileServiceClient: mockClients.MockProfile}
+
+	resp, err := os.RemoveUserFromOrg(ctx, &cloudpb.RemoveUserFromOrgRequest{
+		UserID: userID,
 	})
 	require.NoError(t, err)
-	assert.NotNil(t, resp)
-	assert.Equal(t, "${2:$0px/svc_info} ${1:pl/test}", resp.FormattedInput)
-	assert.False(t, resp.IsExecutable)
-	assert.Equal(t, 2, len(resp.TabSuggestions))
+	assert.True(t, resp.Success)
 }
 
-func TestAutocompleteService_AutocompleteField(t *testing.T) {
+func TestOrganizationServiceServer_RemoveUserFromOrg_LastAdmin(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	orgID, err := uuid.FromString("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
-	require.NoError(t, err)
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
 	ctx := CreateTestContext()
 
-	s := mock_autocomplete.NewMockSuggester(ctrl)
+	userID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c9")
+	mockClients.MockProfile.EXPECT().RemoveUserFromOrg(gomock.Any(), &profilepb.RemoveUserFromOrgRequest{
+		UserID: userID,
+	}).Return(nil, status.Error(codes.FailedPrecondition, "cannot remove the last admin of the org"))
 
-	requests := [][]*autocomplete.SuggestionRequest{
+	os := &controller.OrganizationServiceServer{ProfileServiceClient: mockClients.MockProfile}
+
+	resp, err := os.RemoveUserFromOrg(ctx, &cloudpb.RemoveUserFromOrgRequest{
+		UserID: userID,
+	})
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestOrganizationServiceServer_UpdateUserRole(t *testing.T) {
+	userID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c9")
+
+	tests := []struct {
+		name string
+		role cloudpb.OrgUserRole
+		// Whether the request should be forwarded to the profile service, and the error it returns.
+		callProfile   bool
+		profileErr    error
+		expectErrCode codes.Code
+	}{
 		{
-			{
-				OrgID:        orgID,
-				ClusterUID:   "test",
-				Input:        "px/svc_info",
-				AllowedKinds: []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SVC},
-				AllowedArgs:  []cloudpb.AutocompleteEntityKind{},
-			},
+			name:          "promote member",
+			role:          cloudpb.OUR_ADMIN,
+			callProfile:   true,
+			expectErrCode: codes.OK,
 		},
-	}
-
-	responses := []*autocomplete.SuggestionResult{
 		{
-			Suggestions: []*autocomplete.Suggestion{
-				{
-					Name:  "px/svc_info",
-					Score: 1,
-					State: cloudpb.AES_RUNNING,
-				},
-				{
-					Name:  "px/svc_info2",
-					Score: 1,
-					State: cloudpb.AES_TERMINATED,
-				},
-			},
-			ExactMatch: true,
+			name:          "requestor is not an admin",
+			role:          cloudpb.OUR_ADMIN,
+			callProfile:   true,
+			profileErr:    status.Error(codes.PermissionDenied, "requestor is not an org admin"),
+			expectErrCode: codes.PermissionDenied,
+		},
+		{
+			name:          "demote last admin",
+			role:          cloudpb.OUR_MEMBER,
+			callProfile:   true,
+			profileErr:    status.Error(codes.FailedPrecondition, "org must have at least one admin"),
+			expectErrCode: codes.FailedPrecondition,
+		},
+		{
+			name:          "invalid role",
+			role:          cloudpb.OUR_PENDING_APPROVAL,
+			callProfile:   false,
+			expectErrCode: codes.InvalidArgument,
 		},
 	}
 
-	s.EXPECT().
-		GetSuggestions(gomock.Any()).
-		DoAndReturn(func(req []*autocomplete.SuggestionRequest) ([]*autocomplete.SuggestionResult, error) {
-			assert.ElementsMatch(t, requests[0], req)
-			return responses, nil
-		})
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+			defer cleanup()
+			ctx := CreateTestContext()
+
+			if tc.callProfile {
+				var profileResp *profilepb.UserInfo
+				if tc.profileErr == nil {
+					profileResp = &profilepb.UserInfo{
+						ID:         userID,
+						OrgID:      utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+						FirstName:  "Lindsay",
+						LastName:   "Bluth",
+						Email:      "lindsay@bluth.com",
+						IsApproved: true,
+						IsOrgAdmin: tc.role == cloudpb.OUR_ADMIN,
+					}
+				}
+				mockClients.MockProfile.EXPECT().UpdateUserRole(gomock.Any(), &profilepb.UpdateUserRoleRequest{
+					UserID:     userID,
+					IsOrgAdmin: tc.role == cloudpb.OUR_ADMIN,
+				}).Return(profileResp, tc.profileErr)
+			}
+
+			os := &controller.OrganizationServiceServer{ProfileServiceClient: mockClients.MockProfile}
+
+			resp, err := os.UpdateUserRole(ctx, &cloudpb.UpdateUserRoleRequest{
+				UserID: userID,
+				Role:   tc.role,
+			})
+			if tc.expectErrCode != codes.OK {
+				assert.Nil(t, resp)
+				assert.Equal(t, tc.expectErrCode, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &cloudpb.OrgUser{
+				ID:    userID,
+				Name:  "Lindsay Bluth",
+				Email: "lindsay@bluth.com",
+				Role:  cloudpb.OUR_ADMIN,
+			}, resp)
+		})
+	}
+}
+
+func TestOrganizationServiceServer_ResendInvite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	mockClients.MockProfile.EXPECT().ResendInvite(gomock.Any(), &profilepb.ResendInviteRequest{
+		OrgID: utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+		Email: "bobloblaw@lawblog.law",
+	}).Return(&profilepb.InviteUserResponse{
+		Email:      "bobloblaw@lawblog.law",
+		InviteLink: "withpixie.ai/invite&id=efgh",
+	}, nil)
+
+	os := &controller.OrganizationServiceServer{ProfileServiceClient: mockClients.MockProfile}
+
+	resp, err := os.ResendInvite(ctx, &cloudpb.ResendInviteRequest{
+		Email: "bobloblaw@lawblog.law",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "bobloblaw@lawblog.law", resp.Email)
+	assert.Equal(t, "withpixie.ai/invite&id=efgh", resp.InviteLink)
+	assert.False(t, resp.UserCreated)
+}
+
+func TestAPIHealthServer_HealthCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
 
-	autocompleteServer := &controller.AutocompleteServer{
-		Suggester: s,
+	mockClients.MockVzMgr.EXPECT().
+		GetVizierInfos(gomock.Any(), &vzmgrpb.GetVizierInfosRequest{}).
+		Return(&vzmgrpb.GetVizierInfosResponse{}, nil)
+	// Profile responding with NotFound still means that it's up.
+	mockClients.MockProfile.EXPECT().
+		GetOrgByDomain(gomock.Any(), &profilepb.GetOrgByDomainRequest{}).
+		Return(nil, status.Error(codes.NotFound, "no such org"))
+	mockClients.MockArtifact.EXPECT().
+		GetArtifactList(gomock.Any(), gomock.Any()).
+		Return(nil, status.Error(codes.Unavailable, "connection refused"))
+
+	hs := &controller.APIHealthServer{
+		VzMgr:                 mockClients.MockVzMgr,
+		ProfileServiceClient:  mockClients.MockProfile,
+		ArtifactTrackerClient: mockClients.MockArtifact,
 	}
 
-	resp, err := autocompleteServer.AutocompleteField(ctx, &cloudpb.AutocompleteFieldRequest{
-		Input:      "px/svc_info",
-		FieldType:  cloudpb.AEK_SVC,
-		ClusterUID: "test",
+	resp, err := hs.HealthCheck(ctx, &cloudpb.HealthCheckRequest{
+		Dependencies: []cloudpb.APIDependency{cloudpb.AD_VZMGR, cloudpb.AD_PROFILE, cloudpb.AD_ARTIFACT_TRACKER},
 	})
 	require.NoError(t, err)
-	assert.NotNil(t, resp)
-	assert.Equal(t, 2, len(resp.Suggestions))
+	require.Equal(t, 3, len(resp.Dependencies))
+
+	assert.Equal(t, cloudpb.AD_VZMGR, resp.Dependencies[0].Dependency)
+	assert.True(t, resp.Dependencies[0].Up)
+	assert.Empty(t, resp.Dependencies[0].Error)
+
+	assert.Equal(t, cloudpb.AD_PROFILE, resp.Dependencies[1].Dependency)
+	assert.True(t, resp.Dependencies[1].Up)
+
+	assert.Equal(t, cloudpb.AD_ARTIFACT_TRACKER, resp.Dependencies[2].Dependency)
+	assert.False(t, resp.Dependencies[2].Up)
+	assert.Equal(t, "connection refused", resp.Dependencies[2].Error)
 }
 
-func toAny(t *testing.T, msg proto.Message) *types.Any {
-	any, err := types.MarshalAny(msg)
+func TestAPIHealthServer_HealthCheckTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+	defer cleanup()
+	ctx := CreateTestContext()
+
+	// vzmgr hangs until its probe times out, which shouldn't affect the artifact tracker's probe.
+	mockClients.MockVzMgr.EXPECT().
+		GetVizierInfos(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req *vzmgrpb.GetVizierInfosRequest, opts ...grpc.CallOption) (*vzmgrpb.GetVizierInfosResponse, error) {
+			<-ctx.Done()
+			return nil, status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+		})
+	mockClients.MockArtifact.EXPECT().
+		GetArtifactList(gomock.Any(), gomock.Any()).
+		Return(&versionspb.ArtifactSet{}, nil)
+
+	hs := &controller.APIHealthServer{
+		VzMgr:                 mockClients.MockVzMgr,
+		ArtifactTrackerClient: mockClients.MockArtifact,
+		ProbeTimeout:          10 * time.Millisecond,
+	}
+
+	resp, err := hs.HealthCheck(ctx, &cloudpb.HealthCheckRequest{
+		Dependencies: []cloudpb.APIDependency{cloudpb.AD_VZMGR, cloudpb.AD_ARTIFACT_TRACKER},
+	})
 	require.NoError(t, err)
-	return any
+	require.Equal(t, 2, len(resp.Dependencies))
+	assert.False(t, resp.Dependencies[0].Up)
+	assert.GreaterOrEqual(t, resp.Dependencies[0].LatencyNs, (10 * time.Millisecond).Nanoseconds())
+	assert.True(t, resp.Dependencies[1].Up)
+
+	_, err = hs.HealthCheck(ctx, &cloudpb.HealthCheckRequest{
+		Dependencies: []cloudpb.APIDependency{cloudpb.AD_UNKNOWN},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
 }
 
-func TestScriptMgr(t *testing.T) {
-	var testVis = &vispb.Vis{
-		Widgets: []*vispb.Widget{
-			{
-				FuncOrRef: &vispb.Widget_Func_{
-					Func: &vispb.Widget_Func{
-						Name: "my_func",
-					},
-				},
-				DisplaySpec: toAny(t, &vispb.VegaChart{
-					Spec: "{}",
-				}),
-			},
-		},
-	}
+func TestAuditHook(t *testing.T) {
+	keyID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	orgID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
 
-	ID1 := uuid.Must(uuid.NewV4())
-	ID2 := uuid.Must(uuid.NewV4())
-	testCases := []struct {
-		name         string
-		endpoint     string
-		smReq        proto.Message
-		smResp       proto.Message
-		req          proto.Message
-		expectedResp proto.Message
+	tests := []struct {
+		name           string
+		call           func(ctx context.Context, mockClients *testutils.MockAPIClients, hook controller.AuditHook) error
+		expectedAction string
+		expectedTarget string
 	}{
 		{
-			name:     "GetLiveViews correctly translates from scriptmgrpb to cloudpb.",
-			endpoint: "GetLiveViews",
-			smReq:    &scriptmgrpb.GetLiveViewsReq{},
-			smResp: &scriptmgrpb.GetLiveViewsResp{
-				LiveViews: []*scriptmgrpb.LiveViewMetadata{
-					{
-						ID:   utils.ProtoFromUUID(ID1),
-						Name: "liveview1",
-						Desc: "liveview1 desc",
-					},
-					{
-						ID:   utils.ProtoFromUUID(ID2),
-						Name: "liveview2",
-						Desc: "liveview2 desc",
-					},
-				},
-			},
-			req: &cloudpb.GetLiveViewsReq{},
-			expectedResp: &cloudpb.GetLiveViewsResp{
-				LiveViews: []*cloudpb.LiveViewMetadata{
-					{
-						ID:   ID1.String(),
-						Name: "liveview1",
-						Desc: "liveview1 desc",
-					},
-					{
-						ID:   ID2.String(),
-						Name: "liveview2",
-						Desc: "liveview2 desc",
-					},
-				},
+			name: "create api key",
+			call: func(ctx context.Context, mockClients *testutils.MockAPIClients, hook controller.AuditHook) error {
+				mockClients.MockAPIKey.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(&authpb.APIKey{ID: keyID}, nil)
+				s := &controller.APIKeyServer{APIKeyClient: mockClients.MockAPIKey, AuditHook: hook}
+				_, err := s.Create(ctx, &cloudpb.CreateAPIKeyRequest{})
+				return err
 			},
+			expectedAction: controller.AuditActionCreateAPIKey,
+			expectedTarget: "7ba7b810-9dad-11d1-80b4-00c04fd430c8",
 		},
 		{
-			name:     "GetLiveViewContents correctly translates between scriptmgr and cloudpb.",
-			endpoint: "GetLiveViewContents",
-			smReq: &scriptmgrpb.GetLiveViewContentsReq{
-				LiveViewID: utils.ProtoFromUUID(ID1),
-			},
-			smResp: &scriptmgrpb.GetLiveViewContentsResp{
-				Metadata: &scriptmgrpb.LiveViewMetadata{
-					ID:   utils.ProtoFromUUID(ID1),
-					Name: "liveview1",
-					Desc: "liveview1 desc",
-				},
-				PxlContents: "liveview1 pxl",
-				Vis:         testVis,
-			},
-			req: &cloudpb.GetLiveViewContentsReq{
-				LiveViewID: ID1.String(),
-			},
-			expectedResp: &cloudpb.GetLiveViewContentsResp{
-				Metadata: &cloudpb.LiveViewMetadata{
-					ID:   ID1.String(),
-					Name: "liveview1",
-					Desc: "liveview1 desc",
-				},
-				PxlContents: "liveview1 pxl",
-				Vis:         testVis,
+			name: "delete api key",
+			call: func(ctx context.Context, mockClients *testutils.MockAPIClients, hook controller.AuditHook) error {
+				mockClients.MockAPIKey.EXPECT().
+					Delete(gomock.Any(), keyID).
+					Return(&types.Empty{}, nil)
+				s := &controller.APIKeyServer{APIKeyClient: mockClients.MockAPIKey, AuditHook: hook}
+				_, err := s.Delete(ctx, keyID)
+				return err
 			},
+			expectedAction: controller.AuditActionDeleteAPIKey,
+			expectedTarget: "7ba7b810-9dad-11d1-80b4-00c04fd430c8",
 		},
 		{
-			name:     "GetScripts correctly translates between scriptmgr and cloudpb.",
-			endpoint: "GetScripts",
-			smReq:    &scriptmgrpb.GetScriptsReq{},
-			smResp: &scriptmgrpb.GetScriptsResp{
-				Scripts: []*scriptmgrpb.ScriptMetadata{
-					{
-						ID:          utils.ProtoFromUUID(ID1),
-						Name:        "script1",
-						Desc:        "script1 desc",
-						HasLiveView: false,
-					},
-					{
-						ID:          utils.ProtoFromUUID(ID2),
-						Name:        "liveview1",
-						Desc:        "liveview1 desc",
-						HasLiveView: true,
-					},
-				},
-			},
-			req: &cloudpb.GetScriptsReq{},
-			expectedResp: &cloudpb.GetScriptsResp{
-				Scripts: []*cloudpb.ScriptMetadata{
-					{
-						ID:          ID1.String(),
-						Name:        "script1",
-						Desc:        "script1 desc",
-						HasLiveView: false,
-					},
-					{
-						ID:          ID2.String(),
-						Name:        "liveview1",
-						Desc:        "liveview1 desc",
-						HasLiveView: true,
-					},
-				},
+			name: "create deployment key",
+			call: func(ctx context.Context, mockClients *testutils.MockAPIClients, hook controller.AuditHook) error {
+				mockClients.MockVzDeployKey.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(&vzmgrpb.DeploymentKey{ID: keyID}, nil)
+				s := &controller.VizierDeploymentKeyServer{VzDeploymentKey: mockClients.MockVzDeployKey, AuditHook: hook}
+				_, err := s.Create(ctx, &cloudpb.CreateDeploymentKeyRequest{})
+				return err
 			},
+			expectedAction: controller.AuditActionCreateDeploymentKey,
+			expectedTarget: "7ba7b810-9dad-11d1-80b4-00c04fd430c8",
 		},
 		{
-			name:     "GetScriptContents correctly translates between scriptmgr and cloudpb.",
-			endpoint: "GetScriptContents",
-			smReq: &scriptmgrpb.GetScriptContentsReq{
-				ScriptID: utils.ProtoFromUUID(ID1),
-			},
-			smResp: &scriptmgrpb.GetScriptContentsResp{
-				Metadata: &scriptmgrpb.ScriptMetadata{
-					ID:          utils.ProtoFromUUID(ID1),
-					Name:        "Script1",
-					Desc:        "Script1 desc",
-					HasLiveView: false,
-				},
-				Contents: "Script1 pxl",
+			name: "delete deployment key",
+			call: func(ctx context.Context, mockClients *testutils.MockAPIClients, hook controller.AuditHook) error {
+				mockClients.MockVzDeployKey.EXPECT().
+					Delete(gomock.Any(), keyID).
+					Return(&types.Empty{}, nil)
+				s := &controller.VizierDeploymentKeyServer{VzDeploymentKey: mockClients.MockVzDeployKey, AuditHook: hook}
+				_, err := s.Delete(ctx, keyID)
+				return err
 			},
-			req: &cloudpb.GetScriptContentsReq{
-				ScriptID: ID1.String(),
+			expectedAction: controller.AuditActionDeleteDeploymentKey,
+			expectedTarget: "7ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		},
+		{
+			name: "invite user",
+			call: func(ctx context.Context, mockClients *testutils.MockAPIClients, hook controller.AuditHook) error {
+				mockClients.MockProfile.EXPECT().
+					InviteUser(gomock.Any(), gomock.Any()).
+					Return(&profilepb.InviteUserResponse{Email: "bobloblaw@lawblog.law"}, nil)
+				s := &controller.OrganizationServiceServer{ProfileServiceClient: mockClients.MockProfile, AuditHook: hook}
+				_, err := s.InviteUser(ctx, &cloudpb.InviteUserRequest{Email: "bobloblaw@lawblog.law"})
+				return err
 			},
-			expectedResp: &cloudpb.GetScriptContentsResp{
-				Metadata: &cloudpb.ScriptMetadata{
-					ID:          ID1.String(),
-					Name:        "Script1",
-					Desc:        "Script1 desc",
-					HasLiveView: false,
-				},
-				Contents: "Script1 pxl",
+			expectedAction: controller.AuditActionInviteUser,
+			expectedTarget: "bobloblaw@lawblog.law",
+		},
+		{
+			name: "resend invite",
+			call: func(ctx context.Context, mockClients *testutils.MockAPIClients, hook controller.AuditHook) error {
+				mockClients.MockProfile.EXPECT().
+					ResendInvite(gomock.Any(), &profilepb.ResendInviteRequest{OrgID: orgID, Email: "bobloblaw@lawblog.law"}).
+					Return(&profilepb.InviteUserResponse{Email: "bobloblaw@lawblog.law"}, nil)
+				s := &controller.OrganizationServiceServer{ProfileServiceClient: mockClients.MockProfile, AuditHook: hook}
+				_, err := s.ResendInvite(ctx, &cloudpb.ResendInviteRequest{Email: "bobloblaw@lawblog.law"})
+				return err
 			},
+			expectedAction: controller.AuditActionResendInvite,
+			expectedTarget: "bobloblaw@lawblog.law",
 		},
 	}
 
-	for _, tc := range testCases {
+	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			mockScriptMgr := mock_scriptmgr.NewMockScriptMgrServiceClient(ctrl)
+			_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
+			defer cleanup()
 			ctx := CreateTestContext()
 
-			reflect.ValueOf(mockScriptMgr.EXPECT()).
-				MethodByName(tc.endpoint).
-				Call([]reflect.Value{
-					reflect.ValueOf(gomock.Any()),
-					reflect.ValueOf(tc.smReq),
-				})[0].Interface().(*gomock.Call).
-				Return(tc.smResp, nil)
-
-			scriptMgrServer := &controller.ScriptMgrServer{
-				ScriptMgr: mockScriptMgr,
+			var events []*controller.AuditEvent
+			hook := func(e *controller.AuditEvent) {
+				events = append(events, e)
 			}
 
-			returnVals := reflect.ValueOf(scriptMgrServer).
-				MethodByName(tc.endpoint).
-				Call([]reflect.Value{
-					reflect.ValueOf(ctx),
-					reflect.ValueOf(tc.req),
-				})
-			assert.Nil(t, returnVals[1].Interface())
-			resp := returnVals[0].Interface().(proto.Message)
+			start := time.Now()
+			require.NoError(t, tc.call(ctx, mockClients, hook))
 
-			assert.Equal(t, tc.expectedResp, resp)
+			require.Equal(t, 1, len(events))
+			e := events[0]
+			assert.Equal(t, tc.expectedAction, e.Action)
+			assert.Equal(t, tc.expectedTarget, e.TargetID)
+			assert.Equal(t, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", e.OrgID)
+			assert.Equal(t, "6ba7b810-9dad-11d1-80b4-00c04fd430c9", e.UserID)
+			assert.False(t, e.Time.Before(start))
 		})
 	}
 }
 
-func TestProfileServer_GetOrgInfo(t *testing.T) {
+func TestAuditHook_FailedCall(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	orgID := utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
 
 	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
 	defer cleanup()
 	ctx := CreateTestContext()
 
-	mockClients.MockProfile.EXPECT().GetOrg(gomock.Any(), orgID).
-		Return(&profilepb.OrgInfo{
-			OrgName: "someOrg",
-			ID:      orgID,
-		}, nil)
-
-	profileServer := &controller.ProfileServer{mockClients.MockProfile}
-
-	resp, err := profileServer.GetOrgInfo(ctx, orgID)
-
-	require.NoError(t, err)
-	assert.Equal(t, "someOrg", resp.OrgName)
-	assert.Equal(t, orgID, resp.ID)
-}
-
-func TestOrganizationServiceServer_InviteUser(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
+	keyID := utils.ProtoFromUUIDStrOrNil("7ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	mockClients.MockAPIKey.EXPECT().
+		Delete(gomock.Any(), keyID).
+		Return(nil, status.Error(codes.NotFound, "no such key"))
 
-	_, mockClients, cleanup := testutils.CreateTestAPIEnv(t)
-	defer cleanup()
-	ctx := CreateTestContext()
-	mockReq := &profilepb.InviteUserRequest{
-		OrgID:          utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
-		MustCreateUser: true,
-		Email:          "bobloblaw@lawblog.law",
-		FirstName:      "bob",
-		LastName:       "loblaw",
+	var events []*controller.AuditEvent
+	s := &controller.APIKeyServer{
+		APIKeyClient: mockClients.MockAPIKey,
+		AuditHook: func(e *controller.AuditEvent) {
+			events = append(events, e)
+		},
 	}
 
-	mockClients.MockProfile.EXPECT().InviteUser(gomock.Any(), mockReq).
-		Return(&profilepb.InviteUserResponse{
-			Email:      "bobloblaw@lawblog.law",
-			InviteLink: "withpixie.ai/invite&id=abcd",
-		}, nil)
-
-	os := &controller.OrganizationServiceServer{mockClients.MockProfile}
-
-	resp, err := os.InviteUser(ctx, &cloudpb.InviteUserRequest{
-		Email:     "bobloblaw@lawblog.law",
-		FirstName: "bob",
-		LastName:  "loblaw",
-	})
-
-	require.NoError(t, err)
-	assert.Equal(t, mockReq.Email, resp.Email)
-	assert.Equal(t, "withpixie.ai/invite&id=abcd", resp.InviteLink)
+	// Calls which fail didn't change anything, so they aren't recorded.
+	_, err := s.Delete(ctx, keyID)
+	require.Error(t, err)
+	assert.Empty(t, events)
 }
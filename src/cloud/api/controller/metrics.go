@@ -0,0 +1,73 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package controller
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/status"
+
+	"px.dev/pixie/src/cloud/autocomplete"
+)
+
+// The autocomplete endpoints, used to label the autocomplete metrics.
+const (
+	autocompleteEndpoint            = "Autocomplete"
+	autocompleteFieldEndpoint       = "AutocompleteField"
+	streamAutocompleteFieldEndpoint = "StreamAutocompleteField"
+)
+
+// The autocomplete metrics are only labelled by endpoint and status code, never by the input, org or cluster,
+// to keep their cardinality bounded.
+var (
+	autocompleteRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "autocomplete_requests_total",
+		Help: "The number of autocomplete requests, by endpoint and gRPC status code.",
+	}, []string{"endpoint", "code"})
+	autocompleteSuggestionLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "autocomplete_get_suggestions_duration_seconds",
+		Help:    "The time taken to fetch autocomplete suggestions from the suggester, by endpoint.",
+		Buckets: prometheus.ExponentialBuckets(0.005, 2, 10),
+	}, []string{"endpoint"})
+)
+
+func init() {
+	prometheus.MustRegister(autocompleteRequests, autocompleteSuggestionLatency)
+}
+
+// recordAutocompleteRequest counts a request to the given endpoint which returned err.
+func recordAutocompleteRequest(endpoint string, err error) {
+	autocompleteRequests.WithLabelValues(endpoint, status.Code(err).String()).Inc()
+}
+
+func observeSuggestionLatency(endpoint string, start time.Time) {
+	autocompleteSuggestionLatency.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+}
+
+// timedSuggester records the latency of each call to the underlying Suggester.
+type timedSuggester struct {
+	suggester autocomplete.Suggester
+	endpoint  string
+}
+
+func (s timedSuggester) GetSuggestions(reqs []*autocomplete.SuggestionRequest) ([]*autocomplete.SuggestionResult, error) {
+	defer observeSuggestionLatency(s.endpoint, time.Now())
+	return s.suggester.GetSuggestions(reqs)
+}
@@ -37,8 +37,9 @@ type inviteUserArgs struct {
 
 // UserInviteResolver resolves a user invite.
 type UserInviteResolver struct {
-	Email      string
-	InviteLink string
+	Email       string
+	InviteLink  string
+	UserCreated bool
 }
 
 // InviteUser invites the user with the given name and email address to the org by providing
@@ -57,8 +58,9 @@ func (q *QueryResolver) InviteUser(ctx context.Context, args *inviteUserArgs) (*
 	}
 
 	return &UserInviteResolver{
-		Email:      resp.Email,
-		InviteLink: resp.InviteLink,
+		Email:       resp.Email,
+		InviteLink:  resp.InviteLink,
+		UserCreated: resp.UserCreated,
 	}, nil
 }
 
@@ -86,8 +86,9 @@ func TestUserSettingsResolver_InviteUser(t *testing.T) {
 		FirstName: "Tester",
 		LastName:  "Person",
 	}).Return(&cloudpb.InviteUserResponse{
-		Email:      "test@test.com",
-		InviteLink: "https://pixie.ai/inviteLink",
+		Email:       "test@test.com",
+		InviteLink:  "https://pixie.ai/inviteLink",
+		UserCreated: true,
 	}, nil)
 
 	gqlSchema := LoadSchema(gqlEnv)
@@ -100,6 +101,7 @@ func TestUserSettingsResolver_InviteUser(t *testing.T) {
 					InviteUser(email: "test@test.com", firstName: "Tester", lastName: "Person" ) {
 						email
 						inviteLink
+						userCreated
 					}
 				}
 			`,
@@ -107,7 +109,8 @@ func TestUserSettingsResolver_InviteUser(t *testing.T) {
 				{
 					"InviteUser": {
 						"email": "test@test.com",
-						"inviteLink": "https://pixie.ai/inviteLink"
+						"inviteLink": "https://pixie.ai/inviteLink",
+						"userCreated": true
 					}
 				}
 			`,
@@ -0,0 +1,66 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package controller
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket allows bursts of up to burst requests, refilled at rate requests per second.
+type tokenBucket struct {
+	tokens   float64
+	lastFill time.Time
+}
+
+// orgRateLimiter keeps a separate token bucket for each org, so that one org exceeding its rate doesn't affect
+// requests from any other org.
+type orgRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// allow takes a token from the org's bucket, returning false if the bucket is empty.
+func (l *orgRateLimiter) allow(orgID string, rate float64, burst int, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.buckets == nil {
+		l.buckets = make(map[string]*tokenBucket)
+	}
+	b, ok := l.buckets[orgID]
+	if !ok {
+		b = &tokenBucket{tokens: float64(burst), lastFill: now}
+		l.buckets[orgID] = b
+	}
+
+	if elapsed := now.Sub(b.lastFill); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * rate
+		if b.tokens > float64(burst) {
+			b.tokens = float64(burst)
+		}
+		b.lastFill = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
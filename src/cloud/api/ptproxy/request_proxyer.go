@@ -34,6 +34,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	"px.dev/pixie/src/cloud/shared/vzshard"
+	"px.dev/pixie/src/shared/cvmsgs"
 	"px.dev/pixie/src/shared/cvmsgspb"
 	"px.dev/pixie/src/utils"
 )
@@ -160,7 +161,7 @@ func (p requestProxyer) validateRequestAndFetchCreds(ctx context.Context, debugM
 }
 
 func (p requestProxyer) getSendTopic() string {
-	return vzshard.C2VTopic("VizierPassthroughRequest", p.clusterID)
+	return vzshard.C2VTopic(string(cvmsgs.VizierPassthroughRequestTopic), p.clusterID)
 }
 
 func (p requestProxyer) getRecvTopic() string {
@@ -44,6 +44,7 @@ import (
 	"px.dev/pixie/src/api/proto/vizierpb"
 	"px.dev/pixie/src/cloud/api/ptproxy"
 	"px.dev/pixie/src/cloud/shared/vzshard"
+	"px.dev/pixie/src/shared/cvmsgs"
 	"px.dev/pixie/src/shared/cvmsgspb"
 	"px.dev/pixie/src/shared/services/env"
 	"px.dev/pixie/src/shared/services/server"
@@ -807,7 +808,7 @@ func newFakeVizier(t *testing.T, id uuid.UUID, nc *nats.Conn) *fakeVizier {
 
 func (f *fakeVizier) Run(t *testing.T, responses []*cvmsgspb.V2CAPIStreamResponse) {
 	ch := make(chan *nats.Msg)
-	topic := vzshard.C2VTopic("VizierPassthroughRequest", f.id)
+	topic := vzshard.C2VTopic(string(cvmsgs.VizierPassthroughRequestTopic), f.id)
 	sub, err := f.nc.ChanSubscribe(topic, ch)
 	if err != nil {
 		t.Fatal(err)
@@ -155,10 +155,14 @@ func (s *Server) GetArtifactList(ctx context.Context, in *apb.GetArtifactListReq
               FROM artifacts, artifact_changelogs
               WHERE artifact_name=$1
                     AND artifact_changelogs.artifacts_id=artifacts.id
-                    AND $2=ANY(available_artifacts)
-                    -- Pre release builds contain a '-', so we filter those (but still make them available for download)
-                    -- The permissions of this should eventually be controlled using an RBAC rule.
-                    AND version_str NOT LIKE '%-%'
+                    AND $2=ANY(available_artifacts)`
+	if !in.IncludePrerelease {
+		// Pre release builds contain a '-', so we filter those (but still make them available for download)
+		// The permissions of this should eventually be controlled using an RBAC rule.
+		query += `
+                    AND version_str NOT LIKE '%-%'`
+	}
+	query += `
               ORDER BY create_time DESC`
 
 	var rows *sqlx.Rows
@@ -200,6 +204,8 @@ func downloadSuffix(at vpb.ArtifactType) string {
 		return "linux_amd64"
 	case vpb.AT_DARWIN_AMD64:
 		return "darwin_amd64"
+	case vpb.AT_DARWIN_ARM64:
+		return "darwin_arm64"
 	case vpb.AT_CONTAINER_SET_YAMLS:
 		return "yamls.tar"
 	case vpb.AT_CONTAINER_SET_TEMPLATE_YAMLS:
@@ -208,11 +214,43 @@ func downloadSuffix(at vpb.ArtifactType) string {
 	return "unknown"
 }
 
+func isDownloadable(at vpb.ArtifactType) bool {
+	return at == vpb.AT_DARWIN_AMD64 || at == vpb.AT_DARWIN_ARM64 || at == vpb.AT_LINUX_AMD64 || at == vpb.AT_CONTAINER_SET_YAMLS || at == vpb.AT_CONTAINER_SET_TEMPLATE_YAMLS
+}
+
+// artifactAvailable checks whether the given version of the artifact is available for download as the given type.
+func (s *Server) artifactAvailable(name string, versionStr string, at vpb.ArtifactType) (bool, error) {
+	// If a specific vizier or CLI version is specified, check that the requested version matches. Otherwise, if no version is specified
+	// then we check the DB to see if the version exists.
+	if (at == vpb.AT_CONTAINER_SET_YAMLS || at == vpb.AT_CONTAINER_SET_TEMPLATE_YAMLS) && viper.GetString("vizier_version") != "" {
+		return versionStr == viper.GetString("vizier_version"), nil
+	} else if (at == vpb.AT_CONTAINER_SET_TEMPLATE_YAMLS) && viper.GetString("operator_version") != "" {
+		return versionStr == viper.GetString("operator_version"), nil
+	} else if (at == vpb.AT_DARWIN_AMD64 || at == vpb.AT_DARWIN_ARM64 || at == vpb.AT_LINUX_AMD64) && viper.GetString("cli_version") != "" {
+		return versionStr == viper.GetString("cli_version"), nil
+	}
+
+	query := `SELECT
+				1
+			FROM artifacts
+			WHERE artifact_name=$1
+					AND $2=ANY(available_artifacts)
+					AND version_str=$3
+			LIMIT 1;`
+
+	rows, err := s.db.Query(query, name, utils.ToArtifactTypeDB(at), versionStr)
+	if err != nil {
+		return false, status.Error(codes.Internal, "failed to query database")
+	}
+	defer rows.Close()
+
+	return rows.Next(), nil
+}
+
 // GetDownloadLink returns a signed download link that can be used to download the artifact.
 func (s *Server) GetDownloadLink(ctx context.Context, in *apb.GetDownloadLinkRequest) (*apb.GetDownloadLinkResponse, error) {
 	versionStr := in.VersionStr
 	name := in.ArtifactName
-	at := in.ArtifactType
 
 	if len(name) == 0 {
 		return nil, status.Error(codes.InvalidArgument, "name cannot be empty")
@@ -222,47 +260,36 @@ func (s *Server) GetDownloadLink(ctx context.Context, in *apb.GetDownloadLinkReq
 		return nil, status.Error(codes.InvalidArgument, "versionStr cannot be empty")
 	}
 
-	if at == vpb.AT_UNKNOWN {
-		return nil, status.Error(codes.InvalidArgument, "artifact type cannot be unknown")
-	}
-
-	if !(at == vpb.AT_DARWIN_AMD64 || at == vpb.AT_LINUX_AMD64 || at == vpb.AT_CONTAINER_SET_YAMLS || at == vpb.AT_CONTAINER_SET_TEMPLATE_YAMLS) {
-		return nil, status.Error(codes.InvalidArgument, "artifact type cannot be downloaded")
+	candidates := in.PreferredTypes
+	if len(candidates) == 0 {
+		candidates = []vpb.ArtifactType{in.ArtifactType}
 	}
 
-	// If a specific vizier or CLI version is specified, check that the requested version matches. Otherwise, if no version is specified
-	// then we check the DB to see if the version exists.
-	if (at == vpb.AT_CONTAINER_SET_YAMLS || at == vpb.AT_CONTAINER_SET_TEMPLATE_YAMLS) && viper.GetString("vizier_version") != "" {
-		if versionStr != viper.GetString("vizier_version") {
-			return nil, status.Error(codes.NotFound, "artifact not found")
-		}
-	} else if (at == vpb.AT_CONTAINER_SET_TEMPLATE_YAMLS) && viper.GetString("operator_version") != "" {
-		if versionStr != viper.GetString("operator_version") {
-			return nil, status.Error(codes.NotFound, "artifact not found")
+	for _, at := range candidates {
+		if at == vpb.AT_UNKNOWN {
+			return nil, status.Error(codes.InvalidArgument, "artifact type cannot be unknown")
 		}
-	} else if (at == vpb.AT_DARWIN_AMD64 || at == vpb.AT_LINUX_AMD64) && viper.GetString("cli_version") != "" {
-		if versionStr != viper.GetString("cli_version") {
-			return nil, status.Error(codes.NotFound, "artifact not found")
+
+		if !isDownloadable(at) {
+			return nil, status.Error(codes.InvalidArgument, "artifact type cannot be downloaded")
 		}
-	} else {
-		query := `SELECT
-					1
-				FROM artifacts
-				WHERE artifact_name=$1
-						AND $2=ANY(available_artifacts)
-						AND version_str=$3
-				LIMIT 1;`
-
-		rows, err := s.db.Query(query, name, utils.ToArtifactTypeDB(at), versionStr)
+	}
+
+	// Use the first of the candidate types which is available.
+	at := vpb.AT_UNKNOWN
+	for _, candidate := range candidates {
+		available, err := s.artifactAvailable(name, versionStr, candidate)
 		if err != nil {
-			return nil, status.Error(codes.Internal, "failed to query database")
+			return nil, err
 		}
-		defer rows.Close()
-
-		if !rows.Next() {
-			return nil, status.Error(codes.NotFound, "artifact not found")
+		if available {
+			at = candidate
+			break
 		}
 	}
+	if at == vpb.AT_UNKNOWN {
+		return nil, status.Error(codes.NotFound, "artifact not found")
+	}
 
 	expires := time.Now().Add(time.Minute * 60)
 
@@ -319,8 +346,9 @@ func (s *Server) GetDownloadLink(ctx context.Context, in *apb.GetDownloadLinkReq
 	}
 
 	return &apb.GetDownloadLinkResponse{
-		Url:        url,
-		SHA256:     strings.TrimSpace(string(sha256bytes)),
-		ValidUntil: tpb,
+		Url:          url,
+		SHA256:       strings.TrimSpace(string(sha256bytes)),
+		ValidUntil:   tpb,
+		ArtifactType: at,
 	}, nil
 }
@@ -79,6 +79,15 @@ func mustSetupFakeBucket(t *testing.T) stiface.Client {
 			},
 			nil,
 		),
+		"test-release": testingutils.NewMockGCSBucket(
+			map[string]*testingutils.MockGCSObject{
+				"cli/1.2.3/cli_darwin_amd64": testingutils.NewMockGCSObject(nil, &storage.ObjectAttrs{
+					MediaLink: "the-darwin-url",
+				}),
+				"cli/1.2.3/cli_darwin_amd64.sha256": testingutils.NewMockGCSObject([]byte("the-darwin-sha256"), nil),
+			},
+			nil,
+		),
 	})
 }
 
@@ -210,6 +219,41 @@ func TestServer_GetArtifactList(t *testing.T) {
 			},
 			err: nil,
 		},
+		{
+			name: "cli linux including prereleases should return 3 linux artifacts",
+			req: apb.GetArtifactListRequest{
+				ArtifactName:      "cli",
+				ArtifactType:      vpb.AT_LINUX_AMD64,
+				IncludePrerelease: true,
+			},
+			expectedResp: &vpb.ArtifactSet{
+				Name: "cli",
+				Artifact: []*vpb.Artifact{
+					{
+						Timestamp:          &types.Timestamp{Seconds: 1561230625},
+						CommitHash:         "bda4ac2f4c979e81f5d95a2b550a08fb041e985c",
+						VersionStr:         "1.2.3",
+						AvailableArtifacts: []vpb.ArtifactType{vpb.AT_LINUX_AMD64, vpb.AT_DARWIN_AMD64},
+						Changelog:          "cl 0",
+					},
+					{
+						Timestamp:          &types.Timestamp{Seconds: 1561227025},
+						CommitHash:         "ada4ac2f4c979e81f5d95a2b550a08fb041e985c",
+						VersionStr:         "1.2.1-pre.3",
+						AvailableArtifacts: []vpb.ArtifactType{vpb.AT_LINUX_AMD64},
+						Changelog:          "cl 1",
+					},
+					{
+						Timestamp:          &types.Timestamp{Seconds: 1561144225},
+						CommitHash:         "cda4ac2f4c979e81f5d95a2b550a08fb041e985c",
+						VersionStr:         "1.1.5",
+						AvailableArtifacts: []vpb.ArtifactType{vpb.AT_LINUX_AMD64, vpb.AT_DARWIN_AMD64},
+						Changelog:          "cl 2",
+					},
+				},
+			},
+			err: nil,
+		},
 		{
 			name: "vizier limit 1 should return empty set",
 			req: apb.GetArtifactListRequest{
@@ -333,3 +377,101 @@ func TestServer_GetDownloadLink(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_GetDownloadLinkPreferredTypes(t *testing.T) {
+	mustLoadTestData(db)
+	storageClient := mustSetupFakeBucket(t)
+
+	server := controller.NewServer(db, storageClient, "test-bucket", "test-release", &jwt.Config{
+		Email:      "test@test.com",
+		PrivateKey: []byte("the-key"),
+	})
+
+	controller.URLSigner = func(bucket, name string, opts *storage.SignedURLOptions) (s string, err error) {
+		return "the-url", nil
+	}
+
+	testCases := []struct {
+		name         string
+		req          apb.GetDownloadLinkRequest
+		expectedResp *apb.GetDownloadLinkResponse
+		errCode      codes.Code
+	}{
+		{
+			name: "first available type is chosen",
+			req: apb.GetDownloadLinkRequest{
+				ArtifactName:   "cli",
+				VersionStr:     "1.2.3",
+				PreferredTypes: []vpb.ArtifactType{vpb.AT_DARWIN_AMD64, vpb.AT_LINUX_AMD64},
+			},
+			expectedResp: &apb.GetDownloadLinkResponse{
+				Url:          "the-darwin-url",
+				SHA256:       "the-darwin-sha256",
+				ArtifactType: vpb.AT_DARWIN_AMD64,
+			},
+			errCode: codes.OK,
+		},
+		{
+			name: "falls back to the next type",
+			req: apb.GetDownloadLinkRequest{
+				ArtifactName:   "cli",
+				VersionStr:     "1.2.1-pre.3",
+				PreferredTypes: []vpb.ArtifactType{vpb.AT_DARWIN_AMD64, vpb.AT_LINUX_AMD64},
+			},
+			expectedResp: &apb.GetDownloadLinkResponse{
+				Url:          "the-url",
+				SHA256:       "the-sha256",
+				ArtifactType: vpb.AT_LINUX_AMD64,
+			},
+			errCode: codes.OK,
+		},
+		{
+			name: "preferred types override artifact type",
+			req: apb.GetDownloadLinkRequest{
+				ArtifactName:   "cli",
+				VersionStr:     "1.2.1-pre.3",
+				ArtifactType:   vpb.AT_DARWIN_AMD64,
+				PreferredTypes: []vpb.ArtifactType{vpb.AT_LINUX_AMD64},
+			},
+			expectedResp: &apb.GetDownloadLinkResponse{
+				Url:          "the-url",
+				SHA256:       "the-sha256",
+				ArtifactType: vpb.AT_LINUX_AMD64,
+			},
+			errCode: codes.OK,
+		},
+		{
+			name: "none of the types available should give not found",
+			req: apb.GetDownloadLinkRequest{
+				ArtifactName:   "cli",
+				VersionStr:     "1.2.1-pre.3",
+				PreferredTypes: []vpb.ArtifactType{vpb.AT_DARWIN_AMD64, vpb.AT_CONTAINER_SET_YAMLS},
+			},
+			errCode: codes.NotFound,
+		},
+		{
+			name: "not downloadable preferred type should give an error",
+			req: apb.GetDownloadLinkRequest{
+				ArtifactName:   "cli",
+				VersionStr:     "1.2.1-pre.3",
+				PreferredTypes: []vpb.ArtifactType{vpb.AT_LINUX_AMD64, vpb.AT_CONTAINER_SET_LINUX_AMD64},
+			},
+			errCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := server.GetDownloadLink(context.Background(), &tc.req)
+			if tc.errCode != codes.OK {
+				assert.Equal(t, tc.errCode, status.Code(err))
+				assert.Nil(t, resp)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedResp.Url, resp.Url)
+				assert.Equal(t, tc.expectedResp.SHA256, resp.SHA256)
+				assert.Equal(t, tc.expectedResp.ArtifactType, resp.ArtifactType)
+			}
+		})
+	}
+}
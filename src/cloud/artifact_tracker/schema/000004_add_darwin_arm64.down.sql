@@ -0,0 +1 @@
+ALTER TYPE artifact_type DROP VALUE 'DARWIN_ARM64';
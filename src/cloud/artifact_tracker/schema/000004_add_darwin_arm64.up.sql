@@ -0,0 +1 @@
+ALTER TYPE artifact_type ADD VALUE 'DARWIN_ARM64';
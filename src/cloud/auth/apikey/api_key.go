@@ -84,6 +84,7 @@ func (s *Service) Create(ctx context.Context, req *authpb.CreateAPIKeyRequest) (
 		ID:        utils.ProtoFromUUID(id),
 		Key:       key,
 		CreatedAt: tp,
+		CreatedBy: utils.ProtoFromUUIDStrOrNil(sCtx.Claims.GetUserClaims().UserID),
 	}, nil
 }
 
@@ -95,7 +96,7 @@ func (s *Service) List(ctx context.Context, req *authpb.ListAPIKeyRequest) (*aut
 	}
 
 	// Return all clusters when the OrgID matches.
-	query := `SELECT id, org_id, unsalted_key, created_at, description from api_keys WHERE org_id=$1 ORDER BY created_at`
+	query := `SELECT id, org_id, unsalted_key, created_at, description, user_id from api_keys WHERE org_id=$1 ORDER BY created_at`
 	rows, err := s.db.QueryxContext(ctx, query, sCtx.Claims.GetUserClaims().OrgID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -113,7 +114,8 @@ func (s *Service) List(ctx context.Context, req *authpb.ListAPIKeyRequest) (*aut
 		var key string
 		var createdAt time.Time
 		var desc string
-		err = rows.Scan(&id, &orgID, &key, &createdAt, &desc)
+		var userID string
+		err = rows.Scan(&id, &orgID, &key, &createdAt, &desc, &userID)
 		if err != nil {
 			log.WithError(err).Error("Failed to read data from postgres")
 			return nil, status.Error(codes.Internal, "failed to read data")
@@ -124,6 +126,7 @@ func (s *Service) List(ctx context.Context, req *authpb.ListAPIKeyRequest) (*aut
 			Key:       key,
 			CreatedAt: tProto,
 			Desc:      desc,
+			CreatedBy: utils.ProtoFromUUIDStrOrNil(userID),
 		})
 	}
 	return &authpb.ListAPIKeyResponse{
@@ -145,8 +148,9 @@ func (s *Service) Get(ctx context.Context, req *authpb.GetAPIKeyRequest) (*authp
 	var key string
 	var createdAt time.Time
 	var desc string
-	query := `SELECT unsalted_key, created_at, description from api_keys WHERE org_id=$1 and id=$2`
-	err = s.db.QueryRowxContext(ctx, query, sCtx.Claims.GetUserClaims().OrgID, tokenID).Scan(&key, &createdAt, &desc)
+	var userID string
+	query := `SELECT unsalted_key, created_at, description, user_id from api_keys WHERE org_id=$1 and id=$2`
+	err = s.db.QueryRowxContext(ctx, query, sCtx.Claims.GetUserClaims().OrgID, tokenID).Scan(&key, &createdAt, &desc, &userID)
 	if err != nil {
 		return nil, status.Error(codes.NotFound, "No such API key")
 	}
@@ -157,6 +161,7 @@ func (s *Service) Get(ctx context.Context, req *authpb.GetAPIKeyRequest) (*authp
 		Key:       key,
 		CreatedAt: createdAtProto,
 		Desc:      desc,
+		CreatedBy: utils.ProtoFromUUIDStrOrNil(userID),
 	}}, nil
 }
 
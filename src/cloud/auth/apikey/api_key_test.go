@@ -117,6 +117,7 @@ func TestAPIKeyService_CreateAPIKey(t *testing.T) {
 	// Check if the key has a value and the ID looks valid.
 	assert.Greater(t, len(resp.Key), 0)
 	assert.NotEqual(t, uuid.Nil.String(), utils.UUIDFromProtoOrNil(resp.ID).String())
+	assert.Equal(t, testAuthUserID, utils.UUIDFromProtoOrNil(resp.CreatedBy))
 }
 
 func TestAPIKeyService_ListAPIKeys(t *testing.T) {
@@ -132,6 +133,8 @@ func TestAPIKeyService_ListAPIKeys(t *testing.T) {
 	assert.Equal(t, testKey2ID, utils.UUIDFromProtoOrNil(resp.Keys[1].ID))
 	assert.Equal(t, "here is a desc", resp.Keys[0].Desc)
 	assert.Equal(t, "here is another one", resp.Keys[1].Desc)
+	assert.Equal(t, testAuthUserID, utils.UUIDFromProtoOrNil(resp.Keys[0].CreatedBy))
+	assert.Equal(t, testAuthUserID, utils.UUIDFromProtoOrNil(resp.Keys[1].CreatedBy))
 	assert.Equal(t, "key1", resp.Keys[0].Key)
 	assert.Equal(t, "key2", resp.Keys[1].Key)
 
@@ -182,6 +185,7 @@ func TestAPIKeyService_Get(t *testing.T) {
 	}
 	assert.LessOrEqual(t, diff, int64(10000))
 	assert.Equal(t, "here is a desc", resp.Key.Desc)
+	assert.Equal(t, testAuthUserID, utils.UUIDFromProtoOrNil(resp.Key.CreatedBy))
 }
 
 func TestAPIKeyService_Get_UnownedID(t *testing.T) {
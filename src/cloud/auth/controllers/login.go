@@ -220,7 +220,7 @@ func (s *Server) loginSupportUser(ctx context.Context, in *authpb.LoginRequest,
 	userID := uuid.FromStringOrNil("") // No account actually exists, so this should be a nil UUID.
 	orgID := utils.UUIDFromProtoOrNil(orgInfo.ID)
 	expiresAt := time.Now().Add(RefreshTokenValidDuration)
-	claims := srvutils.GenerateJWTForUser(userID.String(), orgID.String(), userInfo.Email, expiresAt, viper.GetString("domain_name"))
+	claims := srvutils.GenerateJWTForSupportUser(userID.String(), orgID.String(), userInfo.Email, expiresAt, viper.GetString("domain_name"))
 	token, err := srvutils.SignJWTClaims(claims, s.env.JWTSigningKey())
 	if err != nil {
 		return nil, status.Error(codes.Internal, "failed to generate token")
@@ -356,6 +356,14 @@ func TestServer_LoginNewUser_SupportUser(t *testing.T) {
 	assert.Equal(t, resp.UserInfo.LastName, "last")
 	assert.Equal(t, resp.UserInfo.Email, "test@pixie.support")
 	verifyToken(t, resp.Token, userID.String(), orgID, resp.ExpiresAt, "jwtkey")
+
+	// Support accounts are given the admin scope, so that they can act on behalf of other orgs.
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(resp.Token, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte("jwtkey"), nil
+	}, jwt.WithAudience("withpixie.ai"))
+	require.NoError(t, err)
+	assert.Equal(t, "user,admin", claims["Scopes"])
 }
 
 func TestServer_LoginNewUser_InvalidOrg(t *testing.T) {
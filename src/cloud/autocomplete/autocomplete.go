@@ -19,8 +19,10 @@
 package autocomplete
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/gofrs/uuid"
@@ -38,6 +40,15 @@ type Suggester interface {
 	GetSuggestions(reqs []*SuggestionRequest) ([]*SuggestionResult, error)
 }
 
+// StreamingSuggester is a Suggester which can return the suggestions for a request incrementally, as they
+// are scored.
+type StreamingSuggester interface {
+	Suggester
+	// StreamSuggestions calls send with each batch of suggestions for the request, until all of the suggestions
+	// have been sent, send returns an error, or the context is cancelled.
+	StreamSuggestions(ctx context.Context, req *SuggestionRequest, send func([]*Suggestion) error) error
+}
+
 // Suggestion is a suggestion for a token.
 type Suggestion struct {
 	Name           string
@@ -46,8 +57,9 @@ type Suggestion struct {
 	Kind           cloudpb.AutocompleteEntityKind
 	ArgNames       []string // If the suggestion is a script, the args that the script takes.
 	ArgKinds       []cloudpb.AutocompleteEntityKind
-	MatchedIndexes []int64
+	MatchedIndexes []int64 // The indexes of the characters in Name which matched the input, for highlighting.
 	State          cloudpb.AutocompleteEntityState
+	Fuzzy          bool // Whether the suggestion only matched the input after allowing for edits.
 }
 
 // TabStop represents a tab stop in a command.
@@ -65,6 +77,8 @@ type Command struct {
 	TabStops       []*TabStop
 	Executable     bool
 	HasValidScript bool
+	// Why the command isn't executable. EBR_NONE if it is.
+	ExecutableBlockReason cloudpb.ExecutableBlockReason
 }
 
 var kindLabelToProtoMap = map[string]cloudpb.AutocompleteEntityKind{
@@ -81,17 +95,17 @@ var protoToKindLabelMap = map[cloudpb.AutocompleteEntityKind]string{
 	cloudpb.AEK_NAMESPACE: "ns",
 }
 
-// Autocomplete returns a formatted string and suggestions for the given input.
-func Autocomplete(input string, cursorPos int, action cloudpb.AutocompleteActionType, s Suggester, orgID uuid.UUID, clusterUID string) (string, bool, []*cloudpb.TabSuggestion, error) {
+// Autocomplete returns a formatted string, its tab stops and suggestions for the given input.
+func Autocomplete(input string, cursorPos int, action cloudpb.AutocompleteActionType, s Suggester, orgID uuid.UUID, clusterUID string) (string, bool, cloudpb.ExecutableBlockReason, []*cloudpb.TabSuggestion, []*cloudpb.TabStop, error) {
 	inputWithCursor := input[:cursorPos] + "$0" + input[cursorPos:]
 	cmd, err := ParseIntoCommand(inputWithCursor, s, orgID, clusterUID)
 	if err != nil {
-		return "", false, nil, err
+		return "", false, cloudpb.EBR_NONE, nil, nil, err
 	}
 
-	fmtOutput, suggestions := cmd.ToFormatString(action, s, orgID, clusterUID)
+	fmtOutput, suggestions, tabStops := cmd.ToFormatString(action, s, orgID, clusterUID)
 
-	return fmtOutput, cmd.Executable, suggestions, nil
+	return fmtOutput, cmd.Executable, cmd.ExecutableBlockReason, suggestions, tabStops, nil
 }
 
 // ParseIntoCommand takes user input and attempts to parse it into a valid command with suggestions.
@@ -150,7 +164,7 @@ func parseRunScript(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, orgID
 				searchTerm = strings.Replace(searchTerm, CursorMarker, "", 1)
 			}
 
-			res, err := s.GetSuggestions([]*SuggestionRequest{{orgID, clusterUID, searchTerm, []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SCRIPT}, []cloudpb.AutocompleteEntityKind{}}})
+			res, err := s.GetSuggestions([]*SuggestionRequest{{orgID, clusterUID, searchTerm, []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SCRIPT}, []cloudpb.AutocompleteEntityKind{}, false}})
 			if err != nil {
 				return -1, nil, nil, err
 			}
@@ -334,21 +348,40 @@ func parseRunArgs(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, scriptTa
 func validateCommand(scriptDefined bool, cmd *Command) {
 	// Determine if the command is executable.
 	cmd.Executable = true
+	cmd.ExecutableBlockReason = cloudpb.EBR_NONE
 	if !scriptDefined {
 		cmd.Executable = false
+		cmd.ExecutableBlockReason = cloudpb.EBR_MISSING_SCRIPT
 	}
 
 	for _, a := range cmd.TabStops {
 		// All args should be valid.
 		if !a.Valid && a.Value != "" && a.Value != CursorMarker {
+			if cmd.Executable {
+				cmd.ExecutableBlockReason = tabStopBlockReason(a)
+			}
 			cmd.Executable = false
 			break
 		}
 	}
 }
 
+// tabStopBlockReason returns why the given invalid tab stop prevents the command from being executed.
+// Args which are left empty don't block execution.
+func tabStopBlockReason(t *TabStop) cloudpb.ExecutableBlockReason {
+	switch {
+	case t.Kind == cloudpb.AEK_SCRIPT:
+		return cloudpb.EBR_UNKNOWN_SCRIPT
+	case t.ArgName == "":
+		return cloudpb.EBR_UNEXPECTED_ARG
+	default:
+		return cloudpb.EBR_INVALID_ARG
+	}
+}
+
 func parseRunCommand(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, orgID uuid.UUID, clusterUID string) error {
 	if parsedCmd.Args == nil {
+		validateCommand(false, cmd)
 		return nil
 	}
 
@@ -361,9 +394,15 @@ func parseRunCommand(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, orgID
 	var specifiedEntities []cloudpb.AutocompleteEntityKind
 
 	allowedKinds := []cloudpb.AutocompleteEntityKind{cloudpb.AEK_POD, cloudpb.AEK_SVC, cloudpb.AEK_NAMESPACE}
+	preferredKind := cloudpb.AEK_UNKNOWN
 	if cmd.HasValidScript {
 		args, specifiedEntities = parseRunArgsWithScript(parsedCmd, cmd, s, argNames, argTypes, scriptTabIndex)
-		allowedKinds = []cloudpb.AutocompleteEntityKind{}
+		// Args which don't map to a script arg should still be suggested entities that the script takes,
+		// preferring the kind of the script's first arg.
+		allowedKinds = uniqueKinds(argTypes)
+		if len(argTypes) > 0 {
+			preferredKind = argTypes[0]
+		}
 	} else {
 		args, specifiedEntities = parseRunArgs(parsedCmd, cmd, s, scriptTabIndex)
 		if scriptTabIndex == -1 {
@@ -375,14 +414,17 @@ func parseRunCommand(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, orgID
 	reqs := make([]*SuggestionRequest, 0)
 	for _, a := range args {
 		ak := allowedKinds
+		aa := specifiedEntities
 		if a.Kind != cloudpb.AEK_UNKNOWN { // The kind is already specified in the input string.
 			ak = []cloudpb.AutocompleteEntityKind{a.Kind}
+		} else if cmd.HasValidScript {
+			aa = argTypes
 		}
 		searchTerm := a.Value
 		if a.ContainsCursor {
 			searchTerm = strings.Replace(searchTerm, CursorMarker, "", 1)
 		}
-		reqs = append(reqs, &SuggestionRequest{orgID, clusterUID, searchTerm, ak, specifiedEntities})
+		reqs = append(reqs, &SuggestionRequest{orgID, clusterUID, searchTerm, ak, aa, false})
 	}
 
 	res, err := s.GetSuggestions(reqs)
@@ -392,6 +434,9 @@ func parseRunCommand(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, orgID
 
 	for i, a := range args {
 		args[i].Suggestions = res[i].Suggestions
+		if a.Kind == cloudpb.AEK_UNKNOWN && preferredKind != cloudpb.AEK_UNKNOWN {
+			args[i].Suggestions = prioritizeKind(res[i].Suggestions, preferredKind)
+		}
 		args[i].Valid = res[i].ExactMatch && a.Kind != cloudpb.AEK_UNKNOWN && a.ArgName != ""
 	}
 
@@ -402,8 +447,42 @@ func parseRunCommand(parsedCmd *ebnf.ParsedCmd, cmd *Command, s Suggester, orgID
 	return nil
 }
 
+// preferredKindScoreBoost is added to the score of suggestions of the preferred kind for a tab stop.
+const preferredKindScoreBoost = 10.0
+
+// prioritizeKind boosts the score of the suggestions of the given kind, and moves them ahead of the
+// other suggestions, keeping the relative order within each group. The suggestions may be shared with
+// the suggester, so a reordered copy is returned and the given suggestions are left unchanged.
+func prioritizeKind(suggestions []*Suggestion, kind cloudpb.AutocompleteEntityKind) []*Suggestion {
+	prioritized := make([]*Suggestion, len(suggestions))
+	for i, s := range suggestions {
+		if s.Kind == kind {
+			boosted := *s
+			boosted.Score += preferredKindScoreBoost
+			s = &boosted
+		}
+		prioritized[i] = s
+	}
+	sort.SliceStable(prioritized, func(i, j int) bool {
+		return prioritized[i].Kind == kind && prioritized[j].Kind != kind
+	})
+	return prioritized
+}
+
+func uniqueKinds(kinds []cloudpb.AutocompleteEntityKind) []cloudpb.AutocompleteEntityKind {
+	seen := make(map[cloudpb.AutocompleteEntityKind]bool)
+	unique := make([]cloudpb.AutocompleteEntityKind, 0)
+	for _, k := range kinds {
+		if !seen[k] {
+			seen[k] = true
+			unique = append(unique, k)
+		}
+	}
+	return unique
+}
+
 // ToFormatString converts a command to a formatted string with tab indexes, such as: ${1:run} ${2: px/svc_info}
-func (cmd *Command) ToFormatString(action cloudpb.AutocompleteActionType, s Suggester, orgID uuid.UUID, clusterUID string) (formattedInput string, suggestions []*cloudpb.TabSuggestion) {
+func (cmd *Command) ToFormatString(action cloudpb.AutocompleteActionType, s Suggester, orgID uuid.UUID, clusterUID string) (formattedInput string, suggestions []*cloudpb.TabSuggestion, tabStops []*cloudpb.TabStop) {
 	curTabStop, nextInvalidTabStop, invalidTabs := cmd.processTabStops()
 
 	// Move the cursor according to the action that was taken.
@@ -438,7 +517,7 @@ func (cmd *Command) ToFormatString(action cloudpb.AutocompleteActionType, s Sugg
 			}
 			res, err := s.GetSuggestions([]*SuggestionRequest{{orgID, clusterUID, "",
 				[]cloudpb.AutocompleteEntityKind{cloudpb.AEK_POD, cloudpb.AEK_SVC, cloudpb.AEK_NAMESPACE, cloudpb.AEK_SCRIPT},
-				scriptTypes}})
+				scriptTypes, false}})
 			if err == nil {
 				cmd.TabStops[curTabStop].Suggestions = res[0].Suggestions
 			}
@@ -453,6 +532,7 @@ func (cmd *Command) ToFormatString(action cloudpb.AutocompleteActionType, s Sugg
 	// Construct the formatted string and tab suggestions.
 	fStr := ""
 	suggestions = make([]*cloudpb.TabSuggestion, len(cmd.TabStops))
+	tabStops = make([]*cloudpb.TabStop, len(cmd.TabStops))
 	for i, t := range cmd.TabStops {
 		// The tab index of this tabstop is ((idx - (curTabStop + 1)) % numTabStops) + 1.
 		idx := (((i - (curTabStop + 1)) + len(cmd.TabStops)) % len(cmd.TabStops)) + 1
@@ -480,19 +560,22 @@ func (cmd *Command) ToFormatString(action cloudpb.AutocompleteActionType, s Sugg
 		suggestions[i] = ts
 
 		// Append to the formatted string.
+		text := ""
 		if t.Value == "" && t.Kind == cloudpb.AEK_UNKNOWN {
 			fStr += fmt.Sprintf("${%d}", idx)
 		} else {
-			fStr += fmt.Sprintf("${%d:", idx)
 			if t.ArgName != "" {
-				fStr += t.ArgName + ":"
+				text += t.ArgName + ":"
 			} else if t.Kind != cloudpb.AEK_UNKNOWN {
-				fStr += protoToKindLabelMap[t.Kind] + ":"
+				text += protoToKindLabelMap[t.Kind] + ":"
 			}
-			if t.Value != "" {
-				fStr += t.Value
-			}
-			fStr += "}"
+			text += t.Value
+			fStr += fmt.Sprintf("${%d:%s}", idx, text)
+		}
+		tabStops[i] = &cloudpb.TabStop{
+			TabIndex:       int64(idx),
+			Text:           strings.Replace(text, CursorMarker, "", 1),
+			ContainsCursor: strings.Contains(text, CursorMarker),
 		}
 
 		if i != len(cmd.TabStops)-1 {
@@ -500,7 +583,7 @@ func (cmd *Command) ToFormatString(action cloudpb.AutocompleteActionType, s Sugg
 		}
 	}
 
-	return fStr, suggestions
+	return fStr, suggestions, tabStops
 }
 
 // processTabStops iterates through the tabs to determine which is the current tab the cursor is on, which is the next invalid
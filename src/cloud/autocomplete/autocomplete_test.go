@@ -727,8 +727,8 @@ func TestParseIntoCommand(t *testing.T) {
 						OrgID:        orgID,
 						ClusterUID:   "test",
 						Input:        "test",
-						AllowedKinds: []cloudpb.AutocompleteEntityKind{},
-						AllowedArgs:  []cloudpb.AutocompleteEntityKind{},
+						AllowedKinds: []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SVC},
+						AllowedArgs:  []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SVC},
 					},
 				},
 			},
@@ -819,6 +819,85 @@ func TestParseIntoCommand(t *testing.T) {
 	}
 }
 
+func TestParseIntoCommand_PrioritizesScriptArgKind(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+
+	extraArgResult := &autocomplete.SuggestionResult{
+		Suggestions: []*autocomplete.Suggestion{
+			{Name: "pl/test-pod", Kind: cloudpb.AEK_POD, Score: 3},
+			{Name: "pl/test-svc", Kind: cloudpb.AEK_SVC, Score: 1},
+			{Name: "pl/test-pod2", Kind: cloudpb.AEK_POD, Score: 2},
+			{Name: "pl/test-svc2", Kind: cloudpb.AEK_SVC, Score: 0.5},
+		},
+	}
+
+	gomock.InOrder(
+		s.EXPECT().
+			GetSuggestions([]*autocomplete.SuggestionRequest{
+				{
+					OrgID:        orgID,
+					ClusterUID:   "test",
+					Input:        "px/svc_pods",
+					AllowedKinds: []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SCRIPT},
+					AllowedArgs:  []cloudpb.AutocompleteEntityKind{},
+				},
+			}).
+			Return([]*autocomplete.SuggestionResult{
+				{
+					Suggestions: []*autocomplete.Suggestion{
+						{
+							Name:     "px/svc_pods",
+							Score:    1,
+							ArgNames: []string{"svc_name", "pod_name"},
+							ArgKinds: []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SVC, cloudpb.AEK_POD},
+						},
+					},
+					ExactMatch: true,
+				},
+			}, nil),
+		s.EXPECT().
+			GetSuggestions(gomock.Any()).
+			DoAndReturn(func(reqs []*autocomplete.SuggestionRequest) ([]*autocomplete.SuggestionResult, error) {
+				require.Equal(t, 3, len(reqs))
+				// The extra arg should be suggested entities of the kinds that the script takes.
+				assert.Equal(t, "test", reqs[2].Input)
+				assert.Equal(t, []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SVC, cloudpb.AEK_POD}, reqs[2].AllowedKinds)
+				assert.Equal(t, []cloudpb.AutocompleteEntityKind{cloudpb.AEK_SVC, cloudpb.AEK_POD}, reqs[2].AllowedArgs)
+				return []*autocomplete.SuggestionResult{
+					{
+						Suggestions: []*autocomplete.Suggestion{{Name: "pl/test", Kind: cloudpb.AEK_SVC, Score: 1}},
+						ExactMatch:  true,
+					},
+					{
+						Suggestions: []*autocomplete.Suggestion{{Name: "pl/test-pod", Kind: cloudpb.AEK_POD, Score: 1}},
+						ExactMatch:  true,
+					},
+					extraArgResult,
+				}, nil
+			}),
+	)
+
+	cmd, err := autocomplete.ParseIntoCommand("script:px/svc_pods svc_name:pl/test pod_name:pl/test-pod test", s, orgID, "test")
+	require.NoError(t, err)
+	require.Equal(t, 4, len(cmd.TabStops))
+
+	extraArg := cmd.TabStops[3]
+	assert.Equal(t, cloudpb.AEK_UNKNOWN, extraArg.Kind)
+	names := make([]string, len(extraArg.Suggestions))
+	for i, sugg := range extraArg.Suggestions {
+		names[i] = sugg.Name
+	}
+	// Services, the kind of the script's first arg, should rank above the other suggestions.
+	assert.Equal(t, []string{"pl/test-svc", "pl/test-svc2", "pl/test-pod", "pl/test-pod2"}, names)
+	assert.True(t, extraArg.Suggestions[1].Score > extraArg.Suggestions[2].Score)
+
+	// The suggester's results, which may be cached, should be left unchanged.
+	assert.Equal(t, "pl/test-pod", extraArgResult.Suggestions[0].Name)
+	assert.Equal(t, 1.0, extraArgResult.Suggestions[1].Score)
+}
+
 func TestToFormatString(t *testing.T) {
 	tests := []struct {
 		name                  string
@@ -1282,7 +1361,7 @@ func TestToFormatString(t *testing.T) {
 						{
 							orgID, "test", "",
 							[]cloudpb.AutocompleteEntityKind{cloudpb.AEK_POD, cloudpb.AEK_SVC, cloudpb.AEK_NAMESPACE, cloudpb.AEK_SCRIPT},
-							test.suggestionScriptTypes,
+							test.suggestionScriptTypes, false,
 						},
 					}).Return([]*autocomplete.SuggestionResult{
 					{
@@ -1297,7 +1376,7 @@ func TestToFormatString(t *testing.T) {
 				}, nil)
 			}
 
-			output, suggestions := test.cmd.ToFormatString(test.action, s, orgID, "test")
+			output, suggestions, _ := test.cmd.ToFormatString(test.action, s, orgID, "test")
 			assert.Equal(t, test.expectedStr, output)
 			assert.ElementsMatch(t, test.expectedSuggestions, suggestions)
 		})
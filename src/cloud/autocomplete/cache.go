@@ -0,0 +1,198 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package autocomplete
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"px.dev/pixie/src/api/proto/cloudpb"
+)
+
+var suggestionCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "autocomplete_suggestion_cache_lookups_total",
+	Help: "The number of suggestion requests looked up in the autocomplete cache, by whether they were cached.",
+}, []string{"result"})
+
+func init() {
+	prometheus.MustRegister(suggestionCacheLookups)
+}
+
+// DefaultSuggestionCacheTTL is the default amount of time that a CachingSuggester caches results for.
+const DefaultSuggestionCacheTTL = 2 * time.Second
+
+type suggestionCacheKey struct {
+	orgID      uuid.UUID
+	clusterUID string
+	input      string
+	// The allowed kinds and args are part of the key, so that requests for different kinds
+	// of entities with the same input don't collide.
+	allowedKinds string
+	allowedArgs  string
+	fuzzy        bool
+}
+
+type suggestionCacheEntry struct {
+	result    *SuggestionResult
+	expiresAt time.Time
+}
+
+func makeSuggestionCacheKey(req *SuggestionRequest) suggestionCacheKey {
+	return suggestionCacheKey{
+		orgID:        req.OrgID,
+		clusterUID:   req.ClusterUID,
+		input:        strings.TrimSpace(req.Input),
+		allowedKinds: fmt.Sprint(req.AllowedKinds),
+		allowedArgs:  fmt.Sprint(req.AllowedArgs),
+		fuzzy:        req.Fuzzy,
+	}
+}
+
+// CachingSuggester is a Suggester that caches the results of another Suggester for a short amount of
+// time, since autocomplete requests for the same input are often repeated as the user types. Cached
+// results are not invalidated when a cluster's metadata changes, and only expire once their TTL has
+// passed, so the TTL bounds how stale the suggestions can be.
+type CachingSuggester struct {
+	suggester Suggester
+	ttl       time.Duration
+
+	mu      sync.Mutex
+	entries map[suggestionCacheKey]*suggestionCacheEntry
+}
+
+// NewCachingSuggester creates a CachingSuggester which caches the results of the given Suggester for ttl.
+func NewCachingSuggester(suggester Suggester, ttl time.Duration) *CachingSuggester {
+	return &CachingSuggester{
+		suggester: suggester,
+		ttl:       ttl,
+		entries:   make(map[suggestionCacheKey]*suggestionCacheEntry),
+	}
+}
+
+// GetSuggestions returns the cached results for the requests, and only fetches the results which
+// are not cached from the underlying Suggester.
+func (c *CachingSuggester) GetSuggestions(reqs []*SuggestionRequest) ([]*SuggestionResult, error) {
+	now := time.Now()
+	results := make([]*SuggestionResult, len(reqs))
+	keys := make([]suggestionCacheKey, len(reqs))
+
+	c.mu.Lock()
+	c.evictExpired(now)
+	var missedReqs []*SuggestionRequest
+	var missedIdxs []int
+	for i, r := range reqs {
+		keys[i] = makeSuggestionCacheKey(r)
+		if e, ok := c.entries[keys[i]]; ok {
+			suggestionCacheLookups.WithLabelValues("hit").Inc()
+			// Callers may modify the results, such as by re-sorting them, so each hit gets its own copy.
+			results[i] = copySuggestionResult(e.result)
+			continue
+		}
+		suggestionCacheLookups.WithLabelValues("miss").Inc()
+		missedReqs = append(missedReqs, r)
+		missedIdxs = append(missedIdxs, i)
+	}
+	c.mu.Unlock()
+
+	if len(missedReqs) == 0 {
+		return results, nil
+	}
+
+	missedResults, err := c.suggester.GetSuggestions(missedReqs)
+	if err != nil {
+		return nil, err
+	}
+	if len(missedResults) != len(missedReqs) {
+		return nil, errors.New("suggester returned the wrong number of results")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, res := range missedResults {
+		idx := missedIdxs[i]
+		results[idx] = res
+		c.entries[keys[idx]] = &suggestionCacheEntry{
+			result:    copySuggestionResult(res),
+			expiresAt: now.Add(c.ttl),
+		}
+	}
+	return results, nil
+}
+
+// StreamSuggestions streams the suggestions from the underlying Suggester, if it can stream them. Streamed
+// suggestions bypass the cache, since a stream which is cancelled part way through has no complete result to
+// cache. If the underlying Suggester can't stream, the suggestions are fetched through the cache and sent in a
+// single batch.
+func (c *CachingSuggester) StreamSuggestions(ctx context.Context, req *SuggestionRequest, send func([]*Suggestion) error) error {
+	if s, ok := c.suggester.(StreamingSuggester); ok {
+		return s.StreamSuggestions(ctx, req, send)
+	}
+
+	results, err := c.GetSuggestions([]*SuggestionRequest{req})
+	if err != nil {
+		return err
+	}
+	if len(results) != 1 {
+		return errors.New("suggester returned the wrong number of results")
+	}
+	return send(results[0].Suggestions)
+}
+
+// copySuggestionResult makes a deep copy of the given result, so that the cached results aren't shared with callers.
+func copySuggestionResult(res *SuggestionResult) *SuggestionResult {
+	if res == nil {
+		return nil
+	}
+	suggestions := make([]*Suggestion, len(res.Suggestions))
+	for i, s := range res.Suggestions {
+		suggestions[i] = copySuggestion(s)
+	}
+	return &SuggestionResult{
+		Suggestions: suggestions,
+		ExactMatch:  res.ExactMatch,
+		TotalCount:  res.TotalCount,
+	}
+}
+
+func copySuggestion(s *Suggestion) *Suggestion {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.ArgNames = append([]string(nil), s.ArgNames...)
+	c.ArgKinds = append([]cloudpb.AutocompleteEntityKind(nil), s.ArgKinds...)
+	c.MatchedIndexes = append([]int64(nil), s.MatchedIndexes...)
+	return &c
+}
+
+// evictExpired removes the expired entries from the cache. The lock must be held by the caller.
+func (c *CachingSuggester) evictExpired(now time.Time) {
+	for k, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package autocomplete_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"px.dev/pixie/src/api/proto/cloudpb"
+	"px.dev/pixie/src/cloud/autocomplete"
+	mock_autocomplete "px.dev/pixie/src/cloud/autocomplete/mock"
+)
+
+func makeCacheTestRequest(input string, kind cloudpb.AutocompleteEntityKind) *autocomplete.SuggestionRequest {
+	return &autocomplete.SuggestionRequest{
+		OrgID:        orgID,
+		ClusterUID:   "test",
+		Input:        input,
+		AllowedKinds: []cloudpb.AutocompleteEntityKind{kind},
+		AllowedArgs:  []cloudpb.AutocompleteEntityKind{},
+	}
+}
+
+func TestCachingSuggester_RepeatRequestIsCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+	result := &autocomplete.SuggestionResult{
+		Suggestions: []*autocomplete.Suggestion{{Name: "pl/vizier-pem", Kind: cloudpb.AEK_POD}},
+	}
+	s.EXPECT().
+		GetSuggestions([]*autocomplete.SuggestionRequest{makeCacheTestRequest("pl/viz", cloudpb.AEK_POD)}).
+		Return([]*autocomplete.SuggestionResult{result}, nil).
+		Times(1)
+
+	cs := autocomplete.NewCachingSuggester(s, time.Minute)
+	for i := 0; i < 3; i++ {
+		res, err := cs.GetSuggestions([]*autocomplete.SuggestionRequest{makeCacheTestRequest("pl/viz", cloudpb.AEK_POD)})
+		require.NoError(t, err)
+		assert.Equal(t, []*autocomplete.SuggestionResult{result}, res)
+	}
+}
+
+func TestCachingSuggester_ResultsAreCopied(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+	result := &autocomplete.SuggestionResult{
+		Suggestions: []*autocomplete.Suggestion{
+			{Name: "pl/vizier-pem", Kind: cloudpb.AEK_POD, Score: 1},
+			{Name: "pl/vizier-kelvin", Kind: cloudpb.AEK_POD, Score: 0.5},
+		},
+	}
+	s.EXPECT().
+		GetSuggestions([]*autocomplete.SuggestionRequest{makeCacheTestRequest("pl/viz", cloudpb.AEK_POD)}).
+		Return([]*autocomplete.SuggestionResult{result}, nil).
+		Times(1)
+
+	cs := autocomplete.NewCachingSuggester(s, time.Minute)
+	for i := 0; i < 3; i++ {
+		res, err := cs.GetSuggestions([]*autocomplete.SuggestionRequest{makeCacheTestRequest("pl/viz", cloudpb.AEK_POD)})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Len(t, res[0].Suggestions, 2)
+		assert.Equal(t, "pl/vizier-pem", res[0].Suggestions[0].Name)
+		assert.Equal(t, 1.0, res[0].Suggestions[0].Score)
+
+		// Modifying the results shouldn't affect the cached results.
+		res[0].Suggestions[0], res[0].Suggestions[1] = res[0].Suggestions[1], res[0].Suggestions[0]
+		res[0].Suggestions[0].Score += 10
+	}
+}
+
+func TestCachingSuggester_KeyIncludesAllowedKinds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+	podResult := &autocomplete.SuggestionResult{
+		Suggestions: []*autocomplete.Suggestion{{Name: "pl/vizier-pem", Kind: cloudpb.AEK_POD}},
+	}
+	svcResult := &autocomplete.SuggestionResult{
+		Suggestions: []*autocomplete.Suggestion{{Name: "pl/vizier-proxy-service", Kind: cloudpb.AEK_SVC}},
+	}
+	s.EXPECT().
+		GetSuggestions([]*autocomplete.SuggestionRequest{makeCacheTestRequest("pl/viz", cloudpb.AEK_POD)}).
+		Return([]*autocomplete.SuggestionResult{podResult}, nil)
+	s.EXPECT().
+		GetSuggestions([]*autocomplete.SuggestionRequest{makeCacheTestRequest("pl/viz", cloudpb.AEK_SVC)}).
+		Return([]*autocomplete.SuggestionResult{svcResult}, nil)
+
+	cs := autocomplete.NewCachingSuggester(s, time.Minute)
+	res, err := cs.GetSuggestions([]*autocomplete.SuggestionRequest{makeCacheTestRequest("pl/viz", cloudpb.AEK_POD)})
+	require.NoError(t, err)
+	assert.Equal(t, []*autocomplete.SuggestionResult{podResult}, res)
+
+	res, err = cs.GetSuggestions([]*autocomplete.SuggestionRequest{makeCacheTestRequest("pl/viz", cloudpb.AEK_SVC)})
+	require.NoError(t, err)
+	assert.Equal(t, []*autocomplete.SuggestionResult{svcResult}, res)
+}
+
+func TestCachingSuggester_OnlyFetchesMisses(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+	cachedResult := &autocomplete.SuggestionResult{ExactMatch: true}
+	missedResult := &autocomplete.SuggestionResult{}
+	s.EXPECT().
+		GetSuggestions([]*autocomplete.SuggestionRequest{makeCacheTestRequest("px/svc_info", cloudpb.AEK_SCRIPT)}).
+		Return([]*autocomplete.SuggestionResult{cachedResult}, nil)
+	s.EXPECT().
+		GetSuggestions([]*autocomplete.SuggestionRequest{makeCacheTestRequest("pl/", cloudpb.AEK_SVC)}).
+		Return([]*autocomplete.SuggestionResult{missedResult}, nil)
+
+	cs := autocomplete.NewCachingSuggester(s, time.Minute)
+	_, err := cs.GetSuggestions([]*autocomplete.SuggestionRequest{makeCacheTestRequest("px/svc_info", cloudpb.AEK_SCRIPT)})
+	require.NoError(t, err)
+
+	res, err := cs.GetSuggestions([]*autocomplete.SuggestionRequest{
+		makeCacheTestRequest("px/svc_info", cloudpb.AEK_SCRIPT),
+		makeCacheTestRequest("pl/", cloudpb.AEK_SVC),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*autocomplete.SuggestionResult{cachedResult, missedResult}, res)
+}
+
+func TestCachingSuggester_Expiry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+	s.EXPECT().
+		GetSuggestions([]*autocomplete.SuggestionRequest{makeCacheTestRequest("pl/viz", cloudpb.AEK_POD)}).
+		Return([]*autocomplete.SuggestionResult{{}}, nil).
+		Times(2)
+
+	cs := autocomplete.NewCachingSuggester(s, 10*time.Millisecond)
+	_, err := cs.GetSuggestions([]*autocomplete.SuggestionRequest{makeCacheTestRequest("pl/viz", cloudpb.AEK_POD)})
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = cs.GetSuggestions([]*autocomplete.SuggestionRequest{makeCacheTestRequest("pl/viz", cloudpb.AEK_POD)})
+	require.NoError(t, err)
+}
+
+func TestCachingSuggester_StreamSuggestions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	req := makeCacheTestRequest("pl/viz", cloudpb.AEK_POD)
+	s := mock_autocomplete.NewMockStreamingSuggester(ctrl)
+	// Streamed suggestions aren't cached, so each stream is passed through to the underlying suggester.
+	s.EXPECT().
+		StreamSuggestions(gomock.Any(), req, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req *autocomplete.SuggestionRequest, send func([]*autocomplete.Suggestion) error) error {
+			if err := send([]*autocomplete.Suggestion{{Name: "pl/vizier-pem"}}); err != nil {
+				return err
+			}
+			return send([]*autocomplete.Suggestion{{Name: "pl/vizier-query-broker"}})
+		}).
+		Times(2)
+
+	cs := autocomplete.NewCachingSuggester(s, time.Minute)
+	for i := 0; i < 2; i++ {
+		var batches [][]*autocomplete.Suggestion
+		err := cs.StreamSuggestions(context.Background(), req, func(b []*autocomplete.Suggestion) error {
+			batches = append(batches, b)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, len(batches))
+		assert.Equal(t, "pl/vizier-pem", batches[0][0].Name)
+		assert.Equal(t, "pl/vizier-query-broker", batches[1][0].Name)
+	}
+}
+
+func TestCachingSuggester_StreamSuggestionsNonStreamingSuggester(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	req := makeCacheTestRequest("pl/viz", cloudpb.AEK_POD)
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+	s.EXPECT().
+		GetSuggestions([]*autocomplete.SuggestionRequest{req}).
+		Return([]*autocomplete.SuggestionResult{{
+			Suggestions: []*autocomplete.Suggestion{{Name: "pl/vizier-pem"}, {Name: "pl/vizier-query-broker"}},
+		}}, nil).
+		Times(1)
+
+	// The suggestions are fetched through the cache, and sent in a single batch.
+	cs := autocomplete.NewCachingSuggester(s, time.Minute)
+	for i := 0; i < 2; i++ {
+		var batches [][]*autocomplete.Suggestion
+		err := cs.StreamSuggestions(context.Background(), req, func(b []*autocomplete.Suggestion) error {
+			batches = append(batches, b)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(batches))
+		assert.Equal(t, 2, len(batches[0]))
+	}
+}
+
+// cacheLookups returns the number of cache lookups with the given result that have been counted.
+func cacheLookups(t *testing.T, result string) float64 {
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, mf := range mfs {
+		if mf.GetName() != "autocomplete_suggestion_cache_lookups_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "result" && l.GetValue() == result {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestCachingSuggester_Metrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := mock_autocomplete.NewMockSuggester(ctrl)
+	s.EXPECT().
+		GetSuggestions([]*autocomplete.SuggestionRequest{makeCacheTestRequest("pl/viz", cloudpb.AEK_POD)}).
+		Return([]*autocomplete.SuggestionResult{{}}, nil)
+
+	hitsBefore := cacheLookups(t, "hit")
+	missesBefore := cacheLookups(t, "miss")
+
+	cs := autocomplete.NewCachingSuggester(s, time.Minute)
+	for i := 0; i < 3; i++ {
+		_, err := cs.GetSuggestions([]*autocomplete.SuggestionRequest{makeCacheTestRequest("pl/viz", cloudpb.AEK_POD)})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, hitsBefore+2, cacheLookups(t, "hit"))
+	assert.Equal(t, missesBefore+1, cacheLookups(t, "miss"))
+}
@@ -21,6 +21,7 @@ package autocomplete
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"strings"
 
 	"github.com/gofrs/uuid"
@@ -81,12 +82,16 @@ type SuggestionRequest struct {
 	Input        string
 	AllowedKinds []cloudpb.AutocompleteEntityKind
 	AllowedArgs  []cloudpb.AutocompleteEntityKind
+	// Fuzzy also returns suggestions which match the input within a small number of edits.
+	Fuzzy bool
 }
 
 // SuggestionResult contains results for an autocomplete request.
 type SuggestionResult struct {
 	Suggestions []*Suggestion
 	ExactMatch  bool
+	// TotalCount is the total number of matches, which may be more than the number of suggestions.
+	TotalCount int64
 }
 
 func parseHighlightIndexes(highlightStr string, offset int) []int64 {
@@ -130,15 +135,8 @@ func (e *ElasticSuggester) GetSuggestions(reqs []*SuggestionRequest) ([]*Suggest
 	}
 
 	ms := e.client.MultiSearch()
-
-	highlight := elastic.NewHighlight()
-	highlight = highlight.Fields(elastic.NewHighlighterField("*"))
-
 	for _, r := range reqs {
-		ms.Add(elastic.NewSearchRequest().
-			Highlight(highlight).
-			Query(e.getQueryForRequest(r.OrgID, r.ClusterUID, r.Input, r.AllowedKinds, r.AllowedArgs)).
-			Size(5).FetchSourceIncludeExclude([]string{"kind", "name", "ns", "state"}, []string{}))
+		ms.Add(e.entitySearchRequest(r))
 	}
 
 	resp, err := ms.Do(context.Background())
@@ -148,113 +146,17 @@ func (e *ElasticSuggester) GetSuggestions(reqs []*SuggestionRequest) ([]*Suggest
 	}
 
 	// Parse scripts to prepare for matching. This is temporary until we have script indexing.
-	scripts := []string{}
-	scriptArgMap := make(map[string][]cloudpb.AutocompleteEntityKind)
-	scriptArgNames := make(map[string][]string)
-	if br != nil {
-		for _, s := range br.GetScripts() {
-			scripts = append(scripts, s.ScriptName)
-			scriptArgMap[s.ScriptName] = make([]cloudpb.AutocompleteEntityKind, 0)
-			for _, a := range s.Vis.Variables {
-				aKind := cloudpb.AEK_UNKNOWN
-				if a.Type == vispb.PX_POD {
-					aKind = cloudpb.AEK_POD
-				} else if a.Type == vispb.PX_SERVICE {
-					aKind = cloudpb.AEK_SVC
-				}
-
-				if aKind != cloudpb.AEK_UNKNOWN {
-					scriptArgMap[s.ScriptName] = append(scriptArgMap[s.ScriptName], aKind)
-					scriptArgNames[s.ScriptName] = append(scriptArgNames[s.ScriptName], a.Name)
-				}
-			}
-		}
-	}
+	scripts := parseScripts(br)
 
 	for i, r := range resp.Responses {
 		// This is temporary until we index scripts in Elastic.
-		scriptResults := make([]*Suggestion, 0)
-		if br != nil {
-			for _, t := range reqs[i].AllowedKinds {
-				if t == cloudpb.AEK_SCRIPT { // Script is an allowed type for this tabstop, so we should find matching scripts.
-					matches := fuzzy.Find(reqs[i].Input, scripts)
-
-					if reqs[i].Input == "" { // The input is empty, so none of the scripts will match using the fuzzy search.
-						matches = make([]fuzzy.Match, len(scripts))
-						for i, s := range scripts {
-							matches[i] = fuzzy.Match{
-								Str:            s,
-								MatchedIndexes: make([]int, 0),
-							}
-						}
-					}
-					for _, m := range matches {
-						script := br.MustGetScript(m.Str)
-						scriptArgs := scriptArgMap[m.Str]
-						scriptNames := scriptArgNames[m.Str]
-						valid := true
-						if script.OrgID != reqs[i].OrgID.String() {
-							valid = false
-						}
-
-						for _, r := range reqs[i].AllowedArgs { // Check that the script takes the allowed args.
-							found := false
-							for _, arg := range scriptArgs {
-								if arg == r {
-									found = true
-									break
-								}
-							}
-							if !found {
-								valid = false
-								break
-							}
-						}
-						if valid {
-							matchedIdxs := make([]int64, len(m.MatchedIndexes))
-							for i, matched := range m.MatchedIndexes {
-								matchedIdxs[i] = int64(matched)
-							}
-							scriptResults = append(scriptResults, &Suggestion{
-								Name:           m.Str,
-								Kind:           cloudpb.AEK_SCRIPT,
-								Desc:           script.LongDoc,
-								ArgNames:       scriptNames,
-								ArgKinds:       scriptArgs,
-								MatchedIndexes: matchedIdxs,
-							})
-						}
-					}
-					break
-				}
-			}
-		}
+		scriptResults := scripts.suggestions(br, reqs[i])
 		exactMatch := len(scriptResults) > 0 && scriptResults[0].Name == reqs[i].Input
 
 		// Convert elastic entity into a suggestion object.
-		results := make([]*Suggestion, 0)
-		for _, h := range r.Hits.Hits {
-			res := &md.EsMDEntity{}
-			err = json.Unmarshal(h.Source, res)
-			if err != nil {
-				return nil, err
-			}
-
-			matchedIndexes := make([]int64, 0)
-			// Parse highlight string into indexes.
-			if len(h.Highlight["ns"]) > 0 {
-				matchedIndexes = append(matchedIndexes, parseHighlightIndexes(h.Highlight["ns"][0], 0)...)
-			}
-			if len(h.Highlight["name"]) > 0 {
-				matchedIndexes = append(matchedIndexes, parseHighlightIndexes(h.Highlight["name"][0], len(res.NS)+1)...)
-			}
-			results = append(results, &Suggestion{
-				Name:           res.NS + "/" + res.Name,
-				Score:          float64(*h.Score),
-				Kind:           elasticLabelToProtoMap[res.Kind],
-				MatchedIndexes: matchedIndexes,
-				State:          elasticStateToProtoMap[res.State],
-			})
+		results, err := entitySuggestions(r.Hits.Hits, reqs[i])
+		if err != nil {
+			return nil, err
 		}
 
 		for _, r := range results {
@@ -265,42 +167,304 @@ func (e *ElasticSuggester) GetSuggestions(reqs []*SuggestionRequest) ([]*Suggest
 			exactMatch = exactMatch || r.Name == reqs[i].Input
 		}
 
+		totalCount := int64(len(scriptResults) + len(r.Hits.Hits))
+		if r.Hits.TotalHits != nil {
+			totalCount = int64(len(scriptResults)) + r.Hits.TotalHits.Value
+		}
+
 		results = append(scriptResults, results...)
 
 		resps[i] = &SuggestionResult{
 			Suggestions: results,
 			ExactMatch:  exactMatch,
+			TotalCount:  totalCount,
 		}
 	}
 	return resps, nil
 }
 
-func (e *ElasticSuggester) getQueryForRequest(orgID uuid.UUID, clusterUID string, input string, allowedKinds []cloudpb.AutocompleteEntityKind, allowedArgs []cloudpb.AutocompleteEntityKind) *elastic.BoolQuery {
+// StreamSuggestions sends the suggestions for the request in the same order as GetSuggestions. The script
+// suggestions are matched locally, so they are sent first, without waiting for the entities to be found in Elastic.
+func (e *ElasticSuggester) StreamSuggestions(ctx context.Context, req *SuggestionRequest, send func([]*Suggestion) error) error {
+	br := e.br
+
+	scriptResults := parseScripts(br).suggestions(br, req)
+	if len(scriptResults) > 0 {
+		if err := send(scriptResults); err != nil {
+			return err
+		}
+	}
+
+	resp, err := e.client.MultiSearch().Add(e.entitySearchRequest(req)).Do(ctx)
+	if err != nil {
+		return err
+	}
+	if len(resp.Responses) != 1 {
+		return errors.New("elastic returned the wrong number of responses")
+	}
+	results, err := entitySuggestions(resp.Responses[0].Hits.Hits, req)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	return send(results)
+}
+
+// entitySearchRequest returns the request that searches Elastic for the md entities matching the request.
+func (e *ElasticSuggester) entitySearchRequest(r *SuggestionRequest) *elastic.SearchRequest {
+	highlight := elastic.NewHighlight()
+	highlight = highlight.Fields(elastic.NewHighlighterField("*"))
+
+	return elastic.NewSearchRequest().
+		Index(md.IndexName).
+		Highlight(highlight).
+		Query(e.getQueryForRequest(r.OrgID, r.ClusterUID, r.Input, r.AllowedKinds, r.AllowedArgs, r.Fuzzy)).
+		Size(5).FetchSourceIncludeExclude([]string{"kind", "name", "ns", "state"}, []string{})
+}
+
+// entitySuggestions converts the md entities found in Elastic into suggestions.
+func entitySuggestions(hits []*elastic.SearchHit, req *SuggestionRequest) ([]*Suggestion, error) {
+	results := make([]*Suggestion, 0)
+	for _, h := range hits {
+		res := &md.EsMDEntity{}
+		err := json.Unmarshal(h.Source, res)
+		if err != nil {
+			return nil, err
+		}
+
+		matchedIndexes := make([]int64, 0)
+		// Parse highlight string into indexes.
+		if len(h.Highlight["ns"]) > 0 {
+			matchedIndexes = append(matchedIndexes, parseHighlightIndexes(h.Highlight["ns"][0], 0)...)
+		}
+		if len(h.Highlight["name"]) > 0 {
+			matchedIndexes = append(matchedIndexes, parseHighlightIndexes(h.Highlight["name"][0], len(res.NS)+1)...)
+		}
+		results = append(results, &Suggestion{
+			Name:           res.NS + "/" + res.Name,
+			Score:          float64(*h.Score),
+			Kind:           elasticLabelToProtoMap[res.Kind],
+			MatchedIndexes: matchedIndexes,
+			State:          elasticStateToProtoMap[res.State],
+			Fuzzy:          req.Fuzzy && !matchedQuery(h, exactQueryName),
+		})
+	}
+	return results, nil
+}
+
+// parsedScripts holds the names and args of the scripts in a bundle, for matching against requests.
+type parsedScripts struct {
+	names    []string
+	argKinds map[string][]cloudpb.AutocompleteEntityKind
+	argNames map[string][]string
+}
+
+func parseScripts(br *script.BundleManager) *parsedScripts {
+	p := &parsedScripts{
+		names:    []string{},
+		argKinds: make(map[string][]cloudpb.AutocompleteEntityKind),
+		argNames: make(map[string][]string),
+	}
+	if br == nil {
+		return p
+	}
+	for _, s := range br.GetScripts() {
+		p.names = append(p.names, s.ScriptName)
+		p.argKinds[s.ScriptName] = make([]cloudpb.AutocompleteEntityKind, 0)
+		for _, a := range s.Vis.Variables {
+			aKind := cloudpb.AEK_UNKNOWN
+			if a.Type == vispb.PX_POD {
+				aKind = cloudpb.AEK_POD
+			} else if a.Type == vispb.PX_SERVICE {
+				aKind = cloudpb.AEK_SVC
+			}
+
+			if aKind != cloudpb.AEK_UNKNOWN {
+				p.argKinds[s.ScriptName] = append(p.argKinds[s.ScriptName], aKind)
+				p.argNames[s.ScriptName] = append(p.argNames[s.ScriptName], a.Name)
+			}
+		}
+	}
+	return p
+}
+
+// suggestions returns the scripts in the bundle which match the request, if scripts are allowed by the request.
+func (p *parsedScripts) suggestions(br *script.BundleManager, req *SuggestionRequest) []*Suggestion {
+	scriptResults := make([]*Suggestion, 0)
+	if br == nil {
+		return scriptResults
+	}
+	for _, t := range req.AllowedKinds {
+		if t != cloudpb.AEK_SCRIPT {
+			continue
+		}
+		// Script is an allowed type for this tabstop, so we should find matching scripts.
+		matches := fuzzy.Find(req.Input, p.names)
+
+		if req.Input == "" { // The input is empty, so none of the scripts will match using the fuzzy search.
+			matches = make([]fuzzy.Match, len(p.names))
+			for i, s := range p.names {
+				matches[i] = fuzzy.Match{
+					Str:            s,
+					MatchedIndexes: make([]int, 0),
+				}
+			}
+		}
+		fuzzyMatches := make(map[string]bool)
+		if req.Fuzzy && req.Input != "" {
+			matches, fuzzyMatches = appendFuzzyScriptMatches(matches, req.Input, p.names)
+		}
+		for _, m := range matches {
+			script := br.MustGetScript(m.Str)
+			scriptArgs := p.argKinds[m.Str]
+			scriptNames := p.argNames[m.Str]
+			valid := true
+			if script.OrgID != req.OrgID.String() {
+				valid = false
+			}
+
+			for _, r := range req.AllowedArgs { // Check that the script takes the allowed args.
+				found := false
+				for _, arg := range scriptArgs {
+					if arg == r {
+						found = true
+						break
+					}
+				}
+				if !found {
+					valid = false
+					break
+				}
+			}
+			if valid {
+				matchedIdxs := make([]int64, len(m.MatchedIndexes))
+				for i, matched := range m.MatchedIndexes {
+					matchedIdxs[i] = int64(matched)
+				}
+				scriptResults = append(scriptResults, &Suggestion{
+					Name:           m.Str,
+					Kind:           cloudpb.AEK_SCRIPT,
+					Desc:           script.LongDoc,
+					ArgNames:       scriptNames,
+					ArgKinds:       scriptArgs,
+					MatchedIndexes: matchedIdxs,
+					Fuzzy:          fuzzyMatches[m.Str],
+				})
+			}
+		}
+		break
+	}
+	return scriptResults
+}
+
+// exactQueryName is the name of the query which matches entities without allowing for edits, so
+// that fuzzy matches can be told apart from exact matches.
+const exactQueryName = "exact"
+
+func matchedQuery(h *elastic.SearchHit, name string) bool {
+	for _, q := range h.MatchedQueries {
+		if q == name {
+			return true
+		}
+	}
+	return false
+}
+
+// maxScriptEdits returns the number of edits allowed for a fuzzy match on the given input. This
+// mirrors the "AUTO" fuzziness used by Elastic, so that scripts and entities match consistently.
+func maxScriptEdits(input string) int {
+	switch n := len([]rune(input)); {
+	case n <= 2:
+		return 0
+	case n <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance returns the optimal string alignment distance between a and b, which counts
+// insertions, deletions, substitutions and transpositions of adjacent characters.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(minInt(d[i-1][j]+1, d[i][j-1]+1), d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// appendFuzzyScriptMatches appends the scripts which weren't matched by the input, but are within
+// a small number of edits of it. It returns the new matches, and the set of scripts which only
+// matched fuzzily.
+func appendFuzzyScriptMatches(matches []fuzzy.Match, input string, scripts []string) ([]fuzzy.Match, map[string]bool) {
+	matched := make(map[string]bool)
+	for _, m := range matches {
+		matched[m.Str] = true
+	}
+
+	fuzzyMatches := make(map[string]bool)
+	maxEdits := maxScriptEdits(input)
+	for _, s := range scripts {
+		if matched[s] || editDistance(input, s) > maxEdits {
+			continue
+		}
+		matches = append(matches, fuzzy.Match{
+			Str:            s,
+			MatchedIndexes: make([]int, 0),
+		})
+		fuzzyMatches[s] = true
+	}
+	return matches, fuzzyMatches
+}
+
+func (e *ElasticSuggester) getQueryForRequest(orgID uuid.UUID, clusterUID string, input string, allowedKinds []cloudpb.AutocompleteEntityKind, allowedArgs []cloudpb.AutocompleteEntityKind, fuzzy bool) *elastic.BoolQuery {
 	q := elastic.NewBoolQuery()
 
-	q.Should(e.getMDEntityQuery(orgID, clusterUID, input, allowedKinds))
+	q.Should(e.getMDEntityQuery(orgID, clusterUID, input, allowedKinds, fuzzy))
 
 	// Once script indexing is in, we should also query the scripts: q.Should(e.getScriptQuery(orgID, input, allowedArgs))
 	return q
 }
 
-func (e *ElasticSuggester) getMDEntityQuery(orgID uuid.UUID, clusterUID string, input string, allowedKinds []cloudpb.AutocompleteEntityKind) *elastic.BoolQuery {
+func (e *ElasticSuggester) getMDEntityQuery(orgID uuid.UUID, clusterUID string, input string, allowedKinds []cloudpb.AutocompleteEntityKind, fuzzy bool) *elastic.BoolQuery {
 	entityQuery := elastic.NewBoolQuery()
-	entityQuery.Must(elastic.NewTermQuery("_index", md.IndexName))
 
 	// Search by name + namespace.
-	splitInput := strings.Split(input, "/") // If contains "/", then everything preceding "/" is a namespace.
-	name := input
-	if len(splitInput) > 1 {
-		entityQuery.Must(elastic.NewMatchQuery("ns", splitInput[0]))
-		name = splitInput[1]
-
-		if name != "" {
-			entityQuery.Must(elastic.NewMatchQuery("name", name))
-		}
-	} else if name != "" {
-		nsOrNameQuery := elastic.NewMultiMatchQuery(name, "name", "ns")
-		entityQuery.Must(nsOrNameQuery)
+	if fuzzy && input != "" {
+		// Match entities within a small number of edits of the input. The exact query is named,
+		// so that suggestions which only matched fuzzily can be flagged.
+		entityQuery.Must(elastic.NewBoolQuery().
+			Should(getNameQuery(input, "").QueryName(exactQueryName)).
+			Should(getNameQuery(input, "AUTO")).
+			MinimumNumberShouldMatch(1))
+	} else {
+		entityQuery.Must(getNameQuery(input, ""))
 	}
 
 	// Only search for entities in org.
@@ -319,3 +483,35 @@ func (e *ElasticSuggester) getMDEntityQuery(orgID uuid.UUID, clusterUID string,
 
 	return entityQuery
 }
+
+// getNameQuery returns a query matching the input against the name and namespace of entities. If
+// fuzziness is non-empty, the input is matched within the given edit distance.
+func getNameQuery(input string, fuzziness string) *elastic.BoolQuery {
+	q := elastic.NewBoolQuery()
+
+	splitInput := strings.Split(input, "/") // If contains "/", then everything preceding "/" is a namespace.
+	name := input
+	if len(splitInput) > 1 {
+		nsQuery := elastic.NewMatchQuery("ns", splitInput[0])
+		if fuzziness != "" {
+			nsQuery.Fuzziness(fuzziness)
+		}
+		q.Must(nsQuery)
+		name = splitInput[1]
+
+		if name != "" {
+			nameQuery := elastic.NewMatchQuery("name", name)
+			if fuzziness != "" {
+				nameQuery.Fuzziness(fuzziness)
+			}
+			q.Must(nameQuery)
+		}
+	} else if name != "" {
+		nsOrNameQuery := elastic.NewMultiMatchQuery(name, "name", "ns")
+		if fuzziness != "" {
+			nsOrNameQuery.Fuzziness(fuzziness)
+		}
+		q.Must(nsOrNameQuery)
+	}
+	return q
+}
@@ -432,6 +432,56 @@ func TestGetSuggestions(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "one character typo",
+			reqs: []*autocomplete.SuggestionRequest{
+				{
+					Input: "xestService",
+					OrgID: org1,
+					AllowedKinds: []cloudpb.AutocompleteEntityKind{
+						cloudpb.AEK_SVC,
+					},
+					AllowedArgs: []cloudpb.AutocompleteEntityKind{},
+				},
+			},
+			expectedResults: []*autocomplete.SuggestionResult{
+				{
+					ExactMatch:  false,
+					Suggestions: []*autocomplete.Suggestion{},
+				},
+			},
+		},
+		{
+			name: "one character typo with fuzzy",
+			reqs: []*autocomplete.SuggestionRequest{
+				{
+					Input: "xestService",
+					OrgID: org1,
+					AllowedKinds: []cloudpb.AutocompleteEntityKind{
+						cloudpb.AEK_SVC,
+					},
+					AllowedArgs: []cloudpb.AutocompleteEntityKind{},
+					Fuzzy:       true,
+				},
+			},
+			expectedResults: []*autocomplete.SuggestionResult{
+				{
+					ExactMatch: false,
+					Suggestions: []*autocomplete.Suggestion{
+						{
+							Name:  "pl/testService",
+							Kind:  cloudpb.AEK_SVC,
+							Fuzzy: true,
+						},
+						{
+							Name:  "anotherNS/testService",
+							Kind:  cloudpb.AEK_SVC,
+							Fuzzy: true,
+						},
+					},
+				},
+			},
+		},
 		{
 			name:            "empty req",
 			reqs:            []*autocomplete.SuggestionRequest{},
@@ -454,7 +504,60 @@ func TestGetSuggestions(t *testing.T) {
 				}
 				assert.ElementsMatch(t, test.expectedResults[i].Suggestions, r.Suggestions)
 				assert.Equal(t, test.expectedResults[i].ExactMatch, r.ExactMatch)
+				assert.GreaterOrEqual(t, r.TotalCount, int64(len(r.Suggestions)))
 			}
 		})
 	}
 }
+
+func TestGetSuggestions_MatchedIndexes(t *testing.T) {
+	es, _ := autocomplete.NewElasticSuggester(elasticClient, "scripts", nil)
+	results, err := es.GetSuggestions([]*autocomplete.SuggestionRequest{
+		{
+			Input: "pl/test",
+			OrgID: org1,
+			AllowedKinds: []cloudpb.AutocompleteEntityKind{
+				cloudpb.AEK_SVC,
+			},
+			AllowedArgs: []cloudpb.AutocompleteEntityKind{},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(results))
+	require.Equal(t, 1, len(results[0].Suggestions))
+
+	s := results[0].Suggestions[0]
+	assert.Equal(t, "pl/testService", s.Name)
+	// The namespace and the prefix of the name should be highlighted, but not the "/" separator.
+	assert.Equal(t, []int64{0, 1, 3, 4, 5, 6}, s.MatchedIndexes)
+	highlighted := ""
+	for _, idx := range s.MatchedIndexes {
+		highlighted += string(s.Name[idx])
+	}
+	assert.Equal(t, "pltest", highlighted)
+}
+
+func TestStreamSuggestions(t *testing.T) {
+	es, err := autocomplete.NewElasticSuggester(elasticClient, "scripts", nil)
+	require.NoError(t, err)
+	// The API server streams suggestions through the cache.
+	s := autocomplete.NewCachingSuggester(es, autocomplete.DefaultSuggestionCacheTTL)
+
+	var batches [][]*autocomplete.Suggestion
+	err = s.StreamSuggestions(context.Background(), &autocomplete.SuggestionRequest{
+		Input: "pl/test",
+		OrgID: org1,
+		AllowedKinds: []cloudpb.AutocompleteEntityKind{
+			cloudpb.AEK_SVC,
+		},
+		AllowedArgs: []cloudpb.AutocompleteEntityKind{},
+	}, func(b []*autocomplete.Suggestion) error {
+		batches = append(batches, b)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(batches))
+	require.Equal(t, 1, len(batches[0]))
+	assert.Equal(t, "pl/testService", batches[0][0].Name)
+	assert.Equal(t, cloudpb.AEK_SVC, batches[0][0].Kind)
+}
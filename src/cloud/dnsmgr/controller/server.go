@@ -47,6 +47,7 @@ type Server struct {
 type SSLCert struct {
 	CName     string     `db:"cname"`
 	ClusterID *uuid.UUID `db:"cluster_id"`
+	Service   string     `db:"service"`
 	Cert      string     `db:"cert"`
 	Key       string     `db:"key"`
 }
@@ -56,13 +57,13 @@ func NewServer(env dnsmgrenv.DNSMgrEnv, dnsService DNSService, db *sqlx.DB) *Ser
 	return &Server{env: env, dnsService: dnsService, db: db}
 }
 
-func (s *Server) createSSLCert(clusterID uuid.UUID) (*SSLCert, error) {
-	query := `UPDATE ssl_certs SET cluster_id=$1
+func (s *Server) createSSLCert(clusterID uuid.UUID, service string) (*SSLCert, error) {
+	query := `UPDATE ssl_certs SET cluster_id=$1, service=$2
 		WHERE cname =(SELECT cname FROM ssl_certs WHERE cluster_id IS NULL ORDER BY cname LIMIT 1) RETURNING *`
 
 	var val SSLCert
 
-	rows, err := s.db.Queryx(query, clusterID)
+	rows, err := s.db.Queryx(query, clusterID, service)
 	if err != nil {
 		return nil, err
 	}
@@ -78,11 +79,11 @@ func (s *Server) createSSLCert(clusterID uuid.UUID) (*SSLCert, error) {
 	return nil, errors.New("Could not read ssl_cert")
 }
 
-func (s *Server) getClusterSSLCert(clusterID uuid.UUID) (*SSLCert, error) {
-	query := `SELECT * from ssl_certs WHERE cluster_id=$1`
+func (s *Server) getClusterSSLCert(clusterID uuid.UUID, service string) (*SSLCert, error) {
+	query := `SELECT * from ssl_certs WHERE cluster_id=$1 AND service=$2`
 	var val SSLCert
 
-	rows, err := s.db.Queryx(query, clusterID)
+	rows, err := s.db.Queryx(query, clusterID, service)
 	if err != nil {
 		return nil, err
 	}
@@ -184,7 +185,8 @@ func (s *Server) GetDNSAddress(ctx context.Context, req *dnsmgrpb.GetDNSAddressR
 	if useDefault {
 		cname = "default"
 	} else {
-		cert, err := s.getClusterSSLCert(clusterID)
+		// The DNS address points at the Vizier proxy, which uses the cert for the empty service.
+		cert, err := s.getClusterSSLCert(clusterID, "")
 		if err != nil {
 			return nil, err
 		}
@@ -243,7 +245,7 @@ func (s *Server) GetSSLCerts(ctx context.Context, req *dnsmgrpb.GetSSLCertsReque
 	if err != nil {
 		return nil, err
 	}
-	cert, err := s.getClusterSSLCert(clusterID)
+	cert, err := s.getClusterSSLCert(clusterID, req.Service)
 	if err != nil {
 		return nil, err
 	}
@@ -255,7 +257,7 @@ func (s *Server) GetSSLCerts(ctx context.Context, req *dnsmgrpb.GetSSLCertsReque
 		}, nil
 	}
 
-	cert, err = s.createSSLCert(clusterID)
+	cert, err = s.createSSLCert(clusterID, req.Service)
 	if err != nil {
 		return nil, err
 	}
@@ -212,6 +212,41 @@ func TestServer_CreateNewSSLCert(t *testing.T) {
 	assert.Equal(t, "key-befgh", resp.Key)
 }
 
+func TestServer_CreateNewSSLCertForService(t *testing.T) {
+	viper.Set("domain_name", "withpixie.ai")
+	viper.Set("use_default_dns_cert", false)
+	mustLoadTestData(db)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockDNS := mock_controller.NewMockDNSService(ctrl)
+
+	s := controller.NewServer(nil, mockDNS, db)
+
+	req := &dnsmgrpb.GetSSLCertsRequest{
+		ClusterID: utils.ProtoFromUUIDStrOrNil("123e4567-e89b-12d3-a456-426655440000"),
+		Service:   "kelvin",
+	}
+
+	// The cluster already has a cert for the proxy, but the service should get its own.
+	resp, err := s.GetSSLCerts(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "cert-befgh", resp.Cert)
+	assert.Equal(t, "key-befgh", resp.Key)
+
+	// Requesting the cert for the service again should return the same cert.
+	resp, err = s.GetSSLCerts(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "cert-befgh", resp.Cert)
+
+	// The proxy cert should be unchanged.
+	resp, err = s.GetSSLCerts(context.Background(), &dnsmgrpb.GetSSLCertsRequest{
+		ClusterID: utils.ProtoFromUUIDStrOrNil("123e4567-e89b-12d3-a456-426655440000"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "cert-abcd", resp.Cert)
+}
+
 func TestServer_CreateNewSSLCertDefault(t *testing.T) {
 	viper.Set("domain_name", "withpixie.ai")
 	viper.Set("use_default_dns_cert", true)
@@ -0,0 +1 @@
+ALTER TABLE ssl_certs DROP COLUMN IF EXISTS service;
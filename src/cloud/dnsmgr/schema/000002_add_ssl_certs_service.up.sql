@@ -0,0 +1,2 @@
+-- service is the service in the cluster that is using the cert. The empty service is the Vizier proxy.
+ALTER TABLE ssl_certs ADD COLUMN service varchar(100) NOT NULL DEFAULT '';
@@ -53,6 +53,7 @@ func init() {
 	pflag.String("es_passwd", "elastic", "The password for elastic")
 	pflag.String("vzmgr_service", "kubernetes:///vzmgr-service.plc:51800", "The profile service url (load balancer/list is ok)")
 	pflag.String("domain_name", "dev.withpixie.dev", "The domain name of Pixie Cloud")
+	pflag.String("md_name_analyzer", string(md.NameAnalyzerAutocomplete), "The analyzer for the name field of the md index: 'autocomplete' or 'keyword'. Changing this requires a reindex")
 }
 
 func newVZMgrClient() (vzmgrpb.VZMgrServiceClient, error) {
@@ -154,7 +155,7 @@ func main() {
 	})
 
 	es := mustConnectElastic()
-	err = md.InitializeMapping(es)
+	err = md.InitializeMapping(es, md.WithNameAnalyzer(md.NameAnalyzer(viper.GetString("md_name_analyzer"))))
 	if err != nil {
 		log.WithError(err).Fatal("Could not initialize elastic mapping")
 	}
@@ -0,0 +1,218 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package md
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+)
+
+// BackpressureMode determines what BulkIndex does when its queue is full because elastic can't keep up.
+type BackpressureMode int
+
+const (
+	// BackpressureBlock waits for room in the queue before queueing more documents.
+	BackpressureBlock BackpressureMode = iota
+	// BackpressureDropOldest drops the oldest queued document to make room for the new one.
+	BackpressureDropOldest
+)
+
+// ErrBulkIndexDropped is returned for documents which were dropped from a full queue.
+var ErrBulkIndexDropped = errors.New("document dropped because the bulk index queue was full")
+
+var droppedDocs int64
+
+// DroppedDocCount returns the total number of documents dropped by BulkIndex because its queue was full.
+func DroppedDocCount() int64 {
+	return atomic.LoadInt64(&droppedDocs)
+}
+
+// BulkIndexOptions configures how BulkIndex batches documents into elastic bulk requests.
+type BulkIndexOptions struct {
+	// BatchSize is the max number of documents sent in a single bulk request.
+	BatchSize int
+	// FlushInterval is the max amount of time a document is buffered before being sent.
+	FlushInterval time.Duration
+	// QueueSize is the max number of documents waiting to be added to the bulk processor.
+	// If 0, the queue is unbounded.
+	QueueSize int
+	// Backpressure determines what happens when the queue is full.
+	Backpressure BackpressureMode
+}
+
+// DefaultBulkIndexOptions returns the BulkIndexOptions used when none are specified.
+func DefaultBulkIndexOptions() *BulkIndexOptions {
+	return &BulkIndexOptions{
+		BatchSize:     500,
+		FlushInterval: time.Second,
+	}
+}
+
+// bulkUpsertScript replaces the stored entity with the given one, unless the given entity is stale, so that
+// entities indexed out of order don't overwrite newer data with stale data.
+const bulkUpsertScript = `
+def entity = params.doc;
+` + staleEntityCheck + `
+if (stale) {
+  ctx.op = 'noop';
+} else {
+  ctx._source.clear();
+  ctx._source.putAll(params.doc);
+}
+`
+
+// BulkIndex indexes the given entities using the elastic bulk API. Entities are keyed by their
+// vizier, cluster and UID, so indexing an entity that was already indexed replaces the existing
+// document, unless the existing document has the same or a newer ResourceVersion. The returned slice
+// contains an error for each entity that failed to index, at the same position as the entity,
+// and nil for the entities which were indexed successfully. Entities which were skipped because
+// the same or a newer version was already indexed get ErrStaleWrite. A failure to index one entity
+// does not prevent the others from being indexed. The second return value is only set if
+// the bulk processor could not be started, or the context was cancelled.
+func BulkIndex(ctx context.Context, es *elastic.Client, entities []*EsMDEntity, opts *BulkIndexOptions) ([]error, error) {
+	if opts == nil {
+		opts = DefaultBulkIndexOptions()
+	}
+
+	docErrs := make([]error, len(entities))
+	reqIdx := make(map[elastic.BulkableRequest]int, len(entities))
+	var mu sync.Mutex
+
+	after := func(_ int64, reqs []elastic.BulkableRequest, resp *elastic.BulkResponse, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		for i, r := range reqs {
+			idx, ok := reqIdx[r]
+			if !ok {
+				continue
+			}
+			if err != nil {
+				docErrs[idx] = err
+				continue
+			}
+			if resp == nil || i >= len(resp.Items) {
+				continue
+			}
+			for _, item := range resp.Items[i] {
+				if item.Error != nil {
+					docErrs[idx] = fmt.Errorf("failed to index entity '%s': %s: %s", item.Id, item.Error.Type, item.Error.Reason)
+				} else if item.Result == "noop" {
+					docErrs[idx] = ErrStaleWrite
+				}
+			}
+		}
+	}
+
+	p, err := es.BulkProcessor().
+		Name("md-bulk-index").
+		Workers(1).
+		BulkActions(opts.BatchSize).
+		FlushInterval(opts.FlushInterval).
+		After(after).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	add := p.Add
+	var queue chan elastic.BulkableRequest
+	var drained chan struct{}
+	if opts.QueueSize > 0 {
+		queue = make(chan elastic.BulkableRequest, opts.QueueSize)
+		drained = make(chan struct{})
+		go func() {
+			defer close(drained)
+			for r := range queue {
+				p.Add(r)
+			}
+		}()
+		add = func(r elastic.BulkableRequest) {
+			enqueue(ctx, queue, r, opts.Backpressure, func(dropped elastic.BulkableRequest) {
+				atomic.AddInt64(&droppedDocs, 1)
+				mu.Lock()
+				defer mu.Unlock()
+				docErrs[reqIdx[dropped]] = ErrBulkIndexDropped
+			})
+		}
+	}
+
+	for i, e := range entities {
+		if ctx.Err() != nil {
+			break
+		}
+		r := elastic.NewBulkUpdateRequest().
+			Index(IndexName).
+			Id(entityDocID(e)).
+			Script(elastic.NewScript(bulkUpsertScript).Param("doc", e).Lang("painless")).
+			Upsert(e).
+			RetryOnConflict(3)
+		mu.Lock()
+		reqIdx[r] = i
+		mu.Unlock()
+		add(r)
+	}
+
+	if queue != nil {
+		close(queue)
+		<-drained
+	}
+
+	// Close flushes any outstanding requests before returning.
+	err = p.Close()
+	if ctx.Err() != nil {
+		return docErrs, ctx.Err()
+	}
+	if err != nil {
+		return docErrs, err
+	}
+	return docErrs, nil
+}
+
+// enqueue adds the request to the queue, applying the given backpressure mode if the queue is full.
+// It gives up if the context is cancelled while waiting for room in the queue.
+func enqueue(ctx context.Context, queue chan elastic.BulkableRequest, r elastic.BulkableRequest, mode BackpressureMode, onDrop func(elastic.BulkableRequest)) {
+	if mode == BackpressureBlock {
+		select {
+		case queue <- r:
+		case <-ctx.Done():
+		}
+		return
+	}
+
+	for {
+		select {
+		case queue <- r:
+			return
+		default:
+		}
+		// The queue is full, so make room by dropping the oldest request. The queue may have been
+		// drained in the meantime, in which case there's nothing to drop.
+		select {
+		case dropped := <-queue:
+			onDrop(dropped)
+		default:
+		}
+	}
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+package md
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+	"github.com/olivere/elastic/v7"
+)
+
+// CountEntities returns the number of entities indexed for the given cluster in the org. If clusterUID is
+// empty, the entities indexed for all of the org's clusters are counted.
+func CountEntities(ctx context.Context, es *elastic.Client, orgID uuid.UUID, clusterUID string) (int64, error) {
+	q := elastic.NewBoolQuery().
+		Filter(elastic.NewTermQuery("orgID", orgID.String()))
+	if clusterUID != "" {
+		q.Filter(elastic.NewTermQuery("clusterUID", clusterUID))
+	}
+
+	return es.Count(IndexName).
+		Query(q).
+		Do(ctx)
+}
+
+// OrgIndexStats are the indexing stats for a single org.
+type OrgIndexStats struct {
+	// NumEntities is the number of entities indexed for the org.
+	NumEntities int64
+	// ApproxStorageBytes approximates the storage used by the org's entities, excluding replicas.
+	// Elastic doesn't track storage per document, so this is the org's share of the index's
+	// storage, in proportion to its share of the documents.
+	ApproxStorageBytes int64
+}
+
+// GetOrgIndexStats returns the indexing stats for all of the org's clusters.
+func GetOrgIndexStats(ctx context.Context, es *elastic.Client, orgID uuid.UUID) (*OrgIndexStats, error) {
+	count, err := CountEntities(ctx, es, orgID, "")
+	if err != nil {
+		return nil, err
+	}
+	stats := &OrgIndexStats{NumEntities: count}
+	if count == 0 {
+		return stats, nil
+	}
+
+	resp, err := es.IndexStats(IndexName).Metric("docs", "store").Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if resp.All == nil || resp.All.Primaries == nil || resp.All.Primaries.Docs == nil || resp.All.Primaries.Store == nil {
+		return stats, nil
+	}
+	totalDocs := resp.All.Primaries.Docs.Count
+	if totalDocs == 0 {
+		return stats, nil
+	}
+	stats.ApproxStorageBytes = int64(float64(resp.All.Primaries.Store.SizeInBytes) * float64(count) / float64(totalDocs))
+	return stats, nil
+}
+
+// NamespaceCount is the number of entities indexed in a namespace.
+type NamespaceCount struct {
+	Namespace   string
+	NumEntities int64
+}
+
+// GetNamespaces returns up to limit of the namespaces that the org has entities indexed in, along
+// with the number of entities in each. Namespaces with the most entities are returned first.
+func GetNamespaces(ctx context.Context, es *elastic.Client, orgID uuid.UUID, limit int) ([]*NamespaceCount, error) {
+	q := elastic.NewBoolQuery().
+		Filter(elastic.NewTermQuery("orgID", orgID.String())).
+		// Entities which aren't namespaced, such as nodes, have an empty namespace.
+		MustNot(elastic.NewTermQuery("ns.keyword", ""))
+
+	resp, err := es.Search().
+		Index(IndexName).
+		Query(q).
+		Size(0).
+		Aggregation("namespaces", elastic.NewTermsAggregation().Field("ns.keyword").Size(limit)).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make([]*NamespaceCount, 0)
+	terms, ok := resp.Aggregations.Terms("namespaces")
+	if !ok {
+		return namespaces, nil
+	}
+	for _, b := range terms.Buckets {
+		ns, ok := b.Key.(string)
+		if !ok {
+			continue
+		}
+		namespaces = append(namespaces, &NamespaceCount{
+			Namespace:   ns,
+			NumEntities: b.DocCount,
+		})
+	}
+	return namespaces, nil
+}
@@ -20,6 +20,11 @@ package md
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
 
 	"github.com/olivere/elastic/v7"
 )
@@ -49,6 +54,9 @@ type EsMDEntity struct {
 	Name       string `json:"name"`
 	NS         string `json:"ns"`
 	Kind       string `json:"kind"`
+	// PodIP and NodeName are only set for pods, once they have been scheduled.
+	PodIP    string `json:"podIP,omitempty"`
+	NodeName string `json:"nodeName,omitempty"`
 
 	TimeStartedNS int64 `json:"timeStartedNS"`
 	TimeStoppedNS int64 `json:"timeStoppedNS"`
@@ -56,11 +64,15 @@ type EsMDEntity struct {
 	RelatedEntityNames []string `json:"relatedEntityNames"`
 
 	UpdateVersion int64 `json:"updateVersion"`
+	// ResourceVersion is the K8s resourceVersion of the object the entity was indexed from, or 0 if it isn't known.
+	ResourceVersion int64 `json:"resourceVersion"`
 
 	State ESMDEntityState `json:"state"`
 }
 
-// IndexMapping is the index structure for metadata entities.
+// IndexMapping is the index structure for metadata entities. The name, ns and relatedEntityNames
+// fields have a keyword sub-field, which is used for exact matches. Since the mapping of an existing index can't be
+// changed, changes to this mapping only apply to indices created after a reindex, see IndexVersion.
 const IndexMapping = `
 {
   "settings": {
@@ -143,17 +155,33 @@ const IndexMapping = `
       "name": {
         "type": "text",
         "analyzer": "autocomplete",
-        "eager_global_ordinals": true
+        "eager_global_ordinals": true,
+        "fields": {
+          "keyword": {
+            "type": "keyword"
+          }
+        }
       },
       "ns": {
         "type": "text",
         "analyzer": "autocomplete",
-        "eager_global_ordinals": true
+        "eager_global_ordinals": true,
+        "fields": {
+          "keyword": {
+            "type": "keyword"
+          }
+        }
       },
       "kind": {
-        "type": "text",
+        "type": "keyword",
         "eager_global_ordinals": true
       },
+      "podIP": {
+        "type": "keyword"
+      },
+      "nodeName": {
+        "type": "keyword"
+      },
       "timeStartedNS": {
         "type": "long"
       },
@@ -161,11 +189,19 @@ const IndexMapping = `
         "type": "long"
       },
       "relatedEntityNames": {
-        "type": "text"
+        "type": "text",
+        "fields": {
+          "keyword": {
+            "type": "keyword"
+          }
+        }
       },
       "updateVersion": {
         "type": "long"
       },
+      "resourceVersion": {
+        "type": "long"
+      },
       "state": {
         "type": "integer"
       }
@@ -174,20 +210,293 @@ const IndexMapping = `
 }
 `
 
-// IndexName is the name of the ES index.
-// This can be incremented when we have breaking changes,
-// and are willing to lose data in the old index.
-const IndexName = "md_entities_5"
+// NameAnalyzer selects how the name field of the md index is analyzed.
+type NameAnalyzer string
+
+const (
+	// NameAnalyzerAutocomplete analyzes names with the edge-ngram "autocomplete" analyzer, which
+	// supports prefix matches as the user types at the cost of a much larger index.
+	NameAnalyzerAutocomplete NameAnalyzer = "autocomplete"
+	// NameAnalyzerKeyword indexes names as plain text with a keyword sub-field, which keeps the
+	// index small but only matches whole terms.
+	NameAnalyzerKeyword NameAnalyzer = "keyword"
+)
+
+// keywordNameField is the mapping used for the name field by NameAnalyzerKeyword. It keeps the
+// "keyword" sub-field so that exact match queries on name.keyword work with either analyzer.
+var keywordNameField = map[string]interface{}{
+	"type":                  "text",
+	"eager_global_ordinals": true,
+	"fields": map[string]interface{}{
+		"keyword": map[string]interface{}{
+			"type": "keyword",
+		},
+	},
+}
+
+type mappingOptions struct {
+	nameAnalyzer NameAnalyzer
+}
+
+// MappingOption configures the index created by InitializeMapping and ReindexTo.
+type MappingOption func(o *mappingOptions)
+
+// WithNameAnalyzer sets the analyzer used for the name field. Defaults to NameAnalyzerAutocomplete.
+// The analyzer of an existing index can't be changed, so switching analyzers requires creating
+// a new index with ReindexTo.
+func WithNameAnalyzer(a NameAnalyzer) MappingOption {
+	return func(o *mappingOptions) {
+		o.nameAnalyzer = a
+	}
+}
+
+// indexMapping returns the index mapping for the given options.
+func indexMapping(opts ...MappingOption) (string, error) {
+	o := &mappingOptions{
+		nameAnalyzer: NameAnalyzerAutocomplete,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.nameAnalyzer == NameAnalyzerAutocomplete {
+		return IndexMapping, nil
+	}
+	if o.nameAnalyzer != NameAnalyzerKeyword {
+		return "", fmt.Errorf("unknown name analyzer '%s'", o.nameAnalyzer)
+	}
 
-// InitializeMapping creates the index in elastic.
-func InitializeMapping(es *elastic.Client) error {
-	exists, err := es.IndexExists(IndexName).Do(context.Background())
+	var mapping map[string]interface{}
+	if err := json.Unmarshal([]byte(IndexMapping), &mapping); err != nil {
+		return "", err
+	}
+	properties := mapping["mappings"].(map[string]interface{})["properties"].(map[string]interface{})
+	properties["name"] = keywordNameField
+	b, err := json.Marshal(mapping)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// IndexName is the name of the ES alias that all md entity reads and writes go through.
+// The alias points at a single versioned index, see VersionedIndexName.
+const IndexName = "md_entities"
+
+// LegacyIndexName is the concrete index that md entities were stored in before they were moved behind the
+// IndexName alias. If it exists when there is no alias, InitializeMapping moves its documents to the current index.
+const LegacyIndexName = "md_entities_5"
+
+// IndexVersion is the version of the concrete index that IndexName should point at.
+// This should be incremented when IndexMapping has breaking changes, after which
+// ReindexTo can be used to move the existing documents over to the new index.
+const IndexVersion = 11
+
+// catchUpBatchSize is the number of documents read from the old indices per scroll request when
+// copying the documents that changed during a reindex.
+const catchUpBatchSize = 500
+
+// VersionedIndexName returns the name of the concrete index for the given mapping version.
+func VersionedIndexName(version int) string {
+	return fmt.Sprintf("%s_v%d", IndexName, version)
+}
+
+// InitializeMapping creates the versioned index in elastic, along with the alias pointing at it.
+// If the alias already points at an index with an older version than IndexVersion, or there is no alias
+// but LegacyIndexName exists, the documents are moved over to a new index for IndexVersion. Otherwise,
+// the index behind the alias is left untouched, even if it was created with different options. It is
+// safe for multiple indexers to call this concurrently, and to call it again after it failed part way.
+func InitializeMapping(es *elastic.Client, opts ...MappingOption) error {
+	mapping, err := indexMapping(opts...)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	indices, err := aliasedIndices(ctx, es)
 	if err != nil {
 		return err
 	}
-	if exists {
-		return nil
+	if len(indices) > 0 {
+		if aliasedVersion(indices) >= IndexVersion {
+			return nil
+		}
+		return reindex(ctx, es, indices, VersionedIndexName(IndexVersion), mapping)
+	}
+
+	legacyExists, err := es.IndexExists(LegacyIndexName).Do(ctx)
+	if err != nil {
+		return err
+	}
+	if legacyExists {
+		return reindex(ctx, es, []string{LegacyIndexName}, VersionedIndexName(IndexVersion), mapping)
+	}
+
+	_, err = es.CreateIndex(VersionedIndexName(IndexVersion)).BodyString(indexBodyWithAlias(mapping)).Do(ctx)
+	if isAlreadyExists(err) {
+		// Either another indexer created the index after we checked for the alias, or an earlier reindex
+		// failed before pointing the alias at it. Make sure that the alias points at the index.
+		return reindex(ctx, es, nil, VersionedIndexName(IndexVersion), mapping)
 	}
-	_, err = es.CreateIndex(IndexName).Body(IndexMapping).Do(context.Background())
 	return err
 }
+
+// aliasedVersion returns the newest mapping version of the given indices. Indices which weren't
+// named by VersionedIndexName are treated as version 0, so that they are always reindexed.
+func aliasedVersion(indices []string) int {
+	version := 0
+	for _, idx := range indices {
+		v, err := strconv.Atoi(strings.TrimPrefix(idx, IndexName+"_v"))
+		if err == nil && v > version {
+			version = v
+		}
+	}
+	return version
+}
+
+func isAlreadyExists(err error) bool {
+	e, ok := err.(*elastic.Error)
+	return ok && e.Details != nil && e.Details.Type == "resource_already_exists_exception"
+}
+
+// ReindexTo creates a new index for the given mapping version from the current IndexMapping and
+// the given options, copies all documents from the index currently behind the alias into it, and then atomically
+// swaps the alias over to the new index. The old index is left in place so that it can be
+// inspected or cleaned up separately.
+func ReindexTo(ctx context.Context, es *elastic.Client, version int, opts ...MappingOption) error {
+	mapping, err := indexMapping(opts...)
+	if err != nil {
+		return err
+	}
+
+	oldIndices, err := aliasedIndices(ctx, es)
+	if err != nil {
+		return err
+	}
+	newIndex := VersionedIndexName(version)
+	for _, idx := range oldIndices {
+		if idx == newIndex {
+			return fmt.Errorf("alias '%s' already points at index '%s'", IndexName, newIndex)
+		}
+	}
+	return reindex(ctx, es, oldIndices, newIndex, mapping)
+}
+
+// reindex moves the documents in the source indices to newIndex, and points the alias at newIndex.
+// If newIndex already exists, because an earlier reindex failed part way or another indexer is reindexing
+// concurrently, the reindex is resumed. Documents already in newIndex are never overwritten by the copy, and
+// documents which were written to the source indices while they were being copied are copied again once the
+// alias has been swapped, unless newIndex already has the same or a newer version of them.
+func reindex(ctx context.Context, es *elastic.Client, sources []string, newIndex string, mapping string) error {
+	_, err := es.CreateIndex(newIndex).BodyString(mapping).Do(ctx)
+	if err != nil && !isAlreadyExists(err) {
+		return err
+	}
+
+	if len(sources) > 0 {
+		_, err = es.Reindex().
+			Source(elastic.NewReindexSource().Index(sources...)).
+			Destination(elastic.NewReindexDestination().Index(newIndex).OpType("create")).
+			ProceedOnVersionConflict().
+			WaitForCompletion(true).
+			Refresh("true").
+			Do(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = swapAlias(ctx, es, newIndex)
+	if err != nil {
+		return err
+	}
+
+	for _, idx := range sources {
+		if err := copyNewerEntities(ctx, es, idx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// swapAlias points the alias at newIndex, and removes it from all other indices, in a single atomic update.
+func swapAlias(ctx context.Context, es *elastic.Client, newIndex string) error {
+	current, err := aliasedIndices(ctx, es)
+	if err != nil {
+		return err
+	}
+	actions := []elastic.AliasAction{
+		elastic.NewAliasAddAction(IndexName).Index(newIndex).IsWriteIndex(true),
+	}
+	for _, idx := range current {
+		if idx != newIndex {
+			actions = append(actions, elastic.NewAliasRemoveAction(IndexName).Index(idx))
+		}
+	}
+	resp, err := es.Alias().Action(actions...).Do(ctx)
+	if err != nil {
+		// The update fails if another indexer swapped the alias after we looked it up, in which case
+		// it may already point at newIndex.
+		if current, lookupErr := aliasedIndices(ctx, es); lookupErr == nil && len(current) == 1 && current[0] == newIndex {
+			return nil
+		}
+		return err
+	}
+	if !resp.Acknowledged {
+		return fmt.Errorf("failed to swap alias '%s' to index '%s'", IndexName, newIndex)
+	}
+	return nil
+}
+
+// copyNewerEntities writes all of the entities in the given index through the alias, skipping the ones for which
+// the alias's index already has the same or a newer version.
+func copyNewerEntities(ctx context.Context, es *elastic.Client, index string) error {
+	scroll := es.Scroll(index).Size(catchUpBatchSize)
+	defer scroll.Clear(context.Background())
+
+	for {
+		res, err := scroll.Do(ctx)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		entities := make([]*EsMDEntity, len(res.Hits.Hits))
+		for i, hit := range res.Hits.Hits {
+			entities[i] = &EsMDEntity{}
+			if err := json.Unmarshal(hit.Source, entities[i]); err != nil {
+				return err
+			}
+		}
+		docErrs, err := BulkIndex(ctx, es, entities, nil)
+		if err != nil {
+			return err
+		}
+		for _, docErr := range docErrs {
+			if docErr != nil && docErr != ErrStaleWrite {
+				return docErr
+			}
+		}
+	}
+}
+
+// indexBodyWithAlias returns the given mapping with the IndexName alias added to it.
+func indexBodyWithAlias(mapping string) string {
+	return fmt.Sprintf(`{"aliases": {"%s": {"is_write_index": true}}, %s`,
+		IndexName, strings.TrimPrefix(strings.TrimSpace(mapping), "{"))
+}
+
+// aliasedIndices returns the names of the indices currently behind the IndexName alias.
+func aliasedIndices(ctx context.Context, es *elastic.Client) ([]string, error) {
+	resp, err := es.Aliases().Alias(IndexName).Do(ctx)
+	if err != nil {
+		// olivere/elastic has no alias exists endpoint, so a missing alias shows up as a 404.
+		if elastic.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return resp.IndicesByAlias(IndexName), nil
+}
@@ -20,7 +20,9 @@ package md
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/gofrs/uuid"
 	"github.com/nats-io/stan.go"
@@ -109,10 +111,22 @@ func (v *VizierIndexer) nsUpdateToEMD(u *metadatapb.ResourceUpdate, nsUpdate *me
 		TimeStoppedNS:      nsUpdate.StopTimestampNS,
 		RelatedEntityNames: []string{},
 		UpdateVersion:      u.UpdateVersion,
+		ResourceVersion:    resourceVersion(u),
 		State:              getStateFromTimestamps(nsUpdate.StopTimestampNS),
 	}
 }
 
+// resourceVersion returns the K8s resourceVersion of the object the update was generated from. Resource versions
+// are opaque strings, but are numeric in practice, since they're backed by the etcd revision. Unknown or unparseable
+// versions are returned as 0.
+func resourceVersion(u *metadatapb.ResourceUpdate) int64 {
+	rv, err := strconv.ParseInt(u.ResourceVersion, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return rv
+}
+
 func podPhaseToState(podUpdate *metadatapb.PodUpdate) ESMDEntityState {
 	switch podUpdate.Phase {
 	case metadatapb.PENDING:
@@ -144,10 +158,13 @@ func (v *VizierIndexer) podUpdateToEMD(u *metadatapb.ResourceUpdate, podUpdate *
 		Name:               podUpdate.Name,
 		NS:                 podUpdate.Namespace,
 		Kind:               "pod",
+		PodIP:              podUpdate.PodIP,
+		NodeName:           podUpdate.NodeName,
 		TimeStartedNS:      podUpdate.StartTimestampNS,
 		TimeStoppedNS:      podUpdate.StopTimestampNS,
 		RelatedEntityNames: []string{},
 		UpdateVersion:      u.UpdateVersion,
+		ResourceVersion:    resourceVersion(u),
 		State:              podPhaseToState(podUpdate),
 	}
 }
@@ -168,6 +185,7 @@ func (v *VizierIndexer) serviceUpdateToEMD(u *metadatapb.ResourceUpdate, service
 		TimeStoppedNS:      serviceUpdate.StopTimestampNS,
 		RelatedEntityNames: serviceUpdate.PodIDs,
 		UpdateVersion:      u.UpdateVersion,
+		ResourceVersion:    resourceVersion(u),
 		State:              getStateFromTimestamps(serviceUpdate.StopTimestampNS),
 	}
 }
@@ -187,15 +205,39 @@ func (v *VizierIndexer) resourceUpdateToEMD(update *metadatapb.ResourceUpdate) *
 	}
 }
 
+// staleEntityCheck sets stale if the entity in the painless variable "entity" is from the same or an older version of
+// the K8s object as the stored entity. Entities that don't have a resourceVersion are ordered by their updateVersion
+// instead. It's shared by all the scripts that write entities, so that they agree on which writes are stale.
+const staleEntityCheck = `
+boolean stale;
+if (entity.resourceVersion > 0 && ctx._source.resourceVersion != null && ctx._source.resourceVersion > 0) {
+  stale = entity.resourceVersion <= ctx._source.resourceVersion;
+} else {
+  stale = entity.updateVersion <= ctx._source.updateVersion;
+}
+`
+
+// elasticUpdateScript merges the update into the stored entity, unless the update is stale.
 const elasticUpdateScript = `
-if (params.updateVersion <= ctx._source.updateVersion)  {
+def entity = params;
+` + staleEntityCheck + `
+if (stale) {
   ctx.op = 'noop';
 }
 ctx._source.relatedEntityNames.addAll(params.entities);
 ctx._source.relatedEntityNames = ctx._source.relatedEntityNames.stream().distinct().sorted().collect(Collectors.toList());
 ctx._source.timeStoppedNS = params.timeStoppedNS;
 ctx._source.updateVersion = params.updateVersion;
+if (params.resourceVersion > 0) {
+  ctx._source.resourceVersion = params.resourceVersion;
+}
 ctx._source.state = params.state;
+if (params.podIP != '') {
+  ctx._source.podIP = params.podIP;
+}
+if (params.nodeName != '') {
+  ctx._source.nodeName = params.nodeName;
+}
 `
 
 func (v *VizierIndexer) stanMessageHandler(msg *stan.Msg) {
@@ -212,6 +254,11 @@ func (v *VizierIndexer) stanMessageHandler(msg *stan.Msg) {
 	}
 
 	err = v.HandleResourceUpdate(&ru)
+	if errors.Is(err, ErrStaleWrite) {
+		// Updates may be redelivered or arrive out of order, so this is expected.
+		log.WithField("updateVersion", ru.UpdateVersion).Debug("Skipped stale resource update")
+		err = nil
+	}
 	if err != nil {
 		log.WithError(err).Error("Error handling resource update")
 		v.errCh <- err
@@ -229,26 +276,44 @@ func (v *VizierIndexer) stanMessageHandler(msg *stan.Msg) {
 	}
 }
 
-// HandleResourceUpdate indexes the resource update in elastic.
+// ErrStaleWrite is returned for entities which weren't written because a newer version of the
+// entity is already indexed.
+var ErrStaleWrite = errors.New("stale write skipped: a newer version of the entity is already indexed")
+
+// entityDocID returns the elastic document ID for the given entity.
+func entityDocID(e *EsMDEntity) string {
+	return fmt.Sprintf("%s-%s-%s", e.VizierID, e.ClusterUID, e.UID)
+}
+
+// HandleResourceUpdate indexes the resource update in elastic. ErrStaleWrite is returned if the
+// entity has already been indexed from the same or a newer resourceVersion of the K8s object.
 func (v *VizierIndexer) HandleResourceUpdate(update *metadatapb.ResourceUpdate) error {
 	esEntity := v.resourceUpdateToEMD(update)
 	if esEntity == nil { // We are not handling this resource yet.
 		return nil
 	}
 
-	id := fmt.Sprintf("%s-%s-%s", v.vizierID, v.k8sUID, esEntity.UID)
-	_, err := v.es.Update().
+	resp, err := v.es.Update().
 		Index(IndexName).
-		Id(id).
+		Id(entityDocID(esEntity)).
 		Script(
 			elastic.NewScript(elasticUpdateScript).
 				Param("entities", esEntity.RelatedEntityNames).
 				Param("timeStoppedNS", esEntity.TimeStoppedNS).
 				Param("updateVersion", esEntity.UpdateVersion).
+				Param("resourceVersion", esEntity.ResourceVersion).
 				Param("state", esEntity.State).
+				Param("podIP", esEntity.PodIP).
+				Param("nodeName", esEntity.NodeName).
 				Lang("painless")).
 		Upsert(esEntity).
 		Refresh("true").
 		Do(context.Background())
-	return err
+	if err != nil {
+		return err
+	}
+	if resp.Result == "noop" {
+		return ErrStaleWrite
+	}
+	return nil
 }
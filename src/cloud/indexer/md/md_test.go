@@ -19,10 +19,19 @@
 package md_test
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/olivere/elastic/v7"
@@ -283,8 +292,12 @@ func TestVizierIndexer_ResourceUpdate(t *testing.T) {
 			indexer := md.NewVizierIndexer(vzID, orgID, "test", nil, elasticClient)
 
 			for _, u := range test.updates {
+				// The test cases share entities, so some of the updates are older than what's already
+				// indexed. Skipping stale writes is covered by TestVizierIndexer_StaleWrite.
 				err := indexer.HandleResourceUpdate(u)
-				require.NoError(t, err)
+				if !errors.Is(err, md.ErrStaleWrite) {
+					require.NoError(t, err)
+				}
 			}
 
 			resp, err := elasticClient.Search().
@@ -302,3 +315,1430 @@ func TestVizierIndexer_ResourceUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestVizierIndexer_StaleWrite(t *testing.T) {
+	indexer := md.NewVizierIndexer(vzID, orgID, "stale", nil, elasticClient)
+	podUpdate := func(phase metadatapb.PodPhase, resourceVersion string, updateVersion int64) *metadatapb.ResourceUpdate {
+		return &metadatapb.ResourceUpdate{
+			Update: &metadatapb.ResourceUpdate_PodUpdate{
+				PodUpdate: &metadatapb.PodUpdate{
+					UID:              "stale-pod",
+					Name:             "stale-pod",
+					Namespace:        "stalens",
+					StartTimestampNS: 1000,
+					Phase:            phase,
+				},
+			},
+			ResourceVersion: resourceVersion,
+			UpdateVersion:   updateVersion,
+		}
+	}
+
+	require.NoError(t, indexer.HandleResourceUpdate(podUpdate(metadatapb.RUNNING, "20", 5)))
+	// An older snapshot of the pod arriving late must not overwrite the newer one, even if the update
+	// was sent after it.
+	err := indexer.HandleResourceUpdate(podUpdate(metadatapb.PENDING, "10", 6))
+	assert.True(t, errors.Is(err, md.ErrStaleWrite))
+	// A redelivery of the same snapshot is also skipped.
+	err = indexer.HandleResourceUpdate(podUpdate(metadatapb.RUNNING, "20", 7))
+	assert.True(t, errors.Is(err, md.ErrStaleWrite))
+
+	resp, err := elasticClient.Search().
+		Index(md.IndexName).
+		Query(elastic.NewTermQuery("clusterUID", "stale")).
+		Do(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(1), resp.TotalHits())
+	res := &md.EsMDEntity{}
+	require.NoError(t, json.Unmarshal(resp.Hits.Hits[0].Source, res))
+	assert.Equal(t, int64(20), res.ResourceVersion)
+	assert.Equal(t, int64(5), res.UpdateVersion)
+	assert.Equal(t, md.ESMDEntityStateRunning, res.State)
+
+	// A newer snapshot is written, even if the update was sent before the stale ones.
+	require.NoError(t, indexer.HandleResourceUpdate(podUpdate(metadatapb.SUCCEEDED, "30", 4)))
+	resp, err = elasticClient.Search().
+		Index(md.IndexName).
+		Query(elastic.NewTermQuery("clusterUID", "stale")).
+		Do(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(1), resp.TotalHits())
+	res = &md.EsMDEntity{}
+	require.NoError(t, json.Unmarshal(resp.Hits.Hits[0].Source, res))
+	assert.Equal(t, int64(30), res.ResourceVersion)
+	assert.Equal(t, md.ESMDEntityStateTerminated, res.State)
+}
+
+func TestReindexTo(t *testing.T) {
+	indexer := md.NewVizierIndexer(vzID, orgID, "reindex", nil, elasticClient)
+	err := indexer.HandleResourceUpdate(&metadatapb.ResourceUpdate{
+		Update: &metadatapb.ResourceUpdate_PodUpdate{
+			PodUpdate: &metadatapb.PodUpdate{
+				UID:              "reindex-pod",
+				Name:             "reindex-pod",
+				Namespace:        "testns",
+				StartTimestampNS: 1000,
+				Phase:            metadatapb.RUNNING,
+			},
+		},
+		UpdateVersion: 1,
+	})
+	require.NoError(t, err)
+
+	newVersion := md.IndexVersion + 1
+	// An empty index for the new version was left behind by an earlier reindex that failed.
+	_, err = elasticClient.CreateIndex(md.VersionedIndexName(newVersion)).BodyString(md.IndexMapping).Do(context.Background())
+	require.NoError(t, err)
+
+	err = md.ReindexTo(context.Background(), elasticClient, newVersion)
+	require.NoError(t, err)
+
+	aliases, err := elasticClient.Aliases().Alias(md.IndexName).Do(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{md.VersionedIndexName(newVersion)}, aliases.IndicesByAlias(md.IndexName))
+
+	resp, err := elasticClient.Search().
+		Index(md.VersionedIndexName(newVersion)).
+		Query(elastic.NewTermQuery("clusterUID", "reindex")).
+		Do(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resp.TotalHits())
+
+	// Reindexing to the version the alias already points at should fail.
+	err = md.ReindexTo(context.Background(), elasticClient, newVersion)
+	assert.Error(t, err)
+}
+
+func TestInitializeMapping_LegacyIndex(t *testing.T) {
+	ctx := context.Background()
+	// Start from the layout before md entities were moved behind the alias: a single concrete index,
+	// with no alias.
+	_, err := elasticClient.DeleteIndex(md.IndexName + "_*").Do(ctx)
+	require.NoError(t, err)
+	_, err = elasticClient.CreateIndex(md.LegacyIndexName).BodyString(md.IndexMapping).Do(ctx)
+	require.NoError(t, err)
+	legacyEntity := &md.EsMDEntity{
+		OrgID:         orgID.String(),
+		VizierID:      vzID.String(),
+		ClusterUID:    "legacy",
+		UID:           "legacy-pod",
+		Name:          "legacy-pod",
+		NS:            "testns",
+		Kind:          "pod",
+		UpdateVersion: 1,
+		State:         md.ESMDEntityStateRunning,
+	}
+	_, err = elasticClient.Index().
+		Index(md.LegacyIndexName).
+		Id(fmt.Sprintf("%s-legacy-legacy-pod", vzID.String())).
+		BodyJson(legacyEntity).
+		Refresh("true").
+		Do(ctx)
+	require.NoError(t, err)
+
+	err = md.InitializeMapping(elasticClient)
+	require.NoError(t, err)
+
+	aliases, err := elasticClient.Aliases().Alias(md.IndexName).Do(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{md.VersionedIndexName(md.IndexVersion)}, aliases.IndicesByAlias(md.IndexName))
+
+	_, err = elasticClient.Refresh(md.IndexName).Do(ctx)
+	require.NoError(t, err)
+	resp, err := elasticClient.Search().
+		Index(md.IndexName).
+		Query(elastic.NewTermQuery("clusterUID", "legacy")).
+		Do(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), resp.TotalHits())
+	res := &md.EsMDEntity{}
+	require.NoError(t, json.Unmarshal(resp.Hits.Hits[0].Source, res))
+	assert.Equal(t, legacyEntity, res)
+
+	// Initializing again leaves the migrated index untouched.
+	err = md.InitializeMapping(elasticClient)
+	require.NoError(t, err)
+	aliases, err = elasticClient.Aliases().Alias(md.IndexName).Do(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{md.VersionedIndexName(md.IndexVersion)}, aliases.IndicesByAlias(md.IndexName))
+}
+
+// newFakeElastic starts a fake elastic server that has no md alias and records the bodies of
+// the create index requests it receives.
+func newFakeElastic(t *testing.T) (*elastic.Client, *[]map[string]interface{}) {
+	var created []map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error": {"type": "aliases_not_found_exception"}, "status": 404}`)
+			return
+		}
+		body := make(map[string]interface{})
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		created = append(created, body)
+		fmt.Fprintf(w, `{"acknowledged": true, "shards_acknowledged": true, "index": "%s"}`, strings.TrimPrefix(r.URL.Path, "/"))
+	}))
+	t.Cleanup(ts.Close)
+
+	es, err := elastic.NewClient(elastic.SetURL(ts.URL), elastic.SetSniff(false), elastic.SetHealthcheck(false))
+	require.NoError(t, err)
+	return es, &created
+}
+
+func TestInitializeMapping_CreateErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		errType     string
+		expectedErr bool
+	}{
+		{
+			name:        "index created concurrently",
+			errType:     "resource_already_exists_exception",
+			expectedErr: false,
+		},
+		{
+			name:        "other errors",
+			errType:     "mapper_parsing_exception",
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Path == "/_aliases" {
+					fmt.Fprint(w, `{"acknowledged": true}`)
+					return
+				}
+				if r.Method != http.MethodPut {
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, `{"error": {"type": "aliases_not_found_exception"}, "status": 404}`)
+					return
+				}
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, `{"error": {"type": "%s", "reason": "failed"}, "status": 400}`, test.errType)
+			}))
+			defer ts.Close()
+			es, err := elastic.NewClient(elastic.SetURL(ts.URL), elastic.SetSniff(false), elastic.SetHealthcheck(false))
+			require.NoError(t, err)
+
+			err = md.InitializeMapping(es)
+			if test.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// fakeMigrationElastic is a fake elastic server for testing how InitializeMapping moves documents between indices.
+type fakeMigrationElastic struct {
+	// aliasedIndex is the index behind the md alias, if any.
+	aliasedIndex string
+	// legacyExists is whether md.LegacyIndexName exists.
+	legacyExists bool
+	// targetExists is whether the index for md.IndexVersion already exists.
+	targetExists bool
+	// docs are the documents returned when the old index is scrolled through.
+	docs []*md.EsMDEntity
+
+	requests []string
+	bodies   map[string]string
+}
+
+func (f *fakeMigrationElastic) start(t *testing.T) *elastic.Client {
+	f.bodies = make(map[string]string)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := r.Method + " " + r.URL.Path
+		f.requests = append(f.requests, req)
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		f.bodies[req] = string(b)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && f.aliasedIndex == "":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error": {"type": "aliases_not_found_exception"}, "status": 404}`)
+		case r.Method == http.MethodGet:
+			fmt.Fprintf(w, `{"%s": {"aliases": {"%s": {}}}}`, f.aliasedIndex, md.IndexName)
+		case r.Method == http.MethodHead:
+			if !f.legacyExists {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case r.Method == http.MethodPut && f.targetExists:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": {"type": "resource_already_exists_exception", "reason": "exists"}, "status": 400}`)
+		case r.URL.Path == "/_reindex":
+			fmt.Fprint(w, `{"took": 1, "total": 1, "created": 1}`)
+		case r.URL.Path == "/_aliases":
+			f.aliasedIndex = md.VersionedIndexName(md.IndexVersion)
+			fmt.Fprint(w, `{"acknowledged": true}`)
+		case strings.HasSuffix(r.URL.Path, "/_search"):
+			var hits []string
+			for _, d := range f.docs {
+				src, err := json.Marshal(d)
+				require.NoError(t, err)
+				hits = append(hits, fmt.Sprintf(`{"_id": "%s", "_source": %s}`, d.UID, src))
+			}
+			fmt.Fprintf(w, `{"_scroll_id": "scroll", "hits": {"hits": [%s]}}`, strings.Join(hits, ","))
+		case r.URL.Path == "/_search/scroll" && r.Method == http.MethodPost:
+			fmt.Fprint(w, `{"_scroll_id": "scroll", "hits": {"hits": []}}`)
+		case r.URL.Path == "/_bulk":
+			var items []string
+			for range f.docs {
+				items = append(items, `{"update": {"status": 200, "result": "updated"}}`)
+			}
+			fmt.Fprintf(w, `{"took": 1, "errors": false, "items": [%s]}`, strings.Join(items, ","))
+		default:
+			fmt.Fprint(w, `{"acknowledged": true}`)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	es, err := elastic.NewClient(elastic.SetURL(ts.URL), elastic.SetSniff(false), elastic.SetHealthcheck(false))
+	require.NoError(t, err)
+	return es
+}
+
+func TestInitializeMapping_ExistingAlias(t *testing.T) {
+	newIndex := md.VersionedIndexName(md.IndexVersion)
+	tests := []struct {
+		name             string
+		fake             *fakeMigrationElastic
+		expectedRequests []string
+		// The old index that the alias should be removed from, if it's swapped.
+		expectedRemoved string
+	}{
+		{
+			name: "older version is reindexed",
+			fake: &fakeMigrationElastic{aliasedIndex: md.VersionedIndexName(md.IndexVersion - 1)},
+			expectedRequests: []string{
+				"GET /_alias/" + md.IndexName,
+				"PUT /" + newIndex,
+				"POST /_reindex",
+				"GET /_alias/" + md.IndexName,
+				"POST /_aliases",
+				"POST /" + md.VersionedIndexName(md.IndexVersion-1) + "/_search",
+				"DELETE /_search/scroll",
+			},
+			expectedRemoved: md.VersionedIndexName(md.IndexVersion - 1),
+		},
+		{
+			name: "unversioned index is reindexed",
+			fake: &fakeMigrationElastic{aliasedIndex: "md_entities_4"},
+			expectedRequests: []string{
+				"GET /_alias/" + md.IndexName,
+				"PUT /" + newIndex,
+				"POST /_reindex",
+				"GET /_alias/" + md.IndexName,
+				"POST /_aliases",
+				"POST /md_entities_4/_search",
+				"DELETE /_search/scroll",
+			},
+			expectedRemoved: "md_entities_4",
+		},
+		{
+			name: "index left by a failed reindex is resumed",
+			fake: &fakeMigrationElastic{aliasedIndex: md.VersionedIndexName(md.IndexVersion - 1), targetExists: true},
+			expectedRequests: []string{
+				"GET /_alias/" + md.IndexName,
+				"PUT /" + newIndex,
+				"POST /_reindex",
+				"GET /_alias/" + md.IndexName,
+				"POST /_aliases",
+				"POST /" + md.VersionedIndexName(md.IndexVersion-1) + "/_search",
+				"DELETE /_search/scroll",
+			},
+			expectedRemoved: md.VersionedIndexName(md.IndexVersion - 1),
+		},
+		{
+			name: "legacy index without an alias is reindexed",
+			fake: &fakeMigrationElastic{legacyExists: true},
+			expectedRequests: []string{
+				"GET /_alias/" + md.IndexName,
+				"HEAD /" + md.LegacyIndexName,
+				"PUT /" + newIndex,
+				"POST /_reindex",
+				"GET /_alias/" + md.IndexName,
+				"POST /_aliases",
+				"POST /" + md.LegacyIndexName + "/_search",
+				"DELETE /_search/scroll",
+			},
+		},
+		{
+			name: "unaliased index without a legacy index is aliased",
+			fake: &fakeMigrationElastic{targetExists: true},
+			expectedRequests: []string{
+				"GET /_alias/" + md.IndexName,
+				"HEAD /" + md.LegacyIndexName,
+				"PUT /" + newIndex,
+				"PUT /" + newIndex,
+				"GET /_alias/" + md.IndexName,
+				"POST /_aliases",
+			},
+		},
+		{
+			name:             "current version is left untouched",
+			fake:             &fakeMigrationElastic{aliasedIndex: newIndex},
+			expectedRequests: []string{"GET /_alias/" + md.IndexName},
+		},
+		{
+			name:             "newer version is left untouched",
+			fake:             &fakeMigrationElastic{aliasedIndex: md.VersionedIndexName(md.IndexVersion + 1)},
+			expectedRequests: []string{"GET /_alias/" + md.IndexName},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			es := test.fake.start(t)
+
+			err := md.InitializeMapping(es)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedRequests, test.fake.requests)
+
+			if reindexBody, ok := test.fake.bodies["POST /_reindex"]; ok {
+				// Documents already in the new index must not be overwritten by the copy.
+				assert.Contains(t, reindexBody, `"op_type":"create"`)
+				assert.Contains(t, reindexBody, `"conflicts":"proceed"`)
+			}
+			aliasesBody, ok := test.fake.bodies["POST /_aliases"]
+			if !ok {
+				return
+			}
+			// The alias is added to the new index and removed from the old one in a single update.
+			assert.Contains(t, aliasesBody, fmt.Sprintf(`{"add":{"alias":"%s","index":"%s","is_write_index":true}}`, md.IndexName, newIndex))
+			if test.expectedRemoved != "" {
+				assert.Contains(t, aliasesBody, fmt.Sprintf(`{"remove":{"alias":"%s","index":"%s"}}`, md.IndexName, test.expectedRemoved))
+			} else {
+				assert.NotContains(t, aliasesBody, "remove")
+			}
+		})
+	}
+}
+
+func TestInitializeMapping_CopiesWritesMadeDuringReindex(t *testing.T) {
+	// The pod was updated in the legacy index after the reindex copied it.
+	fake := &fakeMigrationElastic{
+		legacyExists: true,
+		docs: []*md.EsMDEntity{{
+			VizierID:      vzID.String(),
+			ClusterUID:    "legacy",
+			UID:           "legacy-pod",
+			Name:          "legacy-pod",
+			Kind:          "pod",
+			UpdateVersion: 5,
+			State:         md.ESMDEntityStateTerminated,
+		}},
+	}
+	es := fake.start(t)
+
+	err := md.InitializeMapping(es)
+	require.NoError(t, err)
+
+	bulkBody, ok := fake.bodies["POST /_bulk"]
+	require.True(t, ok)
+	// The pod is written through the alias, which now points at the new index, with the stale write check
+	// so that it doesn't overwrite a newer version of the pod.
+	assert.Contains(t, bulkBody, fmt.Sprintf(`"_index":"%s"`, md.IndexName))
+	assert.Contains(t, bulkBody, fmt.Sprintf(`"_id":"%s-legacy-legacy-pod"`, vzID.String()))
+	assert.Contains(t, bulkBody, "stale")
+	// The scroll is continued until there are no more documents.
+	assert.Contains(t, fake.requests, "POST /_search/scroll")
+}
+
+func TestInitializeMapping_NameAnalyzer(t *testing.T) {
+	tests := []struct {
+		name              string
+		opts              []md.MappingOption
+		expectedAnalyzer  interface{}
+		expectedErr       bool
+		expectedNumCreate int
+	}{
+		{
+			name:              "defaults to autocomplete",
+			expectedAnalyzer:  "autocomplete",
+			expectedNumCreate: 1,
+		},
+		{
+			name:              "autocomplete",
+			opts:              []md.MappingOption{md.WithNameAnalyzer(md.NameAnalyzerAutocomplete)},
+			expectedAnalyzer:  "autocomplete",
+			expectedNumCreate: 1,
+		},
+		{
+			name:              "keyword",
+			opts:              []md.MappingOption{md.WithNameAnalyzer(md.NameAnalyzerKeyword)},
+			expectedAnalyzer:  nil,
+			expectedNumCreate: 1,
+		},
+		{
+			name:              "unknown analyzer",
+			opts:              []md.MappingOption{md.WithNameAnalyzer("ngram")},
+			expectedErr:       true,
+			expectedNumCreate: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			es, created := newFakeElastic(t)
+
+			err := md.InitializeMapping(es, test.opts...)
+			if test.expectedErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.expectedNumCreate, len(*created))
+			if test.expectedNumCreate == 0 {
+				return
+			}
+
+			body := (*created)[0]
+			assert.Contains(t, body["aliases"], md.IndexName)
+			properties := body["mappings"].(map[string]interface{})["properties"].(map[string]interface{})
+			name := properties["name"].(map[string]interface{})
+			assert.Equal(t, "text", name["type"])
+			assert.Equal(t, test.expectedAnalyzer, name["analyzer"])
+			// The keyword sub-field is used for exact matches, so it must exist with either analyzer.
+			assert.Contains(t, name["fields"], "keyword")
+			// Only the name field should be affected by the option.
+			assert.Equal(t, "autocomplete", properties["ns"].(map[string]interface{})["analyzer"])
+		})
+	}
+}
+
+func makeBulkEntities(clusterUID string, n int) []*md.EsMDEntity {
+	entities := make([]*md.EsMDEntity, n)
+	for i := range entities {
+		entities[i] = &md.EsMDEntity{
+			OrgID:              orgID.String(),
+			VizierID:           vzID.String(),
+			ClusterUID:         clusterUID,
+			UID:                fmt.Sprintf("bulk-pod-%d", i),
+			Name:               fmt.Sprintf("bulk-pod-%d", i),
+			NS:                 "bulkns",
+			Kind:               "pod",
+			TimeStartedNS:      1000,
+			RelatedEntityNames: []string{},
+			State:              md.ESMDEntityStateRunning,
+		}
+	}
+	return entities
+}
+
+func TestBulkIndex(t *testing.T) {
+	entities := makeBulkEntities("bulk", 25)
+
+	docErrs, err := md.BulkIndex(context.Background(), elasticClient, entities, &md.BulkIndexOptions{
+		BatchSize:     10,
+		FlushInterval: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	require.Len(t, docErrs, len(entities))
+	for _, e := range docErrs {
+		assert.NoError(t, e)
+	}
+
+	_, err = elasticClient.Refresh(md.IndexName).Do(context.Background())
+	require.NoError(t, err)
+	count, err := elasticClient.Count(md.IndexName).
+		Query(elastic.NewTermQuery("clusterUID", "bulk")).
+		Do(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(entities)), count)
+}
+
+func TestBulkIndex_CancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := md.BulkIndex(ctx, elasticClient, makeBulkEntities("bulk-cancelled", 5), nil)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestBulkIndex_Dedupe(t *testing.T) {
+	getEntities := func() []*md.EsMDEntity {
+		_, err := elasticClient.Refresh(md.IndexName).Do(context.Background())
+		require.NoError(t, err)
+		resp, err := elasticClient.Search().
+			Index(md.IndexName).
+			Query(elastic.NewTermQuery("clusterUID", "bulk-dedupe")).
+			Do(context.Background())
+		require.NoError(t, err)
+		entities := make([]*md.EsMDEntity, len(resp.Hits.Hits))
+		for i, h := range resp.Hits.Hits {
+			entities[i] = &md.EsMDEntity{}
+			require.NoError(t, json.Unmarshal(h.Source, entities[i]))
+		}
+		return entities
+	}
+	makeEntity := func(name string, resourceVersion int64) *md.EsMDEntity {
+		e := makeBulkEntities("bulk-dedupe", 1)[0]
+		e.Name = name
+		e.ResourceVersion = resourceVersion
+		return e
+	}
+
+	tests := []struct {
+		name         string
+		entity       *md.EsMDEntity
+		expectedErr  error
+		expectedName string
+	}{
+		{
+			name:         "first index",
+			entity:       makeEntity("pod-v2", 2),
+			expectedName: "pod-v2",
+		},
+		{
+			name:         "older update is ignored",
+			entity:       makeEntity("pod-v1", 1),
+			expectedErr:  md.ErrStaleWrite,
+			expectedName: "pod-v2",
+		},
+		{
+			name:         "update of the same version is ignored",
+			entity:       makeEntity("pod-v2-redelivered", 2),
+			expectedErr:  md.ErrStaleWrite,
+			expectedName: "pod-v2",
+		},
+		{
+			name:         "newer update overwrites",
+			entity:       makeEntity("pod-v3", 3),
+			expectedName: "pod-v3",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			docErrs, err := md.BulkIndex(context.Background(), elasticClient, []*md.EsMDEntity{test.entity}, nil)
+			require.NoError(t, err)
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(docErrs[0], test.expectedErr))
+			} else {
+				assert.NoError(t, docErrs[0])
+			}
+
+			entities := getEntities()
+			require.Len(t, entities, 1)
+			assert.Equal(t, test.expectedName, entities[0].Name)
+		})
+	}
+}
+
+// newSlowElasticClient returns a client for a fake elastic server that takes the given delay to
+// respond to each bulk request. The returned counter is the number of documents the server indexed.
+func newSlowElasticClient(t *testing.T, delay time.Duration) (*elastic.Client, *int64) {
+	var indexed int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		var items []map[string]interface{}
+		scanner := bufio.NewScanner(r.Body)
+		// Each document is an action line followed by a source line.
+		for i := 0; scanner.Scan(); i++ {
+			if i%2 == 1 {
+				continue
+			}
+			items = append(items, map[string]interface{}{
+				"index": map[string]interface{}{"_index": md.IndexName, "status": 201},
+			})
+		}
+		atomic.AddInt64(&indexed, int64(len(items)))
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"took":   1,
+			"errors": false,
+			"items":  items,
+		})
+		assert.NoError(t, err)
+	}))
+	t.Cleanup(srv.Close)
+
+	es, err := elastic.NewClient(elastic.SetURL(srv.URL), elastic.SetSniff(false), elastic.SetHealthcheck(false))
+	require.NoError(t, err)
+	return es, &indexed
+}
+
+func TestBulkIndex_BackpressureBlock(t *testing.T) {
+	es, indexed := newSlowElasticClient(t, 20*time.Millisecond)
+	entities := makeBulkEntities("bulk-block", 10)
+	droppedBefore := md.DroppedDocCount()
+
+	docErrs, err := md.BulkIndex(context.Background(), es, entities, &md.BulkIndexOptions{
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		QueueSize:     2,
+		Backpressure:  md.BackpressureBlock,
+	})
+	require.NoError(t, err)
+	require.Len(t, docErrs, len(entities))
+	for _, e := range docErrs {
+		assert.NoError(t, e)
+	}
+	// Every document should eventually be indexed, since the queue waits for elastic.
+	assert.Equal(t, int64(len(entities)), atomic.LoadInt64(indexed))
+	assert.Equal(t, droppedBefore, md.DroppedDocCount())
+}
+
+func TestBulkIndex_BackpressureDropOldest(t *testing.T) {
+	es, indexed := newSlowElasticClient(t, 200*time.Millisecond)
+	entities := makeBulkEntities("bulk-drop", 20)
+	droppedBefore := md.DroppedDocCount()
+
+	docErrs, err := md.BulkIndex(context.Background(), es, entities, &md.BulkIndexOptions{
+		BatchSize:     5,
+		FlushInterval: time.Hour,
+		QueueSize:     1,
+		Backpressure:  md.BackpressureDropOldest,
+	})
+	require.NoError(t, err)
+	require.Len(t, docErrs, len(entities))
+
+	dropped := 0
+	for _, e := range docErrs {
+		if e != nil {
+			assert.Equal(t, md.ErrBulkIndexDropped, e)
+			dropped++
+		}
+	}
+	// Elastic is too slow to keep up, so some documents should have been dropped rather than queued.
+	assert.Greater(t, dropped, 0)
+	assert.Equal(t, int64(dropped), md.DroppedDocCount()-droppedBefore)
+	assert.Equal(t, int64(len(entities)-dropped), atomic.LoadInt64(indexed))
+}
+
+func indexSearchEntities(t *testing.T, entities []*md.EsMDEntity) {
+	docErrs, err := md.BulkIndex(context.Background(), elasticClient, entities, nil)
+	require.NoError(t, err)
+	for _, e := range docErrs {
+		require.NoError(t, e)
+	}
+	_, err = elasticClient.Refresh(md.IndexName).Do(context.Background())
+	require.NoError(t, err)
+}
+
+func makeSearchEntity(org uuid.UUID, uid string, ns string, name string, kind string) *md.EsMDEntity {
+	return &md.EsMDEntity{
+		OrgID:              org.String(),
+		VizierID:           vzID.String(),
+		ClusterUID:         "search",
+		UID:                uid,
+		Name:               name,
+		NS:                 ns,
+		Kind:               kind,
+		TimeStartedNS:      1000,
+		RelatedEntityNames: []string{},
+		State:              md.ESMDEntityStateRunning,
+	}
+}
+
+func entityNames(entities []*md.EsMDEntity) []string {
+	names := make([]string, len(entities))
+	for i, e := range entities {
+		names[i] = e.NS + "/" + e.Name
+	}
+	return names
+}
+
+func TestSearchEntities(t *testing.T) {
+	searchOrgID := uuid.Must(uuid.NewV4())
+	otherOrgID := uuid.Must(uuid.NewV4())
+	indexSearchEntities(t, []*md.EsMDEntity{
+		makeSearchEntity(searchOrgID, "search-1", "px-sock-shop", "checkout-service", "service"),
+		makeSearchEntity(searchOrgID, "search-2", "px-sock-shop", "checkout", "service"),
+		makeSearchEntity(searchOrgID, "search-3", "px-sock-shop", "cart", "service"),
+		makeSearchEntity(searchOrgID, "search-4", "default", "checkout", "pod"),
+		makeSearchEntity(otherOrgID, "search-5", "px-sock-shop", "checkout", "service"),
+		makeSearchEntity(searchOrgID, "search-6", "px-sock-shop", "cart-deployment", "deployment"),
+	})
+
+	tests := []struct {
+		name          string
+		orgID         uuid.UUID
+		query         string
+		opts          *md.SearchOptions
+		limit         int
+		expectedNames []string
+	}{
+		{
+			name:  "prefix match",
+			orgID: searchOrgID,
+			query: "chec",
+			opts: &md.SearchOptions{
+				Namespace: "px-sock-shop",
+			},
+			limit:         10,
+			expectedNames: []string{"px-sock-shop/checkout", "px-sock-shop/checkout-service"},
+		},
+		{
+			name:          "exact match ranked first",
+			orgID:         searchOrgID,
+			query:         "checkout",
+			limit:         1,
+			opts:          &md.SearchOptions{Kinds: []string{"service"}},
+			expectedNames: []string{"px-sock-shop/checkout"},
+		},
+		{
+			name:  "namespace filter",
+			orgID: searchOrgID,
+			query: "checkout",
+			opts: &md.SearchOptions{
+				Namespace: "default",
+			},
+			limit:         10,
+			expectedNames: []string{"default/checkout"},
+		},
+		{
+			name:          "kind filter",
+			orgID:         searchOrgID,
+			query:         "checkout",
+			opts:          &md.SearchOptions{Kinds: []string{"pod"}},
+			limit:         10,
+			expectedNames: []string{"default/checkout"},
+		},
+		{
+			name:          "multiple kinds filter",
+			orgID:         searchOrgID,
+			query:         "c",
+			opts:          &md.SearchOptions{Kinds: []string{"pod", "deployment"}},
+			limit:         10,
+			expectedNames: []string{"default/checkout", "px-sock-shop/cart-deployment"},
+		},
+		{
+			name:          "kind must match exactly",
+			orgID:         searchOrgID,
+			query:         "checkout",
+			opts:          &md.SearchOptions{Kinds: []string{"po"}},
+			limit:         10,
+			expectedNames: []string{},
+		},
+		{
+			name:          "org isolation",
+			orgID:         otherOrgID,
+			query:         "c",
+			limit:         10,
+			expectedNames: []string{"px-sock-shop/checkout"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entities, err := md.SearchEntities(context.Background(), elasticClient, test.orgID, test.query, test.opts, test.limit)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, test.expectedNames, entityNames(entities))
+		})
+	}
+}
+
+func TestSearchEntities_NameAnalyzer(t *testing.T) {
+	tests := []struct {
+		name             string
+		analyzer         md.NameAnalyzer
+		expectedAnalyzer string
+	}{
+		{
+			name:             "defaults to autocomplete",
+			expectedAnalyzer: "autocomplete",
+		},
+		{
+			name:             "autocomplete",
+			analyzer:         md.NameAnalyzerAutocomplete,
+			expectedAnalyzer: "autocomplete",
+		},
+		{
+			name:             "keyword",
+			analyzer:         md.NameAnalyzerKeyword,
+			expectedAnalyzer: "standard",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var query map[string]interface{}
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body := make(map[string]interface{})
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				query = body["query"].(map[string]interface{})
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"hits": {"total": {"value": 0}, "hits": []}}`)
+			}))
+			defer ts.Close()
+			es, err := elastic.NewClient(elastic.SetURL(ts.URL), elastic.SetSniff(false), elastic.SetHealthcheck(false))
+			require.NoError(t, err)
+
+			_, err = md.SearchEntities(context.Background(), es, orgID, "checkout", &md.SearchOptions{
+				NameAnalyzer: test.analyzer,
+			}, 10)
+			require.NoError(t, err)
+
+			must := query["bool"].(map[string]interface{})["must"].(map[string]interface{})
+			name := must["match"].(map[string]interface{})["name"].(map[string]interface{})
+			assert.Equal(t, "checkout", name["query"])
+			assert.Equal(t, test.expectedAnalyzer, name["analyzer"])
+		})
+	}
+}
+
+func TestSearchEntities_RecencyWeighting(t *testing.T) {
+	searchOrgID := uuid.Must(uuid.NewV4())
+	now := time.Now()
+
+	older := makeSearchEntity(searchOrgID, "recency-1", "ns-a", "frontend", "service")
+	older.TimeStartedNS = now.Add(-72 * time.Hour).UnixNano()
+	newer := makeSearchEntity(searchOrgID, "recency-2", "ns-b", "frontend", "service")
+	newer.TimeStartedNS = now.Add(-1 * time.Hour).UnixNano()
+	indexSearchEntities(t, []*md.EsMDEntity{older, newer})
+
+	entities, err := md.SearchEntities(context.Background(), elasticClient, searchOrgID, "frontend", &md.SearchOptions{
+		RecencyWeight: 1,
+		Now:           now,
+	}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ns-b/frontend", "ns-a/frontend"}, entityNames(entities))
+
+	// Moving the reference time back to when the older entity started should flip the order.
+	entities, err = md.SearchEntities(context.Background(), elasticClient, searchOrgID, "frontend", &md.SearchOptions{
+		RecencyWeight: 1,
+		Now:           now.Add(-72 * time.Hour),
+	}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ns-a/frontend", "ns-b/frontend"}, entityNames(entities))
+}
+
+func TestSearchEntities_IncludeStopped(t *testing.T) {
+	searchOrgID := uuid.Must(uuid.NewV4())
+	now := time.Now()
+
+	running := makeSearchEntity(searchOrgID, "stopped-1", "ns", "worker-running", "pod")
+	stopped := makeSearchEntity(searchOrgID, "stopped-2", "ns", "worker-stopped", "pod")
+	stopped.TimeStoppedNS = now.Add(-time.Hour).UnixNano()
+	stopped.State = md.ESMDEntityStateTerminated
+	stopping := makeSearchEntity(searchOrgID, "stopped-3", "ns", "worker-stopping", "pod")
+	stopping.TimeStoppedNS = now.Add(time.Hour).UnixNano()
+	indexSearchEntities(t, []*md.EsMDEntity{running, stopped, stopping})
+
+	tests := []struct {
+		name           string
+		includeStopped bool
+		expectedNames  []string
+	}{
+		{
+			name:           "exclude stopped",
+			includeStopped: false,
+			expectedNames:  []string{"ns/worker-running", "ns/worker-stopping"},
+		},
+		{
+			name:           "include stopped",
+			includeStopped: true,
+			expectedNames:  []string{"ns/worker-running", "ns/worker-stopped", "ns/worker-stopping"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entities, err := md.SearchEntities(context.Background(), elasticClient, searchOrgID, "worker", &md.SearchOptions{
+				IncludeStopped: test.includeStopped,
+				Now:            now,
+			}, 10)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, test.expectedNames, entityNames(entities))
+		})
+	}
+}
+
+func TestFindRelated(t *testing.T) {
+	relatedOrgID := uuid.Must(uuid.NewV4())
+	otherOrgID := uuid.Must(uuid.NewV4())
+
+	service := makeSearchEntity(relatedOrgID, "related-svc", "ns", "checkout", "service")
+	service.RelatedEntityNames = []string{"checkout-pod-1", "checkout-pod-2", "checkout-pod-3"}
+	entities := []*md.EsMDEntity{service}
+	for i, podName := range service.RelatedEntityNames {
+		pod := makeSearchEntity(relatedOrgID, fmt.Sprintf("related-pod-%d", i), "ns", podName, "pod")
+		pod.RelatedEntityNames = []string{"checkout"}
+		entities = append(entities, pod)
+	}
+	// An unrelated pod whose related name shares a token with the service's name.
+	unrelated := makeSearchEntity(relatedOrgID, "related-pod-other", "ns", "other-pod", "pod")
+	unrelated.RelatedEntityNames = []string{"checkout-v2"}
+	otherOrgPod := makeSearchEntity(otherOrgID, "related-pod-other-org", "ns", "checkout-pod-1", "pod")
+	otherOrgPod.RelatedEntityNames = []string{"checkout"}
+	indexSearchEntities(t, append(entities, unrelated, otherOrgPod))
+
+	tests := []struct {
+		name          string
+		orgID         uuid.UUID
+		entityName    string
+		limit         int
+		expectedNames []string
+	}{
+		{
+			name:          "pods related to service",
+			orgID:         relatedOrgID,
+			entityName:    "checkout",
+			limit:         10,
+			expectedNames: []string{"ns/checkout-pod-1", "ns/checkout-pod-2", "ns/checkout-pod-3"},
+		},
+		{
+			name:          "service related to pod",
+			orgID:         relatedOrgID,
+			entityName:    "checkout-pod-2",
+			limit:         10,
+			expectedNames: []string{"ns/checkout"},
+		},
+		{
+			name:          "org isolation",
+			orgID:         otherOrgID,
+			entityName:    "checkout",
+			limit:         10,
+			expectedNames: []string{"ns/checkout-pod-1"},
+		},
+		{
+			name:          "no related entities",
+			orgID:         relatedOrgID,
+			entityName:    "checkout-pod",
+			limit:         10,
+			expectedNames: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entities, err := md.FindRelated(context.Background(), elasticClient, test.orgID, test.entityName, test.limit)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, test.expectedNames, entityNames(entities))
+		})
+	}
+
+	t.Run("limit", func(t *testing.T) {
+		entities, err := md.FindRelated(context.Background(), elasticClient, relatedOrgID, "checkout", 2)
+		require.NoError(t, err)
+		assert.Len(t, entities, 2)
+	})
+}
+
+func TestIndexMapping_NameKeyword(t *testing.T) {
+	resp, err := elasticClient.GetFieldMapping().Index(md.IndexName).Field("name.keyword").Do(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, resp)
+	for _, idx := range resp {
+		mappings := idx.(map[string]interface{})["mappings"].(map[string]interface{})
+		field := mappings["name.keyword"].(map[string]interface{})["mapping"].(map[string]interface{})["keyword"].(map[string]interface{})
+		assert.Equal(t, "keyword", field["type"])
+	}
+}
+
+func TestFindEntitiesByName(t *testing.T) {
+	searchOrgID := uuid.Must(uuid.NewV4())
+	otherOrgID := uuid.Must(uuid.NewV4())
+	stopped := makeSearchEntity(searchOrgID, "exact-4", "default", "payments", "pod")
+	stopped.TimeStoppedNS = time.Now().Add(-time.Hour).UnixNano()
+	indexSearchEntities(t, []*md.EsMDEntity{
+		makeSearchEntity(searchOrgID, "exact-1", "px-sock-shop", "payments", "service"),
+		makeSearchEntity(searchOrgID, "exact-2", "px-sock-shop", "payments-db", "service"),
+		makeSearchEntity(searchOrgID, "exact-3", "px-sock-shop", "Payments", "service"),
+		stopped,
+		makeSearchEntity(otherOrgID, "exact-5", "px-sock-shop", "payments", "service"),
+	})
+
+	tests := []struct {
+		name          string
+		orgID         uuid.UUID
+		query         string
+		expectedNames []string
+	}{
+		{
+			name:          "exact match only",
+			orgID:         searchOrgID,
+			query:         "payments",
+			expectedNames: []string{"px-sock-shop/payments", "default/payments"},
+		},
+		{
+			name:          "case sensitive",
+			orgID:         searchOrgID,
+			query:         "Payments",
+			expectedNames: []string{"px-sock-shop/Payments"},
+		},
+		{
+			name:          "prefix does not match",
+			orgID:         searchOrgID,
+			query:         "pay",
+			expectedNames: []string{},
+		},
+		{
+			name:          "org isolation",
+			orgID:         otherOrgID,
+			query:         "payments",
+			expectedNames: []string{"px-sock-shop/payments"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entities, err := md.FindEntitiesByName(context.Background(), elasticClient, test.orgID, test.query, 10)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, test.expectedNames, entityNames(entities))
+		})
+	}
+}
+
+func TestSearchEntities_PodIP(t *testing.T) {
+	ipOrgID := uuid.Must(uuid.NewV4())
+	indexer := md.NewVizierIndexer(vzID, ipOrgID, "podip", nil, elasticClient)
+
+	// Pods don't have an IP until they have been scheduled, so the IP should be picked up by later updates.
+	updates := []*metadatapb.ResourceUpdate{
+		{
+			Update: &metadatapb.ResourceUpdate_PodUpdate{
+				PodUpdate: &metadatapb.PodUpdate{
+					UID:              "ip-pod",
+					Name:             "ip-pod",
+					Namespace:        "ipns",
+					StartTimestampNS: 1000,
+					Phase:            metadatapb.PENDING,
+				},
+			},
+			UpdateVersion: 1,
+		},
+		{
+			Update: &metadatapb.ResourceUpdate_PodUpdate{
+				PodUpdate: &metadatapb.PodUpdate{
+					UID:              "ip-pod",
+					Name:             "ip-pod",
+					Namespace:        "ipns",
+					StartTimestampNS: 1000,
+					Phase:            metadatapb.RUNNING,
+					PodIP:            "10.1.2.3",
+					NodeName:         "node-1",
+				},
+			},
+			UpdateVersion:     2,
+			PrevUpdateVersion: 1,
+		},
+		{
+			Update: &metadatapb.ResourceUpdate_ServiceUpdate{
+				ServiceUpdate: &metadatapb.ServiceUpdate{
+					UID:              "ip-service",
+					Name:             "ip-service",
+					Namespace:        "ipns",
+					StartTimestampNS: 1000,
+				},
+			},
+			UpdateVersion: 3,
+		},
+	}
+	for _, u := range updates {
+		require.NoError(t, indexer.HandleResourceUpdate(u))
+	}
+
+	tests := []struct {
+		name          string
+		opts          *md.SearchOptions
+		expectedNames []string
+	}{
+		{
+			name:          "pod IP",
+			opts:          &md.SearchOptions{PodIP: "10.1.2.3"},
+			expectedNames: []string{"ipns/ip-pod"},
+		},
+		{
+			name:          "pod IP must match exactly",
+			opts:          &md.SearchOptions{PodIP: "10.1.2.30"},
+			expectedNames: []string{},
+		},
+		{
+			name:          "node name",
+			opts:          &md.SearchOptions{NodeName: "node-1"},
+			expectedNames: []string{"ipns/ip-pod"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entities, err := md.SearchEntities(context.Background(), elasticClient, ipOrgID, "", test.opts, 10)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, test.expectedNames, entityNames(entities))
+			for _, e := range entities {
+				assert.Equal(t, "10.1.2.3", e.PodIP)
+				assert.Equal(t, "node-1", e.NodeName)
+			}
+		})
+	}
+
+	// Entities without an IP or node shouldn't have the fields at all.
+	resp, err := elasticClient.Search().
+		Index(md.IndexName).
+		Query(elastic.NewBoolQuery().
+			Filter(elastic.NewTermQuery("orgID", ipOrgID.String())).
+			Filter(elastic.NewTermQuery("kind", "service"))).
+		Do(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(1), resp.TotalHits())
+	source := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(resp.Hits.Hits[0].Source, &source))
+	assert.NotContains(t, source, "podIP")
+	assert.NotContains(t, source, "nodeName")
+}
+
+func TestSearchEntities_TimeRange(t *testing.T) {
+	searchOrgID := uuid.Must(uuid.NewV4())
+	makeEntity := func(uid string, name string, start int64, stop int64) *md.EsMDEntity {
+		e := makeSearchEntity(searchOrgID, uid, "ns", name, "pod")
+		e.TimeStartedNS = start
+		e.TimeStoppedNS = stop
+		return e
+	}
+	// The incident window is [1000, 2000].
+	indexSearchEntities(t, []*md.EsMDEntity{
+		makeEntity("range-1", "job-inside", 1200, 1800),
+		makeEntity("range-2", "job-overlaps-start", 500, 1500),
+		makeEntity("range-3", "job-overlaps-end", 1500, 2500),
+		makeEntity("range-4", "job-spans", 500, 2500),
+		makeEntity("range-5", "job-running", 1500, 0),
+		makeEntity("range-6", "job-before", 100, 900),
+		makeEntity("range-7", "job-after", 2100, 0),
+	})
+
+	tests := []struct {
+		name          string
+		start         int64
+		end           int64
+		expectedNames []string
+	}{
+		{
+			name:  "window",
+			start: 1000,
+			end:   2000,
+			expectedNames: []string{
+				"ns/job-inside", "ns/job-overlaps-start", "ns/job-overlaps-end", "ns/job-spans", "ns/job-running",
+			},
+		},
+		{
+			name: "open start",
+			end:  1000,
+			expectedNames: []string{
+				"ns/job-overlaps-start", "ns/job-spans", "ns/job-before",
+			},
+		},
+		{
+			name:  "open end",
+			start: 2000,
+			expectedNames: []string{
+				"ns/job-overlaps-end", "ns/job-spans", "ns/job-running", "ns/job-after",
+			},
+		},
+		{
+			name: "unset range",
+			expectedNames: []string{
+				"ns/job-inside", "ns/job-overlaps-start", "ns/job-overlaps-end", "ns/job-spans", "ns/job-running",
+				"ns/job-before", "ns/job-after",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entities, err := md.SearchEntities(context.Background(), elasticClient, searchOrgID, "job", &md.SearchOptions{
+				StartTimeNS: test.start,
+				EndTimeNS:   test.end,
+				// Only affects the unset range, which would otherwise leave out the stopped entities.
+				IncludeStopped: true,
+			}, 10)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, test.expectedNames, entityNames(entities))
+		})
+	}
+}
+
+func TestPruneStoppedEntities(t *testing.T) {
+	pruneOrgID := uuid.Must(uuid.NewV4())
+	otherOrgID := uuid.Must(uuid.NewV4())
+	now := time.Now()
+
+	running := makeSearchEntity(pruneOrgID, "prune-1", "ns", "running", "pod")
+	recentlyStopped := makeSearchEntity(pruneOrgID, "prune-2", "ns", "recently-stopped", "pod")
+	recentlyStopped.TimeStoppedNS = now.Add(-time.Hour).UnixNano()
+	oldStopped := makeSearchEntity(pruneOrgID, "prune-3", "ns", "old-stopped", "pod")
+	oldStopped.TimeStoppedNS = now.Add(-48 * time.Hour).UnixNano()
+	otherOrgOldStopped := makeSearchEntity(otherOrgID, "prune-4", "ns", "old-stopped", "pod")
+	otherOrgOldStopped.TimeStoppedNS = now.Add(-48 * time.Hour).UnixNano()
+	indexSearchEntities(t, []*md.EsMDEntity{running, recentlyStopped, oldStopped, otherOrgOldStopped})
+
+	deleted, err := md.PruneStoppedEntities(context.Background(), elasticClient, pruneOrgID, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	entities, err := md.SearchEntities(context.Background(), elasticClient, pruneOrgID, "", &md.SearchOptions{
+		IncludeStopped: true,
+	}, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"ns/running", "ns/recently-stopped"}, entityNames(entities))
+
+	// Entities in other orgs should be left alone.
+	entities, err = md.SearchEntities(context.Background(), elasticClient, otherOrgID, "", &md.SearchOptions{
+		IncludeStopped: true,
+	}, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"ns/old-stopped"}, entityNames(entities))
+}
+
+func TestPruneStoppedEntities_CancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := md.PruneStoppedEntities(ctx, elasticClient, orgID, time.Hour)
+	assert.Error(t, err)
+}
+
+func TestCountEntities(t *testing.T) {
+	countOrgID := uuid.Must(uuid.NewV4())
+	otherOrgID := uuid.Must(uuid.NewV4())
+
+	var entities []*md.EsMDEntity
+	for i, clusterUID := range []string{"count-a", "count-a", "count-a", "count-b", "count-b"} {
+		e := makeSearchEntity(countOrgID, fmt.Sprintf("count-%d", i), "ns", fmt.Sprintf("entity-%d", i), "pod")
+		e.ClusterUID = clusterUID
+		entities = append(entities, e)
+	}
+	otherOrgEntity := makeSearchEntity(otherOrgID, "count-5", "ns", "entity-5", "pod")
+	otherOrgEntity.ClusterUID = "count-a"
+	entities = append(entities, otherOrgEntity)
+	indexSearchEntities(t, entities)
+
+	tests := []struct {
+		name          string
+		orgID         uuid.UUID
+		clusterUID    string
+		expectedCount int64
+	}{
+		{
+			name:          "cluster a",
+			orgID:         countOrgID,
+			clusterUID:    "count-a",
+			expectedCount: 3,
+		},
+		{
+			name:          "cluster b",
+			orgID:         countOrgID,
+			clusterUID:    "count-b",
+			expectedCount: 2,
+		},
+		{
+			name:          "all clusters in org",
+			orgID:         countOrgID,
+			expectedCount: 5,
+		},
+		{
+			name:          "org isolation",
+			orgID:         otherOrgID,
+			clusterUID:    "count-a",
+			expectedCount: 1,
+		},
+		{
+			name:          "unknown cluster",
+			orgID:         countOrgID,
+			clusterUID:    "count-c",
+			expectedCount: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			count, err := md.CountEntities(context.Background(), elasticClient, test.orgID, test.clusterUID)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedCount, count)
+		})
+	}
+}
+
+func BenchmarkIndexing(b *testing.B) {
+	entities := makeBulkEntities("bench", 500)
+
+	b.Run("single", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for _, e := range entities {
+				_, err := elasticClient.Index().
+					Index(md.IndexName).
+					Id(fmt.Sprintf("%s-%s-%s", e.VizierID, e.ClusterUID, e.UID)).
+					BodyJson(e).
+					Do(context.Background())
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("bulk", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			_, err := md.BulkIndex(context.Background(), elasticClient, entities, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestGetOrgIndexStats(t *testing.T) {
+	bigOrgID := uuid.Must(uuid.NewV4())
+	smallOrgID := uuid.Must(uuid.NewV4())
+
+	var entities []*md.EsMDEntity
+	for i := 0; i < 4; i++ {
+		entities = append(entities, makeSearchEntity(bigOrgID, fmt.Sprintf("stats-big-%d", i), "ns", fmt.Sprintf("entity-%d", i), "pod"))
+	}
+	entities = append(entities, makeSearchEntity(smallOrgID, "stats-small-0", "ns", "entity-0", "pod"))
+	indexSearchEntities(t, entities)
+
+	bigStats, err := md.GetOrgIndexStats(context.Background(), elasticClient, bigOrgID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), bigStats.NumEntities)
+	assert.Greater(t, bigStats.ApproxStorageBytes, int64(0))
+
+	smallStats, err := md.GetOrgIndexStats(context.Background(), elasticClient, smallOrgID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), smallStats.NumEntities)
+	assert.Greater(t, smallStats.ApproxStorageBytes, int64(0))
+	assert.Less(t, smallStats.ApproxStorageBytes, bigStats.ApproxStorageBytes)
+
+	emptyStats, err := md.GetOrgIndexStats(context.Background(), elasticClient, uuid.Must(uuid.NewV4()))
+	require.NoError(t, err)
+	assert.Equal(t, &md.OrgIndexStats{}, emptyStats)
+}
+
+func TestGetNamespaces(t *testing.T) {
+	nsOrgID := uuid.Must(uuid.NewV4())
+	otherOrgID := uuid.Must(uuid.NewV4())
+
+	var entities []*md.EsMDEntity
+	for i, ns := range []string{"px-sock-shop", "px-sock-shop", "px-sock-shop", "default", "default", "kube-system", ""} {
+		entities = append(entities, makeSearchEntity(nsOrgID, fmt.Sprintf("ns-entity-%d", i), ns, fmt.Sprintf("entity-%d", i), "pod"))
+	}
+	entities = append(entities, makeSearchEntity(otherOrgID, "ns-entity-other", "other-ns", "entity", "pod"))
+	indexSearchEntities(t, entities)
+
+	namespaces, err := md.GetNamespaces(context.Background(), elasticClient, nsOrgID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []*md.NamespaceCount{
+		{Namespace: "px-sock-shop", NumEntities: 3},
+		{Namespace: "default", NumEntities: 2},
+		{Namespace: "kube-system", NumEntities: 1},
+	}, namespaces)
+
+	namespaces, err = md.GetNamespaces(context.Background(), elasticClient, nsOrgID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []*md.NamespaceCount{
+		{Namespace: "px-sock-shop", NumEntities: 3},
+		{Namespace: "default", NumEntities: 2},
+	}, namespaces)
+
+	namespaces, err = md.GetNamespaces(context.Background(), elasticClient, uuid.Must(uuid.NewV4()), 10)
+	require.NoError(t, err)
+	assert.Empty(t, namespaces)
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package md
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/olivere/elastic/v7"
+)
+
+// PruneStoppedEntities deletes the entities in the given org which stopped more than retention ago,
+// and returns the number of entities that were deleted. Entities which are still running are never deleted.
+func PruneStoppedEntities(ctx context.Context, es *elastic.Client, orgID uuid.UUID, retention time.Duration) (int64, error) {
+	cutoffNS := time.Now().Add(-retention).UnixNano()
+
+	q := elastic.NewBoolQuery().
+		Filter(elastic.NewTermQuery("orgID", orgID.String())).
+		// Entities which haven't stopped have a stop time of 0.
+		Filter(elastic.NewRangeQuery("timeStoppedNS").Gt(0).Lt(cutoffNS))
+
+	resp, err := es.DeleteByQuery(IndexName).
+		Query(q).
+		// The indexer may update entities while they are being deleted, those updates should win.
+		ProceedOnVersionConflict().
+		Refresh("true").
+		Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Deleted, nil
+}
@@ -0,0 +1,231 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package md
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/olivere/elastic/v7"
+)
+
+// exactMatchBoost is the boost applied to entities whose name exactly matches the query,
+// so that they rank above entities which only share a prefix with it.
+const exactMatchBoost = 10.0
+
+// defaultRecencyScale is the RecencyScale used when none is specified.
+const defaultRecencyScale = 24 * time.Hour
+
+// SearchOptions are the optional filters and ranking options for SearchEntities.
+type SearchOptions struct {
+	// Kinds restricts the results to entities of any of the given kinds, such as "pod" or "service".
+	Kinds []string
+	// Namespace restricts the results to entities in the given namespace.
+	Namespace string
+	// PodIP restricts the results to pods with the given IP.
+	PodIP string
+	// NodeName restricts the results to pods scheduled on the given node.
+	NodeName string
+	// RecencyWeight is how much the start time of an entity contributes to its score, relative to
+	// the text relevance. An entity started at Now has its score increased by RecencyWeight, and the
+	// increase decays exponentially as the start time gets older. 0 disables recency weighting.
+	RecencyWeight float64
+	// RecencyScale is the age at which the recency boost of an entity has decayed by half.
+	// Defaults to 24 hours.
+	RecencyScale time.Duration
+	// IncludeStopped includes entities which have stopped in the results. By default, only
+	// entities which have not stopped as of Now are returned.
+	IncludeStopped bool
+	// StartTimeNS and EndTimeNS restrict the results to entities which were alive at some point
+	// in the window between them. Either end of the window may be left unset (0) to leave it open.
+	// When a window is set, it replaces the default filtering of entities which have stopped as of Now.
+	StartTimeNS int64
+	EndTimeNS   int64
+	// Now is the time that the age and stopped state of entities are computed relative to.
+	// Defaults to time.Now().
+	Now time.Time
+	// NameAnalyzer is the analyzer that the name field of the index was created with, see
+	// WithNameAnalyzer. The query is analyzed to match it. Defaults to NameAnalyzerAutocomplete.
+	NameAnalyzer NameAnalyzer
+}
+
+// SearchEntities returns up to limit entities in the given org whose name matches the query,
+// ordered by relevance. Entities whose name exactly matches the query are ranked first. An empty
+// query matches every entity allowed by the filters in opts, such as all pods with a given IP.
+func SearchEntities(ctx context.Context, es *elastic.Client, orgID uuid.UUID, query string, opts *SearchOptions, limit int) ([]*EsMDEntity, error) {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	resp, err := es.Search().
+		Index(IndexName).
+		Query(withRecencyWeighting(searchQuery(orgID, query, opts, now), opts, now)).
+		Size(limit).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return hitsToEntities(resp.Hits.Hits)
+}
+
+// FindEntitiesByName returns up to limit entities in the given org whose name is exactly the given
+// name, including entities which have stopped. Unlike SearchEntities, names which only share a
+// prefix with the given name are not matched.
+func FindEntitiesByName(ctx context.Context, es *elastic.Client, orgID uuid.UUID, name string, limit int) ([]*EsMDEntity, error) {
+	q := elastic.NewBoolQuery().
+		Filter(elastic.NewTermQuery("orgID", orgID.String())).
+		Filter(elastic.NewTermQuery("name.keyword", name))
+
+	resp, err := es.Search().
+		Index(IndexName).
+		Query(q).
+		Size(limit).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return hitsToEntities(resp.Hits.Hits)
+}
+
+// FindRelated returns up to limit entities in the given org which are related to the entity with the
+// given name, such as the services that a pod belongs to. Only direct relationships are returned.
+func FindRelated(ctx context.Context, es *elastic.Client, orgID uuid.UUID, name string, limit int) ([]*EsMDEntity, error) {
+	q := elastic.NewBoolQuery().
+		Filter(elastic.NewTermQuery("orgID", orgID.String())).
+		Filter(elastic.NewTermQuery("relatedEntityNames.keyword", name))
+
+	resp, err := es.Search().
+		Index(IndexName).
+		Query(q).
+		Size(limit).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return hitsToEntities(resp.Hits.Hits)
+}
+
+func hitsToEntities(hits []*elastic.SearchHit) ([]*EsMDEntity, error) {
+	entities := make([]*EsMDEntity, len(hits))
+	for i, h := range hits {
+		e := &EsMDEntity{}
+		err := json.Unmarshal(h.Source, e)
+		if err != nil {
+			return nil, err
+		}
+		entities[i] = e
+	}
+	return entities, nil
+}
+
+func searchQuery(orgID uuid.UUID, query string, opts *SearchOptions, now time.Time) *elastic.BoolQuery {
+	q := elastic.NewBoolQuery()
+
+	if query != "" {
+		q.Must(nameMatchQuery(query, opts.NameAnalyzer))
+		q.Should(elastic.NewTermQuery("name.keyword", query).Boost(exactMatchBoost))
+	}
+
+	// Filters don't contribute to the score, they only restrict the set of matching entities.
+	q.Filter(elastic.NewTermQuery("orgID", orgID.String()))
+	if len(opts.Kinds) > 0 {
+		kinds := make([]interface{}, len(opts.Kinds))
+		for i, k := range opts.Kinds {
+			kinds[i] = k
+		}
+		q.Filter(elastic.NewTermsQuery("kind", kinds...))
+	}
+	if opts.Namespace != "" {
+		q.Filter(elastic.NewTermQuery("ns.keyword", opts.Namespace))
+	}
+	if opts.PodIP != "" {
+		q.Filter(elastic.NewTermQuery("podIP", opts.PodIP))
+	}
+	if opts.NodeName != "" {
+		q.Filter(elastic.NewTermQuery("nodeName", opts.NodeName))
+	}
+	if opts.StartTimeNS > 0 || opts.EndTimeNS > 0 {
+		q.Filter(aliveDuringQuery(opts.StartTimeNS, opts.EndTimeNS))
+	} else if !opts.IncludeStopped {
+		// Entities which haven't stopped have a stop time of 0.
+		q.Filter(elastic.NewBoolQuery().
+			Should(elastic.NewTermQuery("timeStoppedNS", 0)).
+			Should(elastic.NewRangeQuery("timeStoppedNS").Gt(now.UnixNano())).
+			MinimumNumberShouldMatch(1))
+	}
+	return q
+}
+
+// nameMatchQuery matches the query against the name field, analyzing it the same way as the
+// name field was analyzed by the given NameAnalyzer.
+func nameMatchQuery(query string, analyzer NameAnalyzer) *elastic.MatchQuery {
+	if analyzer == NameAnalyzerKeyword {
+		// Names are indexed as whole terms by the standard analyzer, so only whole terms can match.
+		return elastic.NewMatchQuery("name", query).Analyzer("standard").Operator("and")
+	}
+	// Every ngram of the query must be present, so that the query behaves as a prefix match.
+	return elastic.NewMatchQuery("name", query).Analyzer("autocomplete").Operator("and")
+}
+
+// aliveDuringQuery matches entities whose lifetime overlaps the window between start and end,
+// where an unset (0) start or end leaves that side of the window open.
+func aliveDuringQuery(start int64, end int64) *elastic.BoolQuery {
+	q := elastic.NewBoolQuery()
+	if end > 0 {
+		q.Filter(elastic.NewRangeQuery("timeStartedNS").Lte(end))
+	}
+	if start > 0 {
+		// Entities which haven't stopped have a stop time of 0.
+		q.Filter(elastic.NewBoolQuery().
+			Should(elastic.NewTermQuery("timeStoppedNS", 0)).
+			Should(elastic.NewRangeQuery("timeStoppedNS").Gte(start)).
+			MinimumNumberShouldMatch(1))
+	}
+	return q
+}
+
+// withRecencyWeighting adds a score to the given query that decays with the age of the entity,
+// if recency weighting is enabled.
+func withRecencyWeighting(q elastic.Query, opts *SearchOptions, now time.Time) elastic.Query {
+	if opts.RecencyWeight <= 0 {
+		return q
+	}
+	scale := opts.RecencyScale
+	if scale <= 0 {
+		scale = defaultRecencyScale
+	}
+
+	decay := elastic.NewExponentialDecayFunction().
+		FieldName("timeStartedNS").
+		Origin(now.UnixNano()).
+		Scale(scale.Nanoseconds()).
+		Decay(0.5).
+		Weight(opts.RecencyWeight)
+	return elastic.NewFunctionScoreQuery().
+		Query(q).
+		AddScoreFunc(decay).
+		BoostMode("sum")
+}
@@ -18,13 +18,18 @@
 
 package idmanager
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // CreateInviteLinkRequest is the request value for the IdentityProvider interface.
 type CreateInviteLinkRequest struct {
 	Email    string
 	PLOrgID  string
 	PLUserID string
+	// TTL is how long the invite link is valid for. If 0, the identity provider's default lifetime is used.
+	TTL time.Duration
 }
 
 // CreateInviteLinkResponse is the response value for the IdentityProvider interface.
@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/badoux/checkmail"
 	"github.com/gofrs/uuid"
@@ -66,6 +67,8 @@ type Datastore interface {
 	GetOrgByDomain(string) (*datastore.OrgInfo, error)
 	// Delete Org and all of its users
 	DeleteOrgAndUsers(uuid.UUID) error
+	// DeleteUser deletes the user.
+	DeleteUser(uuid.UUID) error
 	// UpdateUser updates the user info.
 	UpdateUser(*datastore.UserInfo) error
 	// ApproveAllOrgUsers sets is_approved for all users.
@@ -79,6 +82,13 @@ type Datastore interface {
 
 	// GetUsersInOrg gets all of the users in the given org.
 	GetUsersInOrg(uuid.UUID) ([]*datastore.UserInfo, error)
+
+	// CreateInvite records an invite sent to a user.
+	CreateInvite(*datastore.InviteInfo) error
+	// GetLatestInvite gets the most recent invite of the user to the org.
+	GetLatestInvite(uuid.UUID, uuid.UUID) (*datastore.InviteInfo, error)
+	// GetPendingInvitesForOrg gets the invites to the given org that have not been accepted or expired.
+	GetPendingInvitesForOrg(uuid.UUID) ([]*datastore.InviteInfo, error)
 }
 
 // UserSettingsDatastore is the interface used to the backing store for user settings.
@@ -118,6 +128,7 @@ func userInfoToProto(u *datastore.UserInfo) *profilepb.UserInfo {
 		IsApproved:       u.IsApproved,
 		IdentityProvider: u.IdentityProvider,
 		AuthProviderID:   u.AuthProviderID,
+		IsOrgAdmin:       u.IsOrgAdmin,
 	}
 }
 
@@ -229,6 +240,7 @@ func (s *Server) CreateOrgAndUser(ctx context.Context, req *profilepb.CreateOrgA
 		// By default, the creating user is the owner and should be approved.
 		IsApproved:     true,
 		AuthProviderID: req.User.AuthProviderID,
+		IsOrgAdmin:     true,
 	}
 	if len(orgInfo.DomainName) == 0 {
 		return nil, status.Error(codes.InvalidArgument, "invalid domain name")
@@ -408,8 +420,18 @@ func (s *Server) UpdateUserSettings(ctx context.Context, req *profilepb.UpdateUs
 
 // InviteUser implements the Profile interface's InviteUser method.
 func (s *Server) InviteUser(ctx context.Context, req *profilepb.InviteUserRequest) (*profilepb.InviteUserResponse, error) {
+	var ttl time.Duration
+	if req.TTL != nil {
+		var err error
+		ttl, err = types.DurationFromProto(req.TTL)
+		if err != nil || ttl < 0 {
+			return nil, status.Error(codes.InvalidArgument, "invalid invite TTL")
+		}
+	}
+
 	userInfo, err := s.d.GetUserByEmail(req.Email)
 	var userID uuid.UUID
+	userCreated := false
 	if err == datastore.ErrUserNotFound {
 		createUserReq := &profilepb.CreateUserRequest{
 			OrgID:            req.OrgID,
@@ -425,6 +447,7 @@ func (s *Server) InviteUser(ctx context.Context, req *profilepb.InviteUserReques
 			return nil, err
 		}
 		userID = utils.UUIDFromProtoOrNil(userIDPb)
+		userCreated = true
 
 		// Auto-approve user.
 		_, err = s.UpdateUser(ctx, &profilepb.UpdateUserRequest{
@@ -448,6 +471,7 @@ func (s *Server) InviteUser(ctx context.Context, req *profilepb.InviteUserReques
 		Email:    req.Email,
 		PLOrgID:  utils.ProtoToUUIDStr(req.OrgID),
 		PLUserID: userID.String(),
+		TTL:      ttl,
 	}
 
 	resp, err := s.IDManager.CreateInviteLink(ctx, idpCreateAccReq)
@@ -455,10 +479,99 @@ func (s *Server) InviteUser(ctx context.Context, req *profilepb.InviteUserReques
 		return nil, err
 	}
 
-	return &profilepb.InviteUserResponse{
+	invite := &datastore.InviteInfo{
+		OrgID:     utils.UUIDFromProtoOrNil(req.OrgID),
+		UserID:    userID,
+		InviterID: requestingUserID(ctx),
+	}
+	inviteResp := &profilepb.InviteUserResponse{
+		Email:       resp.Email,
+		InviteLink:  resp.InviteLink,
+		UserCreated: userCreated,
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().UTC().Add(ttl)
+		invite.ExpiresAt = &expiresAt
+		inviteResp.ExpiresAt, err = types.TimestampProto(expiresAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.d.CreateInvite(invite); err != nil {
+		return nil, err
+	}
+	return inviteResp, nil
+}
+
+// requestingUserID returns the ID of the user making the request, or nil if the request was not made by a user.
+func requestingUserID(ctx context.Context) *uuid.UUID {
+	sCtx, err := authcontext.FromContext(ctx)
+	if err != nil || claimsutils.GetClaimsType(sCtx.Claims) != claimsutils.UserClaimType {
+		return nil
+	}
+	userID, err := uuid.FromString(sCtx.Claims.GetUserClaims().UserID)
+	if err != nil {
+		return nil
+	}
+	return &userID
+}
+
+// ResendInvite regenerates the invite link for a user with a pending invite to the org. A user's invite is
+// pending until they first log in, which links them to a user in the auth provider.
+func (s *Server) ResendInvite(ctx context.Context, req *profilepb.ResendInviteRequest) (*profilepb.InviteUserResponse, error) {
+	orgID := utils.UUIDFromProtoOrNil(req.OrgID)
+	userInfo, err := s.d.GetUserByEmail(req.Email)
+	if err == datastore.ErrUserNotFound {
+		return nil, status.Error(codes.NotFound, "no pending invite for user")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if userInfo.OrgID != orgID || userInfo.AuthProviderID != "" {
+		return nil, status.Error(codes.NotFound, "no pending invite for user")
+	}
+
+	// The resent invite is valid for as long as the original invite was.
+	var ttl time.Duration
+	latest, err := s.d.GetLatestInvite(orgID, userInfo.ID)
+	if err != nil && err != datastore.ErrInviteNotFound {
+		return nil, err
+	}
+	if latest != nil && latest.CreatedAt != nil && latest.ExpiresAt != nil {
+		ttl = latest.ExpiresAt.Sub(*latest.CreatedAt)
+	}
+
+	resp, err := s.IDManager.CreateInviteLink(ctx, &idmanager.CreateInviteLinkRequest{
+		Email:    userInfo.Email,
+		PLOrgID:  orgID.String(),
+		PLUserID: userInfo.ID.String(),
+		TTL:      ttl,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invite := &datastore.InviteInfo{
+		OrgID:     orgID,
+		UserID:    userInfo.ID,
+		InviterID: requestingUserID(ctx),
+	}
+	inviteResp := &profilepb.InviteUserResponse{
 		Email:      resp.Email,
 		InviteLink: resp.InviteLink,
-	}, nil
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().UTC().Add(ttl)
+		invite.ExpiresAt = &expiresAt
+		inviteResp.ExpiresAt, err = types.TimestampProto(expiresAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.d.CreateInvite(invite); err != nil {
+		return nil, err
+	}
+	return inviteResp, nil
 }
 
 // GetUsersInOrg gets the users in the requested org, given that the requestor has permissions.
@@ -489,6 +602,142 @@ func (s *Server) GetUsersInOrg(ctx context.Context, req *profilepb.GetUsersInOrg
 	}, nil
 }
 
+// ListOrgInvites lists the pending invites to the requested org, given that the requestor has permissions.
+func (s *Server) ListOrgInvites(ctx context.Context, req *profilepb.ListOrgInvitesRequest) (*profilepb.ListOrgInvitesResponse, error) {
+	sCtx, err := authcontext.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claimsOrgID := uuid.FromStringOrNil(sCtx.Claims.GetUserClaims().OrgID)
+	reqOrgID := utils.UUIDFromProtoOrNil(req.OrgID)
+
+	if claimsOrgID != reqOrgID {
+		return nil, status.Error(codes.PermissionDenied, "unauthorized to list invites for org")
+	}
+
+	invites, err := s.d.GetPendingInvitesForOrg(reqOrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	invitesProto := make([]*profilepb.OrgInvite, len(invites))
+	for i, inv := range invites {
+		invitesProto[i] = &profilepb.OrgInvite{
+			Email: inv.Email,
+		}
+		if inv.InviterEmail != nil {
+			invitesProto[i].InviterEmail = *inv.InviterEmail
+		}
+		if inv.CreatedAt != nil {
+			invitesProto[i].CreatedAt, _ = types.TimestampProto(*inv.CreatedAt)
+		}
+		if inv.ExpiresAt != nil {
+			invitesProto[i].ExpiresAt, _ = types.TimestampProto(*inv.ExpiresAt)
+		}
+	}
+
+	return &profilepb.ListOrgInvitesResponse{
+		Invites: invitesProto,
+	}, nil
+}
+
+// authorizeOrgAdmin returns the user with the given ID, given that the requestor is an admin of the user's org.
+func (s *Server) authorizeOrgAdmin(ctx context.Context, userID uuid.UUID) (*datastore.UserInfo, error) {
+	requestorID := requestingUserID(ctx)
+	if requestorID == nil {
+		return nil, status.Error(codes.PermissionDenied, "requestor is not an org admin")
+	}
+	requestor, err := s.d.GetUser(*requestorID)
+	if err != nil {
+		return nil, toExternalError(err)
+	}
+
+	if userID == uuid.Nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user id")
+	}
+	userInfo, err := s.d.GetUser(userID)
+	if err != nil {
+		return nil, toExternalError(err)
+	}
+
+	sCtx, err := authcontext.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claimsOrgID := uuid.FromStringOrNil(sCtx.Claims.GetUserClaims().OrgID)
+	if !requestor.IsOrgAdmin || requestor.OrgID != claimsOrgID || userInfo.OrgID != claimsOrgID {
+		return nil, status.Error(codes.PermissionDenied, "requestor is not an org admin")
+	}
+	return userInfo, nil
+}
+
+// checkNotLastOrgAdmin errors out with FailedPrecondition if the given user is the only admin of their org.
+func (s *Server) checkNotLastOrgAdmin(userInfo *datastore.UserInfo) error {
+	if !userInfo.IsOrgAdmin {
+		return nil
+	}
+	users, err := s.d.GetUsersInOrg(userInfo.OrgID)
+	if err != nil {
+		return err
+	}
+	numAdmins := 0
+	for _, u := range users {
+		if u.IsOrgAdmin {
+			numAdmins++
+		}
+	}
+	if numAdmins <= 1 {
+		return status.Error(codes.FailedPrecondition, "org must have at least one admin")
+	}
+	return nil
+}
+
+// RemoveUserFromOrg removes a user from their org, given that the requestor is an admin of the same org.
+func (s *Server) RemoveUserFromOrg(ctx context.Context, req *profilepb.RemoveUserFromOrgRequest) (*profilepb.RemoveUserFromOrgResponse, error) {
+	userInfo, err := s.authorizeOrgAdmin(ctx, utils.UUIDFromProtoOrNil(req.UserID))
+	if err != nil {
+		return nil, err
+	}
+	err = s.checkNotLastOrgAdmin(userInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.d.DeleteUser(userInfo.ID)
+	if err != nil {
+		return nil, toExternalError(err)
+	}
+	return &profilepb.RemoveUserFromOrgResponse{Success: true}, nil
+}
+
+// UpdateUserRole updates whether a user is an admin of their org, given that the requestor is an admin of the same org.
+func (s *Server) UpdateUserRole(ctx context.Context, req *profilepb.UpdateUserRoleRequest) (*profilepb.UserInfo, error) {
+	userInfo, err := s.authorizeOrgAdmin(ctx, utils.UUIDFromProtoOrNil(req.UserID))
+	if err != nil {
+		return nil, err
+	}
+	if userInfo.IsOrgAdmin == req.IsOrgAdmin {
+		return userInfoToProto(userInfo), nil
+	}
+
+	if req.IsOrgAdmin && !userInfo.IsApproved {
+		return nil, status.Error(codes.FailedPrecondition, "user must be approved before becoming an org admin")
+	}
+	if !req.IsOrgAdmin {
+		err = s.checkNotLastOrgAdmin(userInfo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	userInfo.IsOrgAdmin = req.IsOrgAdmin
+	err = s.d.UpdateUser(userInfo)
+	if err != nil {
+		return nil, toExternalError(err)
+	}
+	return userInfoToProto(userInfo), nil
+}
+
 // UpdateOrg updates an orgs info.
 func (s *Server) UpdateOrg(ctx context.Context, req *profilepb.UpdateOrgRequest) (*profilepb.OrgInfo, error) {
 	id := utils.UUIDFromProtoOrNil(req.ID)
@@ -501,6 +750,10 @@ func (s *Server) UpdateOrg(ctx context.Context, req *profilepb.UpdateOrgRequest)
 		return nil, err
 	}
 
+	if req.OrgName != nil && strings.TrimSpace(req.OrgName.Value) == "" {
+		return nil, status.Error(codes.InvalidArgument, "org name cannot be empty")
+	}
+
 	// Only check the claims type for users.
 	if claimsutils.GetClaimsType(sCtx.Claims) == claimsutils.UserClaimType {
 		claimsOrgID := uuid.FromStringOrNil(sCtx.Claims.GetUserClaims().OrgID)
@@ -508,6 +761,17 @@ func (s *Server) UpdateOrg(ctx context.Context, req *profilepb.UpdateOrgRequest)
 		if id != claimsOrgID {
 			return nil, status.Error(codes.PermissionDenied, "user does not have permissions to update org field")
 		}
+
+		// Only admins of the org may rename it.
+		if req.OrgName != nil {
+			requestor, err := s.d.GetUser(uuid.FromStringOrNil(sCtx.Claims.GetUserClaims().UserID))
+			if err != nil {
+				return nil, toExternalError(err)
+			}
+			if !requestor.IsOrgAdmin || requestor.OrgID != id {
+				return nil, status.Error(codes.PermissionDenied, "user does not have permissions to rename org")
+			}
+		}
 	}
 
 	// Get OrgInfo.
@@ -516,17 +780,24 @@ func (s *Server) UpdateOrg(ctx context.Context, req *profilepb.UpdateOrgRequest)
 		return nil, toExternalError(err)
 	}
 
+	approvalsChanged := req.EnableApprovals != nil && orgInfo.EnableApprovals != req.EnableApprovals.Value
+	nameChanged := req.OrgName != nil && orgInfo.OrgName != req.OrgName.Value
 	// If the values are the same, no need to update.
-	if req.EnableApprovals == nil || orgInfo.EnableApprovals == req.EnableApprovals.Value {
+	if !approvalsChanged && !nameChanged {
 		return orgInfoToProto(orgInfo), nil
 	}
 
-	orgInfo.EnableApprovals = req.EnableApprovals.Value
+	if approvalsChanged {
+		orgInfo.EnableApprovals = req.EnableApprovals.Value
+	}
+	if nameChanged {
+		orgInfo.OrgName = req.OrgName.Value
+	}
 	if err := s.d.UpdateOrg(orgInfo); err != nil {
 		return nil, toExternalError(err)
 	}
 	// If EnableApprovals has changed to false, we flip the flag for all users to approve them.
-	if !orgInfo.EnableApprovals {
+	if approvalsChanged && !orgInfo.EnableApprovals {
 		err = s.d.ApproveAllOrgUsers(id)
 		if err != nil {
 			return nil, toExternalError(err)
@@ -463,6 +463,7 @@ func TestServer_CreateOrgAndUser_SuccessCases(t *testing.T) {
 				IsApproved:       true,
 				IdentityProvider: tc.req.User.IdentityProvider,
 				AuthProviderID:   tc.req.User.AuthProviderID,
+				IsOrgAdmin:       true,
 			}
 			exOrg := &datastore.OrgInfo{
 				DomainName: tc.req.Org.DomainName,
@@ -625,6 +626,7 @@ func TestServer_CreateOrgAndUser_CreateProjectFailed(t *testing.T) {
 		Email:            req.User.Email,
 		IsApproved:       true,
 		IdentityProvider: "github",
+		IsOrgAdmin:       true,
 	}
 	exOrg := &datastore.OrgInfo{
 		DomainName: req.Org.DomainName,
@@ -984,6 +986,86 @@ func TestServer_UpdateOrg(t *testing.T) {
 	assert.Equal(t, resp.EnableApprovals, true)
 }
 
+func TestServer_UpdateOrg_Rename(t *testing.T) {
+	// The user ID in the test context.
+	requestorID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c9")
+	orgID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	tests := []struct {
+		name          string
+		orgID         uuid.UUID
+		orgName       string
+		requestor     *datastore.UserInfo
+		expectUpdate  bool
+		expectErrCode codes.Code
+	}{
+		{
+			name:          "admin renames org",
+			orgID:         orgID,
+			orgName:       "new-name",
+			requestor:     &datastore.UserInfo{ID: requestorID, OrgID: orgID, IsApproved: true, IsOrgAdmin: true},
+			expectUpdate:  true,
+			expectErrCode: codes.OK,
+		},
+		{
+			name:          "non-admin",
+			orgID:         orgID,
+			orgName:       "new-name",
+			requestor:     &datastore.UserInfo{ID: requestorID, OrgID: orgID, IsApproved: true},
+			expectErrCode: codes.PermissionDenied,
+		},
+		{
+			name:          "other org",
+			orgID:         uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c7"),
+			orgName:       "new-name",
+			expectErrCode: codes.PermissionDenied,
+		},
+		{
+			name:          "empty name",
+			orgID:         orgID,
+			orgName:       " ",
+			expectErrCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d := mock_controller.NewMockDatastore(ctrl)
+			s := controller.NewServer(nil, d, nil, nil)
+
+			if tc.requestor != nil {
+				d.EXPECT().
+					GetUser(requestorID).
+					Return(tc.requestor, nil)
+			}
+			if tc.expectUpdate {
+				d.EXPECT().
+					GetOrg(orgID).
+					Return(&datastore.OrgInfo{ID: orgID, OrgName: "old-name", DomainName: "test.com"}, nil)
+				d.EXPECT().
+					UpdateOrg(&datastore.OrgInfo{ID: orgID, OrgName: tc.orgName, DomainName: "test.com"}).
+					Return(nil)
+			}
+
+			resp, err := s.UpdateOrg(CreateTestContext(), &profilepb.UpdateOrgRequest{
+				ID:      utils.ProtoFromUUID(tc.orgID),
+				OrgName: &types.StringValue{Value: tc.orgName},
+			})
+			if tc.expectErrCode != codes.OK {
+				assert.Nil(t, resp)
+				assert.Equal(t, tc.expectErrCode, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.orgName, resp.OrgName)
+			assert.Equal(t, "test.com", resp.DomainName)
+		})
+	}
+}
+
 func TestServer_UpdateOrg_DisableApprovals(t *testing.T) {
 	// Disabling approvals now causes all existing users to become approved.
 	ctrl := gomock.NewController(t)
@@ -1209,6 +1291,8 @@ func CreateTestContext() context.Context {
 
 func TestServerInviteUser(t *testing.T) {
 	userID := uuid.Must(uuid.NewV4())
+	// The user ID in the test context.
+	inviterID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c9")
 
 	orgID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
 
@@ -1339,6 +1423,13 @@ func TestServerInviteUser(t *testing.T) {
 					Email:      req.Email,
 					InviteLink: "self-service/recovery/methods",
 				}, nil)
+				d.EXPECT().
+					CreateInvite(&datastore.InviteInfo{
+						OrgID:     orgID,
+						UserID:    userID,
+						InviterID: &inviterID,
+					}).
+					Return(nil)
 			}
 
 			resp, err := s.InviteUser(ctx, req)
@@ -1347,6 +1438,7 @@ func TestServerInviteUser(t *testing.T) {
 				require.NoError(t, err)
 				assert.Equal(t, resp.Email, req.Email)
 				assert.Regexp(t, "self-service/recovery/methods", resp.InviteLink)
+				assert.Equal(t, !tc.doesUserExist, resp.UserCreated)
 			} else {
 				assert.Equal(t, err, tc.err)
 			}
@@ -1354,6 +1446,521 @@ func TestServerInviteUser(t *testing.T) {
 	}
 }
 
+func TestServerInviteUser_WithTTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d := mock_controller.NewMockDatastore(ctrl)
+	client := mock_idmanager.NewMockManager(ctrl)
+	s := controller.NewServer(nil, d, nil, client)
+
+	userID := uuid.Must(uuid.NewV4())
+	orgID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	ctx := CreateTestContext()
+
+	req := &profilepb.InviteUserRequest{
+		OrgID:            utils.ProtoFromUUID(orgID),
+		Email:            "bobloblaw@lawblog.com",
+		FirstName:        "Bob",
+		LastName:         "Loblaw",
+		IdentityProvider: "kratos",
+		TTL:              types.DurationProto(48 * time.Hour),
+	}
+
+	d.EXPECT().
+		GetUserByEmail("bobloblaw@lawblog.com").
+		Return(&datastore.UserInfo{
+			ID:               userID,
+			OrgID:            orgID,
+			Email:            req.Email,
+			IsApproved:       true,
+			IdentityProvider: "kratos",
+		}, nil)
+	client.EXPECT().
+		CreateInviteLink(
+			gomock.Any(),
+			&idmanager.CreateInviteLinkRequest{
+				Email:    req.Email,
+				PLOrgID:  orgID.String(),
+				PLUserID: userID.String(),
+				TTL:      48 * time.Hour,
+			},
+		).Return(&idmanager.CreateInviteLinkResponse{
+		Email:      req.Email,
+		InviteLink: "self-service/recovery/methods",
+	}, nil)
+	var invite *datastore.InviteInfo
+	d.EXPECT().
+		CreateInvite(gomock.Any()).
+		DoAndReturn(func(i *datastore.InviteInfo) error {
+			invite = i
+			return nil
+		})
+
+	before := time.Now()
+	resp, err := s.InviteUser(ctx, req)
+	require.NoError(t, err)
+	require.NotNil(t, resp.ExpiresAt)
+	expiresAt, err := types.TimestampFromProto(resp.ExpiresAt)
+	require.NoError(t, err)
+	// The expiration time should be recorded with the invite.
+	require.NotNil(t, invite)
+	require.NotNil(t, invite.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*invite.ExpiresAt))
+	assert.False(t, expiresAt.Before(before.Add(48*time.Hour)))
+	assert.False(t, expiresAt.After(time.Now().Add(48*time.Hour)))
+}
+
+func TestServerInviteUser_InvalidTTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d := mock_controller.NewMockDatastore(ctrl)
+	client := mock_idmanager.NewMockManager(ctrl)
+	s := controller.NewServer(nil, d, nil, client)
+
+	resp, err := s.InviteUser(CreateTestContext(), &profilepb.InviteUserRequest{
+		OrgID: utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+		Email: "bobloblaw@lawblog.com",
+		TTL:   types.DurationProto(-time.Hour),
+	})
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServer_ListOrgInvites(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d := mock_controller.NewMockDatastore(ctrl)
+	s := controller.NewServer(nil, d, nil, nil)
+
+	orgID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	createdAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(24 * time.Hour)
+	inviterEmail := "test@test.com"
+
+	d.EXPECT().
+		GetPendingInvitesForOrg(orgID).
+		Return([]*datastore.InviteInfo{
+			{
+				OrgID:        orgID,
+				UserID:       uuid.Must(uuid.NewV4()),
+				Email:        "bobloblaw@lawblog.com",
+				InviterEmail: &inviterEmail,
+				CreatedAt:    &createdAt,
+				ExpiresAt:    &expiresAt,
+			},
+			{
+				OrgID:     orgID,
+				UserID:    uuid.Must(uuid.NewV4()),
+				Email:     "lindsay@bluth.com",
+				CreatedAt: &createdAt,
+			},
+		}, nil)
+
+	resp, err := s.ListOrgInvites(CreateTestContext(), &profilepb.ListOrgInvitesRequest{
+		OrgID: utils.ProtoFromUUID(orgID),
+	})
+	require.NoError(t, err)
+
+	createdAtPb, _ := types.TimestampProto(createdAt)
+	expiresAtPb, _ := types.TimestampProto(expiresAt)
+	assert.Equal(t, []*profilepb.OrgInvite{
+		{
+			Email:        "bobloblaw@lawblog.com",
+			InviterEmail: "test@test.com",
+			CreatedAt:    createdAtPb,
+			ExpiresAt:    expiresAtPb,
+		},
+		{
+			Email:     "lindsay@bluth.com",
+			CreatedAt: createdAtPb,
+		},
+	}, resp.Invites)
+}
+
+func TestServer_ListOrgInvites_OtherOrg(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d := mock_controller.NewMockDatastore(ctrl)
+	s := controller.NewServer(nil, d, nil, nil)
+
+	resp, err := s.ListOrgInvites(CreateTestContext(), &profilepb.ListOrgInvitesRequest{
+		OrgID: utils.ProtoFromUUIDStrOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c7"),
+	})
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestServer_RemoveUserFromOrg(t *testing.T) {
+	// The user ID in the test context.
+	requestorID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c9")
+	userID := uuid.Must(uuid.NewV4())
+	orgID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	otherOrgID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c7")
+
+	tests := []struct {
+		name      string
+		requestor *datastore.UserInfo
+		user      *datastore.UserInfo
+		// The users in the org, if they should be looked up.
+		orgUsers      []*datastore.UserInfo
+		expectDelete  bool
+		expectErrCode codes.Code
+	}{
+		{
+			name:      "remove admin",
+			requestor: &datastore.UserInfo{ID: requestorID, OrgID: orgID, IsOrgAdmin: true},
+			user:      &datastore.UserInfo{ID: userID, OrgID: orgID, IsOrgAdmin: true},
+			orgUsers: []*datastore.UserInfo{
+				{ID: requestorID, OrgID: orgID, IsOrgAdmin: true},
+				{ID: userID, OrgID: orgID, IsOrgAdmin: true},
+			},
+			expectDelete:  true,
+			expectErrCode: codes.OK,
+		},
+		{
+			name:          "remove non-admin user",
+			requestor:     &datastore.UserInfo{ID: requestorID, OrgID: orgID, IsOrgAdmin: true},
+			user:          &datastore.UserInfo{ID: userID, OrgID: orgID, IsOrgAdmin: false},
+			expectDelete:  true,
+			expectErrCode: codes.OK,
+		},
+		{
+			name:          "user in another org",
+			requestor:     &datastore.UserInfo{ID: requestorID, OrgID: orgID, IsOrgAdmin: true},
+			user:          &datastore.UserInfo{ID: userID, OrgID: otherOrgID, IsOrgAdmin: true},
+			expectDelete:  false,
+			expectErrCode: codes.PermissionDenied,
+		},
+		{
+			name:          "requestor is not an admin",
+			requestor:     &datastore.UserInfo{ID: requestorID, OrgID: orgID, IsOrgAdmin: false},
+			user:          &datastore.UserInfo{ID: userID, OrgID: orgID, IsOrgAdmin: true},
+			expectDelete:  false,
+			expectErrCode: codes.PermissionDenied,
+		},
+		{
+			name:      "last admin",
+			requestor: &datastore.UserInfo{ID: requestorID, OrgID: orgID, IsOrgAdmin: true},
+			user:      &datastore.UserInfo{ID: requestorID, OrgID: orgID, IsOrgAdmin: true},
+			orgUsers: []*datastore.UserInfo{
+				{ID: requestorID, OrgID: orgID, IsOrgAdmin: true},
+				{ID: userID, OrgID: orgID, IsOrgAdmin: false},
+			},
+			expectDelete:  false,
+			expectErrCode: codes.FailedPrecondition,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d := mock_controller.NewMockDatastore(ctrl)
+			s := controller.NewServer(nil, d, nil, nil)
+
+			if tc.user.ID == requestorID {
+				d.EXPECT().
+					GetUser(requestorID).
+					Return(tc.requestor, nil).
+					Times(2)
+			} else {
+				d.EXPECT().
+					GetUser(requestorID).
+					Return(tc.requestor, nil)
+				d.EXPECT().
+					GetUser(tc.user.ID).
+					Return(tc.user, nil)
+			}
+			if tc.orgUsers != nil {
+				d.EXPECT().
+					GetUsersInOrg(orgID).
+					Return(tc.orgUsers, nil)
+			}
+			if tc.expectDelete {
+				d.EXPECT().
+					DeleteUser(tc.user.ID).
+					Return(nil)
+			}
+
+			resp, err := s.RemoveUserFromOrg(CreateTestContext(), &profilepb.RemoveUserFromOrgRequest{
+				UserID: utils.ProtoFromUUID(tc.user.ID),
+			})
+			if tc.expectErrCode != codes.OK {
+				assert.Nil(t, resp)
+				assert.Equal(t, tc.expectErrCode, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, resp.Success)
+		})
+	}
+}
+
+func TestServer_UpdateUserRole(t *testing.T) {
+	// The user ID in the test context.
+	requestorID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c9")
+	userID := uuid.Must(uuid.NewV4())
+	orgID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	tests := []struct {
+		name       string
+		requestor  *datastore.UserInfo
+		user       *datastore.UserInfo
+		isOrgAdmin bool
+		// The users in the org, if they should be looked up.
+		orgUsers      []*datastore.UserInfo
+		expectUpdate  bool
+		expectErrCode codes.Code
+	}{
+		{
+			name:          "promote member",
+			requestor:     &datastore.UserInfo{ID: requestorID, OrgID: orgID, IsApproved: true, IsOrgAdmin: true},
+			user:          &datastore.UserInfo{ID: userID, OrgID: orgID, IsApproved: true},
+			isOrgAdmin:    true,
+			expectUpdate:  true,
+			expectErrCode: codes.OK,
+		},
+		{
+			name:       "demote admin",
+			requestor:  &datastore.UserInfo{ID: requestorID, OrgID: orgID, IsApproved: true, IsOrgAdmin: true},
+			user:       &datastore.UserInfo{ID: userID, OrgID: orgID, IsApproved: true, IsOrgAdmin: true},
+			isOrgAdmin: false,
+			orgUsers: []*datastore.UserInfo{
+				{ID: requestorID, OrgID: orgID, IsApproved: true, IsOrgAdmin: true},
+				{ID: userID, OrgID: orgID, IsApproved: true, IsOrgAdmin: true},
+			},
+			expectUpdate:  true,
+			expectErrCode: codes.OK,
+		},
+		{
+			name:          "requestor is not an admin",
+			requestor:     &datastore.UserInfo{ID: requestorID, OrgID: orgID, IsApproved: true},
+			user:          &datastore.UserInfo{ID: userID, OrgID: orgID, IsApproved: true},
+			isOrgAdmin:    true,
+			expectUpdate:  false,
+			expectErrCode: codes.PermissionDenied,
+		},
+		{
+			name:          "promote unapproved user",
+			requestor:     &datastore.UserInfo{ID: requestorID, OrgID: orgID, IsApproved: true, IsOrgAdmin: true},
+			user:          &datastore.UserInfo{ID: userID, OrgID: orgID, IsApproved: false},
+			isOrgAdmin:    true,
+			expectUpdate:  false,
+			expectErrCode: codes.FailedPrecondition,
+		},
+		{
+			name:       "demote last admin",
+			requestor:  &datastore.UserInfo{ID: requestorID, OrgID: orgID, IsApproved: true, IsOrgAdmin: true},
+			user:       &datastore.UserInfo{ID: requestorID, OrgID: orgID, IsApproved: true, IsOrgAdmin: true},
+			isOrgAdmin: false,
+			orgUsers: []*datastore.UserInfo{
+				{ID: requestorID, OrgID: orgID, IsApproved: true, IsOrgAdmin: true},
+				{ID: userID, OrgID: orgID, IsApproved: true},
+			},
+			expectUpdate:  false,
+			expectErrCode: codes.FailedPrecondition,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d := mock_controller.NewMockDatastore(ctrl)
+			s := controller.NewServer(nil, d, nil, nil)
+
+			if tc.user.ID == requestorID {
+				d.EXPECT().
+					GetUser(requestorID).
+					Return(tc.requestor, nil).
+					Times(2)
+			} else {
+				d.EXPECT().
+					GetUser(requestorID).
+					Return(tc.requestor, nil)
+				d.EXPECT().
+					GetUser(tc.user.ID).
+					Return(tc.user, nil)
+			}
+			if tc.orgUsers != nil {
+				d.EXPECT().
+					GetUsersInOrg(orgID).
+					Return(tc.orgUsers, nil)
+			}
+			if tc.expectUpdate {
+				d.EXPECT().
+					UpdateUser(&datastore.UserInfo{
+						ID:         tc.user.ID,
+						OrgID:      orgID,
+						IsApproved: tc.user.IsApproved,
+						IsOrgAdmin: tc.isOrgAdmin,
+					}).
+					Return(nil)
+			}
+
+			resp, err := s.UpdateUserRole(CreateTestContext(), &profilepb.UpdateUserRoleRequest{
+				UserID:     utils.ProtoFromUUID(tc.user.ID),
+				IsOrgAdmin: tc.isOrgAdmin,
+			})
+			if tc.expectErrCode != codes.OK {
+				assert.Nil(t, resp)
+				assert.Equal(t, tc.expectErrCode, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, utils.ProtoFromUUID(tc.user.ID), resp.ID)
+			assert.Equal(t, tc.isOrgAdmin, resp.IsOrgAdmin)
+		})
+	}
+}
+
+func TestServer_ResendInvite(t *testing.T) {
+	userID := uuid.Must(uuid.NewV4())
+	// The user ID in the test context.
+	inviterID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c9")
+	orgID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	otherOrgID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c7")
+
+	ctx := CreateTestContext()
+	tests := []struct {
+		name string
+		// The user returned by the datastore, nil if the user doesn't exist.
+		user *datastore.UserInfo
+		// The TTL of the original invite, 0 if it didn't have one.
+		inviteTTL     time.Duration
+		expectResend  bool
+		expectErrCode codes.Code
+	}{
+		{
+			name: "pending invite",
+			user: &datastore.UserInfo{
+				ID:    userID,
+				OrgID: orgID,
+				Email: "bobloblaw@lawblog.com",
+			},
+			expectResend:  true,
+			expectErrCode: codes.OK,
+		},
+		{
+			name: "pending invite with a TTL",
+			user: &datastore.UserInfo{
+				ID:    userID,
+				OrgID: orgID,
+				Email: "bobloblaw@lawblog.com",
+			},
+			inviteTTL:     48 * time.Hour,
+			expectResend:  true,
+			expectErrCode: codes.OK,
+		},
+		{
+			name:          "user does not exist",
+			user:          nil,
+			expectErrCode: codes.NotFound,
+		},
+		{
+			name: "user in another org",
+			user: &datastore.UserInfo{
+				ID:    userID,
+				OrgID: otherOrgID,
+				Email: "bobloblaw@lawblog.com",
+			},
+			expectErrCode: codes.NotFound,
+		},
+		{
+			name: "invite already accepted",
+			user: &datastore.UserInfo{
+				ID:             userID,
+				OrgID:          orgID,
+				Email:          "bobloblaw@lawblog.com",
+				AuthProviderID: "github|abcdefg",
+			},
+			expectErrCode: codes.NotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d := mock_controller.NewMockDatastore(ctrl)
+			client := mock_idmanager.NewMockManager(ctrl)
+			s := controller.NewServer(nil, d, nil, client)
+
+			if tc.user == nil {
+				d.EXPECT().
+					GetUserByEmail("bobloblaw@lawblog.com").
+					Return(nil, datastore.ErrUserNotFound)
+			} else {
+				d.EXPECT().
+					GetUserByEmail("bobloblaw@lawblog.com").
+					Return(tc.user, nil)
+			}
+
+			// A new user should never be created when resending an invite.
+			if tc.expectResend {
+				createdAt := time.Now().Add(-time.Hour)
+				latest := &datastore.InviteInfo{
+					OrgID:     orgID,
+					UserID:    userID,
+					CreatedAt: &createdAt,
+				}
+				if tc.inviteTTL > 0 {
+					expiresAt := createdAt.Add(tc.inviteTTL)
+					latest.ExpiresAt = &expiresAt
+				}
+				d.EXPECT().
+					GetLatestInvite(orgID, userID).
+					Return(latest, nil)
+				client.EXPECT().
+					CreateInviteLink(gomock.Any(), &idmanager.CreateInviteLinkRequest{
+						Email:    "bobloblaw@lawblog.com",
+						PLOrgID:  orgID.String(),
+						PLUserID: userID.String(),
+						TTL:      tc.inviteTTL,
+					}).
+					Return(&idmanager.CreateInviteLinkResponse{
+						Email:      "bobloblaw@lawblog.com",
+						InviteLink: "self-service/recovery/methods",
+					}, nil)
+				d.EXPECT().
+					CreateInvite(gomock.Any()).
+					DoAndReturn(func(invite *datastore.InviteInfo) error {
+						assert.Equal(t, orgID, invite.OrgID)
+						assert.Equal(t, userID, invite.UserID)
+						assert.Equal(t, &inviterID, invite.InviterID)
+						if tc.inviteTTL == 0 {
+							assert.Nil(t, invite.ExpiresAt)
+						} else {
+							require.NotNil(t, invite.ExpiresAt)
+							assert.WithinDuration(t, time.Now().Add(tc.inviteTTL), *invite.ExpiresAt, time.Minute)
+						}
+						return nil
+					})
+			}
+
+			resp, err := s.ResendInvite(ctx, &profilepb.ResendInviteRequest{
+				OrgID: utils.ProtoFromUUID(orgID),
+				Email: "bobloblaw@lawblog.com",
+			})
+			assert.Equal(t, tc.expectErrCode, status.Code(err))
+			if tc.expectResend {
+				require.NoError(t, err)
+				assert.Equal(t, "bobloblaw@lawblog.com", resp.Email)
+				assert.Equal(t, "self-service/recovery/methods", resp.InviteLink)
+				assert.False(t, resp.UserCreated)
+				assert.Equal(t, tc.inviteTTL > 0, resp.ExpiresAt != nil)
+			}
+		})
+	}
+}
+
 func TestServer_GetUsersInOrg(t *testing.T) {
 	orgID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
 	otherOrgID := uuid.FromStringOrNil("6ba7b810-9dad-11d1-80b4-00c04fd430c7")
@@ -43,6 +43,8 @@ type UserInfo struct {
 	IsApproved       bool       `db:"is_approved"`
 	IdentityProvider string     `db:"identity_provider"`
 	AuthProviderID   string     `db:"auth_provider_id"`
+	// IsOrgAdmin is whether the user can manage the other users in their org.
+	IsOrgAdmin bool `db:"is_org_admin"`
 }
 
 // OrgInfo tracks information about an organization.
@@ -55,6 +57,18 @@ type OrgInfo struct {
 	CreatedAt       *time.Time `db:"created_at"`
 }
 
+// InviteInfo tracks an invite that was sent to a user to join an org.
+type InviteInfo struct {
+	OrgID     uuid.UUID  `db:"org_id"`
+	UserID    uuid.UUID  `db:"user_id"`
+	InviterID *uuid.UUID `db:"inviter_id"`
+	CreatedAt *time.Time `db:"created_at"`
+	ExpiresAt *time.Time `db:"expires_at"`
+	// Email and InviterEmail are looked up from the users table when reading invites.
+	Email        string  `db:"email"`
+	InviterEmail *string `db:"inviter_email"`
+}
+
 // Datastore is a postgres backed storage for entities.
 type Datastore struct {
 	db *sqlx.DB
@@ -70,6 +84,8 @@ var (
 	ErrUserNotFound = fmt.Errorf("user not found")
 	// ErrOrgNotFound is used when the org is not found when looking up by a filter condition.
 	ErrOrgNotFound = fmt.Errorf("org not found")
+	// ErrInviteNotFound is used when a user has never been invited to an org.
+	ErrInviteNotFound = fmt.Errorf("invite not found")
 )
 
 // CreateUser creates a new user.
@@ -237,8 +253,36 @@ func (d *Datastore) DeleteOrgAndUsers(orgID uuid.UUID) error {
 	return txn.Commit()
 }
 
+// DeleteUser deletes the user with the given ID, along with their settings.
+func (d *Datastore) DeleteUser(userID uuid.UUID) error {
+	txn, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer txn.Rollback()
+
+	deleteSettingsQuery := `DELETE FROM user_settings WHERE user_id=$1`
+	deleteUserQuery := `DELETE FROM users WHERE id=$1`
+	_, err = txn.Exec(deleteSettingsQuery, userID)
+	if err != nil {
+		return err
+	}
+	res, err := txn.Exec(deleteUserQuery, userID)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrUserNotFound
+	}
+	return txn.Commit()
+}
+
 func (d *Datastore) createUserUsingTxn(txn *sqlx.Tx, userInfo *UserInfo) (uuid.UUID, error) {
-	query := `INSERT INTO users (org_id, username, first_name, last_name, email, is_approved, identity_provider, auth_provider_id) VALUES (:org_id, :username, :first_name, :last_name, :email, :is_approved, :identity_provider, :auth_provider_id) RETURNING id`
+	query := `INSERT INTO users (org_id, username, first_name, last_name, email, is_approved, identity_provider, auth_provider_id, is_org_admin) VALUES (:org_id, :username, :first_name, :last_name, :email, :is_approved, :identity_provider, :auth_provider_id, :is_org_admin) RETURNING id`
 	row, err := txn.NamedQuery(query, userInfo)
 	if err != nil {
 		return uuid.Nil, err
@@ -296,14 +340,14 @@ func (d *Datastore) GetUsersInOrg(orgID uuid.UUID) ([]*UserInfo, error) {
 
 // UpdateUser updates the user in the database.
 func (d *Datastore) UpdateUser(userInfo *UserInfo) error {
-	query := `UPDATE users SET profile_picture = :profile_picture, is_approved = :is_approved WHERE id = :id`
+	query := `UPDATE users SET profile_picture = :profile_picture, is_approved = :is_approved, is_org_admin = :is_org_admin WHERE id = :id`
 	_, err := d.db.NamedExec(query, userInfo)
 	return err
 }
 
 // UpdateOrg updates the org in the database.
 func (d *Datastore) UpdateOrg(orgInfo *OrgInfo) error {
-	query := `UPDATE orgs SET enable_approvals = :enable_approvals WHERE id = :id`
+	query := `UPDATE orgs SET org_name = :org_name, enable_approvals = :enable_approvals WHERE id = :id`
 	_, err := d.db.NamedExec(query, orgInfo)
 	return err
 }
@@ -319,6 +363,65 @@ func (d *Datastore) ApproveAllOrgUsers(orgID uuid.UUID) error {
 	return err
 }
 
+// CreateInvite records an invite that was sent to a user.
+func (d *Datastore) CreateInvite(inviteInfo *InviteInfo) error {
+	query := `INSERT INTO org_invites (org_id, user_id, inviter_id, expires_at) VALUES (:org_id, :user_id, :inviter_id, :expires_at)`
+	_, err := d.db.NamedExec(query, inviteInfo)
+	return err
+}
+
+// GetLatestInvite gets the most recent invite of the given user to the given org, whether or not it is still pending.
+func (d *Datastore) GetLatestInvite(orgID uuid.UUID, userID uuid.UUID) (*InviteInfo, error) {
+	query := `SELECT org_id, user_id, inviter_id, created_at, expires_at FROM org_invites
+		WHERE org_id=$1 AND user_id=$2 ORDER BY created_at DESC LIMIT 1`
+	rows, err := d.db.Queryx(query, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var inviteInfo InviteInfo
+		err := rows.StructScan(&inviteInfo)
+		return &inviteInfo, err
+	}
+	return nil, ErrInviteNotFound
+}
+
+// GetPendingInvitesForOrg gets the latest invite for each user in the given org that has neither
+// accepted nor let their invite expire, ordered from most to least recent.
+func (d *Datastore) GetPendingInvitesForOrg(orgID uuid.UUID) ([]*InviteInfo, error) {
+	query := `
+		SELECT * FROM (
+			SELECT DISTINCT ON (i.user_id) i.org_id, i.user_id, i.inviter_id, i.created_at, i.expires_at,
+				u.email, inviter.email AS inviter_email
+			FROM org_invites i
+			JOIN users u ON u.id = i.user_id
+			LEFT JOIN users inviter ON inviter.id = i.inviter_id
+			WHERE i.org_id=$1 AND u.org_id=$1
+			AND (u.auth_provider_id IS NULL OR u.auth_provider_id = '')
+			ORDER BY i.user_id, i.created_at DESC
+		) AS latest
+		WHERE latest.expires_at IS NULL OR latest.expires_at > NOW()
+		ORDER BY latest.created_at DESC`
+	rows, err := d.db.Queryx(query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := make([]*InviteInfo, 0)
+	for rows.Next() {
+		var inviteInfo InviteInfo
+		err := rows.StructScan(&inviteInfo)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, &inviteInfo)
+	}
+	return invites, nil
+}
+
 // UserSetting is a key-value setting for a user configuration.
 type UserSetting struct {
 	UserID uuid.UUID `db:"user_id"`
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gofrs/uuid"
 	bindata "github.com/golang-migrate/migrate/source/go_bindata"
@@ -63,6 +64,7 @@ func testMain(m *testing.M) error {
 
 func mustLoadTestData(db *sqlx.DB) {
 	// Cleanup.
+	db.MustExec(`DELETE FROM org_invites`)
 	db.MustExec(`DELETE FROM user_settings`)
 	db.MustExec(`DELETE FROM users`)
 	db.MustExec(`DELETE FROM orgs`)
@@ -302,6 +304,26 @@ func TestDatastore(t *testing.T) {
 		require.Nil(t, orgInfo)
 	})
 
+	t.Run("delete user", func(t *testing.T) {
+		mustLoadTestData(db)
+		d := datastore.NewDatastore(db)
+
+		userID := uuid.FromStringOrNil("123e4567-e89b-12d3-a456-426655440001")
+		require.NoError(t, d.DeleteUser(userID))
+
+		userInfo, err := d.GetUser(userID)
+		assert.NotNil(t, err)
+		assert.Nil(t, userInfo)
+
+		// The other users in the org should not be affected.
+		users, err := d.GetUsersInOrg(uuid.FromStringOrNil("123e4567-e89b-12d3-a456-426655440000"))
+		require.NoError(t, err)
+		require.Equal(t, 1, len(users))
+		assert.Equal(t, "person2@my-org.com", users[0].Email)
+
+		assert.Equal(t, datastore.ErrUserNotFound, d.DeleteUser(userID))
+	})
+
 	t.Run("update user", func(t *testing.T) {
 		mustLoadTestData(db)
 		d := datastore.NewDatastore(db)
@@ -375,6 +397,7 @@ func TestDatastore(t *testing.T) {
 		orgID := "123e4567-e89b-12d3-a456-426655440000"
 		require.NoError(t, d.UpdateOrg(&datastore.OrgInfo{
 			ID:              uuid.FromStringOrNil(orgID),
+			OrgName:         "my-renamed-org",
 			EnableApprovals: true,
 		}))
 
@@ -382,6 +405,7 @@ func TestDatastore(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, orgInfoFetched)
 		assert.True(t, orgInfoFetched.EnableApprovals)
+		assert.Equal(t, "my-renamed-org", orgInfoFetched.OrgName)
 	})
 
 	t.Run("approve all users", func(t *testing.T) {
@@ -397,4 +421,93 @@ func TestDatastore(t *testing.T) {
 		assert.True(t, users[0].IsApproved)
 		assert.True(t, users[1].IsApproved)
 	})
+
+	t.Run("get pending invites for org", func(t *testing.T) {
+		mustLoadTestData(db)
+		d := datastore.NewDatastore(db)
+
+		orgID := uuid.FromStringOrNil("123e4567-e89b-12d3-a456-426655440000")
+		inviterID := uuid.FromStringOrNil("123e4567-e89b-12d3-a456-426655440001")
+		invitedID := uuid.FromStringOrNil("123e4567-e89b-12d3-a456-426655440002")
+
+		acceptedID, err := d.CreateUser(&datastore.UserInfo{
+			OrgID:          orgID,
+			Username:       "accepted@my-org.com",
+			Email:          "accepted@my-org.com",
+			AuthProviderID: "github|abcdefg",
+		})
+		require.NoError(t, err)
+
+		expired := time.Now().UTC().Add(-time.Hour)
+		expiresAt := time.Now().UTC().Add(time.Hour)
+		// An invite that has expired.
+		require.NoError(t, d.CreateInvite(&datastore.InviteInfo{
+			OrgID:     orgID,
+			UserID:    inviterID,
+			ExpiresAt: &expired,
+		}))
+		// An invite that has already been accepted.
+		require.NoError(t, d.CreateInvite(&datastore.InviteInfo{
+			OrgID:     orgID,
+			UserID:    acceptedID,
+			InviterID: &inviterID,
+		}))
+		// An invite that was sent twice, only the latest should be returned.
+		require.NoError(t, d.CreateInvite(&datastore.InviteInfo{
+			OrgID:     orgID,
+			UserID:    invitedID,
+			InviterID: &inviterID,
+		}))
+		require.NoError(t, d.CreateInvite(&datastore.InviteInfo{
+			OrgID:     orgID,
+			UserID:    invitedID,
+			InviterID: &inviterID,
+			ExpiresAt: &expiresAt,
+		}))
+
+		invites, err := d.GetPendingInvitesForOrg(orgID)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(invites))
+		assert.Equal(t, invitedID, invites[0].UserID)
+		assert.Equal(t, "person2@my-org.com", invites[0].Email)
+		require.NotNil(t, invites[0].InviterEmail)
+		assert.Equal(t, "person@my-org.com", *invites[0].InviterEmail)
+		assert.NotNil(t, invites[0].CreatedAt)
+		require.NotNil(t, invites[0].ExpiresAt)
+	})
+
+	t.Run("get latest invite", func(t *testing.T) {
+		mustLoadTestData(db)
+		d := datastore.NewDatastore(db)
+
+		orgID := uuid.FromStringOrNil("123e4567-e89b-12d3-a456-426655440000")
+		inviterID := uuid.FromStringOrNil("123e4567-e89b-12d3-a456-426655440001")
+		invitedID := uuid.FromStringOrNil("123e4567-e89b-12d3-a456-426655440002")
+
+		_, err := d.GetLatestInvite(orgID, invitedID)
+		assert.Equal(t, datastore.ErrInviteNotFound, err)
+
+		expiresAt := time.Now().UTC().Add(time.Hour)
+		require.NoError(t, d.CreateInvite(&datastore.InviteInfo{
+			OrgID:     orgID,
+			UserID:    invitedID,
+			InviterID: &inviterID,
+			ExpiresAt: &expiresAt,
+		}))
+		// The latest invite is returned even if it has expired.
+		expired := time.Now().UTC().Add(-time.Hour)
+		require.NoError(t, d.CreateInvite(&datastore.InviteInfo{
+			OrgID:     orgID,
+			UserID:    invitedID,
+			InviterID: &inviterID,
+			ExpiresAt: &expired,
+		}))
+
+		invite, err := d.GetLatestInvite(orgID, invitedID)
+		require.NoError(t, err)
+		assert.Equal(t, invitedID, invite.UserID)
+		require.NotNil(t, invite.CreatedAt)
+		require.NotNil(t, invite.ExpiresAt)
+		assert.WithinDuration(t, expired, *invite.ExpiresAt, time.Second)
+	})
 }
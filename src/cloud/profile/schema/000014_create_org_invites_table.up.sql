@@ -0,0 +1,17 @@
+-- This table contains the invites that were sent to users to join an org.
+CREATE TABLE org_invites (
+  id UUID UNIQUE DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL,
+  -- The user that was invited.
+  user_id UUID NOT NULL,
+  -- The user that sent the invite, NULL if unknown.
+  inviter_id UUID,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  -- The time the invite link expires, NULL if it uses the identity provider's default lifetime.
+  expires_at TIMESTAMP,
+
+  PRIMARY KEY(id),
+  FOREIGN KEY (org_id) REFERENCES orgs(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (inviter_id) REFERENCES users(id) ON DELETE SET NULL
+);
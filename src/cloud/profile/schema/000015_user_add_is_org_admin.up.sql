@@ -0,0 +1,6 @@
+-- is_org_admin is whether the user can manage the other users in their org. Every approved user was
+-- able to manage their org before this column existed, so they keep that ability.
+ALTER TABLE users
+ADD COLUMN is_org_admin BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE users SET is_org_admin = true WHERE is_approved = true;
@@ -33,11 +33,12 @@ to store scripts.
 */
 
 type pixieScript struct {
-	Pxl       string `json:"pxl"`
-	Vis       string `json:"vis"`
-	Placement string `json:"placement"`
-	ShortDoc  string `json:"ShortDoc"`
-	LongDoc   string `json:"LongDoc"`
+	Pxl       string   `json:"pxl"`
+	Vis       string   `json:"vis"`
+	Placement string   `json:"placement"`
+	ShortDoc  string   `json:"ShortDoc"`
+	LongDoc   string   `json:"LongDoc"`
+	Tags      []string `json:"tags"`
 }
 
 type bundle struct {
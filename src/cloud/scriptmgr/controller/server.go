@@ -21,6 +21,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -41,6 +42,7 @@ type scriptModel struct {
 	desc        string
 	pxl         string
 	hasLiveView bool
+	tags        []string
 }
 
 type liveViewModel struct {
@@ -114,6 +116,7 @@ func (s *Server) addScript(name string, bundleScript *pixieScript, hasLiveView b
 		desc:        bundleScript.ShortDoc,
 		pxl:         bundleScript.Pxl,
 		hasLiveView: hasLiveView,
+		tags:        bundleScript.Tags,
 	}
 }
 
@@ -176,10 +179,35 @@ func (s *Server) Start() {
 	go s.storeUpdater()
 }
 
-// GetLiveViews returns a list of all available live views.
+// funcReferencesScript returns whether the func is the given script, or a func within it.
+func funcReferencesScript(f *vispb.Widget_Func, script string) bool {
+	name := f.GetName()
+	return name == script || strings.HasPrefix(name, script+".")
+}
+
+// visReferencesScript returns whether any widget or global func in the vis calls the given script.
+func visReferencesScript(vis *vispb.Vis, script string) bool {
+	for _, w := range vis.GetWidgets() {
+		if funcReferencesScript(w.GetFunc(), script) {
+			return true
+		}
+	}
+	for _, f := range vis.GetGlobalFuncs() {
+		if funcReferencesScript(f.GetFunc(), script) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetLiveViews returns a list of all available live views, limited to the live views referencing the
+// requested script if one is set.
 func (s *Server) GetLiveViews(ctx context.Context, req *scriptmgrpb.GetLiveViewsReq) (*scriptmgrpb.GetLiveViewsResp, error) {
 	resp := &scriptmgrpb.GetLiveViewsResp{}
 	for id, liveView := range s.store.LiveViews {
+		if req.ReferencedScript != "" && !visReferencesScript(liveView.vis, req.ReferencedScript) {
+			continue
+		}
 		resp.LiveViews = append(resp.LiveViews, &scriptmgrpb.LiveViewMetadata{
 			Name: liveView.name,
 			Desc: liveView.desc,
@@ -211,15 +239,36 @@ func (s *Server) GetLiveViewContents(ctx context.Context, req *scriptmgrpb.GetLi
 	}, nil
 }
 
-// GetScripts returns a list of all available scripts.
+// hasAllTags returns whether the script carries every one of the given tags.
+func hasAllTags(script *scriptModel, tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, t := range script.tags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// GetScripts returns a list of all available scripts, limited to the scripts carrying all tags in the tag filter.
 func (s *Server) GetScripts(ctx context.Context, req *scriptmgrpb.GetScriptsReq) (*scriptmgrpb.GetScriptsResp, error) {
 	resp := &scriptmgrpb.GetScriptsResp{}
 	for id, script := range s.store.Scripts {
+		if !hasAllTags(script, req.TagFilter) {
+			continue
+		}
 		resp.Scripts = append(resp.Scripts, &scriptmgrpb.ScriptMetadata{
 			ID:          utils.ProtoFromUUID(id),
 			Name:        script.name,
 			Desc:        script.desc,
 			HasLiveView: script.hasLiveView,
+			Tags:        script.tags,
 		})
 	}
 	return resp, nil
@@ -241,7 +290,79 @@ func (s *Server) GetScriptContents(ctx context.Context, req *scriptmgrpb.GetScri
 			Name:        script.name,
 			Desc:        script.desc,
 			HasLiveView: script.hasLiveView,
+			Tags:        script.tags,
 		},
 		Contents: script.pxl,
 	}, nil
 }
+
+// Ranks of how well a script matches a search query. Lower ranks are more relevant.
+const (
+	scriptNameExactMatch = iota
+	scriptNamePrefixMatch
+	scriptNameMatch
+	scriptDescMatch
+	scriptNoMatch
+)
+
+// scriptMatchRank returns how well the script's name and description match the lowercased query.
+func scriptMatchRank(script *scriptModel, query string) int {
+	name := strings.ToLower(script.name)
+	switch {
+	case name == query:
+		return scriptNameExactMatch
+	case strings.HasPrefix(name, query):
+		return scriptNamePrefixMatch
+	case strings.Contains(name, query):
+		return scriptNameMatch
+	case strings.Contains(strings.ToLower(script.desc), query):
+		return scriptDescMatch
+	default:
+		return scriptNoMatch
+	}
+}
+
+// SearchScripts returns the scripts whose name or description matches the query, most relevant first.
+func (s *Server) SearchScripts(ctx context.Context, req *scriptmgrpb.SearchScriptsReq) (*scriptmgrpb.SearchScriptsResp, error) {
+	query := strings.ToLower(strings.TrimSpace(req.Query))
+
+	type rankedScript struct {
+		metadata *scriptmgrpb.ScriptMetadata
+		rank     int
+	}
+	var matches []*rankedScript
+	for id, script := range s.store.Scripts {
+		if req.HasLiveView != nil && req.HasLiveView.Value != script.hasLiveView {
+			continue
+		}
+		rank := scriptMatchRank(script, query)
+		if rank == scriptNoMatch {
+			continue
+		}
+		matches = append(matches, &rankedScript{
+			metadata: &scriptmgrpb.ScriptMetadata{
+				ID:          utils.ProtoFromUUID(id),
+				Name:        script.name,
+				Desc:        script.desc,
+				HasLiveView: script.hasLiveView,
+				Tags:        script.tags,
+			},
+			rank: rank,
+		})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].rank != matches[j].rank {
+			return matches[i].rank < matches[j].rank
+		}
+		return matches[i].metadata.Name < matches[j].metadata.Name
+	})
+
+	resp := &scriptmgrpb.SearchScriptsResp{
+		Scripts: make([]*scriptmgrpb.ScriptMetadata, len(matches)),
+	}
+	for i, m := range matches {
+		resp.Scripts[i] = m.metadata
+	}
+	return resp, nil
+}
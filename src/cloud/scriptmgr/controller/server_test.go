@@ -28,6 +28,7 @@ import (
 	"cloud.google.com/go/storage"
 	"github.com/gofrs/uuid"
 	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
 	"github.com/googleapis/google-cloud-go-testing/storage/stiface"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -88,7 +89,7 @@ var testBundle = map[string]scriptsDef{
 	},
 }
 
-func mustSetupFakeBucket(t *testing.T, testBundle map[string]scriptsDef) stiface.Client {
+func mustSetupFakeBucket(t *testing.T, testBundle interface{}) stiface.Client {
 	bundleJSON, err := json.Marshal(testBundle)
 	require.NoError(t, err)
 
@@ -150,6 +151,81 @@ func TestScriptMgr_GetLiveViews(t *testing.T) {
 	}
 }
 
+var testGlobalFuncLiveView = `{
+	"globalFuncs": [{
+		"outputName": "stats",
+		"func": {
+			"name": "px.service_stats.make_stats",
+			"args": []
+		}
+	}],
+	"widgets": [{
+		"globalFuncOutputName": "stats",
+		"displaySpec": {
+			"@type": "types.px.dev/px.vispb.Table"
+		}
+	}]
+}`
+
+func TestScriptMgr_GetLiveViewsReferencedScript(t *testing.T) {
+	bundle := map[string]scriptsDef{
+		"scripts": {
+			"liveview1": {
+				"pxl":      "liveview1 pxl",
+				"vis":      testLiveView,
+				"ShortDoc": "liveview1 desc",
+			},
+			"liveview2": {
+				"pxl":      "liveview2 pxl",
+				"vis":      testGlobalFuncLiveView,
+				"ShortDoc": "liveview2 desc",
+			},
+		},
+	}
+
+	testCases := []struct {
+		name             string
+		referencedScript string
+		expectedNames    []string
+	}{
+		{
+			name:          "empty filter returns all live views",
+			expectedNames: []string{"liveview1", "liveview2"},
+		},
+		{
+			name:             "widget func",
+			referencedScript: "make_output",
+			expectedNames:    []string{"liveview1"},
+		},
+		{
+			name:             "global func within a script",
+			referencedScript: "px.service_stats",
+			expectedNames:    []string{"liveview2"},
+		},
+		{
+			name:             "func name prefix isn't a reference",
+			referencedScript: "make",
+			expectedNames:    []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := mustSetupFakeBucket(t, bundle)
+			s := controller.NewServer(bundleBucket, bundlePath, c)
+			ctx := context.Background()
+
+			resp, err := s.GetLiveViews(ctx, &scriptmgrpb.GetLiveViewsReq{ReferencedScript: tc.referencedScript})
+			require.NoError(t, err)
+			names := make([]string, len(resp.LiveViews))
+			for i, liveView := range resp.LiveViews {
+				names[i] = liveView.Name
+			}
+			assert.ElementsMatch(t, tc.expectedNames, names)
+		})
+	}
+}
+
 func TestScriptMgr_GetLiveViewContents(t *testing.T) {
 	testCases := []struct {
 		name         string
@@ -323,3 +399,165 @@ func TestScriptMgr_GetScriptContents(t *testing.T) {
 		})
 	}
 }
+
+var searchTestBundle = map[string]scriptsDef{
+	"scripts": {
+		"px/http_data": {
+			"pxl":       "http_data pxl",
+			"vis":       "",
+			"placement": "",
+			"ShortDoc":  "Recent HTTP requests",
+			"LongDoc":   "",
+		},
+		"px/service_stats": {
+			"pxl":       "service_stats pxl",
+			"vis":       testLiveView,
+			"placement": "",
+			"ShortDoc":  "Overview of the HTTP traffic of a service",
+			"LongDoc":   "",
+		},
+		"px/cluster": {
+			"pxl":       "cluster pxl",
+			"vis":       testLiveView,
+			"placement": "",
+			"ShortDoc":  "Cluster overview",
+			"LongDoc":   "",
+		},
+		"px/http": {
+			"pxl":       "http pxl",
+			"vis":       testLiveView,
+			"placement": "",
+			"ShortDoc":  "HTTP overview",
+			"LongDoc":   "",
+		},
+	},
+}
+
+func TestScriptMgr_SearchScripts(t *testing.T) {
+	testCases := []struct {
+		name          string
+		req           *scriptmgrpb.SearchScriptsReq
+		expectedNames []string
+	}{
+		{
+			name:          "name match ranks above desc match",
+			req:           &scriptmgrpb.SearchScriptsReq{Query: "http"},
+			expectedNames: []string{"px/http", "px/http_data", "px/service_stats"},
+		},
+		{
+			name:          "exact name match ranks first",
+			req:           &scriptmgrpb.SearchScriptsReq{Query: "px/http"},
+			expectedNames: []string{"px/http", "px/http_data"},
+		},
+		{
+			name:          "desc match is case-insensitive",
+			req:           &scriptmgrpb.SearchScriptsReq{Query: "OVERVIEW"},
+			expectedNames: []string{"px/cluster", "px/http", "px/service_stats"},
+		},
+		{
+			name: "live view filter",
+			req: &scriptmgrpb.SearchScriptsReq{
+				Query:       "http",
+				HasLiveView: &types.BoolValue{Value: false},
+			},
+			expectedNames: []string{"px/http_data"},
+		},
+		{
+			name: "empty query returns all scripts matching the filter",
+			req: &scriptmgrpb.SearchScriptsReq{
+				HasLiveView: &types.BoolValue{Value: true},
+			},
+			expectedNames: []string{"px/cluster", "px/http", "px/service_stats"},
+		},
+		{
+			name:          "no match",
+			req:           &scriptmgrpb.SearchScriptsReq{Query: "mysql"},
+			expectedNames: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := mustSetupFakeBucket(t, searchTestBundle)
+			s := controller.NewServer(bundleBucket, bundlePath, c)
+			ctx := context.Background()
+
+			resp, err := s.SearchScripts(ctx, tc.req)
+			require.NoError(t, err)
+			names := make([]string, len(resp.Scripts))
+			for i, script := range resp.Scripts {
+				names[i] = script.Name
+				assert.NotNil(t, script.ID)
+			}
+			assert.Equal(t, tc.expectedNames, names)
+		})
+	}
+}
+
+// tagTestBundle is untyped, since the tags in the bundle.json aren't strings.
+var tagTestBundle = map[string]map[string]map[string]interface{}{
+	"scripts": {
+		"px/http_data": {
+			"pxl":      "http_data pxl",
+			"ShortDoc": "Recent HTTP requests",
+			"tags":     []string{"network", "http"},
+		},
+		"px/dns_data": {
+			"pxl":      "dns_data pxl",
+			"ShortDoc": "Recent DNS requests",
+			"tags":     []string{"network", "dns"},
+		},
+		"px/cluster": {
+			"pxl":      "cluster pxl",
+			"vis":      testLiveView,
+			"ShortDoc": "Cluster overview",
+		},
+	},
+}
+
+func TestScriptMgr_GetScriptsTagFilter(t *testing.T) {
+	testCases := []struct {
+		name          string
+		tagFilter     []string
+		expectedNames []string
+	}{
+		{
+			name:          "empty filter returns all scripts",
+			expectedNames: []string{"px/cluster", "px/dns_data", "px/http_data"},
+		},
+		{
+			name:          "single tag",
+			tagFilter:     []string{"network"},
+			expectedNames: []string{"px/dns_data", "px/http_data"},
+		},
+		{
+			name:          "multiple tags must all match",
+			tagFilter:     []string{"network", "http"},
+			expectedNames: []string{"px/http_data"},
+		},
+		{
+			name:          "no script has all tags",
+			tagFilter:     []string{"http", "dns"},
+			expectedNames: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := mustSetupFakeBucket(t, tagTestBundle)
+			s := controller.NewServer(bundleBucket, bundlePath, c)
+			ctx := context.Background()
+
+			resp, err := s.GetScripts(ctx, &scriptmgrpb.GetScriptsReq{TagFilter: tc.tagFilter})
+			require.NoError(t, err)
+			names := make([]string, len(resp.Scripts))
+			for i, script := range resp.Scripts {
+				names[i] = script.Name
+				for _, tag := range tc.tagFilter {
+					assert.Contains(t, script.Tags, tag)
+				}
+			}
+			assert.ElementsMatch(t, tc.expectedNames, names)
+		})
+	}
+}
@@ -704,10 +704,15 @@ func (c *HydraKratosClient) CreateInviteLink(ctx context.Context, req *idmanager
 		return nil, err
 	}
 
+	expiresIn := viper.GetString("kratos_recovery_link_lifetime")
+	if req.TTL > 0 {
+		expiresIn = req.TTL.String()
+	}
+
 	recovery, err := c.kratosAdminClient.CreateRecoveryLink(&kratosAdmin.CreateRecoveryLinkParams{
 		Context: ctx,
 		Body: &kratosModels.CreateRecoveryLink{
-			ExpiresIn:  viper.GetString("kratos_recovery_link_lifetime"),
+			ExpiresIn:  expiresIn,
 			IdentityID: idResp.Payload.ID,
 		},
 	})
@@ -34,6 +34,7 @@ import (
 
 	"px.dev/pixie/src/cloud/vzconn/vzconnpb"
 	"px.dev/pixie/src/cloud/vzmgr/vzmgrpb"
+	"px.dev/pixie/src/shared/cvmsgs"
 	"px.dev/pixie/src/shared/cvmsgspb"
 	"px.dev/pixie/src/shared/services/utils"
 	utils2 "px.dev/pixie/src/utils"
@@ -95,7 +96,7 @@ func (s *GRPCServer) NATSBridge(srv vzconnpb.VZConnService_NATSBridgeServer) err
 	}
 
 	// We expect register message to be the first.
-	if msg.Topic != "register" {
+	if cvmsgs.Topic(msg.Topic) != cvmsgs.RegisterTopic {
 		return convertToGRPCErr(ErrMissingRegistrationMessage)
 	}
 
@@ -141,7 +142,7 @@ func (s *GRPCServer) handleRegisterMessage(msg *cvmsgspb.RegisterVizierRequest,
 	}
 
 	sendErr := srv.Send(&vzconnpb.C2VBridgeMessage{
-		Topic: "registerAck",
+		Topic: string(cvmsgs.RegisterAckTopic),
 		Msg:   respAsAny,
 	})
 	// If registration failed it's an error and we should destroy the stream processor.
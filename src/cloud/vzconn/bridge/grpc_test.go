@@ -43,6 +43,7 @@ import (
 	"px.dev/pixie/src/cloud/vzconn/vzconnpb"
 	"px.dev/pixie/src/cloud/vzmgr/vzmgrpb"
 	mock_vzmgrpb "px.dev/pixie/src/cloud/vzmgr/vzmgrpb/mock"
+	"px.dev/pixie/src/shared/cvmsgs"
 	"px.dev/pixie/src/shared/cvmsgspb"
 	"px.dev/pixie/src/utils"
 	"px.dev/pixie/src/utils/testingutils"
@@ -172,7 +173,7 @@ func TestNATSGRPCBridgeHandshakeTest_CorrectRegistration(t *testing.T) {
 	readCh := grpcReader(stream)
 
 	err = stream.Send(&vzconnpb.V2CBridgeMessage{
-		Topic:     "register",
+		Topic:     string(cvmsgs.RegisterTopic),
 		SessionId: 0,
 		Msg:       convertToAny(regReq),
 	})
@@ -181,7 +182,7 @@ func TestNATSGRPCBridgeHandshakeTest_CorrectRegistration(t *testing.T) {
 	// Should get the register ACK.
 	m := <-readCh
 	assert.Nil(t, m.err)
-	assert.Equal(t, "registerAck", m.msg.Topic)
+	assert.Equal(t, string(cvmsgs.RegisterAckTopic), m.msg.Topic)
 	ack := cvmsgspb.RegisterVizierAck{}
 	err = types.UnmarshalAny(m.msg.Msg, &ack)
 	if err != nil {
@@ -234,7 +235,7 @@ func TestNATSGRPCBridgeHandshakeTest_NilRegister(t *testing.T) {
 
 	readCh := grpcReader(stream)
 	err = stream.Send(&vzconnpb.V2CBridgeMessage{
-		Topic:     "register",
+		Topic:     string(cvmsgs.RegisterTopic),
 		SessionId: 0,
 		Msg:       nil,
 	})
@@ -262,7 +263,7 @@ func TestNATSGRPCBridgeHandshakeTest_MalformedRegister(t *testing.T) {
 
 	readCh := grpcReader(stream)
 	err = stream.Send(&vzconnpb.V2CBridgeMessage{
-		Topic:     "register",
+		Topic:     string(cvmsgs.RegisterTopic),
 		SessionId: 0,
 		Msg:       convertToAny(&cvmsgspb.RegisterVizierAck{}),
 	})
@@ -287,7 +288,7 @@ func registerVizier(ts *testState, vizierID uuid.UUID, stream vzconnpb.VZConnSer
 		Return(&cvmsgspb.RegisterVizierAck{Status: cvmsgspb.ST_OK}, nil)
 
 	err := stream.Send(&vzconnpb.V2CBridgeMessage{
-		Topic:     "register",
+		Topic:     string(cvmsgs.RegisterTopic),
 		SessionId: 0,
 		Msg:       convertToAny(regReq),
 	})
@@ -296,7 +297,7 @@ func registerVizier(ts *testState, vizierID uuid.UUID, stream vzconnpb.VZConnSer
 	// Should get the register ACK.
 	m := <-readCh
 	assert.Nil(ts.t, m.err)
-	assert.Equal(ts.t, "registerAck", m.msg.Topic)
+	assert.Equal(ts.t, string(cvmsgs.RegisterAckTopic), m.msg.Topic)
 	ack := cvmsgspb.RegisterVizierAck{}
 	err = types.UnmarshalAny(m.msg.Msg, &ack)
 	if err != nil {
@@ -29,6 +29,8 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"google.golang.org/grpc"
+	// Register the gzip compressor, so that Viziers can compress the messages they send over the bridge.
+	_ "google.golang.org/grpc/encoding/gzip"
 
 	"px.dev/pixie/src/cloud/vzconn/bridge"
 	"px.dev/pixie/src/cloud/vzconn/vzconnpb"
@@ -99,6 +101,11 @@ func main() {
 			"/px.services.VZConnService/NATSBridge":               true,
 			"/px.services.VZConnService/RegisterVizierDeployment": true,
 		},
+		// Viziers may send messages of up to 16MB over the bridge, since heartbeats include the status of every pod
+		// in the cluster.
+		GRPCServerOpts: []grpc.ServerOption{
+			grpc.MaxRecvMsgSize(16 * 1024 * 1024),
+		},
 	}
 
 	s := server.NewPLServerWithOptions(env.New(viper.GetString("domain_name")), mux, serverOpts)
@@ -27,6 +27,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/gogo/protobuf/proto"
@@ -47,6 +48,7 @@ import (
 	"px.dev/pixie/src/cloud/shared/vzshard"
 	"px.dev/pixie/src/cloud/vzmgr/vzerrors"
 	"px.dev/pixie/src/cloud/vzmgr/vzmgrpb"
+	"px.dev/pixie/src/shared/cvmsgs"
 	"px.dev/pixie/src/shared/cvmsgspb"
 	"px.dev/pixie/src/shared/services/authcontext"
 	"px.dev/pixie/src/shared/services/events"
@@ -72,7 +74,7 @@ type Server struct {
 	nc            *nats.Conn
 	natsCh        chan *nats.Msg
 	natsSubs      []*nats.Subscription
-	msgHandlerMap map[string]HandleNATSMessageFunc
+	msgHandlerMap map[cvmsgs.Topic]HandleNATSMessageFunc
 	updater       VzUpdater
 }
 
@@ -87,13 +89,14 @@ type VzUpdater interface {
 func New(db *sqlx.DB, dbKey string, dnsMgrClient dnsmgrpb.DNSMgrServiceClient, nc *nats.Conn, updater VzUpdater) *Server {
 	natsSubs := make([]*nats.Subscription, 0)
 	natsCh := make(chan *nats.Msg, 1024)
-	msgHandlerMap := make(map[string]HandleNATSMessageFunc)
+	msgHandlerMap := make(map[cvmsgs.Topic]HandleNATSMessageFunc)
 	s := &Server{db, dbKey, dnsMgrClient, nc, natsCh, natsSubs, msgHandlerMap, updater}
 
 	// Register NATS message handlers.
 	if nc != nil {
-		s.registerMessageHandler("heartbeat", s.HandleVizierHeartbeat)
-		s.registerMessageHandler("ssl", s.HandleSSLRequest)
+		s.registerMessageHandler(cvmsgs.HeartbeatTopic, s.HandleVizierHeartbeat)
+		s.registerMessageHandler(cvmsgs.HeartbeatBatchTopic, s.HandleVizierHeartbeatBatch)
+		s.registerMessageHandler(cvmsgs.SSLTopic, s.HandleSSLRequest)
 
 		go s.handleMessageBus()
 	}
@@ -101,7 +104,7 @@ func New(db *sqlx.DB, dbKey string, dnsMgrClient dnsmgrpb.DNSMgrServiceClient, n
 	return s
 }
 
-func (s *Server) registerMessageHandler(topic string, fn HandleNATSMessageFunc) {
+func (s *Server) registerMessageHandler(topic cvmsgs.Topic, fn HandleNATSMessageFunc) {
 	sub, err := s.nc.ChanSubscribe(fmt.Sprintf("v2c.*.*.%s", topic), s.natsCh)
 	if err != nil {
 		log.WithError(err).Fatal("Failed to subscribe to NATS channel")
@@ -127,7 +130,7 @@ func (s *Server) handleMessageBus() {
 			log.WithError(err).Error("Could not unmarshal message")
 		}
 
-		if handler, ok := s.msgHandlerMap[topic]; ok {
+		if handler, ok := s.msgHandlerMap[cvmsgs.Topic(topic)]; ok {
 			handler(pb)
 		} else {
 			log.WithField("topic", msg.Subject).Error("Could not find handler for topic")
@@ -135,7 +138,7 @@ func (s *Server) handleMessageBus() {
 	}
 }
 
-func (s *Server) sendNATSMessage(topic string, msg *types.Any, vizierID uuid.UUID) {
+func (s *Server) sendNATSMessage(topic cvmsgs.Topic, msg *types.Any, vizierID uuid.UUID) {
 	wrappedMsg := &cvmsgspb.C2VMessage{
 		VizierID: vizierID.String(),
 		Msg:      msg,
@@ -146,9 +149,9 @@ func (s *Server) sendNATSMessage(topic string, msg *types.Any, vizierID uuid.UUI
 		log.WithError(err).Error("Could not marshal message to bytes")
 		return
 	}
-	topic = vzshard.C2VTopic(topic, vizierID)
-	log.WithField("topic", topic).Info("Sending message")
-	err = s.nc.Publish(topic, b)
+	natsTopic := vzshard.C2VTopic(string(topic), vizierID)
+	log.WithField("topic", natsTopic).Info("Sending message")
+	err = s.nc.Publish(natsTopic, b)
 
 	if err != nil {
 		log.WithError(err).Error("Could not publish message to nats")
@@ -316,19 +319,24 @@ func (s *Server) GetViziersByOrg(ctx context.Context, orgID *uuidpb.UUID) (*vzmg
 
 // VizierInfo represents all info we want to fetch about a Vizier.
 type VizierInfo struct {
-	ID                      uuid.UUID    `db:"vizier_cluster_id"`
-	Status                  vizierStatus `db:"status"`
-	LastHeartbeat           *int64       `db:"last_heartbeat"`
-	PassthroughEnabled      bool         `db:"passthrough_enabled"`
-	AutoUpdateEnabled       bool         `db:"auto_update_enabled"`
-	ClusterUID              *string      `db:"cluster_uid"`
-	ClusterName             *string      `db:"cluster_name"`
-	ClusterVersion          *string      `db:"cluster_version"`
-	VizierVersion           *string      `db:"vizier_version"`
-	ControlPlanePodStatuses PodStatuses  `db:"control_plane_pod_statuses"`
-	NumNodes                int32        `db:"num_nodes"`
-	NumInstrumentedNodes    int32        `db:"num_instrumented_nodes"`
-	OrgID                   uuid.UUID    `db:"org_id"`
+	ID                      uuid.UUID      `db:"vizier_cluster_id"`
+	Status                  vizierStatus   `db:"status"`
+	LastHeartbeat           *int64         `db:"last_heartbeat"`
+	PassthroughEnabled      bool           `db:"passthrough_enabled"`
+	AutoUpdateEnabled       bool           `db:"auto_update_enabled"`
+	UpdateChannel           string         `db:"update_channel"`
+	ClusterUID              *string        `db:"cluster_uid"`
+	ClusterName             *string        `db:"cluster_name"`
+	ClusterVersion          *string        `db:"cluster_version"`
+	VizierVersion           *string        `db:"vizier_version"`
+	PreviousVizierVersion   *string        `db:"previous_vizier_version"`
+	LastUpdateAutomatic     bool           `db:"last_update_automatic"`
+	ControlPlanePodStatuses PodStatuses    `db:"control_plane_pod_statuses"`
+	NumNodes                int32          `db:"num_nodes"`
+	NumInstrumentedNodes    int32          `db:"num_instrumented_nodes"`
+	PluginStatuses          PluginStatuses `db:"plugin_statuses"`
+	LastScriptRunAt         *time.Time     `db:"last_script_run_at"`
+	OrgID                   uuid.UUID      `db:"org_id"`
 }
 
 func vizierInfoToProto(vzInfo VizierInfo) *cvmsgspb.VizierInfo {
@@ -336,6 +344,7 @@ func vizierInfoToProto(vzInfo VizierInfo) *cvmsgspb.VizierInfo {
 	clusterName := ""
 	clusterVersion := ""
 	vizierVersion := ""
+	previousVizierVersion := ""
 
 	lastHearbeat := int64(-1)
 	if vzInfo.LastHeartbeat != nil {
@@ -354,6 +363,19 @@ func vizierInfoToProto(vzInfo VizierInfo) *cvmsgspb.VizierInfo {
 	if vzInfo.VizierVersion != nil {
 		vizierVersion = *vzInfo.VizierVersion
 	}
+	if vzInfo.PreviousVizierVersion != nil {
+		previousVizierVersion = *vzInfo.PreviousVizierVersion
+	}
+
+	var lastScriptRunAt *types.Timestamp
+	if vzInfo.LastScriptRunAt != nil {
+		ts, err := types.TimestampProto(*vzInfo.LastScriptRunAt)
+		if err != nil {
+			log.WithError(err).Error("Could not convert last script run time")
+		} else {
+			lastScriptRunAt = ts
+		}
+	}
 
 	return &cvmsgspb.VizierInfo{
 		VizierID:        utils.ProtoFromUUID(vzInfo.ID),
@@ -362,6 +384,7 @@ func vizierInfoToProto(vzInfo VizierInfo) *cvmsgspb.VizierInfo {
 		Config: &cvmsgspb.VizierConfig{
 			PassthroughEnabled: vzInfo.PassthroughEnabled,
 			AutoUpdateEnabled:  vzInfo.AutoUpdateEnabled,
+			UpdateChannel:      vzInfo.UpdateChannel,
 		},
 		ClusterUID:              clusterUID,
 		ClusterName:             clusterName,
@@ -370,6 +393,10 @@ func vizierInfoToProto(vzInfo VizierInfo) *cvmsgspb.VizierInfo {
 		ControlPlanePodStatuses: vzInfo.ControlPlanePodStatuses,
 		NumNodes:                vzInfo.NumNodes,
 		NumInstrumentedNodes:    vzInfo.NumInstrumentedNodes,
+		PluginStatuses:          vzInfo.PluginStatuses,
+		LastScriptRunAt:         lastScriptRunAt,
+		PreviousVizierVersion:   previousVizierVersion,
+		AutoUpdateInProgress:    vzInfo.LastUpdateAutomatic && vzInfo.Status.ToProto() == cvmsgspb.VZ_ST_UPDATING,
 	}
 }
 
@@ -392,7 +419,8 @@ func (s *Server) GetVizierInfos(ctx context.Context, req *vzmgrpb.GetVizierInfos
 
 	strQuery := `SELECT i.vizier_cluster_id, c.cluster_uid, c.cluster_name, c.cluster_version, i.vizier_version, c.org_id,
 			  i.status, (EXTRACT(EPOCH FROM age(now(), i.last_heartbeat))*1E9)::bigint as last_heartbeat,
-              i.passthrough_enabled, i.auto_update_enabled, i.control_plane_pod_statuses, num_nodes, num_instrumented_nodes
+              i.passthrough_enabled, i.auto_update_enabled, i.update_channel, i.control_plane_pod_statuses, num_nodes, num_instrumented_nodes,
+              i.plugin_statuses, i.last_script_run_at, i.previous_vizier_version, i.last_update_automatic
               from vizier_cluster_info as i, vizier_cluster as c
               WHERE i.vizier_cluster_id=c.id AND i.vizier_cluster_id IN (?) AND c.org_id='%s'`
 	strQuery = fmt.Sprintf(strQuery, orgIDstr)
@@ -444,7 +472,8 @@ func (s *Server) GetVizierInfo(ctx context.Context, req *uuidpb.UUID) (*cvmsgspb
 
 	query := `SELECT i.vizier_cluster_id, c.cluster_uid, c.cluster_name, c.cluster_version, i.vizier_version,
 			  i.status, (EXTRACT(EPOCH FROM age(now(), i.last_heartbeat))*1E9)::bigint as last_heartbeat,
-              i.passthrough_enabled, i.auto_update_enabled, i.control_plane_pod_statuses, num_nodes, num_instrumented_nodes
+              i.passthrough_enabled, i.auto_update_enabled, i.update_channel, i.control_plane_pod_statuses, num_nodes, num_instrumented_nodes,
+              i.plugin_statuses, i.last_script_run_at, i.previous_vizier_version, i.last_update_automatic
               from vizier_cluster_info as i, vizier_cluster as c
               WHERE i.vizier_cluster_id=$1 AND i.vizier_cluster_id=c.id`
 	vzInfo := VizierInfo{}
@@ -481,12 +510,13 @@ func (s *Server) getVizierConfig(ctx context.Context, vizierIDPb *uuidpb.UUID) (
 	vizierID := utils.UUIDFromProtoOrNil(vizierIDPb)
 
 	query := `
-		SELECT passthrough_enabled, auto_update_enabled
+		SELECT passthrough_enabled, auto_update_enabled, update_channel
 		FROM vizier_cluster_info
 		WHERE vizier_cluster_id = $1`
 	var val struct {
-		PassthroughEnabled bool `db:"passthrough_enabled"`
-		AutoUpdateEnabled  bool `db:"auto_update_enabled"`
+		PassthroughEnabled bool   `db:"passthrough_enabled"`
+		AutoUpdateEnabled  bool   `db:"auto_update_enabled"`
+		UpdateChannel      string `db:"update_channel"`
 	}
 
 	err := s.db.Get(&val, query, vizierID)
@@ -499,6 +529,7 @@ func (s *Server) getVizierConfig(ctx context.Context, vizierIDPb *uuidpb.UUID) (
 	return &cvmsgspb.VizierConfig{
 		PassthroughEnabled: val.PassthroughEnabled,
 		AutoUpdateEnabled:  val.AutoUpdateEnabled,
+		UpdateChannel:      val.UpdateChannel,
 	}, nil
 }
 
@@ -521,11 +552,16 @@ func (s *Server) UpdateVizierConfig(ctx context.Context, req *cvmsgspb.UpdateViz
 
 	ptEnabled := currentConfig.PassthroughEnabled
 	auEnabled := currentConfig.AutoUpdateEnabled
+	updateChannel := currentConfig.UpdateChannel
 
 	if req.ConfigUpdate.PassthroughEnabled != nil {
 		ptEnabled = req.ConfigUpdate.PassthroughEnabled.Value
 	}
 
+	if req.ConfigUpdate.UpdateChannel != nil {
+		updateChannel = req.ConfigUpdate.UpdateChannel.Value
+	}
+
 	if req.ConfigUpdate.AutoUpdateEnabled != nil {
 		return nil, status.Error(codes.InvalidArgument, "Deprecated. Please configure auto-update through Vizier pl-cluster-config ConfigMap.")
 	}
@@ -533,10 +569,11 @@ func (s *Server) UpdateVizierConfig(ctx context.Context, req *cvmsgspb.UpdateViz
 	query := `
     UPDATE vizier_cluster_info
     SET passthrough_enabled = $1,
-        auto_update_enabled = $2
-    WHERE vizier_cluster_id = $3`
+        auto_update_enabled = $2,
+        update_channel = $3
+    WHERE vizier_cluster_id = $4`
 
-	res, err := s.db.Exec(query, ptEnabled, auEnabled, vizierID)
+	res, err := s.db.Exec(query, ptEnabled, auEnabled, updateChannel, vizierID)
 	if err != nil {
 		return nil, err
 	}
@@ -553,7 +590,7 @@ func (s *Server) UpdateVizierConfig(ctx context.Context, req *cvmsgspb.UpdateViz
 			log.WithError(err).Error("Could not marshal proto to any")
 		}
 		// Tell certmgr about the vizier config
-		s.sendNATSMessage("sslVizierConfigResp", anyMsg, vizierID)
+		s.sendNATSMessage(cvmsgs.SSLVizierConfigRespTopic, anyMsg, vizierID)
 	}
 
 	return &cvmsgspb.UpdateVizierConfigResponse{}, nil
@@ -794,24 +831,14 @@ func (s *Server) HandleVizierHeartbeat(v2cMsg *cvmsgspb.V2CMessage) {
 	query := `
     UPDATE vizier_cluster_info
     SET last_heartbeat = NOW(), status = $1, address= $2, control_plane_pod_statuses= $3,
-    	num_nodes = $4, num_instrumented_nodes = $5, auto_update_enabled = $6
-    WHERE vizier_cluster_id = $7`
-
-	vzStatus := "HEALTHY"
-	if req.Address == "" {
-		vzStatus = "UNHEALTHY"
-	}
-	if req.BootstrapMode {
-		vzStatus = "UPDATING"
-	}
+    	num_nodes = $4, num_instrumented_nodes = $5, auto_update_enabled = $6, plugin_statuses = $7,
+    	last_script_run_at = COALESCE($8, last_script_run_at)
+    WHERE vizier_cluster_id = $9`
 
-	if req.Status != cvmsgspb.VZ_ST_UNKNOWN {
-		s, err := vizierStatus(req.Status).Value()
-		if err != nil {
-			log.WithError(err).Error("Could not convert status")
-			return
-		}
-		vzStatus = s.(string)
+	vzStatus, err := heartbeatStatus(req)
+	if err != nil {
+		log.WithError(err).Error("Could not convert status")
+		return
 	}
 
 	// Send cluster-level info.
@@ -840,11 +867,23 @@ func (s *Server) HandleVizierHeartbeat(v2cMsg *cvmsgspb.V2CMessage) {
 		})
 	}
 
+	// Keep the previous script run time if the vizier hasn't run a script since it last started.
+	var lastScriptRunAt *time.Time
+	if req.LastScriptRunAt != nil {
+		t, err := types.TimestampFromProto(req.LastScriptRunAt)
+		if err != nil {
+			log.WithError(err).Error("Could not convert last script run time")
+		} else {
+			lastScriptRunAt = &t
+		}
+	}
+
 	_, err = s.db.Exec(query, vzStatus, addr, PodStatuses(req.PodStatuses), req.NumNodes,
-		req.NumInstrumentedNodes, !req.DisableAutoUpdate, vizierID)
+		req.NumInstrumentedNodes, !req.DisableAutoUpdate, PluginStatuses(req.PluginStatuses), lastScriptRunAt, vizierID)
 	if err != nil {
 		log.WithError(err).Error("Could not update vizier heartbeat")
 	}
+	s.sendHeartbeatAck(vizierID, req.SequenceNumber, err)
 	if prevInfo.Status == "UPDATING" {
 		return
 	}
@@ -865,6 +904,78 @@ func (s *Server) HandleVizierHeartbeat(v2cMsg *cvmsgspb.V2CMessage) {
 	}()
 }
 
+// heartbeatStatus returns the status of the vizier that sent the heartbeat.
+func heartbeatStatus(hb *cvmsgspb.VizierHeartbeat) (string, error) {
+	if hb.Status != cvmsgspb.VZ_ST_UNKNOWN {
+		s, err := vizierStatus(hb.Status).Value()
+		if err != nil {
+			return "", err
+		}
+		return s.(string), nil
+	}
+	if hb.BootstrapMode {
+		return "UPDATING", nil
+	}
+	if hb.Address == "" {
+		return "UNHEALTHY", nil
+	}
+	return "HEALTHY", nil
+}
+
+// HandleVizierHeartbeatBatch handles the heartbeats that a vizier recorded while it was disconnected.
+// The vizier's current state is updated by the heartbeats it sends after reconnecting, so the batched
+// heartbeats are only used to backfill the heartbeat events for the gap.
+func (s *Server) HandleVizierHeartbeatBatch(v2cMsg *cvmsgspb.V2CMessage) {
+	batch := &cvmsgspb.VizierHeartbeatBatch{}
+	err := types.UnmarshalAny(v2cMsg.Msg, batch)
+	if err != nil {
+		log.WithError(err).Error("Could not unmarshal NATS message")
+		return
+	}
+
+	for _, hb := range batch.Heartbeats {
+		vizierID := utils.UUIDFromProtoOrNil(hb.VizierID)
+		vzStatus, err := heartbeatStatus(hb)
+		if err != nil {
+			log.WithError(err).Error("Could not convert status")
+			continue
+		}
+		events.Client().Enqueue(&analytics.Track{
+			UserId:    vizierID.String(),
+			Event:     events.VizierHeartbeat,
+			Timestamp: time.Unix(0, hb.Time),
+			Properties: analytics.NewProperties().
+				Set("cluster_id", vizierID.String()).
+				Set("status", vzStatus).
+				Set("num_nodes", hb.NumNodes).
+				Set("num_instrumented_nodes", hb.NumInstrumentedNodes).
+				Set("sequence_number", hb.SequenceNumber).
+				Set("buffered", true),
+		})
+	}
+}
+
+// sendHeartbeatAck acks the heartbeat with the given sequence number, so that the vizier knows that
+// its heartbeats are reaching the cloud.
+func (s *Server) sendHeartbeatAck(vizierID uuid.UUID, seqNum int64, hbErr error) {
+	ack := &cvmsgspb.VizierHeartbeatAck{
+		Status:         cvmsgspb.HB_OK,
+		Time:           time.Now().UnixNano(),
+		SequenceNumber: seqNum,
+	}
+	if hbErr != nil {
+		ack.Status = cvmsgspb.HB_ERROR
+		ack.ErrorMessage = hbErr.Error()
+	}
+
+	ackAny, err := types.MarshalAny(ack)
+	if err != nil {
+		log.WithError(err).Error("Could not marshal heartbeat ack")
+		return
+	}
+	s.sendNATSMessage(cvmsgs.HeartbeatAckTopic, ackAny, vizierID)
+}
+
 // HandleSSLRequest registers certs for the vizier cluster.
 func (s *Server) HandleSSLRequest(v2cMsg *cvmsgspb.V2CMessage) {
 	anyMsg := v2cMsg.Msg
@@ -898,22 +1009,26 @@ func (s *Server) HandleSSLRequest(v2cMsg *cvmsgspb.V2CMessage) {
 
 	vizierID := utils.UUIDFromProtoOrNil(req.VizierID)
 	// Tell certmgr about the vizier config
-	s.sendNATSMessage("sslVizierConfigResp", respAnyMsg, vizierID)
+	s.sendNATSMessage(cvmsgs.SSLVizierConfigRespTopic, respAnyMsg, vizierID)
 
 	if vizierConf.GetPassthroughEnabled() {
 		// We don't need SSL certs for the cluster if it is running in passthrough mode.
 		return
 	}
 
-	dnsMgrReq := &dnsmgrpb.GetSSLCertsRequest{ClusterID: req.VizierID}
+	dnsMgrReq := &dnsmgrpb.GetSSLCertsRequest{
+		ClusterID: req.VizierID,
+		Service:   req.Service,
+	}
 	resp, err := s.dnsMgrClient.GetSSLCerts(ctx, dnsMgrReq)
 	if err != nil {
 		log.WithError(err).Error("Could not get SSL certs")
 		return
 	}
 	natsResp := &cvmsgspb.VizierSSLCertResponse{
-		Key:  resp.Key,
-		Cert: resp.Cert,
+		Key:     resp.Key,
+		Cert:    resp.Cert,
+		Service: req.Service,
 	}
 
 	respAnyMsg, err = types.MarshalAny(natsResp)
@@ -923,7 +1038,7 @@ func (s *Server) HandleSSLRequest(v2cMsg *cvmsgspb.V2CMessage) {
 	}
 
 	log.WithField("SSL", respAnyMsg.String()).Info("sending SSL response")
-	s.sendNATSMessage("sslResp", respAnyMsg, vizierID)
+	s.sendNATSMessage(cvmsgs.SSLRespTopic, respAnyMsg, vizierID)
 }
 
 // getServiceCredentials returns JWT credentials for inter-service requests.
@@ -1031,7 +1146,8 @@ func setClusterName(ctx context.Context, tx *sqlx.Tx, clusterID uuid.UUID, gener
 }
 
 // ProvisionOrClaimVizier provisions a given cluster or returns the ID if it already exists,
-func (s *Server) ProvisionOrClaimVizier(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, clusterUID string, clusterName string, clusterVersion string) (uuid.UUID, error) {
+// recording the deployment key that was used to register it.
+func (s *Server) ProvisionOrClaimVizier(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, deployKeyID uuid.UUID, clusterUID string, clusterName string, clusterVersion string) (uuid.UUID, error) {
 	// TODO(zasgar): This duplicates some functionality in the Create function. Will deprecate that Create function soon.
 	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
@@ -1055,7 +1171,22 @@ func (s *Server) ProvisionOrClaimVizier(ctx context.Context, orgID uuid.UUID, us
 		return name
 	}
 
+	commit := func() (uuid.UUID, error) {
+		if err := tx.Commit(); err != nil {
+			log.WithError(err).Error("Failed to commit transaction")
+			return uuid.Nil, vzerrors.ErrInternalDB
+		}
+		return clusterID, nil
+	}
+
 	assignNameAndCommit := func() (uuid.UUID, error) {
+		if deployKeyID != uuid.Nil {
+			query := `UPDATE vizier_cluster SET deployment_key_id=$1 WHERE id=$2`
+			if _, err := tx.ExecContext(ctx, query, deployKeyID, clusterID); err != nil {
+				return uuid.Nil, vzerrors.ErrInternalDB
+			}
+		}
+
 		// Check if cluster already has a name.
 		var existingName *string
 
@@ -1068,7 +1199,7 @@ func (s *Server) ProvisionOrClaimVizier(ctx context.Context, orgID uuid.UUID, us
 		if existingName != nil {
 			// No input name specified, so no need to change cluster name.
 			if inputName == "" {
-				return clusterID, nil
+				return commit()
 			}
 
 			// The existing name is already the same as the input name, or a derivation
@@ -1079,14 +1210,14 @@ func (s *Server) ProvisionOrClaimVizier(ctx context.Context, orgID uuid.UUID, us
 			// cannot distinguish between randomly generated names and actual-unaltered names.
 			dbName := *existingName
 			if inputName == dbName {
-				return clusterID, nil
+				return commit()
 			}
 			prefixIndex := strings.LastIndex(dbName, "_")
 			if prefixIndex != -1 {
 				dbName = dbName[:prefixIndex]
 			}
 			if inputName == dbName {
-				return clusterID, nil
+				return commit()
 			}
 		}
 
@@ -1098,12 +1229,7 @@ func (s *Server) ProvisionOrClaimVizier(ctx context.Context, orgID uuid.UUID, us
 		if err := setClusterName(ctx, tx, clusterID, generateNameFunc); err != nil {
 			return uuid.Nil, vzerrors.ErrInternalDB
 		}
-
-		if err := tx.Commit(); err != nil {
-			log.WithError(err).Error("Failed to commit transaction")
-			return uuid.Nil, vzerrors.ErrInternalDB
-		}
-		return clusterID, nil
+		return commit()
 	}
 
 	assignClusterVersion := func(clusterID uuid.UUID) error {
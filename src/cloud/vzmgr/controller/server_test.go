@@ -80,6 +80,18 @@ var testPodStatuses controller.PodStatuses = map[string]*cvmsgspb.PodStatus{
 	},
 }
 
+var testPluginStatuses controller.PluginStatuses = []*cvmsgspb.PluginStatus{
+	{
+		Name:    "otel-export",
+		Healthy: true,
+	},
+	{
+		Name:    "prometheus-export",
+		Healthy: false,
+		Message: "remote write failed",
+	},
+}
+
 func TestMain(m *testing.M) {
 	err := testMain(m)
 	if err != nil {
@@ -248,8 +260,55 @@ func TestServer_GetVizierInfo(t *testing.T) {
 	assert.Equal(t, testPodStatuses, controller.PodStatuses(resp.ControlPlanePodStatuses))
 }
 
+func TestServer_GetVizierInfo_AutoUpdateInProgress(t *testing.T) {
+	tests := []struct {
+		name                 string
+		status               string
+		lastUpdateAutomatic  bool
+		autoUpdateInProgress bool
+	}{
+		{
+			name:                 "automatic update",
+			status:               "UPDATING",
+			lastUpdateAutomatic:  true,
+			autoUpdateInProgress: true,
+		},
+		{
+			name:                 "requested update",
+			status:               "UPDATING",
+			lastUpdateAutomatic:  false,
+			autoUpdateInProgress: false,
+		},
+		{
+			name:                 "finished automatic update",
+			status:               "HEALTHY",
+			lastUpdateAutomatic:  true,
+			autoUpdateInProgress: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mustLoadTestData(db)
+			db.MustExec(`UPDATE vizier_cluster_info SET status = $1, last_update_automatic = $2 WHERE vizier_cluster_id = $3`,
+				tc.status, tc.lastUpdateAutomatic, "123e4567-e89b-12d3-a456-426655440001")
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockDNSClient := mock_dnsmgrpb.NewMockDNSMgrServiceClient(ctrl)
+
+			s := controller.New(db, "test", mockDNSClient, nil, nil)
+			resp, err := s.GetVizierInfo(CreateTestContext(), utils.ProtoFromUUIDStrOrNil("123e4567-e89b-12d3-a456-426655440001"))
+			require.NoError(t, err)
+			assert.Equal(t, tc.autoUpdateInProgress, resp.AutoUpdateInProgress)
+		})
+	}
+}
+
 func TestServer_GetVizierInfos(t *testing.T) {
 	mustLoadTestData(db)
+	db.MustExec(`UPDATE vizier_cluster_info SET previous_vizier_version = 'oldVzVers' WHERE vizier_cluster_id = $1`,
+		"123e4567-e89b-12d3-a456-426655440001")
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -272,10 +331,12 @@ func TestServer_GetVizierInfos(t *testing.T) {
 	assert.Equal(t, 4, len(resp.VizierInfos))
 	assert.Equal(t, utils.ProtoFromUUIDStrOrNil("123e4567-e89b-12d3-a456-426655440001"), resp.VizierInfos[0].VizierID)
 	assert.Equal(t, "vzVers", resp.VizierInfos[0].VizierVersion)
+	assert.Equal(t, "oldVzVers", resp.VizierInfos[0].PreviousVizierVersion)
 	assert.Equal(t, &cvmsgspb.VizierInfo{}, resp.VizierInfos[1])
 	assert.Equal(t, &cvmsgspb.VizierInfo{}, resp.VizierInfos[2])
 	assert.Equal(t, utils.ProtoFromUUIDStrOrNil("123e4567-e89b-12d3-a456-426655440000"), resp.VizierInfos[3].VizierID)
 	assert.Equal(t, "k8sID", resp.VizierInfos[3].ClusterUID)
+	assert.Equal(t, "", resp.VizierInfos[3].PreviousVizierVersion)
 }
 
 func TestServer_UpdateVizierConfig(t *testing.T) {
@@ -303,6 +364,92 @@ func TestServer_UpdateVizierConfig(t *testing.T) {
 	assert.Equal(t, infoResp.Config.PassthroughEnabled, true)
 }
 
+func TestServer_UpdateVizierConfig_UpdateChannel(t *testing.T) {
+	mustLoadTestData(db)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockDNSClient := mock_dnsmgrpb.NewMockDNSMgrServiceClient(ctrl)
+
+	s := controller.New(db, "test", mockDNSClient, nil, nil)
+	vzIDpb := utils.ProtoFromUUIDStrOrNil("123e4567-e89b-12d3-a456-426655440001")
+
+	// Clusters are on the stable channel until they're moved to another one.
+	infoResp, err := s.GetVizierInfo(CreateTestContext(), vzIDpb)
+	require.NoError(t, err)
+	assert.Equal(t, "stable", infoResp.Config.UpdateChannel)
+
+	_, err = s.UpdateVizierConfig(CreateTestContext(), &cvmsgspb.UpdateVizierConfigRequest{
+		VizierID: vzIDpb,
+		ConfigUpdate: &cvmsgspb.VizierConfigUpdate{
+			UpdateChannel: &types.StringValue{Value: "beta"},
+		},
+	})
+	require.NoError(t, err)
+
+	infoResp, err = s.GetVizierInfo(CreateTestContext(), vzIDpb)
+	require.NoError(t, err)
+	assert.Equal(t, "beta", infoResp.Config.UpdateChannel)
+	assert.False(t, infoResp.Config.PassthroughEnabled)
+}
+
+func TestServer_UpdateVizierConfig_PartialUpdates(t *testing.T) {
+	mustLoadTestData(db)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockDNSClient := mock_dnsmgrpb.NewMockDNSMgrServiceClient(ctrl)
+
+	s := controller.New(db, "test", mockDNSClient, nil, nil)
+	vzIDpb := utils.ProtoFromUUIDStrOrNil("123e4567-e89b-12d3-a456-426655440001")
+
+	// Each update sets a single field, and the other fields should keep the values from the previous steps.
+	steps := []struct {
+		name      string
+		update    *cvmsgspb.VizierConfigUpdate
+		expectErr bool
+		expected  *cvmsgspb.VizierConfig
+	}{
+		{
+			name:     "passthrough",
+			update:   &cvmsgspb.VizierConfigUpdate{PassthroughEnabled: &types.BoolValue{Value: true}},
+			expected: &cvmsgspb.VizierConfig{PassthroughEnabled: true, AutoUpdateEnabled: true, UpdateChannel: "stable"},
+		},
+		{
+			name:     "update channel",
+			update:   &cvmsgspb.VizierConfigUpdate{UpdateChannel: &types.StringValue{Value: "beta"}},
+			expected: &cvmsgspb.VizierConfig{PassthroughEnabled: true, AutoUpdateEnabled: true, UpdateChannel: "beta"},
+		},
+		{
+			name:     "passthrough again",
+			update:   &cvmsgspb.VizierConfigUpdate{PassthroughEnabled: &types.BoolValue{Value: false}},
+			expected: &cvmsgspb.VizierConfig{PassthroughEnabled: false, AutoUpdateEnabled: true, UpdateChannel: "beta"},
+		},
+		{
+			name:      "auto update",
+			update:    &cvmsgspb.VizierConfigUpdate{AutoUpdateEnabled: &types.BoolValue{Value: false}},
+			expectErr: true,
+			expected:  &cvmsgspb.VizierConfig{PassthroughEnabled: false, AutoUpdateEnabled: true, UpdateChannel: "beta"},
+		},
+	}
+
+	for _, step := range steps {
+		_, err := s.UpdateVizierConfig(CreateTestContext(), &cvmsgspb.UpdateVizierConfigRequest{
+			VizierID:     vzIDpb,
+			ConfigUpdate: step.update,
+		})
+		if step.expectErr {
+			require.Error(t, err, step.name)
+		} else {
+			require.NoError(t, err, step.name)
+		}
+
+		infoResp, err := s.GetVizierInfo(CreateTestContext(), vzIDpb)
+		require.NoError(t, err)
+		assert.Equal(t, step.expected, infoResp.Config, step.name)
+	}
+}
+
 func TestServer_UpdateVizierConfig_AutoUpdate(t *testing.T) {
 	mustLoadTestData(db)
 
@@ -525,6 +672,7 @@ func TestServer_HandleVizierHeartbeat(t *testing.T) {
 		expectDeploy               bool
 		expectedFetchVizierVersion bool
 		controlPlanePodStatuses    controller.PodStatuses
+		pluginStatuses             controller.PluginStatuses
 		numNodes                   int32
 		numInstrumentedNodes       int32
 		checkVersion               bool
@@ -545,6 +693,7 @@ func TestServer_HandleVizierHeartbeat(t *testing.T) {
 			updatedClusterStatus:    "HEALTHY",
 			expectedClusterAddress:  "abc.clusters.dev.withpixie.dev:123",
 			controlPlanePodStatuses: testPodStatuses,
+			pluginStatuses:          testPluginStatuses,
 			numNodes:                4,
 			numInstrumentedNodes:    3,
 			checkVersion:            true,
@@ -635,6 +784,13 @@ func TestServer_HandleVizierHeartbeat(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
+			ackCh := make(chan *nats.Msg, 1)
+			ackSub, err := nc.ChanSubscribe(fmt.Sprintf("c2v.%s.heartbeatAck", tc.vizierID), ackCh)
+			require.NoError(t, err)
+			defer func() {
+				require.NoError(t, ackSub.Unsubscribe())
+			}()
+
 			dnsMgrReq := &dnsmgrpb.GetDNSAddressRequest{
 				ClusterID: utils.ProtoFromUUIDStrOrNil(tc.vizierID),
 				IPAddress: tc.hbAddress,
@@ -679,6 +835,7 @@ func TestServer_HandleVizierHeartbeat(t *testing.T) {
 				NumNodes:             tc.numNodes,
 				NumInstrumentedNodes: tc.numInstrumentedNodes,
 				DisableAutoUpdate:    tc.disableAutoUpdate,
+				PluginStatuses:       tc.pluginStatuses,
 			}
 			nestedAny, err := types.MarshalAny(nestedMsg)
 			if err != nil {
@@ -691,17 +848,32 @@ func TestServer_HandleVizierHeartbeat(t *testing.T) {
 
 			s.HandleVizierHeartbeat(req)
 
+			// The heartbeat should be acked.
+			select {
+			case m := <-ackCh:
+				c2vMsg := &cvmsgspb.C2VMessage{}
+				require.NoError(t, c2vMsg.Unmarshal(m.Data))
+				ack := &cvmsgspb.VizierHeartbeatAck{}
+				require.NoError(t, types.UnmarshalAny(c2vMsg.Msg, ack))
+				assert.Equal(t, cvmsgspb.HB_OK, ack.Status)
+				assert.Equal(t, int64(200), ack.SequenceNumber)
+			case <-time.After(1 * time.Second):
+				t.Fatal("Timed out waiting for heartbeat ack")
+			}
+
 			// Check database.
 			clusterQuery := `
-			SELECT status, address, control_plane_pod_statuses, num_nodes, num_instrumented_nodes, auto_update_enabled
+			SELECT status, address, control_plane_pod_statuses, num_nodes, num_instrumented_nodes, auto_update_enabled,
+			plugin_statuses
 			FROM vizier_cluster_info WHERE vizier_cluster_id=$1`
 			var clusterInfo struct {
-				Status                  string                 `db:"status"`
-				Address                 string                 `db:"address"`
-				ControlPlanePodStatuses controller.PodStatuses `db:"control_plane_pod_statuses"`
-				NumNodes                int32                  `db:"num_nodes"`
-				NumInstrumentedNodes    int32                  `db:"num_instrumented_nodes"`
-				AutoUpdateEnabled       bool                   `db:"auto_update_enabled"`
+				Status                  string                    `db:"status"`
+				Address                 string                    `db:"address"`
+				ControlPlanePodStatuses controller.PodStatuses    `db:"control_plane_pod_statuses"`
+				NumNodes                int32                     `db:"num_nodes"`
+				NumInstrumentedNodes    int32                     `db:"num_instrumented_nodes"`
+				AutoUpdateEnabled       bool                      `db:"auto_update_enabled"`
+				PluginStatuses          controller.PluginStatuses `db:"plugin_statuses"`
 			}
 			clusterID, err := uuid.FromString(tc.vizierID)
 			require.NoError(t, err)
@@ -714,10 +886,62 @@ func TestServer_HandleVizierHeartbeat(t *testing.T) {
 			assert.Equal(t, tc.numNodes, clusterInfo.NumNodes)
 			assert.Equal(t, tc.numInstrumentedNodes, clusterInfo.NumInstrumentedNodes)
 			assert.Equal(t, !tc.disableAutoUpdate, clusterInfo.AutoUpdateEnabled)
+			if tc.checkDB {
+				assert.ElementsMatch(t, tc.pluginStatuses, clusterInfo.PluginStatuses)
+			}
 		})
 	}
 }
 
+func TestServer_HandleVizierHeartbeatBatch(t *testing.T) {
+	mustLoadTestData(db)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockDNSClient := mock_dnsmgrpb.NewMockDNSMgrServiceClient(ctrl)
+
+	nc, cleanup := testingutils.MustStartTestNATS(t)
+	defer cleanup()
+
+	updater := mock_controller.NewMockVzUpdater(ctrl)
+	s := controller.New(db, "test", mockDNSClient, nc, updater)
+
+	vizierID := uuid.FromStringOrNil("123e4567-e89b-12d3-a456-426655440001")
+	clusterQuery := `SELECT status, address FROM vizier_cluster_info WHERE vizier_cluster_id=$1`
+	type clusterInfo struct {
+		Status  string `db:"status"`
+		Address string `db:"address"`
+	}
+	var before clusterInfo
+	require.NoError(t, db.Get(&before, clusterQuery, vizierID))
+
+	batch := &cvmsgspb.VizierHeartbeatBatch{
+		Heartbeats: []*cvmsgspb.VizierHeartbeat{
+			{
+				VizierID:       utils.ProtoFromUUID(vizierID),
+				Time:           100,
+				SequenceNumber: 10,
+				Status:         cvmsgspb.VZ_ST_UNHEALTHY,
+			},
+			{
+				VizierID:       utils.ProtoFromUUID(vizierID),
+				Time:           200,
+				SequenceNumber: 11,
+				Address:        "127.0.0.2",
+			},
+		},
+	}
+	batchAny, err := types.MarshalAny(batch)
+	require.NoError(t, err)
+
+	// The heartbeats in the batch are stale, so they shouldn't change the vizier's current state.
+	s.HandleVizierHeartbeatBatch(&cvmsgspb.V2CMessage{Msg: batchAny})
+
+	var after clusterInfo
+	require.NoError(t, db.Get(&after, clusterQuery, vizierID))
+	assert.Equal(t, before, after)
+}
+
 func TestServer_GetSSLCerts(t *testing.T) {
 	mustLoadTestData(db)
 
@@ -787,6 +1011,53 @@ func TestServer_GetSSLCerts(t *testing.T) {
 			t.Fatal("Timeout")
 		}
 	})
+
+	t.Run("named service", func(t *testing.T) {
+		// The service should get its own cert from dnsmgr.
+		dnsMgrReq := &dnsmgrpb.GetSSLCertsRequest{
+			ClusterID: utils.ProtoFromUUIDStrOrNil("123e4567-e89b-12d3-a456-426655440001"),
+			Service:   "kelvin",
+		}
+
+		dnsMgrResp := &dnsmgrpb.GetSSLCertsResponse{
+			Key:  "abcd",
+			Cert: "efgh",
+		}
+
+		mockDNSClient.EXPECT().
+			GetSSLCerts(gomock.Any(), dnsMgrReq).
+			Return(dnsMgrResp, nil)
+
+		nestedMsg := &cvmsgspb.VizierSSLCertRequest{
+			VizierID: utils.ProtoFromUUIDStrOrNil("123e4567-e89b-12d3-a456-426655440001"),
+			Service:  "kelvin",
+		}
+		nestedAny, err := types.MarshalAny(nestedMsg)
+		if err != nil {
+			t.Fatal("Could not marshal pb")
+		}
+
+		s.HandleSSLRequest(&cvmsgspb.V2CMessage{Msg: nestedAny})
+
+		select {
+		case m := <-subCh:
+			pb := &cvmsgspb.C2VMessage{}
+			err = proto.Unmarshal(m.Data, pb)
+			if err != nil {
+				t.Fatal("Could not unmarshal message")
+			}
+			resp := &cvmsgspb.VizierSSLCertResponse{}
+			err = types.UnmarshalAny(pb.Msg, resp)
+			if err != nil {
+				t.Fatal("Could not unmarshal any message")
+			}
+			assert.Equal(t, "kelvin", resp.Service)
+			assert.Equal(t, "abcd", resp.Key)
+			assert.Equal(t, "efgh", resp.Cert)
+		case <-time.After(1 * time.Second):
+			t.Fatal("Timeout")
+		}
+	})
 }
 
 func TestServer_UpdateOrInstallVizier(t *testing.T) {
@@ -1032,7 +1303,7 @@ func TestServer_ProvisionOrClaimVizier(t *testing.T) {
 	userID := uuid.Must(uuid.NewV4())
 
 	// This should select the first cluster with an empty UID that is disconnected.
-	clusterID, err := s.ProvisionOrClaimVizier(context.Background(), uuid.FromStringOrNil(testAuthOrgID), userID, "my cluster", "", "1.1")
+	clusterID, err := s.ProvisionOrClaimVizier(context.Background(), uuid.FromStringOrNil(testAuthOrgID), userID, uuid.Nil, "my cluster", "", "1.1")
 	require.NoError(t, err)
 	// Should select the disconnected cluster.
 	assert.Equal(t, testDisconnectedClusterEmptyUID, clusterID.String())
@@ -1077,21 +1348,25 @@ func TestServer_ProvisionOrClaimVizierWIthExistingUID(t *testing.T) {
 
 			s := controller.New(db, "test", nil, nil, nil)
 			userID := uuid.Must(uuid.NewV4())
+			deployKeyID := uuid.Must(uuid.NewV4())
 
 			// This should select the existing cluster with the same UID.
-			clusterID, err := s.ProvisionOrClaimVizier(context.Background(), uuid.FromStringOrNil(testAuthOrgID), userID, "existing_cluster", test.inputName, "1.1")
+			clusterID, err := s.ProvisionOrClaimVizier(context.Background(), uuid.FromStringOrNil(testAuthOrgID), userID, deployKeyID, "existing_cluster", test.inputName, "1.1")
 			require.NoError(t, err)
 			// Should select the disconnected cluster.
 			assert.Equal(t, testExistingCluster, clusterID.String())
 
-			// Check cluster name.
+			// Check cluster name and the deployment key that was used.
 			var clusterInfo struct {
-				ClusterName *string `db:"cluster_name"`
+				ClusterName     *string    `db:"cluster_name"`
+				DeploymentKeyID *uuid.UUID `db:"deployment_key_id"`
 			}
-			nameQuery := `SELECT cluster_name from vizier_cluster WHERE id=$1`
+			nameQuery := `SELECT cluster_name, deployment_key_id from vizier_cluster WHERE id=$1`
 			err = db.Get(&clusterInfo, nameQuery, clusterID)
 			require.NoError(t, err)
 			assert.Equal(t, *clusterInfo.ClusterName, test.expectedName)
+			require.NotNil(t, clusterInfo.DeploymentKeyID)
+			assert.Equal(t, deployKeyID, *clusterInfo.DeploymentKeyID)
 		})
 	}
 }
@@ -1102,7 +1377,7 @@ func TestServer_ProvisionOrClaimVizier_WithExistingActiveUID(t *testing.T) {
 	s := controller.New(db, "test", nil, nil, nil)
 	userID := uuid.Must(uuid.NewV4())
 	// This should select cause an error b/c we are trying to provision a cluster that is not disconnected.
-	clusterID, err := s.ProvisionOrClaimVizier(context.Background(), uuid.FromStringOrNil(testAuthOrgID), userID, "my_other_cluster", "", "1.1")
+	clusterID, err := s.ProvisionOrClaimVizier(context.Background(), uuid.FromStringOrNil(testAuthOrgID), userID, uuid.Nil, "my_other_cluster", "", "1.1")
 	assert.NotNil(t, err)
 	assert.Equal(t, vzerrors.ErrProvisionFailedVizierIsActive, err)
 	assert.Equal(t, uuid.Nil, clusterID)
@@ -1114,7 +1389,7 @@ func TestServer_ProvisionOrClaimVizier_WithNewCluster(t *testing.T) {
 	s := controller.New(db, "test", nil, nil, nil)
 	userID := uuid.Must(uuid.NewV4())
 	// This should select cause an error b/c we are trying to provision a cluster that is not disconnected.
-	clusterID, err := s.ProvisionOrClaimVizier(context.Background(), uuid.FromStringOrNil(testNonAuthOrgID), userID, "my_other_cluster", "", "1.1")
+	clusterID, err := s.ProvisionOrClaimVizier(context.Background(), uuid.FromStringOrNil(testNonAuthOrgID), userID, uuid.Nil, "my_other_cluster", "", "1.1")
 	require.NoError(t, err)
 	assert.NotEqual(t, uuid.Nil, clusterID)
 }
@@ -1126,7 +1401,7 @@ func TestServer_ProvisionOrClaimVizier_WithExistingName(t *testing.T) {
 	userID := uuid.Must(uuid.NewV4())
 
 	// This should select the existing cluster with the same UID.
-	clusterID, err := s.ProvisionOrClaimVizier(context.Background(), uuid.FromStringOrNil(testAuthOrgID), userID, "some_cluster", "test_cluster_1234\n", "1.1")
+	clusterID, err := s.ProvisionOrClaimVizier(context.Background(), uuid.FromStringOrNil(testAuthOrgID), userID, uuid.Nil, "some_cluster", "test_cluster_1234\n", "1.1")
 	require.NoError(t, err)
 	// Should select the disconnected cluster.
 	assert.Equal(t, testDisconnectedClusterEmptyUID, clusterID.String())
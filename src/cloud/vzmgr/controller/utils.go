@@ -54,3 +54,33 @@ func (p *PodStatuses) Scan(src interface{}) error {
 
 	return nil
 }
+
+// PluginStatuses Type to use in sqlx for the list of plugin statuses.
+type PluginStatuses []*cvmsgspb.PluginStatus
+
+// Value Returns a golang database/sql driver value for PluginStatuses.
+func (p PluginStatuses) Value() (driver.Value, error) {
+	if p == nil {
+		p = PluginStatuses{}
+	}
+	res, err := json.Marshal(p)
+	if err != nil {
+		return res, err
+	}
+	return driver.Value(res), err
+}
+
+// Scan Scans the sqlx database type ([]bytes) into the PluginStatuses type.
+func (p *PluginStatuses) Scan(src interface{}) error {
+	switch jsonText := src.(type) {
+	case []byte:
+		err := json.Unmarshal(jsonText, p)
+		if err != nil {
+			return status.Error(codes.Internal, "could not unmarshal plugin statuses")
+		}
+	default:
+		return status.Error(codes.Internal, "could not unmarshal plugin statuses")
+	}
+
+	return nil
+}
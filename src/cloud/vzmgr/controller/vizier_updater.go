@@ -38,6 +38,7 @@ import (
 	"px.dev/pixie/src/cloud/artifact_tracker/artifacttrackerpb"
 	"px.dev/pixie/src/cloud/shared/vzshard"
 	"px.dev/pixie/src/shared/artifacts/versionspb"
+	"px.dev/pixie/src/shared/cvmsgs"
 	"px.dev/pixie/src/shared/cvmsgspb"
 	jwtutils "px.dev/pixie/src/shared/services/utils"
 	"px.dev/pixie/src/utils"
@@ -132,11 +133,12 @@ func (u *Updater) getLatestVizierVersion() (string, error) {
 // UpdateOrInstallVizier immediately updates or installs the Vizier instance. This should be used in cases where
 // the user is bootstrapping Vizier for the first time, or has manually sent an update request.
 func (u *Updater) UpdateOrInstallVizier(vizierID uuid.UUID, version string, redeployEtcd bool) (*cvmsgspb.V2CMessage, error) {
-	return u.updateOrInstallVizier(vizierID, version, redeployEtcd)
+	return u.updateOrInstallVizier(vizierID, version, redeployEtcd, false)
 }
 
-// Helper method for updating/installing a Vizier instance.
-func (u *Updater) updateOrInstallVizier(vizierID uuid.UUID, version string, redeployEtcd bool) (*cvmsgspb.V2CMessage, error) {
+// Helper method for updating/installing a Vizier instance. automatic is whether the update was started by the
+// updater itself, rather than requested.
+func (u *Updater) updateOrInstallVizier(vizierID uuid.UUID, version string, redeployEtcd bool, automatic bool) (*cvmsgspb.V2CMessage, error) {
 	// Set up ctx.
 	serviceAuthToken, err := getServiceCredentials(viper.GetString("jwt_signing_key"))
 	if err != nil {
@@ -169,9 +171,14 @@ func (u *Updater) updateOrInstallVizier(vizierID uuid.UUID, version string, rede
 		return nil, errors.New("Could not generate Vizier token")
 	}
 
-	// Update state in DB.
-	query := `UPDATE vizier_cluster_info SET status = 'UPDATING' WHERE vizier_cluster_id = $1`
-	_, err = u.db.Exec(query, vizierID)
+	// Update state in DB. The version the cluster is currently running is kept, so that the update can be rolled
+	// back. It isn't overwritten if the cluster is already running the requested version, such as when an update
+	// is retried after the cluster finished updating. Whether the update was automatic is recorded, so that
+	// automatic updates can be told apart from requested ones while the cluster is updating.
+	query := `UPDATE vizier_cluster_info SET status = 'UPDATING', last_update_automatic = $3,
+		previous_vizier_version = CASE WHEN vizier_version = $2 THEN previous_vizier_version ELSE vizier_version END
+		WHERE vizier_cluster_id = $1`
+	_, err = u.db.Exec(query, vizierID, version, automatic)
 	if err != nil {
 		return nil, errors.New("Could not update Vizier status")
 	}
@@ -190,14 +197,14 @@ func (u *Updater) updateOrInstallVizier(vizierID uuid.UUID, version string, rede
 
 	// Subscribe to topic that the response will be sent on.
 	subCh := make(chan *nats.Msg, 1024)
-	sub, err := u.nc.ChanSubscribe(vzshard.V2CTopic("VizierUpdateResponse", vizierID), subCh)
+	sub, err := u.nc.ChanSubscribe(vzshard.V2CTopic(string(cvmsgs.VizierUpdateResponseTopic), vizierID), subCh)
 	if err != nil {
 		return nil, err
 	}
 	defer sub.Unsubscribe()
 
 	log.WithField("Vizier ID", vizierID.String()).WithField("version", req.Version).Info("Sending update request to Vizier")
-	u.sendNATSMessage("VizierUpdate", reqAnyMsg, vizierID)
+	u.sendNATSMessage(cvmsgs.VizierUpdateTopic, reqAnyMsg, vizierID)
 
 	// Wait to receive a response from the vizier that it has received the update message.
 	for {
@@ -223,7 +230,7 @@ func (u *Updater) updateOrInstallVizier(vizierID uuid.UUID, version string, rede
 	}
 }
 
-func (u *Updater) sendNATSMessage(topic string, msg *types.Any, vizierID uuid.UUID) {
+func (u *Updater) sendNATSMessage(topic cvmsgs.Topic, msg *types.Any, vizierID uuid.UUID) {
 	wrappedMsg := &cvmsgspb.C2VMessage{
 		VizierID: vizierID.String(),
 		Msg:      msg,
@@ -234,9 +241,9 @@ func (u *Updater) sendNATSMessage(topic string, msg *types.Any, vizierID uuid.UU
 		log.WithError(err).Error("Could not marshal message to bytes")
 		return
 	}
-	topic = vzshard.C2VTopic(topic, vizierID)
-	log.WithField("topic", topic).Info("Sending message")
-	err = u.nc.Publish(topic, b)
+	natsTopic := vzshard.C2VTopic(string(topic), vizierID)
+	log.WithField("topic", natsTopic).Info("Sending message")
+	err = u.nc.Publish(natsTopic, b)
 
 	if err != nil {
 		log.WithError(err).Error("Could not publish message to nats")
@@ -283,7 +290,7 @@ func (u *Updater) ProcessUpdateQueue() {
 			log.Info("Quit signal, stopping Vizier updates")
 			return
 		case vzID := <-u.updateQueue:
-			_, err := u.updateOrInstallVizier(vzID, "", false)
+			_, err := u.updateOrInstallVizier(vzID, "", false, true)
 			if err != nil {
 				log.WithError(err).Error("Failed to send update to Vizier.")
 			}
@@ -38,6 +38,7 @@ import (
 	"px.dev/pixie/src/cloud/shared/vzshard"
 	"px.dev/pixie/src/cloud/vzmgr/controller"
 	"px.dev/pixie/src/shared/artifacts/versionspb"
+	"px.dev/pixie/src/shared/cvmsgs"
 	"px.dev/pixie/src/shared/cvmsgspb"
 	"px.dev/pixie/src/utils/testingutils"
 )
@@ -76,7 +77,7 @@ func setUpUpdater(t *testing.T) (*controller.Updater, *nats.Conn, *sqlx.DB, *moc
 }
 
 func TestUpdater_UpdateOrInstallVizier(t *testing.T) {
-	updater, nc, _, mockArtifactTrackerClient, cleanup := setUpUpdater(t)
+	updater, nc, db, mockArtifactTrackerClient, cleanup := setUpUpdater(t)
 	defer cleanup()
 	viper.Set("domain_name", "withpixie.ai")
 
@@ -111,7 +112,7 @@ func TestUpdater_UpdateOrInstallVizier(t *testing.T) {
 
 			b, err := wrappedMsg.Marshal()
 			require.NoError(t, err)
-			topic := vzshard.V2CTopic("VizierUpdateResponse", vizierID)
+			topic := vzshard.V2CTopic(string(cvmsgs.VizierUpdateResponseTopic), vizierID)
 			err = nc.Publish(topic, b)
 			require.NoError(t, err)
 		})
@@ -127,6 +128,18 @@ func TestUpdater_UpdateOrInstallVizier(t *testing.T) {
 
 	_, err := updater.UpdateOrInstallVizier(vizierID, "123", false)
 	require.NoError(t, err)
+
+	// The version the cluster ran before the update is kept, so that the update can be rolled back.
+	var previousVersion string
+	err = db.Get(&previousVersion, `SELECT previous_vizier_version FROM vizier_cluster_info WHERE vizier_cluster_id = $1`, vizierID)
+	require.NoError(t, err)
+	assert.Equal(t, "vzVers", previousVersion)
+
+	// A requested update isn't an auto-update.
+	var automatic bool
+	err = db.Get(&automatic, `SELECT last_update_automatic FROM vizier_cluster_info WHERE vizier_cluster_id = $1`, vizierID)
+	require.NoError(t, err)
+	assert.False(t, automatic)
 }
 
 func TestUpdater_VersionUpToDate(t *testing.T) {
@@ -153,7 +166,7 @@ func TestUpdater_AddToUpdateQueue(t *testing.T) {
 }
 
 func TestUpdater_ProcessUpdateQueue(t *testing.T) {
-	updater, nc, _, _, cleanup := setUpUpdater(t)
+	updater, nc, db, _, cleanup := setUpUpdater(t)
 	defer cleanup()
 	vizierID, _ := uuid.FromString("123e4567-e89b-12d3-a456-426655440001")
 	viper.Set("domain_name", "withpixie.ai")
@@ -196,7 +209,7 @@ func TestUpdater_ProcessUpdateQueue(t *testing.T) {
 
 			b, err := wrappedMsg.Marshal()
 			require.NoError(t, err)
-			topic := vzshard.V2CTopic("VizierUpdateResponse", vizierID)
+			topic := vzshard.V2CTopic(string(cvmsgs.VizierUpdateResponseTopic), vizierID)
 			err = nc.Publish(topic, b)
 			require.NoError(t, err)
 			wg.Done()
@@ -205,4 +218,11 @@ func TestUpdater_ProcessUpdateQueue(t *testing.T) {
 	}()
 
 	go updater.ProcessUpdateQueue()
+	wg.Wait()
+
+	// Updates from the queue are started by the updater, so they are auto-updates.
+	var automatic bool
+	err := db.Get(&automatic, `SELECT last_update_automatic FROM vizier_cluster_info WHERE vizier_cluster_id = $1`, vizierID)
+	require.NoError(t, err)
+	assert.True(t, automatic)
 }
@@ -32,15 +32,15 @@ import (
 
 // InfoFetcher fetches information about deployments using the key.
 type InfoFetcher interface {
-	FetchOrgUserIDUsingDeploymentKey(context.Context, string) (uuid.UUID, uuid.UUID, error)
+	FetchOrgUserIDUsingDeploymentKey(context.Context, string) (uuid.UUID, uuid.UUID, uuid.UUID, error)
 }
 
 // VizierProvisioner provisions a new Vizier.
 type VizierProvisioner interface {
-	// ProvisionVizier creates the vizier, with specified org_id, user_id, cluster_uid. Returns
+	// ProvisionVizier creates the vizier, with specified org_id, user_id, deployment key ID, cluster_uid. Returns
 	// Cluster ID or error. If it already exists it will return the current cluster ID. Will return an error if the cluster is
 	// currently active (ie. Not disconnected).
-	ProvisionOrClaimVizier(context.Context, uuid.UUID, uuid.UUID, string, string, string) (uuid.UUID, error)
+	ProvisionOrClaimVizier(context.Context, uuid.UUID, uuid.UUID, uuid.UUID, string, string, string) (uuid.UUID, error)
 }
 
 // Service is the deployment service.
@@ -59,8 +59,8 @@ func (s *Service) RegisterVizierDeployment(ctx context.Context, req *vzmgrpb.Reg
 	if len(req.K8sClusterUID) == 0 {
 		return nil, status.Error(codes.InvalidArgument, "empty cluster UID is not allowed")
 	}
-	// Fetch the orgID and userID based on the deployment key.
-	orgID, userID, err := s.deploymentInfoFetcher.FetchOrgUserIDUsingDeploymentKey(ctx, req.DeploymentKey)
+	// Fetch the orgID, userID and key ID based on the deployment key.
+	orgID, userID, keyID, err := s.deploymentInfoFetcher.FetchOrgUserIDUsingDeploymentKey(ctx, req.DeploymentKey)
 	if err != nil {
 		return nil, status.Error(codes.Unauthenticated, "invalid/unknown deployment key")
 	}
@@ -69,7 +69,7 @@ func (s *Service) RegisterVizierDeployment(ctx context.Context, req *vzmgrpb.Reg
 	// 2. If the UID matches then return that cluster.
 	// 3. Otherwise, pick a cluster with no UID specified and claim it.
 	// 4. If no empty clusters exist then we create a new cluster.
-	clusterID, err := s.vp.ProvisionOrClaimVizier(ctx, orgID, userID, req.K8sClusterUID, req.K8sClusterName, req.K8sClusterVersion)
+	clusterID, err := s.vp.ProvisionOrClaimVizier(ctx, orgID, userID, keyID, req.K8sClusterUID, req.K8sClusterName, req.K8sClusterVersion)
 	if err != nil {
 		return nil, vzerrors.ToGRPCError(err)
 	}
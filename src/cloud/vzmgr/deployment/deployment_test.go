@@ -41,23 +41,24 @@ var (
 
 	testValidClusterID = uuid.FromStringOrNil("553e4567-e89b-12d3-a456-426655440000")
 
-	testValidDeploymentKey = "883e4567-e89b-12d3-a456-426655440000"
+	testValidDeploymentKey   = "883e4567-e89b-12d3-a456-426655440000"
+	testValidDeploymentKeyID = uuid.FromStringOrNil("993e4567-e89b-12d3-a456-426655440000")
 )
 
 type fakeDF struct{}
 
-func (f *fakeDF) FetchOrgUserIDUsingDeploymentKey(ctx context.Context, key string) (uuid.UUID, uuid.UUID, error) {
+func (f *fakeDF) FetchOrgUserIDUsingDeploymentKey(ctx context.Context, key string) (uuid.UUID, uuid.UUID, uuid.UUID, error) {
 	if key == testValidDeploymentKey {
-		return testOrgID, testUserID, nil
+		return testOrgID, testUserID, testValidDeploymentKeyID, nil
 	}
-	return uuid.Nil, uuid.Nil, vzerrors.ErrDeploymentKeyNotFound
+	return uuid.Nil, uuid.Nil, uuid.Nil, vzerrors.ErrDeploymentKeyNotFound
 }
 
 type fakeProvisioner struct {
 }
 
-func (f *fakeProvisioner) ProvisionOrClaimVizier(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, clusterUID string, clusterName string, clusterVersion string) (uuid.UUID, error) {
-	if testOrgID == orgID && testUserID == userID && clusterUID == "cluster1" && clusterName == "test" && clusterVersion == "1.1" {
+func (f *fakeProvisioner) ProvisionOrClaimVizier(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, deployKeyID uuid.UUID, clusterUID string, clusterName string, clusterVersion string) (uuid.UUID, error) {
+	if testOrgID == orgID && testUserID == userID && testValidDeploymentKeyID == deployKeyID && clusterUID == "cluster1" && clusterName == "test" && clusterVersion == "1.1" {
 		return testValidClusterID, nil
 	}
 	if testOrgID == orgID && testUserID == userID && clusterUID == "cluster2" {
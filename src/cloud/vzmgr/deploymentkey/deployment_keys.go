@@ -79,6 +79,7 @@ func (s *Service) Create(ctx context.Context, req *vzmgrpb.CreateDeploymentKeyRe
 		ID:        utils.ProtoFromUUID(id),
 		Key:       key,
 		CreatedAt: tp,
+		CreatedBy: utils.ProtoFromUUIDStrOrNil(sCtx.Claims.GetUserClaims().UserID),
 	}, nil
 }
 
@@ -90,7 +91,7 @@ func (s *Service) List(ctx context.Context, req *vzmgrpb.ListDeploymentKeyReques
 	}
 
 	// Return all clusters when the OrgID matches.
-	query := `SELECT id, org_id, PGP_SYM_DECRYPT(key::bytea, $1), created_at, description from vizier_deployment_keys WHERE org_id=$2 ORDER BY created_at`
+	query := `SELECT id, org_id, PGP_SYM_DECRYPT(key::bytea, $1), created_at, description, user_id from vizier_deployment_keys WHERE org_id=$2 ORDER BY created_at`
 	rows, err := s.db.QueryxContext(ctx, query, s.dbKey, sCtx.Claims.GetUserClaims().OrgID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -108,7 +109,8 @@ func (s *Service) List(ctx context.Context, req *vzmgrpb.ListDeploymentKeyReques
 		var key string
 		var createdAt time.Time
 		var desc string
-		err = rows.Scan(&id, &orgID, &key, &createdAt, &desc)
+		var userID string
+		err = rows.Scan(&id, &orgID, &key, &createdAt, &desc, &userID)
 		if err != nil {
 			log.WithError(err).Error("Failed to read data from postgres")
 			return nil, status.Error(codes.Internal, "failed to read data")
@@ -119,6 +121,7 @@ func (s *Service) List(ctx context.Context, req *vzmgrpb.ListDeploymentKeyReques
 			Key:       key,
 			CreatedAt: tProto,
 			Desc:      desc,
+			CreatedBy: utils.ProtoFromUUIDStrOrNil(userID),
 		})
 	}
 	return &vzmgrpb.ListDeploymentKeyResponse{
@@ -140,8 +143,9 @@ func (s *Service) Get(ctx context.Context, req *vzmgrpb.GetDeploymentKeyRequest)
 	var key string
 	var createdAt time.Time
 	var desc string
-	query := `SELECT PGP_SYM_DECRYPT(key::bytea, $1), created_at, description from vizier_deployment_keys WHERE org_id=$2 and id=$3`
-	err = s.db.QueryRowxContext(ctx, query, s.dbKey, sCtx.Claims.GetUserClaims().OrgID, tokenID).Scan(&key, &createdAt, &desc)
+	var userID string
+	query := `SELECT PGP_SYM_DECRYPT(key::bytea, $1), created_at, description, user_id from vizier_deployment_keys WHERE org_id=$2 and id=$3`
+	err = s.db.QueryRowxContext(ctx, query, s.dbKey, sCtx.Claims.GetUserClaims().OrgID, tokenID).Scan(&key, &createdAt, &desc, &userID)
 	if err != nil {
 		return nil, status.Error(codes.NotFound, "No such deployment key")
 	}
@@ -152,6 +156,7 @@ func (s *Service) Get(ctx context.Context, req *vzmgrpb.GetDeploymentKeyRequest)
 		Key:       key,
 		CreatedAt: createdAtProto,
 		Desc:      desc,
+		CreatedBy: utils.ProtoFromUUIDStrOrNil(userID),
 	}}, nil
 }
 
@@ -187,17 +192,77 @@ func (s *Service) Delete(ctx context.Context, req *uuidpb.UUID) (*types.Empty, e
 	return &types.Empty{}, nil
 }
 
-// FetchOrgUserIDUsingDeploymentKey gets the org and user ID based on the deployment key.
-func (s *Service) FetchOrgUserIDUsingDeploymentKey(ctx context.Context, key string) (uuid.UUID, uuid.UUID, error) {
-	query := `SELECT org_id, user_id from vizier_deployment_keys WHERE PGP_SYM_DECRYPT(key::bytea, $2)=$1`
+// GetDeployedClusters returns the clusters that were registered using a specific key if it's owned by the org.
+func (s *Service) GetDeployedClusters(ctx context.Context, req *vzmgrpb.GetDeployedClustersRequest) (*vzmgrpb.GetDeployedClustersResponse, error) {
+	sCtx, err := authcontext.FromContext(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	keyID, err := utils.UUIDFromProto(req.KeyID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid id format")
+	}
+	orgID := sCtx.Claims.GetUserClaims().OrgID
+
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 from vizier_deployment_keys WHERE org_id=$1 and id=$2)`
+	err = s.db.QueryRowxContext(ctx, query, orgID, keyID).Scan(&exists)
+	if err != nil {
+		log.WithError(err).Error("Failed to fetch deployment key")
+		return nil, status.Error(codes.Internal, "failed to fetch deployment key")
+	}
+	if !exists {
+		return nil, status.Error(codes.NotFound, "No such deployment key")
+	}
+
+	query = `SELECT id, cluster_name, cluster_uid, created_at from vizier_cluster WHERE org_id=$1 and deployment_key_id=$2 ORDER BY created_at`
+	rows, err := s.db.QueryxContext(ctx, query, orgID, keyID)
+	if err != nil {
+		log.WithError(err).Error("Failed to fetch deployed clusters")
+		return nil, status.Error(codes.Internal, "failed to fetch deployed clusters")
+	}
+	defer rows.Close()
+
+	clusters := make([]*vzmgrpb.DeployedCluster, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		var clusterName *string
+		var clusterUID *string
+		var createdAt time.Time
+		err = rows.Scan(&id, &clusterName, &clusterUID, &createdAt)
+		if err != nil {
+			log.WithError(err).Error("Failed to read data from postgres")
+			return nil, status.Error(codes.Internal, "failed to read data")
+		}
+		cluster := &vzmgrpb.DeployedCluster{
+			ID: utils.ProtoFromUUID(id),
+		}
+		if clusterName != nil {
+			cluster.ClusterName = *clusterName
+		}
+		if clusterUID != nil {
+			cluster.ClusterUID = *clusterUID
+		}
+		cluster.CreatedAt, _ = types.TimestampProto(createdAt)
+		clusters = append(clusters, cluster)
+	}
+	return &vzmgrpb.GetDeployedClustersResponse{
+		Clusters: clusters,
+	}, nil
+}
+
+// FetchOrgUserIDUsingDeploymentKey gets the org and user ID, as well as the ID of the key itself, based on the deployment key.
+func (s *Service) FetchOrgUserIDUsingDeploymentKey(ctx context.Context, key string) (uuid.UUID, uuid.UUID, uuid.UUID, error) {
+	query := `SELECT org_id, user_id, id from vizier_deployment_keys WHERE PGP_SYM_DECRYPT(key::bytea, $2)=$1`
 	var orgID uuid.UUID
 	var userID uuid.UUID
-	err := s.db.QueryRowxContext(ctx, query, key, s.dbKey).Scan(&orgID, &userID)
+	var keyID uuid.UUID
+	err := s.db.QueryRowxContext(ctx, query, key, s.dbKey).Scan(&orgID, &userID, &keyID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return uuid.Nil, uuid.Nil, vzerrors.ErrDeploymentKeyNotFound
+			return uuid.Nil, uuid.Nil, uuid.Nil, vzerrors.ErrDeploymentKeyNotFound
 		}
-		return uuid.Nil, uuid.Nil, err
+		return uuid.Nil, uuid.Nil, uuid.Nil, err
 	}
-	return orgID, userID, nil
+	return orgID, userID, keyID, nil
 }
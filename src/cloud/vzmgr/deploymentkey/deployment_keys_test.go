@@ -118,6 +118,7 @@ func TestDeploymentKeyService_CreateDeploymentKey(t *testing.T) {
 	// Check if the key has a value and the ID looks valid.
 	assert.Greater(t, len(resp.Key), 0)
 	assert.NotEqual(t, uuid.Nil.String(), utils.UUIDFromProtoOrNil(resp.ID).String())
+	assert.Equal(t, testAuthUserID, utils.UUIDFromProtoOrNil(resp.CreatedBy))
 }
 
 func TestDeploymentKeyService_ListDeploymentKeys(t *testing.T) {
@@ -133,6 +134,8 @@ func TestDeploymentKeyService_ListDeploymentKeys(t *testing.T) {
 	assert.Equal(t, testKey2ID, utils.UUIDFromProtoOrNil(resp.Keys[1].ID))
 	assert.Equal(t, "here is a desc", resp.Keys[0].Desc)
 	assert.Equal(t, "here is another one", resp.Keys[1].Desc)
+	assert.Equal(t, testAuthUserID, utils.UUIDFromProtoOrNil(resp.Keys[0].CreatedBy))
+	assert.Equal(t, testAuthUserID, utils.UUIDFromProtoOrNil(resp.Keys[1].CreatedBy))
 	assert.Equal(t, "key1", resp.Keys[0].Key)
 	assert.Equal(t, "key2", resp.Keys[1].Key)
 
@@ -183,6 +186,7 @@ func TestDeploymentKeyService_Get(t *testing.T) {
 	}
 	assert.LessOrEqual(t, diff, int64(10000))
 	assert.Equal(t, "here is a desc", resp.Key.Desc)
+	assert.Equal(t, testAuthUserID, utils.UUIDFromProtoOrNil(resp.Key.CreatedBy))
 }
 
 func TestDeploymentKeyService_Get_UnownedID(t *testing.T) {
@@ -267,16 +271,68 @@ func TestDeploymentKeyService_Delete_NonExistentKey(t *testing.T) {
 	assert.Equal(t, codes.NotFound, status.Code(err))
 }
 
+func mustLoadDeployedClusters(db *sqlx.DB) {
+	db.MustExec(`DELETE FROM vizier_cluster_info`)
+	db.MustExec(`DELETE FROM vizier_cluster`)
+
+	insertCluster := `INSERT INTO vizier_cluster(id, org_id, cluster_name, cluster_uid, deployment_key_id, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	db.MustExec(insertCluster, "123e4567-e89b-12d3-a456-426655440000", testAuthOrgID, "cluster_1", "uid_1", testKey1ID, "2021-01-01 00:00:00")
+	db.MustExec(insertCluster, "123e4567-e89b-12d3-a456-426655440001", testAuthOrgID, "cluster_2", "uid_2", testKey1ID, "2021-01-02 00:00:00")
+	db.MustExec(insertCluster, "123e4567-e89b-12d3-a456-426655440002", testAuthOrgID, "cluster_3", "uid_3", testKey2ID, "2021-01-03 00:00:00")
+	db.MustExec(insertCluster, "123e4567-e89b-12d3-a456-426655440003", testAuthOrgID, "cluster_4", "uid_4", nil, "2021-01-04 00:00:00")
+	db.MustExec(insertCluster, "123e4567-e89b-12d3-a456-426655440004", testNonAuthOrgID, "cluster_5", "uid_5", testNonAuthUserKeyID, "2021-01-05 00:00:00")
+}
+
+func TestDeploymentKeyService_GetDeployedClusters(t *testing.T) {
+	mustLoadTestData(db)
+	mustLoadDeployedClusters(db)
+
+	ctx := createTestContext()
+	svc := New(db, testDBKey)
+
+	resp, err := svc.GetDeployedClusters(ctx, &vzmgrpb.GetDeployedClustersRequest{
+		KeyID: utils.ProtoFromUUID(testKey1ID),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(resp.Clusters))
+	assert.Equal(t, "123e4567-e89b-12d3-a456-426655440000", utils.UUIDFromProtoOrNil(resp.Clusters[0].ID).String())
+	assert.Equal(t, "cluster_1", resp.Clusters[0].ClusterName)
+	assert.Equal(t, "uid_1", resp.Clusters[0].ClusterUID)
+	assert.Equal(t, "123e4567-e89b-12d3-a456-426655440001", utils.UUIDFromProtoOrNil(resp.Clusters[1].ID).String())
+	assert.Equal(t, "cluster_2", resp.Clusters[1].ClusterName)
+	assert.Equal(t, "uid_2", resp.Clusters[1].ClusterUID)
+
+	ts, err := types.TimestampFromProto(resp.Clusters[0].CreatedAt)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), ts)
+}
+
+func TestDeploymentKeyService_GetDeployedClusters_UnownedKey(t *testing.T) {
+	mustLoadTestData(db)
+	mustLoadDeployedClusters(db)
+
+	ctx := createTestContext()
+	svc := New(db, testDBKey)
+
+	resp, err := svc.GetDeployedClusters(ctx, &vzmgrpb.GetDeployedClustersRequest{
+		KeyID: utils.ProtoFromUUID(testNonAuthUserKeyID),
+	})
+	assert.Nil(t, resp)
+	assert.NotNil(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
 func TestService_FetchOrgUserIDUsingDeploymentKey(t *testing.T) {
 	mustLoadTestData(db)
 
 	ctx := createTestContext()
 	svc := New(db, testDBKey)
 
-	orgID, userID, err := svc.FetchOrgUserIDUsingDeploymentKey(ctx, "key1")
+	orgID, userID, keyID, err := svc.FetchOrgUserIDUsingDeploymentKey(ctx, "key1")
 	require.NoError(t, err)
 	assert.Equal(t, testAuthOrgID, orgID)
 	assert.Equal(t, testAuthUserID, userID)
+	assert.Equal(t, testKey1ID, keyID)
 }
 
 func TestService_FetchOrgUserIDUsingDeploymentKey_BadKey(t *testing.T) {
@@ -285,9 +341,10 @@ func TestService_FetchOrgUserIDUsingDeploymentKey_BadKey(t *testing.T) {
 	ctx := createTestContext()
 	svc := New(db, testDBKey)
 
-	orgID, userID, err := svc.FetchOrgUserIDUsingDeploymentKey(ctx, "some rando key that does not exist")
+	orgID, userID, keyID, err := svc.FetchOrgUserIDUsingDeploymentKey(ctx, "some rando key that does not exist")
 	assert.NotNil(t, err)
 	assert.Equal(t, vzerrors.ErrDeploymentKeyNotFound, err)
 	assert.Equal(t, uuid.Nil, orgID)
 	assert.Equal(t, uuid.Nil, userID)
+	assert.Equal(t, uuid.Nil, keyID)
 }
@@ -0,0 +1,2 @@
+ALTER TABLE vizier_cluster_info
+ADD COLUMN plugin_statuses json NOT NULL DEFAULT '[]';
@@ -0,0 +1 @@
+ALTER TABLE vizier_cluster_info DROP COLUMN last_script_run_at;
@@ -0,0 +1,2 @@
+ALTER TABLE vizier_cluster_info
+ADD COLUMN last_script_run_at TIMESTAMP;
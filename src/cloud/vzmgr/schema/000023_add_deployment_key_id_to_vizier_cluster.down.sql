@@ -0,0 +1 @@
+ALTER TABLE vizier_cluster DROP COLUMN deployment_key_id;
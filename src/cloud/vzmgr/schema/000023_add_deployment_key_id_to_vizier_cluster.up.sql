@@ -0,0 +1,2 @@
+ALTER TABLE vizier_cluster
+ADD COLUMN deployment_key_id UUID;
@@ -0,0 +1,2 @@
+ALTER TABLE vizier_cluster_info
+ADD COLUMN update_channel varchar(50) NOT NULL DEFAULT 'stable';
@@ -0,0 +1 @@
+ALTER TABLE vizier_cluster_info DROP COLUMN previous_vizier_version;
@@ -0,0 +1,2 @@
+ALTER TABLE vizier_cluster_info
+ADD COLUMN previous_vizier_version varchar(1000);
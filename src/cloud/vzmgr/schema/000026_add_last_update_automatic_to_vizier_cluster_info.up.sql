@@ -0,0 +1,2 @@
+ALTER TABLE vizier_cluster_info
+ADD COLUMN last_update_automatic boolean NOT NULL DEFAULT false;
@@ -172,10 +172,17 @@ func listAPIKeys(cloudAddr string) ([]*cloudpb.APIKey, error) {
 		return nil, err
 	}
 
-	resp, err := apiKeyMgr.List(ctxWithCreds, &cloudpb.ListAPIKeyRequest{})
-	if err != nil {
-		return nil, err
+	var keys []*cloudpb.APIKey
+	pageToken := ""
+	for {
+		resp, err := apiKeyMgr.List(ctxWithCreds, &cloudpb.ListAPIKeyRequest{PageToken: pageToken})
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, resp.Keys...)
+		if resp.NextPageToken == "" {
+			return keys, nil
+		}
+		pageToken = resp.NextPageToken
 	}
-
-	return resp.Keys, nil
 }
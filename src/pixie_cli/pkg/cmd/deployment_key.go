@@ -172,10 +172,17 @@ func listDeployKeys(cloudAddr string) ([]*cloudpb.DeploymentKey, error) {
 		return nil, err
 	}
 
-	resp, err := deployMgrClient.List(ctxWithCreds, &cloudpb.ListDeploymentKeyRequest{})
-	if err != nil {
-		return nil, err
+	var keys []*cloudpb.DeploymentKey
+	pageToken := ""
+	for {
+		resp, err := deployMgrClient.List(ctxWithCreds, &cloudpb.ListDeploymentKeyRequest{PageToken: pageToken})
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, resp.Keys...)
+		if resp.NextPageToken == "" {
+			return keys, nil
+		}
+		pageToken = resp.NextPageToken
 	}
-
-	return resp.Keys, nil
 }
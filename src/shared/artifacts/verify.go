@@ -0,0 +1,101 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package artifacts
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+// Digest algorithm names, used in DigestMismatchError.
+const (
+	SHA256 = "sha256"
+	SHA512 = "sha512"
+)
+
+// Digests are the expected hex-encoded digests of an artifact. Empty digests aren't verified.
+type Digests struct {
+	SHA256 string
+	SHA512 string
+}
+
+// DigestMismatchError is returned when an artifact doesn't have the expected digest.
+type DigestMismatchError struct {
+	// Algorithm is the digest which didn't match, either SHA256 or SHA512.
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+// Error returns a description of the mismatched digest.
+func (e *DigestMismatchError) Error() string {
+	return fmt.Sprintf("artifact %s mismatch: expected %s, got %s", e.Algorithm, e.Expected, e.Actual)
+}
+
+type digestCheck struct {
+	algorithm string
+	expected  string
+	hash      hash.Hash
+}
+
+// VerifyArtifact reads the artifact from r and checks it against the expected digests, returning a
+// *DigestMismatchError for the first digest which doesn't match. The artifact is hashed as it's read,
+// so it isn't held in memory.
+func VerifyArtifact(r io.Reader, expected Digests) error {
+	var checks []*digestCheck
+	if expected.SHA256 != "" {
+		checks = append(checks, &digestCheck{algorithm: SHA256, expected: expected.SHA256, hash: sha256.New()})
+	}
+	if expected.SHA512 != "" {
+		checks = append(checks, &digestCheck{algorithm: SHA512, expected: expected.SHA512, hash: sha512.New()})
+	}
+	if len(checks) == 0 {
+		return errors.New("no digests to verify the artifact against")
+	}
+
+	writers := make([]io.Writer, len(checks))
+	for i, c := range checks {
+		if _, err := hex.DecodeString(c.expected); err != nil {
+			return fmt.Errorf("invalid expected %s digest: %w", c.algorithm, err)
+		}
+		writers[i] = c.hash
+	}
+
+	if _, err := io.Copy(io.MultiWriter(writers...), r); err != nil {
+		return err
+	}
+
+	for _, c := range checks {
+		actual := hex.EncodeToString(c.hash.Sum(nil))
+		if !strings.EqualFold(actual, c.expected) {
+			return &DigestMismatchError{
+				Algorithm: c.algorithm,
+				Expected:  c.expected,
+				Actual:    actual,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package artifacts_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"px.dev/pixie/src/shared/artifacts"
+)
+
+const (
+	testArtifact       = "pixie artifact"
+	testArtifactSHA256 = "3e0ddab0fc5b2f207c5e17d07308d245a5ff910e91a2382049c145c234885968"
+	testArtifactSHA512 = "2c2929b2429e5cf55259d83fa09b06154bd6222b8ed0c875d2166098eaa068dbc135e09b3ae248ecd2fd45be11f95af3104347ca1bb116699620f38e56ef2d8f"
+)
+
+func TestVerifyArtifact(t *testing.T) {
+	tests := []struct {
+		name              string
+		expected          artifacts.Digests
+		expectedMismatch  string
+		expectedOtherFail bool
+	}{
+		{
+			name:     "sha256 matches",
+			expected: artifacts.Digests{SHA256: testArtifactSHA256},
+		},
+		{
+			name:     "sha512 matches",
+			expected: artifacts.Digests{SHA512: testArtifactSHA512},
+		},
+		{
+			name:     "both match",
+			expected: artifacts.Digests{SHA256: testArtifactSHA256, SHA512: testArtifactSHA512},
+		},
+		{
+			name:     "uppercase digest matches",
+			expected: artifacts.Digests{SHA256: strings.ToUpper(testArtifactSHA256)},
+		},
+		{
+			name:             "sha256 mismatch",
+			expected:         artifacts.Digests{SHA256: strings.Repeat("0", 64), SHA512: testArtifactSHA512},
+			expectedMismatch: artifacts.SHA256,
+		},
+		{
+			name:             "sha512 mismatch",
+			expected:         artifacts.Digests{SHA256: testArtifactSHA256, SHA512: strings.Repeat("0", 128)},
+			expectedMismatch: artifacts.SHA512,
+		},
+		{
+			name:              "no digests",
+			expected:          artifacts.Digests{},
+			expectedOtherFail: true,
+		},
+		{
+			name:              "invalid digest",
+			expected:          artifacts.Digests{SHA256: "not hex"},
+			expectedOtherFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := artifacts.VerifyArtifact(strings.NewReader(testArtifact), test.expected)
+			if test.expectedMismatch == "" && !test.expectedOtherFail {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+
+			var mismatch *artifacts.DigestMismatchError
+			if test.expectedOtherFail {
+				assert.False(t, errors.As(err, &mismatch))
+				return
+			}
+			require.True(t, errors.As(err, &mismatch))
+			assert.Equal(t, test.expectedMismatch, mismatch.Algorithm)
+		})
+	}
+}
@@ -32,6 +32,7 @@ const (
 	ATUnknown                   ArtifactTypeDB = "UNKNOWN"
 	ATLinuxAMD64                ArtifactTypeDB = "LINUX_AMD64"
 	ATDarwinAMD64               ArtifactTypeDB = "DARWIN_AMD64"
+	ATDarwinARM64               ArtifactTypeDB = "DARWIN_ARM64"
 	ATContainerSetYAMLs         ArtifactTypeDB = "CONTAINER_SET_YAMLS"
 	ATContainerSetLinuxAMD64    ArtifactTypeDB = "CONTAINER_SET_LINUX_AMD64"
 	ATContainerSetTemplateYAMLs ArtifactTypeDB = "CONTAINER_SET_TEMPLATE_YAMLS"
@@ -62,6 +63,8 @@ func ToArtifactTypeDB(a versionspb.ArtifactType) ArtifactTypeDB {
 		return ATLinuxAMD64
 	case versionspb.AT_DARWIN_AMD64:
 		return ATDarwinAMD64
+	case versionspb.AT_DARWIN_ARM64:
+		return ATDarwinARM64
 	case versionspb.AT_CONTAINER_SET_YAMLS:
 		return ATContainerSetYAMLs
 	case versionspb.AT_CONTAINER_SET_LINUX_AMD64:
@@ -80,6 +83,8 @@ func ToProtoArtifactType(a ArtifactTypeDB) versionspb.ArtifactType {
 		return versionspb.AT_LINUX_AMD64
 	case ATDarwinAMD64:
 		return versionspb.AT_DARWIN_AMD64
+	case ATDarwinARM64:
+		return versionspb.AT_DARWIN_ARM64
 
 	case ATContainerSetYAMLs:
 		return versionspb.AT_CONTAINER_SET_YAMLS
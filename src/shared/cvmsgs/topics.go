@@ -0,0 +1,54 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+// Package cvmsgs contains the topics of the messages sent over the bridge between Vizier and the cloud.
+package cvmsgs
+
+// Topic is the topic of a message sent over the bridge between Vizier and the cloud. Messages on
+// other topics are forwarded between the Vizier and cloud NATS domains as is.
+type Topic string
+
+// Topics of the messages sent from Vizier to the cloud.
+const (
+	// RegisterTopic is the topic of the RegisterVizierRequest, which must be the first message on the bridge.
+	RegisterTopic Topic = "register"
+	// HeartbeatTopic is the topic of VizierHeartbeats.
+	HeartbeatTopic Topic = "heartbeat"
+	// HeartbeatBatchTopic is the topic of the VizierHeartbeatBatch buffered while Vizier was disconnected.
+	HeartbeatBatchTopic Topic = "heartbeatBatch"
+	// SSLTopic is the topic of VizierSSLCertRequests.
+	SSLTopic Topic = "ssl"
+	// VizierUpdateResponseTopic is the topic of the UpdateOrInstallVizierResponse sent when Vizier receives an update.
+	VizierUpdateResponseTopic Topic = "VizierUpdateResponse"
+)
+
+// Topics of the messages sent from the cloud to Vizier.
+const (
+	// RegisterAckTopic is the topic of the RegisterVizierAck sent in response to a RegisterTopic message.
+	RegisterAckTopic Topic = "registerAck"
+	// HeartbeatAckTopic is the topic of VizierHeartbeatAcks.
+	HeartbeatAckTopic Topic = "heartbeatAck"
+	// SSLRespTopic is the topic of the VizierSSLCertResponse sent in response to an SSLTopic message.
+	SSLRespTopic Topic = "sslResp"
+	// SSLVizierConfigRespTopic is the topic of the VizierConfig sent when Vizier's config changes.
+	SSLVizierConfigRespTopic Topic = "sslVizierConfigResp"
+	// VizierUpdateTopic is the topic of UpdateOrInstallVizierRequests.
+	VizierUpdateTopic Topic = "VizierUpdate"
+	// VizierPassthroughRequestTopic is the topic of C2VAPIStreamRequests.
+	VizierPassthroughRequestTopic Topic = "VizierPassthroughRequest"
+)
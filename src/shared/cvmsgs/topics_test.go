@@ -0,0 +1,53 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package cvmsgs_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"px.dev/pixie/src/shared/cvmsgs"
+)
+
+// The topics are sent over the wire, so they must not change or older Viziers can't talk to the cloud.
+func TestTopics(t *testing.T) {
+	tests := []struct {
+		topic    cvmsgs.Topic
+		expected string
+	}{
+		{cvmsgs.RegisterTopic, "register"},
+		{cvmsgs.HeartbeatTopic, "heartbeat"},
+		{cvmsgs.HeartbeatBatchTopic, "heartbeatBatch"},
+		{cvmsgs.SSLTopic, "ssl"},
+		{cvmsgs.VizierUpdateResponseTopic, "VizierUpdateResponse"},
+		{cvmsgs.RegisterAckTopic, "registerAck"},
+		{cvmsgs.HeartbeatAckTopic, "heartbeatAck"},
+		{cvmsgs.SSLRespTopic, "sslResp"},
+		{cvmsgs.SSLVizierConfigRespTopic, "sslVizierConfigResp"},
+		{cvmsgs.VizierUpdateTopic, "VizierUpdate"},
+		{cvmsgs.VizierPassthroughRequestTopic, "VizierPassthroughRequest"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			assert.Equal(t, test.expected, string(test.topic))
+		})
+	}
+}
@@ -43,6 +43,9 @@ const (
 	ClusterClaimType
 )
 
+// AdminScope is the scope of the claims of Pixie support accounts, which may act on behalf of any org.
+const AdminScope = "admin"
+
 // PBToMapClaims maps protobuf claims to map claims.
 func PBToMapClaims(pb *jwtpb.JWTClaims) jwt.MapClaims {
 	claims := jwt.MapClaims{}
@@ -187,6 +190,13 @@ func GenerateJWTForUser(userID string, orgID string, email string, expiresAt tim
 	return &claims
 }
 
+// GenerateJWTForSupportUser creates a protobuf claims for a Pixie support account acting in the given org.
+func GenerateJWTForSupportUser(userID string, orgID string, email string, expiresAt time.Time, audience string) *jwtpb.JWTClaims {
+	claims := GenerateJWTForUser(userID, orgID, email, expiresAt, audience)
+	claims.Scopes = append(claims.Scopes, AdminScope)
+	return claims
+}
+
 // GenerateJWTForService creates a protobuf claims for the given service.
 func GenerateJWTForService(serviceID string, audience string) *jwtpb.JWTClaims {
 	pbClaims := jwtpb.JWTClaims{
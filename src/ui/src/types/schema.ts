@@ -203,6 +203,7 @@ export interface GQLClusterConnectionInfo {
 export interface GQLUserInvite {
   email: string;
   inviteLink: string;
+  userCreated: boolean;
 }
 
 export interface GQLLiveViewMetadata {
@@ -233,6 +234,7 @@ export enum GQLArtifactType {
   AT_UNKNOWN = 'AT_UNKNOWN',
   AT_LINUX_AMD64 = 'AT_LINUX_AMD64',
   AT_DARWIN_AMD64 = 'AT_DARWIN_AMD64',
+  AT_DARWIN_ARM64 = 'AT_DARWIN_ARM64',
   AT_CONTAINER_SET_YAMLS = 'AT_CONTAINER_SET_YAMLS',
   AT_CONTAINER_SET_LINUX_AMD64 = 'AT_CONTAINER_SET_LINUX_AMD64',
   AT_CONTAINER_SET_TEMPLATE_YAMLS = 'AT_CONTAINER_SET_TEMPLATE_YAMLS'
@@ -902,6 +904,7 @@ export interface ClusterConnectionInfoToTokenResolver<TParent = any, TResult = a
 export interface GQLUserInviteTypeResolver<TParent = any> {
   email?: UserInviteToEmailResolver<TParent>;
   inviteLink?: UserInviteToInviteLinkResolver<TParent>;
+  userCreated?: UserInviteToUserCreatedResolver<TParent>;
 }
 
 export interface UserInviteToEmailResolver<TParent = any, TResult = any> {
@@ -912,6 +915,10 @@ export interface UserInviteToInviteLinkResolver<TParent = any, TResult = any> {
   (parent: TParent, args: {}, context: any, info: GraphQLResolveInfo): TResult;
 }
 
+export interface UserInviteToUserCreatedResolver<TParent = any, TResult = any> {
+  (parent: TParent, args: {}, context: any, info: GraphQLResolveInfo): TResult;
+}
+
 export interface GQLLiveViewMetadataTypeResolver<TParent = any> {
   id?: LiveViewMetadataToIdResolver<TParent>;
   name?: LiveViewMetadataToNameResolver<TParent>;
@@ -20,6 +20,7 @@ package main
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gofrs/uuid"
@@ -40,6 +41,24 @@ func init() {
 	pflag.String("namespace", "pl", "The namespace of Vizier")
 	pflag.String("cluster_id", "", "The Cluster ID to use for Pixie Cloud")
 	pflag.String("nats_url", "pl-nats", "The URL of NATS")
+	pflag.StringSlice("cert_targets", []string{}, "Additional named certs to request, each in the form <name>:<secret name>:<k8s service>")
+}
+
+// getCertTargets returns the cert for the Vizier proxy, along with any additional certs specified in the flags.
+func getCertTargets() []controller.CertTarget {
+	targets := []controller.CertTarget{controller.DefaultCertTarget}
+	for _, t := range viper.GetStringSlice("cert_targets") {
+		parts := strings.Split(t, ":")
+		if len(parts) != 3 || parts[0] == "" {
+			log.WithField("target", t).Fatal("Invalid cert target")
+		}
+		targets = append(targets, controller.CertTarget{
+			Service:    parts[0],
+			SecretName: parts[1],
+			K8sService: parts[2],
+		})
+	}
+	return targets
 }
 
 func main() {
@@ -101,7 +120,7 @@ func main() {
 	}
 
 	env := certmgrenv.New("vizier")
-	svr := controller.NewServer(env, clusterID, nc, k8sAPI)
+	svr := controller.NewServerWithCertTargets(env, clusterID, nc, k8sAPI, getCertTargets())
 	go svr.CertRequester()
 	defer svr.StopCertRequester()
 
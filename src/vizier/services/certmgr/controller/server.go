@@ -20,7 +20,7 @@ package controller
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"time"
 
 	"github.com/gofrs/uuid"
@@ -28,6 +28,7 @@ import (
 	"github.com/nats-io/nats.go"
 	log "github.com/sirupsen/logrus"
 
+	"px.dev/pixie/src/shared/cvmsgs"
 	"px.dev/pixie/src/shared/cvmsgspb"
 	"px.dev/pixie/src/utils"
 	"px.dev/pixie/src/vizier/services/certmgr/certmgrenv"
@@ -42,61 +43,98 @@ type K8sAPI interface {
 	DeletePod(name string) error
 }
 
+// CertTarget is a named cert that is requested from the cloud, and the place it is installed.
+type CertTarget struct {
+	// Service is the name the cert is requested under. The empty name is the cert for the Vizier proxy.
+	Service string
+	// SecretName is the name of the TLS secret that the cert is stored in.
+	SecretName string
+	// K8sService is the k8s service whose pods are bounced to pick up the new cert.
+	K8sService string
+}
+
+// DefaultCertTarget is the cert for the Vizier proxy, which is requested when no other targets are specified.
+var DefaultCertTarget = CertTarget{
+	SecretName: "proxy-tls-certs",
+	K8sService: "vizier-proxy-service",
+}
+
 // Server is an implementation of GRPC server for certmgr service.
 type Server struct {
 	env       certmgrenv.CertMgrEnv
 	clusterID uuid.UUID
 	k8sAPI    K8sAPI
 	nc        *nats.Conn
+	targets   map[string]CertTarget
 	done      chan bool
 }
 
-// NewServer creates a new GRPC certmgr server.
+// NewServer creates a new GRPC certmgr server, which requests the cert for the Vizier proxy.
 func NewServer(env certmgrenv.CertMgrEnv, clusterID uuid.UUID, nc *nats.Conn, k8sAPI K8sAPI) *Server {
+	return NewServerWithCertTargets(env, clusterID, nc, k8sAPI, []CertTarget{DefaultCertTarget})
+}
+
+// NewServerWithCertTargets creates a new GRPC certmgr server, which requests a cert for each of the given targets.
+func NewServerWithCertTargets(env certmgrenv.CertMgrEnv, clusterID uuid.UUID, nc *nats.Conn, k8sAPI K8sAPI, targets []CertTarget) *Server {
+	targetMap := make(map[string]CertTarget)
+	for _, t := range targets {
+		targetMap[t.Service] = t
+	}
 	return &Server{
 		env:       env,
 		clusterID: clusterID,
 		nc:        nc,
 		k8sAPI:    k8sAPI,
+		targets:   targetMap,
 		done:      make(chan bool),
 	}
 }
 
 // UpdateCerts updates the proxy certs with the given DNS address.
 func (s *Server) UpdateCerts(ctx context.Context, req *certmgrpb.UpdateCertsRequest) (*certmgrpb.UpdateCertsResponse, error) {
-	// Load secrets.
-	err := s.k8sAPI.CreateTLSSecret("proxy-tls-certs", req.Key, req.Cert)
+	err := s.updateCertsForTarget(DefaultCertTarget, req.Key, req.Cert)
 	if err != nil {
 		return nil, err
 	}
 
-	// Bounce proxy service.
-	pods, err := s.k8sAPI.GetPodNamesForService("vizier-proxy-service")
+	return &certmgrpb.UpdateCertsResponse{
+		OK: true,
+	}, nil
+}
+
+// updateCertsForTarget loads the cert into the target's secret, and bounces the target's service.
+func (s *Server) updateCertsForTarget(target CertTarget, key, cert string) error {
+	// Load secrets.
+	err := s.k8sAPI.CreateTLSSecret(target.SecretName, key, cert)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	// Bounce service.
+	pods, err := s.k8sAPI.GetPodNamesForService(target.K8sService)
+	if err != nil {
+		return err
 	}
 
 	if len(pods) == 0 {
-		return nil, errors.New("No pods exist for proxy service")
+		return fmt.Errorf("No pods exist for service %s", target.K8sService)
 	}
 
 	for _, pod := range pods {
 		err = s.k8sAPI.DeletePod(pod)
 
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
-
-	return &certmgrpb.UpdateCertsResponse{
-		OK: true,
-	}, nil
+	return nil
 }
 
-func (s *Server) sendSSLCertRequest() error {
+func (s *Server) sendSSLCertRequest(service string) error {
 	// Send over a request for SSL certs.
 	regReq := &cvmsgspb.VizierSSLCertRequest{
 		VizierID: utils.ProtoFromUUID(s.clusterID),
+		Service:  service,
 	}
 
 	regReqAny, err := types.MarshalAny(regReq)
@@ -113,14 +151,31 @@ func (s *Server) sendSSLCertRequest() error {
 		return err
 	}
 
-	return s.nc.Publish(messagebus.V2CTopic("ssl"), b)
+	return s.nc.Publish(messagebus.V2CTopic(string(cvmsgs.SSLTopic)), b)
+}
+
+// sendSSLCertRequests requests the certs for the targets that are still pending.
+func (s *Server) sendSSLCertRequests(pending map[string]bool) {
+	for service := range pending {
+		err := s.sendSSLCertRequest(service)
+		if err != nil {
+			log.WithError(err).WithField("service", service).Warn("Failed to send message to request SSL certs")
+		}
+	}
+}
+
+// markAllTargetsPending marks the certs for all of the targets as needing to be requested.
+func (s *Server) markAllTargetsPending(pending map[string]bool) {
+	for service := range s.targets {
+		pending[service] = true
+	}
 }
 
 // CertRequester is a routine to go loop through cert requests. It's should be run in a go routine.
 func (s *Server) CertRequester() {
 	log.Info("Requesting SSL certs")
-	sslCh := make(chan *nats.Msg)
-	sub, err := s.nc.ChanSubscribe(messagebus.C2VTopic("sslResp"), sslCh)
+	sslCh := make(chan *nats.Msg, 1024)
+	sub, err := s.nc.ChanSubscribe(messagebus.C2VTopic(string(cvmsgs.SSLRespTopic)), sslCh)
 	if err != nil {
 		log.WithError(err).Warn("Failed to subscribe to sslResp channel")
 	}
@@ -131,8 +186,8 @@ func (s *Server) CertRequester() {
 		}
 	}()
 
-	configCh := make(chan *nats.Msg)
-	sub, err = s.nc.ChanSubscribe(messagebus.C2VTopic("sslVizierConfigResp"), configCh)
+	configCh := make(chan *nats.Msg, 1024)
+	sub, err = s.nc.ChanSubscribe(messagebus.C2VTopic(string(cvmsgs.SSLVizierConfigRespTopic)), configCh)
 	if err != nil {
 		log.WithError(err).Warn("Failed to subscribe to sslVizierConfigResp channel")
 	}
@@ -143,10 +198,10 @@ func (s *Server) CertRequester() {
 		}
 	}()
 
-	err = s.sendSSLCertRequest()
-	if err != nil {
-		log.WithError(err).Warn("Failed to send message to request SSL certs")
-	}
+	// The targets that we are still waiting on certs for.
+	pending := make(map[string]bool)
+	s.markAllTargetsPending(pending)
+	s.sendSSLCertRequests(pending)
 
 	t := time.NewTicker(30 * time.Second)
 	defer t.Stop()
@@ -159,11 +214,13 @@ func (s *Server) CertRequester() {
 		case <-s.done:
 			return
 		case <-t.C:
-			log.Info("Timeout waiting for SSL certs. Re-requesting")
-			err = s.sendSSLCertRequest()
-			if err != nil {
-				log.WithError(err).Warn("Failed to send message to request SSL certs")
+			if len(pending) == 0 {
+				log.Info("Refreshing SSL certs")
+				s.markAllTargetsPending(pending)
+			} else {
+				log.Info("Timeout waiting for SSL certs. Re-requesting")
 			}
+			s.sendSSLCertRequests(pending)
 		case confMsg := <-configCh:
 			log.Info("Got Vizier Config message")
 			envelope := &cvmsgspb.C2VMessage{}
@@ -185,11 +242,9 @@ func (s *Server) CertRequester() {
 				// config channel.
 				t.Reset(1 * time.Hour)
 			} else {
+				// The config is sent in response to our cert requests, which the cloud
+				// will still answer, so just wait for the certs and retry on timeout.
 				t.Reset(30 * time.Second)
-				err = s.sendSSLCertRequest()
-				if err != nil {
-					log.WithError(err).Warn("Failed to send message to request SSL certs")
-				}
 			}
 		case sslMsg := <-sslCh:
 			log.Info("Got SSL message")
@@ -207,23 +262,22 @@ func (s *Server) CertRequester() {
 				break
 			}
 
-			certMgrReq := &certmgrpb.UpdateCertsRequest{
-				Key:  sslResp.Key,
-				Cert: sslResp.Cert,
+			target, ok := s.targets[sslResp.Service]
+			if !ok {
+				log.WithField("service", sslResp.Service).Warn("Got SSL response for unknown service")
+				break
 			}
 
-			ctx := context.Background()
-			certMgrResp, err := s.UpdateCerts(ctx, certMgrReq)
+			err = s.updateCertsForTarget(target, sslResp.Key, sslResp.Cert)
 			if err != nil {
-				log.WithError(err).Fatal("Failed to update certs")
+				log.WithError(err).WithField("service", sslResp.Service).Fatal("Failed to update certs")
 			}
+			log.WithField("service", sslResp.Service).Info("Certs Updated")
 
-			if !certMgrResp.OK {
-				log.Fatal("Failed to update certs")
+			delete(pending, sslResp.Service)
+			if len(pending) == 0 {
+				t.Reset(5 * time.Minute)
 			}
-			log.WithField("reply", certMgrResp.String()).Info("Certs Updated")
-
-			t.Reset(5 * time.Minute)
 		}
 	}
 }
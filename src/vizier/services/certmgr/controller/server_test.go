@@ -22,15 +22,23 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/gofrs/uuid"
+	"github.com/gogo/protobuf/types"
 	"github.com/golang/mock/gomock"
+	"github.com/nats-io/nats.go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"px.dev/pixie/src/shared/cvmsgs"
+	"px.dev/pixie/src/shared/cvmsgspb"
+	"px.dev/pixie/src/utils"
+	"px.dev/pixie/src/utils/testingutils"
 	"px.dev/pixie/src/vizier/services/certmgr/certmgrpb"
 	"px.dev/pixie/src/vizier/services/certmgr/controller"
 	mock_controller "px.dev/pixie/src/vizier/services/certmgr/controller/mock"
+	"px.dev/pixie/src/vizier/utils/messagebus"
 )
 
 func TestServer_UpdateCerts(t *testing.T) {
@@ -141,3 +149,85 @@ func TestServer_UpdateCerts_FailedPodDeletion(t *testing.T) {
 	assert.Nil(t, resp)
 	assert.NotNil(t, err)
 }
+
+func TestServer_CertRequester_NamedCerts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockK8s := mock_controller.NewMockK8sAPI(ctrl)
+
+	nc, cleanup := testingutils.MustStartTestNATS(t)
+	defer cleanup()
+
+	reqCh := make(chan *nats.Msg, 2)
+	sub, err := nc.ChanSubscribe(messagebus.V2CTopic(string(cvmsgs.SSLTopic)), reqCh)
+	require.NoError(t, err)
+	defer func() {
+		err = sub.Unsubscribe()
+		require.NoError(t, err)
+	}()
+
+	targets := []controller.CertTarget{
+		{Service: "kelvin", SecretName: "kelvin-tls-certs", K8sService: "vizier-kelvin-svc"},
+		{Service: "query-broker", SecretName: "query-broker-tls-certs", K8sService: "vizier-query-broker-svc"},
+	}
+	clusterID := uuid.Must(uuid.NewV4())
+	s := controller.NewServerWithCertTargets(nil, clusterID, nc, mockK8s, targets)
+
+	updated := make(chan string, 2)
+	for _, target := range targets {
+		svc := target.Service
+		mockK8s.EXPECT().
+			CreateTLSSecret(target.SecretName, svc+"-key", svc+"-cert").
+			Return(nil)
+		mockK8s.EXPECT().
+			GetPodNamesForService(target.K8sService).
+			Return([]string{svc + "-pod"}, nil)
+		mockK8s.EXPECT().
+			DeletePod(svc + "-pod").
+			DoAndReturn(func(name string) error {
+				updated <- svc
+				return nil
+			})
+	}
+
+	go s.CertRequester()
+	defer s.StopCertRequester()
+
+	// Reply to each cert request with a cert for the requested service.
+	requested := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		select {
+		case m := <-reqCh:
+			v2cMsg := &cvmsgspb.V2CMessage{}
+			require.NoError(t, v2cMsg.Unmarshal(m.Data))
+			req := &cvmsgspb.VizierSSLCertRequest{}
+			require.NoError(t, types.UnmarshalAny(v2cMsg.Msg, req))
+			assert.Equal(t, clusterID, utils.UUIDFromProtoOrNil(req.VizierID))
+			requested[req.Service] = true
+
+			respAny, err := types.MarshalAny(&cvmsgspb.VizierSSLCertResponse{
+				Key:     req.Service + "-key",
+				Cert:    req.Service + "-cert",
+				Service: req.Service,
+			})
+			require.NoError(t, err)
+			b, err := (&cvmsgspb.C2VMessage{Msg: respAny}).Marshal()
+			require.NoError(t, err)
+			require.NoError(t, nc.Publish(messagebus.C2VTopic(string(cvmsgs.SSLRespTopic)), b))
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for cert request")
+		}
+	}
+	assert.Equal(t, map[string]bool{"kelvin": true, "query-broker": true}, requested)
+
+	got := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		select {
+		case svc := <-updated:
+			got[svc] = true
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for certs to be updated")
+		}
+	}
+	assert.Equal(t, map[string]bool{"kelvin": true, "query-broker": true}, got)
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package bridge
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"px.dev/pixie/src/shared/cvmsgs"
+	"px.dev/pixie/src/shared/cvmsgspb"
+)
+
+// heartbeatBuffer is a ring buffer holding the most recent heartbeats, which overwrites the oldest
+// heartbeat once it's full.
+type heartbeatBuffer struct {
+	mu         sync.Mutex
+	heartbeats []*cvmsgspb.VizierHeartbeat
+	// The index of the oldest heartbeat in the buffer.
+	start int
+	size  int
+}
+
+func newHeartbeatBuffer(capacity int) *heartbeatBuffer {
+	return &heartbeatBuffer{
+		heartbeats: make([]*cvmsgspb.VizierHeartbeat, capacity),
+	}
+}
+
+func (b *heartbeatBuffer) add(hb *cvmsgspb.VizierHeartbeat) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	capacity := len(b.heartbeats)
+	if b.size < capacity {
+		b.heartbeats[(b.start+b.size)%capacity] = hb
+		b.size++
+		return
+	}
+	b.heartbeats[b.start] = hb
+	b.start = (b.start + 1) % capacity
+}
+
+// drain empties the buffer, returning its heartbeats oldest first.
+func (b *heartbeatBuffer) drain() []*cvmsgspb.VizierHeartbeat {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hbs := make([]*cvmsgspb.VizierHeartbeat, b.size)
+	for i := range hbs {
+		idx := (b.start + i) % len(b.heartbeats)
+		hbs[i] = b.heartbeats[idx]
+		b.heartbeats[idx] = nil
+	}
+	b.start = 0
+	b.size = 0
+	return hbs
+}
+
+func (s *Bridge) isConnected() bool {
+	s.callbackMu.Lock()
+	defer s.callbackMu.Unlock()
+	return s.connected
+}
+
+// isDisconnected returns true if the bridge has lost its connection to the cloud. The bridge is not
+// considered disconnected before it first connects.
+func (s *Bridge) isDisconnected() bool {
+	s.callbackMu.Lock()
+	defer s.callbackMu.Unlock()
+	return s.wasConnected && !s.connected
+}
+
+// bufferHeartbeats records a heartbeat every heartbeat interval after the bridge loses its connection to
+// the cloud. The buffered heartbeats are sent in a batch once the bridge reconnects.
+func (s *Bridge) bufferHeartbeats() {
+	defer s.wdWg.Done()
+	t := time.NewTicker(s.hbInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-s.quitCh:
+			log.Trace("Quitting heartbeat buffering")
+			return
+		case <-t.C:
+			if !s.isDisconnected() {
+				continue
+			}
+			hb := s.newHeartbeat()
+			// Each buffered heartbeat takes its own sequence number, so that cloud can tell them apart.
+			hb.SequenceNumber = atomic.AddInt64(&s.hbSeqNum, 1) - 1
+			s.hbBuffer.add(hb)
+		}
+	}
+}
+
+// flushHeartbeatBuffer sends the heartbeats recorded while the bridge was disconnected, if any.
+func (s *Bridge) flushHeartbeatBuffer() error {
+	if s.hbBuffer == nil {
+		return nil
+	}
+	hbs := s.hbBuffer.drain()
+	if len(hbs) == 0 {
+		return nil
+	}
+	log.WithField("count", len(hbs)).Info("Sending heartbeats buffered while disconnected")
+	return s.publishProtoToBridgeCh(cvmsgs.HeartbeatBatchTopic, &cvmsgspb.VizierHeartbeatBatch{
+		Heartbeats: hbs,
+	})
+}
@@ -20,10 +20,12 @@ package bridge
 
 import (
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"math"
+	"math/rand"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -44,6 +46,7 @@ import (
 
 	"px.dev/pixie/src/api/proto/vizierpb"
 	"px.dev/pixie/src/cloud/vzconn/vzconnpb"
+	"px.dev/pixie/src/shared/cvmsgs"
 	"px.dev/pixie/src/shared/cvmsgspb"
 	"px.dev/pixie/src/utils"
 	"px.dev/pixie/src/vizier/utils/messagebus"
@@ -122,24 +125,39 @@ spec:
   completions: 1`
 
 const (
-	heartbeatIntervalS = 5 * time.Second
-	// HeartbeatTopic is the topic that heartbeats are written to.
-	HeartbeatTopic                = "heartbeat"
+	heartbeatIntervalS            = 5 * time.Second
+	heartbeatAckTimeout           = 30 * time.Second
 	registrationTimeout           = 30 * time.Second
+	registrationAttempts          = 1
 	passthroughReplySubjectPrefix = "v2c.reply-"
 	vizStatusCheckFailInterval    = 10 * time.Second
+	// The maximum number of heartbeats buffered while disconnected.
+	maxHeartbeatBufferSize = 1000
+	// The maximum delay before the first heartbeat on a stream. This keeps the first heartbeat well
+	// within the watchdog's check interval.
+	maxHeartbeatInitialJitter = 20 * time.Second
 )
 
 // ErrRegistrationTimeout is the registration timeout error.
 var ErrRegistrationTimeout = errors.New("Registration timeout")
 
+// ErrRegistrationNotFound is returned when the cloud does not know about the cluster being registered.
+var ErrRegistrationNotFound = errors.New("registration not found, cluster unknown in pixie-cloud")
+
+// ErrHeartbeatAckTimeout is returned when too many consecutive heartbeats go unacknowledged.
+var ErrHeartbeatAckTimeout = errors.New("Heartbeat ack timeout")
+
 const upgradeJobName = "vizier-upgrade-job"
 
+// jwtSigningKeyBytes is the number of random bytes in a Vizier JWT signing key. The key is stored hex encoded.
+const jwtSigningKeyBytes = 64
+
 // VizierInfo fetches information about Vizier.
 type VizierInfo interface {
 	GetAddress() (string, int32, error)
 	GetVizierClusterInfo() (*cvmsgspb.VizierClusterInfo, error)
 	GetK8sState() (map[string]*cvmsgspb.PodStatus, int32, int32, time.Time)
+	GetPluginStatuses() []*cvmsgspb.PluginStatus
 	ParseJobYAML(yamlStr string, imageTag map[string]string, envSubtitutions map[string]string) (*batchv1.Job, error)
 	LaunchJob(j *batchv1.Job) (*batchv1.Job, error)
 	CreateSecret(string, map[string]string) error
@@ -151,6 +169,7 @@ type VizierInfo interface {
 	UpdateClusterID(string) error
 	GetVizierPodLogs(string, bool, string) (string, error)
 	GetVizierPods() ([]*vizierpb.VizierPodStatus, []*vizierpb.VizierPodStatus, error)
+	GetStatus() (cvmsgspb.VizierStatus, error)
 }
 
 // VizierUpdater updates and fetches info about the Vizier CRD.
@@ -176,6 +195,30 @@ type Bridge struct {
 	vizChecker   VizierHealthChecker
 
 	hbSeqNum int64
+	// The number of heartbeats sent on the stream. Unlike hbSeqNum, this doesn't include the heartbeats
+	// buffered while disconnected, so the watchdog can tell when the stream is dead.
+	numHbSent int64
+	// The time of the last heartbeat ack, in unix nanoseconds. 0 if no heartbeat has been acked.
+	lastHbAckNs int64
+	// The time at which a script last finished running successfully, in unix nanoseconds. 0 if no script
+	// has finished since the bridge started.
+	lastScriptRunNs int64
+	// The interval at which heartbeats are sent.
+	hbInterval time.Duration
+	// The time to wait for an ack after sending a heartbeat.
+	hbAckTimeout time.Duration
+	// The first heartbeat on a stream is delayed by a random duration up to hbInitialJitter, so that
+	// viziers reconnecting at the same time don't all send their first heartbeat at once.
+	hbInitialJitter time.Duration
+	jitterRand      *rand.Rand
+	// The number of consecutive ack timeouts tolerated before restarting the stream. 0 disables the check.
+	maxHbAckTimeouts int
+	// Heartbeats recorded while disconnected, which are sent once the bridge reconnects. Nil if disabled.
+	hbBuffer *heartbeatBuffer
+	// The number of times a registration request is sent on a stream before restarting the stream.
+	regAttempts int
+	// The time to wait for a registration ack after sending a registration request.
+	regTimeout time.Duration
 
 	nc         *nats.Conn
 	natsCh     chan *nats.Msg
@@ -205,21 +248,83 @@ type Bridge struct {
 	updateFailed  bool         // True if an update has failed (sticky).
 
 	droppedMessagesBeforeResume int64 // Number of messages dropped before successful resume.
+
+	callbackMu     sync.Mutex // Guards the connection state and callbacks below.
+	connected      bool       // True if the stream to the cloud is registered.
+	wasConnected   bool       // True if the stream to the cloud has been registered at least once.
+	fatalErr       error      // The error which permanently stopped the stream, if any.
+	onConnected    []func()
+	onDisconnected []func()
+}
+
+// validateJWTSigningKey checks that the key has the format of the keys generated for Vizier.
+func validateJWTSigningKey(key string) error {
+	if key == "" {
+		return errors.New("JWT signing key is empty")
+	}
+	if len(key) != hex.EncodedLen(jwtSigningKeyBytes) {
+		return fmt.Errorf("JWT signing key has length %d, expected %d", len(key), hex.EncodedLen(jwtSigningKeyBytes))
+	}
+	if _, err := hex.DecodeString(key); err != nil {
+		return errors.New("JWT signing key is not hex encoded")
+	}
+	return nil
 }
 
-// New creates a cloud connector to cloud bridge.
-func New(vizierID uuid.UUID, jwtSigningKey string, deployKey string, sessionID int64, vzClient vzconnpb.VZConnServiceClient, vzInfo VizierInfo, vzUpdater VizierUpdater, nc *nats.Conn, checker VizierHealthChecker) *Bridge {
+// New creates a cloud connector to cloud bridge. It returns an error if the JWT signing key is malformed.
+func New(vizierID uuid.UUID, jwtSigningKey string, deployKey string, sessionID int64, vzClient vzconnpb.VZConnServiceClient, vzInfo VizierInfo, vzUpdater VizierUpdater, nc *nats.Conn, checker VizierHealthChecker) (*Bridge, error) {
+	if err := validateJWTSigningKey(jwtSigningKey); err != nil {
+		return nil, err
+	}
+
+	hbInterval := viper.GetDuration("heartbeat_interval")
+	if hbInterval <= 0 {
+		hbInterval = heartbeatIntervalS
+	}
+	hbAckTimeout := viper.GetDuration("heartbeat_ack_timeout")
+	if hbAckTimeout <= 0 {
+		hbAckTimeout = heartbeatAckTimeout
+	}
+	regAttempts := viper.GetInt("registration_attempts")
+	if regAttempts <= 0 {
+		regAttempts = registrationAttempts
+	}
+	regTimeout := viper.GetDuration("registration_timeout")
+	if regTimeout <= 0 {
+		regTimeout = registrationTimeout
+	}
+	hbInitialJitter := viper.GetDuration("heartbeat_initial_jitter")
+	if hbInitialJitter > maxHeartbeatInitialJitter {
+		hbInitialJitter = maxHeartbeatInitialJitter
+	}
+	var hbBuffer *heartbeatBuffer
+	hbBufferSize := viper.GetInt("heartbeat_buffer_size")
+	if hbBufferSize > maxHeartbeatBufferSize {
+		hbBufferSize = maxHeartbeatBufferSize
+	}
+	if hbBufferSize > 0 {
+		hbBuffer = newHeartbeatBuffer(hbBufferSize)
+	}
+
 	return &Bridge{
-		vizierID:      vizierID,
-		jwtSigningKey: jwtSigningKey,
-		deployKey:     deployKey,
-		sessionID:     sessionID,
-		vzConnClient:  vzClient,
-		vizChecker:    checker,
-		vzInfo:        vzInfo,
-		vzUpdater:     vzUpdater,
-		hbSeqNum:      0,
-		nc:            nc,
+		vizierID:         vizierID,
+		jwtSigningKey:    jwtSigningKey,
+		deployKey:        deployKey,
+		sessionID:        sessionID,
+		vzConnClient:     vzClient,
+		vizChecker:       checker,
+		vzInfo:           vzInfo,
+		vzUpdater:        vzUpdater,
+		hbSeqNum:         0,
+		hbInterval:       hbInterval,
+		hbAckTimeout:     hbAckTimeout,
+		hbInitialJitter:  hbInitialJitter,
+		jitterRand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		maxHbAckTimeouts: viper.GetInt("max_heartbeat_ack_timeouts"),
+		hbBuffer:         hbBuffer,
+		regAttempts:      regAttempts,
+		regTimeout:       regTimeout,
+		nc:               nc,
 		// Buffer NATS channels to make sure we don't back-pressure NATS
 		natsCh:            make(chan *nats.Msg, 5000),
 		registered:        false,
@@ -230,7 +335,82 @@ func New(vizierID uuid.UUID, jwtSigningKey string, deployKey string, sessionID i
 		quitCh:            make(chan bool),
 		wg:                sync.WaitGroup{},
 		wdWg:              sync.WaitGroup{},
+	}, nil
+}
+
+// OnConnected registers a callback that is called whenever the bridge connects to the cloud. Callbacks are
+// run on their own goroutine, so they don't block the stream.
+func (s *Bridge) OnConnected(cb func()) {
+	s.callbackMu.Lock()
+	defer s.callbackMu.Unlock()
+	s.onConnected = append(s.onConnected, cb)
+}
+
+// OnDisconnected registers a callback that is called whenever the bridge's connection to the cloud ends.
+// Callbacks are run on their own goroutine, so they don't block the stream.
+func (s *Bridge) OnDisconnected(cb func()) {
+	s.callbackMu.Lock()
+	defer s.callbackMu.Unlock()
+	s.onDisconnected = append(s.onDisconnected, cb)
+}
+
+// setConnected updates the connection state, invoking the registered callbacks if it changed.
+func (s *Bridge) setConnected(connected bool) {
+	s.callbackMu.Lock()
+	if s.connected == connected {
+		s.callbackMu.Unlock()
+		return
+	}
+	s.connected = connected
+	cbs := s.onDisconnected
+	if connected {
+		s.wasConnected = true
+		cbs = s.onConnected
 	}
+	s.callbackMu.Unlock()
+
+	for _, cb := range cbs {
+		go cb()
+	}
+}
+
+// setFatalError records an error that the bridge can't recover from by restarting the stream.
+func (s *Bridge) setFatalError(err error) {
+	s.callbackMu.Lock()
+	defer s.callbackMu.Unlock()
+	s.fatalErr = err
+}
+
+func (s *Bridge) fatalError() error {
+	s.callbackMu.Lock()
+	defer s.callbackMu.Unlock()
+	return s.fatalErr
+}
+
+// Status is a snapshot of the bridge's connection to the cloud, for debugging.
+type Status struct {
+	// The number of heartbeats sent, including those buffered while disconnected.
+	HeartbeatSeqNum int64
+	// The time the last heartbeat ack was received. Zero if no heartbeat has been acked.
+	LastHeartbeatAck time.Time
+	// Whether the stream to the cloud is registered.
+	Connected bool
+	// The error which permanently stopped the stream to the cloud, such as ErrRegistrationNotFound.
+	// Nil while the bridge is still connecting or connected.
+	FatalError error
+}
+
+// GetStatus returns the current status of the bridge. It's safe to call concurrently with the stream.
+func (s *Bridge) GetStatus() *Status {
+	status := &Status{
+		HeartbeatSeqNum: atomic.LoadInt64(&s.hbSeqNum),
+		Connected:       s.isConnected(),
+		FatalError:      s.fatalError(),
+	}
+	if ackNs := atomic.LoadInt64(&s.lastHbAckNs); ackNs != 0 {
+		status.LastHeartbeatAck = time.Unix(0, ackNs)
+	}
+	return status
 }
 
 // WatchDog watches and make sure the bridge is functioning. If not commits suicide to try to self-heal.
@@ -239,14 +419,18 @@ func (s *Bridge) WatchDog() {
 	t := time.NewTicker(30 * time.Second)
 
 	for {
-		lastHbSeq := atomic.LoadInt64(&s.hbSeqNum)
+		lastNumHbSent := atomic.LoadInt64(&s.numHbSent)
 		select {
 		case <-s.quitCh:
 			log.Trace("Quitting watchdog")
 			return
 		case <-t.C:
-			currentHbSeqNum := atomic.LoadInt64(&s.hbSeqNum)
-			if currentHbSeqNum == lastHbSeq {
+			// The stream was stopped on purpose, so restarting won't help.
+			if s.fatalError() != nil {
+				log.Trace("Quitting watchdog, the stream was stopped permanently")
+				return
+			}
+			if atomic.LoadInt64(&s.numHbSent) == lastNumHbSent {
 				log.Fatal("Heartbeat messages failed, assuming stream is dead. Killing self to restart...")
 			}
 		}
@@ -271,8 +455,9 @@ func (s *Bridge) WaitForUpdater() {
 }
 
 // RegisterDeployment registers the vizier cluster using the deployment key.
-func (s *Bridge) RegisterDeployment() error {
-	ctx := context.Background()
+func (s *Bridge) RegisterDeployment(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, registrationTimeout)
+	defer cancel()
 	ctx = metadata.AppendToOutgoingContext(ctx, "X-API-KEY", s.deployKey)
 	clusterInfo, err := s.vzInfo.GetVizierClusterInfo()
 	if err != nil {
@@ -293,8 +478,10 @@ func (s *Bridge) RegisterDeployment() error {
 	return s.vzInfo.UpdateClusterID(s.vizierID.String())
 }
 
-// RunStream manages starting and restarting the stream to VZConn.
-func (s *Bridge) RunStream() {
+// RunStream manages starting and restarting the stream to VZConn. It returns once Stop is called,
+// the given context is cancelled, or the cloud permanently rejects the registration, in which case
+// the error is reported by GetStatus.
+func (s *Bridge) RunStream(ctx context.Context) {
 	s.updateRunning.Store(false)
 
 	if s.vzConnClient == nil {
@@ -366,6 +553,17 @@ func (s *Bridge) RunStream() {
 			err := natsSub.Unsubscribe()
 			log.WithError(err).Error("Failed to unsubscribe from NATS")
 		}()
+
+		scriptRunSub, err := s.nc.Subscribe(messagebus.ScriptRunTopic, s.handleScriptRun)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to subscribe to script runs.")
+		}
+		defer func() {
+			err := scriptRunSub.Unsubscribe()
+			if err != nil {
+				log.WithError(err).Error("Failed to unsubscribe from script runs")
+			}
+		}()
 	}
 
 	// Check if there is an existing update job. If so, then set the status to "UPDATING".
@@ -380,7 +578,7 @@ func (s *Bridge) RunStream() {
 
 	// Get the cluster ID, if not already specified.
 	if s.vizierID == uuid.Nil {
-		err = s.RegisterDeployment()
+		err = s.RegisterDeployment(ctx)
 		if err != nil {
 			log.WithError(err).Fatal("Failed to register vizier deployment")
 		}
@@ -388,22 +586,36 @@ func (s *Bridge) RunStream() {
 
 	s.wdWg.Add(1)
 	go s.WatchDog()
+	if s.hbBuffer != nil {
+		s.wdWg.Add(1)
+		go s.bufferHeartbeats()
+	}
 
 	for {
 		s.registered = false
 		select {
 		case <-s.quitCh:
 			return
+		case <-ctx.Done():
+			return
 		default:
 			log.Trace("Starting stream")
 			errCh := make(chan error)
-			err := s.StartStream(errCh)
+			err := s.StartStream(ctx, errCh)
+			close(errCh)
+			if errors.Is(err, ErrRegistrationNotFound) {
+				// Retrying won't help until the cluster is registered in the cloud again.
+				s.setFatalError(err)
+				log.WithField("vizierID", s.vizierID.String()).
+					Error("This cluster is not registered in Pixie Cloud, so the connection to the cloud has been stopped. " +
+						"Redeploy Vizier to register the cluster again.")
+				return
+			}
 			if err == nil {
 				log.Trace("Stream ending")
 			} else {
 				log.WithError(err).Error("Stream errored. Restarting stream")
 			}
-			close(errCh)
 		}
 	}
 }
@@ -471,7 +683,7 @@ func (s *Bridge) handleUpdateMessage(msg *types.Any) error {
 	if err != nil {
 		return err
 	}
-	err = s.nc.Publish(messagebus.V2CTopic("VizierUpdateResponse"), b)
+	err = s.nc.Publish(messagebus.V2CTopic(string(cvmsgs.VizierUpdateResponseTopic)), b)
 	if err != nil {
 		log.WithError(err).Error("Failed to publish VizierUpdateResponse")
 		return err
@@ -598,7 +810,7 @@ func (s *Bridge) handleDebugPodsRequest(reqID string, req *vizierpb.DebugPodsReq
 	return s.sendDebugStreamResponse(reqID, resps)
 }
 
-func (s *Bridge) doRegistrationHandshake(stream vzconnpb.VZConnService_NATSBridgeClient) error {
+func (s *Bridge) doRegistrationHandshake(ctx context.Context, stream vzconnpb.VZConnService_NATSBridgeClient) error {
 	addr, _, err := s.vzInfo.GetAddress()
 	if err != nil {
 		log.WithError(err).Error("Unable to get vizier proxy address")
@@ -616,42 +828,63 @@ func (s *Bridge) doRegistrationHandshake(stream vzconnpb.VZConnService_NATSBridg
 		ClusterInfo: clusterInfo,
 	}
 
-	err = s.publishBridgeSync(stream, "register", regReq)
-	if err != nil {
-		return err
+	for attempt := 1; ; attempt++ {
+		err = s.publishBridgeSync(stream, cvmsgs.RegisterTopic, regReq)
+		if err != nil {
+			return err
+		}
+
+		// Only timeouts are retried, other failures such as the cluster not being found are permanent.
+		err = s.waitForRegistrationAck(ctx)
+		if err != ErrRegistrationTimeout {
+			return err
+		}
+		if attempt >= s.regAttempts {
+			log.Info("Timeout with registration terminating stream")
+			return err
+		}
+		log.WithField("attempt", attempt).Info("Timeout with registration, retrying")
 	}
+}
+
+func (s *Bridge) waitForRegistrationAck(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.regTimeout)
+	defer cancel()
 
 	for {
 		select {
-		case <-time.After(registrationTimeout):
-			log.Info("Timeout with registration terminating stream")
+		case <-ctx.Done():
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return ctx.Err()
+			}
 			return ErrRegistrationTimeout
 		case resp := <-s.grpcInCh:
 			// Try to receive the registerAck.
-			if resp.Topic != "registerAck" {
+			if cvmsgs.Topic(resp.Topic) != cvmsgs.RegisterAckTopic {
 				log.Error("Unexpected message type while waiting for ACK")
 			}
 			registerAck := &cvmsgspb.RegisterVizierAck{}
-			err = types.UnmarshalAny(resp.Msg, registerAck)
+			err := types.UnmarshalAny(resp.Msg, registerAck)
 			if err != nil {
 				return err
 			}
 			switch registerAck.Status {
 			case cvmsgspb.ST_FAILED_NOT_FOUND:
-				return errors.New("registration not found, cluster unknown in pixie-cloud")
+				return ErrRegistrationNotFound
 			case cvmsgspb.ST_OK:
 				s.registered = true
 				return nil
 			default:
-				return errors.New("registration unsuccessful: " + err.Error())
+				return fmt.Errorf("registration unsuccessful: %s", registerAck.Status)
 			}
 		}
 	}
 }
 
-// StartStream starts the stream between the cloud connector and Vizier connector.
-func (s *Bridge) StartStream(errCh chan error) error {
-	ctx, cancel := context.WithCancel(context.Background())
+// StartStream starts the stream between the cloud connector and Vizier connector. Cancelling the given
+// context terminates the stream.
+func (s *Bridge) StartStream(ctx context.Context, errCh chan error) error {
+	ctx, cancel := context.WithCancel(ctx)
 	stream, err := s.vzConnClient.NATSBridge(ctx)
 	if err != nil {
 		log.WithError(err).Error("Error starting stream")
@@ -662,6 +895,8 @@ func (s *Bridge) StartStream(errCh chan error) error {
 	defer func() {
 		s.wg.Wait()
 	}()
+	// The connection ends when the stream does.
+	defer s.setConnected(false)
 
 	// Setup the stream reader go routine.
 	done := make(chan bool)
@@ -678,19 +913,26 @@ func (s *Bridge) StartStream(errCh chan error) error {
 
 	if !s.registered {
 		// Need to do registration handshake before we allow any cvmsgs.
-		err := s.doRegistrationHandshake(stream)
+		err := s.doRegistrationHandshake(ctx, stream)
 		if err != nil {
 			return err
 		}
 	}
 	log.Trace("Registration Complete.")
+	s.setConnected(true)
+
+	if err := s.flushHeartbeatBuffer(); err != nil {
+		log.WithError(err).Error("Failed to send buffered heartbeats")
+	}
 
-	// Check to see if Stop was called while we waited for the
-	// registrationHandshake and if so, skip setting up NATS
-	// bridging.
+	// Check to see if Stop was called or the context was cancelled while
+	// we waited for the registrationHandshake and if so, skip setting up
+	// NATS bridging.
 	select {
 	case <-s.quitCh:
 		return nil
+	case <-ctx.Done():
+		return nil
 	default:
 	}
 
@@ -844,6 +1086,11 @@ func (s *Bridge) HandleNATSBridging(stream vzconnpb.VZConnService_NATSBridgeClie
 	log.Info("Starting NATS bridge.")
 	hbChan := s.generateHeartbeats(done)
 
+	// Tracks the ack for the outstanding heartbeat. A single slow ack is tolerated, but
+	// maxHbAckTimeouts consecutive timeouts terminate the stream so that it gets restarted.
+	var hbAckTimer <-chan time.Time
+	hbAckTimeouts := 0
+
 	for {
 		select {
 		case <-s.quitCh:
@@ -887,15 +1134,19 @@ func (s *Bridge) HandleNATSBridging(stream vzconnpb.VZConnService_NATSBridgeClie
 				WithField("type", bridgeMsg.Msg.TypeUrl).
 				Trace("Got Message on GRPC channel")
 
-			if bridgeMsg.Topic == "VizierUpdate" {
+			switch cvmsgs.Topic(bridgeMsg.Topic) {
+			case cvmsgs.HeartbeatAckTopic:
+				atomic.StoreInt64(&s.lastHbAckNs, time.Now().UnixNano())
+				hbAckTimer = nil
+				hbAckTimeouts = 0
+				continue
+			case cvmsgs.VizierUpdateTopic:
 				err := s.handleUpdateMessage(bridgeMsg.Msg)
 				if err != nil && !k8sErrors.IsAlreadyExists(err) {
 					log.WithError(err).Error("Failed to launch vizier update job")
 				}
 				continue
-			}
-
-			if bridgeMsg.Topic == "VizierPassthroughRequest" {
+			case cvmsgs.VizierPassthroughRequestTopic:
 				pb := &cvmsgspb.C2VAPIStreamRequest{}
 				err := types.UnmarshalAny(bridgeMsg.Msg, pb)
 				if err != nil {
@@ -941,10 +1192,21 @@ func (s *Bridge) HandleNATSBridging(stream vzconnpb.VZConnService_NATSBridgeClie
 			}
 		case hbMsg := <-hbChan:
 			log.WithField("heartbeat", hbMsg.GoString()).Trace("Sending heartbeat")
-			err := s.publishProtoToBridgeCh(HeartbeatTopic, hbMsg)
+			err := s.publishProtoToBridgeCh(cvmsgs.HeartbeatTopic, hbMsg)
 			if err != nil {
 				return err
 			}
+			if s.maxHbAckTimeouts > 0 && hbAckTimer == nil {
+				hbAckTimer = time.After(s.hbAckTimeout)
+			}
+		case <-hbAckTimer:
+			hbAckTimer = nil
+			hbAckTimeouts++
+			log.WithField("consecutiveTimeouts", hbAckTimeouts).Info("Timed out waiting for heartbeat ack")
+			if hbAckTimeouts >= s.maxHbAckTimeouts {
+				log.Error("Too many consecutive heartbeat ack timeouts, terminating stream")
+				return ErrHeartbeatAckTimeout
+			}
 		case <-stream.Context().Done():
 			log.Info("Stream has been closed, shutting down grpc readers")
 			return nil
@@ -997,23 +1259,23 @@ func (s *Bridge) publishPTBridgeCh(topic string, msg *types.Any) error {
 	return nil
 }
 
-func (s *Bridge) publishProtoToBridgeCh(topic string, msg proto.Message) error {
+func (s *Bridge) publishProtoToBridgeCh(topic cvmsgs.Topic, msg proto.Message) error {
 	anyMsg, err := types.MarshalAny(msg)
 	if err != nil {
 		return err
 	}
 
-	return s.publishBridgeCh(topic, anyMsg)
+	return s.publishBridgeCh(string(topic), anyMsg)
 }
 
-func (s *Bridge) publishBridgeSync(stream vzconnpb.VZConnService_NATSBridgeClient, topic string, msg proto.Message) error {
+func (s *Bridge) publishBridgeSync(stream vzconnpb.VZConnService_NATSBridgeClient, topic cvmsgs.Topic, msg proto.Message) error {
 	anyMsg, err := types.MarshalAny(msg)
 	if err != nil {
 		return err
 	}
 
 	wrappedReq := &vzconnpb.V2CBridgeMessage{
-		Topic:     topic,
+		Topic:     string(topic),
 		SessionId: s.sessionID,
 		Msg:       anyMsg,
 	}
@@ -1024,30 +1286,68 @@ func (s *Bridge) publishBridgeSync(stream vzconnpb.VZConnService_NATSBridgeClien
 	return nil
 }
 
+// handleScriptRun records the time at which a script finished running, as published by the query broker.
+func (s *Bridge) handleScriptRun(msg *nats.Msg) {
+	ts := &types.Timestamp{}
+	err := ts.Unmarshal(msg.Data)
+	if err != nil {
+		log.WithError(err).Error("Could not unmarshal script run time")
+		return
+	}
+	t, err := types.TimestampFromProto(ts)
+	if err != nil {
+		log.WithError(err).Error("Got invalid script run time")
+		return
+	}
+	// Script runs may be delivered out of order, so only move the time forward.
+	for {
+		last := atomic.LoadInt64(&s.lastScriptRunNs)
+		if t.UnixNano() <= last || atomic.CompareAndSwapInt64(&s.lastScriptRunNs, last, t.UnixNano()) {
+			return
+		}
+	}
+}
+
+// newHeartbeat returns a heartbeat with the current state of the vizier.
+func (s *Bridge) newHeartbeat() *cvmsgspb.VizierHeartbeat {
+	addr, port, err := s.vzInfo.GetAddress()
+	if err != nil {
+		log.WithError(err).Info("Failed to get vizier address")
+	}
+	podStatuses, numNodes, numInstrumentedNodes, updatedTime := s.vzInfo.GetK8sState()
+	return &cvmsgspb.VizierHeartbeat{
+		VizierID:               utils.ProtoFromUUID(s.vizierID),
+		Time:                   time.Now().UnixNano(),
+		SequenceNumber:         atomic.LoadInt64(&s.hbSeqNum),
+		Address:                addr,
+		Port:                   port,
+		NumNodes:               numNodes,
+		NumInstrumentedNodes:   numInstrumentedNodes,
+		PodStatuses:            podStatuses,
+		PodStatusesLastUpdated: updatedTime.UnixNano(),
+		Status:                 s.currentStatus(),
+		BootstrapMode:          viper.GetBool("bootstrap_mode"),
+		BootstrapVersion:       viper.GetString("bootstrap_version"),
+		DisableAutoUpdate:      viper.GetBool("disable_auto_update"),
+		PluginStatuses:         s.vzInfo.GetPluginStatuses(),
+		LastScriptRunAt:        s.lastScriptRunAt(),
+	}
+}
+
+// lastScriptRunAt returns the time at which a script last finished running, or nil if none has.
+func (s *Bridge) lastScriptRunAt() *types.Timestamp {
+	ns := atomic.LoadInt64(&s.lastScriptRunNs)
+	if ns == 0 {
+		return nil
+	}
+	return nanosToTimestampProto(ns)
+}
+
 func (s *Bridge) generateHeartbeats(done <-chan bool) chan *cvmsgspb.VizierHeartbeat {
 	hbCh := make(chan *cvmsgspb.VizierHeartbeat)
 
 	sendHeartbeat := func() {
-		addr, port, err := s.vzInfo.GetAddress()
-		if err != nil {
-			log.WithError(err).Info("Failed to get vizier address")
-		}
-		podStatuses, numNodes, numInstrumentedNodes, updatedTime := s.vzInfo.GetK8sState()
-		hbMsg := &cvmsgspb.VizierHeartbeat{
-			VizierID:               utils.ProtoFromUUID(s.vizierID),
-			Time:                   time.Now().UnixNano(),
-			SequenceNumber:         atomic.LoadInt64(&s.hbSeqNum),
-			Address:                addr,
-			Port:                   port,
-			NumNodes:               numNodes,
-			NumInstrumentedNodes:   numInstrumentedNodes,
-			PodStatuses:            podStatuses,
-			PodStatusesLastUpdated: updatedTime.UnixNano(),
-			Status:                 s.currentStatus(),
-			BootstrapMode:          viper.GetBool("bootstrap_mode"),
-			BootstrapVersion:       viper.GetString("bootstrap_version"),
-			DisableAutoUpdate:      viper.GetBool("disable_auto_update"),
-		}
+		hbMsg := s.newHeartbeat()
 		select {
 		case <-s.quitCh:
 			return
@@ -1055,13 +1355,32 @@ func (s *Bridge) generateHeartbeats(done <-chan bool) chan *cvmsgspb.VizierHeart
 			return
 		case hbCh <- hbMsg:
 			atomic.AddInt64(&s.hbSeqNum, 1)
+			atomic.AddInt64(&s.numHbSent, 1)
 		}
 	}
 
+	var initialDelay time.Duration
+	if s.hbInitialJitter > 0 {
+		initialDelay = time.Duration(s.jitterRand.Int63n(int64(s.hbInitialJitter)))
+	}
+
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
-		ticker := time.NewTicker(heartbeatIntervalS)
+
+		if initialDelay > 0 {
+			select {
+			case <-s.quitCh:
+				log.Info("Stopping heartbeat routine")
+				return
+			case <-done:
+				log.Info("Stopping heartbeat routine")
+				return
+			case <-time.After(initialDelay):
+			}
+		}
+
+		ticker := time.NewTicker(s.hbInterval)
 		defer ticker.Stop()
 
 		// Send first heartbeat.
@@ -1090,6 +1409,11 @@ func (s *Bridge) currentStatus() cvmsgspb.VizierStatus {
 		return cvmsgspb.VZ_ST_UPDATE_FAILED
 	}
 
+	// A degraded vizier is reported as is, otherwise the health check determines the status.
+	if vzStatus, ok := s.vizierInfoStatus(); ok && vzStatus != cvmsgspb.VZ_ST_HEALTHY {
+		return vzStatus
+	}
+
 	t, status := s.vizChecker.GetStatus()
 	if time.Since(t) > vizStatusCheckFailInterval {
 		return cvmsgspb.VZ_ST_UNKNOWN
@@ -1100,6 +1424,25 @@ func (s *Bridge) currentStatus() cvmsgspb.VizierStatus {
 	return cvmsgspb.VZ_ST_HEALTHY
 }
 
+// vizierInfoStatus returns the status reported by the VizierInfo, defaulting to unknown if it can't be determined.
+// Returns false if the VizierInfo hasn't collected the state of the cluster yet, in which case the health check
+// should be used instead.
+func (s *Bridge) vizierInfoStatus() (cvmsgspb.VizierStatus, bool) {
+	if s.vzInfo == nil {
+		log.Error("No vizier info available to get vizier status")
+		return cvmsgspb.VZ_ST_UNKNOWN, true
+	}
+	status, err := s.vzInfo.GetStatus()
+	if errors.Is(err, ErrK8sStateNotCollected) {
+		return cvmsgspb.VZ_ST_UNKNOWN, false
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to get vizier status")
+		return cvmsgspb.VZ_ST_UNKNOWN, true
+	}
+	return status, true
+}
+
 // DebugLog is the GRPC stream method to fetch debug logs from vizier.
 func (s *Bridge) DebugLog(req *vizierpb.DebugLogRequest, srv vizierpb.VizierDebugService_DebugLogServer) error {
 	logs, err := s.vzInfo.GetVizierPodLogs(req.PodName, req.Previous, req.Container)
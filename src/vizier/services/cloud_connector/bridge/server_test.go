@@ -20,10 +20,13 @@ package bridge_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -31,29 +34,49 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 	"github.com/nats-io/nats.go"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/test/bufconn"
 	batchv1 "k8s.io/api/batch/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 
 	"px.dev/pixie/src/api/proto/vizierpb"
 	"px.dev/pixie/src/cloud/vzconn/vzconnpb"
+	"px.dev/pixie/src/shared/cvmsgs"
 	"px.dev/pixie/src/shared/cvmsgspb"
 	"px.dev/pixie/src/shared/k8s/metadatapb"
 	"px.dev/pixie/src/utils"
 	"px.dev/pixie/src/utils/testingutils"
 	"px.dev/pixie/src/vizier/services/cloud_connector/bridge"
+	"px.dev/pixie/src/vizier/utils/messagebus"
 )
 
 const bufSize = 1024 * 1024
 
+var testJWTSigningKey = strings.Repeat("0f", 64)
+
 type FakeVZConnServer struct {
 	quitCh chan bool
 	msgQ   []*vzconnpb.V2CBridgeMessage
 	wg     *sync.WaitGroup
 	t      *testing.T
+	// Decides whether the nth heartbeat (1-indexed) should be acked. Heartbeats are not acked if nil.
+	ackHeartbeat     func(n int32) bool
+	numHeartbeats    int32
+	numRegistrations int32
+	// If set, registration requests are never acked.
+	dropRegistrations bool
+	// Decides whether the nth registration request (1-indexed) should never be acked. Registrations are acked if nil.
+	dropRegistration func(n int32) bool
+	// If set, registration requests are acked with ST_FAILED_NOT_FOUND.
+	registrationNotFound bool
+	// If set, received heartbeats are sent on this channel.
+	heartbeats chan *cvmsgspb.VizierHeartbeat
+	// If set, received heartbeat batches are sent on this channel.
+	heartbeatBatches chan *cvmsgspb.VizierHeartbeatBatch
 }
 
 func marshalAndSend(srv vzconnpb.VZConnService_NATSBridgeServer, topic string, msg proto.Message) error {
@@ -70,8 +93,8 @@ func marshalAndSend(srv vzconnpb.VZConnService_NATSBridgeServer, topic string, m
 }
 
 func handleMsg(srv vzconnpb.VZConnService_NATSBridgeServer, msg *vzconnpb.V2CBridgeMessage) error {
-	if msg.Topic == "register" {
-		return marshalAndSend(srv, "registerAck", &cvmsgspb.RegisterVizierAck{Status: cvmsgspb.ST_OK})
+	if msg.Topic == string(cvmsgs.RegisterTopic) {
+		return marshalAndSend(srv, string(cvmsgs.RegisterAckTopic), &cvmsgspb.RegisterVizierAck{Status: cvmsgspb.ST_OK})
 	}
 	if msg.Topic == "randomtopic" {
 		return nil
@@ -115,16 +138,65 @@ func (fs *FakeVZConnServer) NATSBridge(srv vzconnpb.VZConnService_NATSBridgeServ
 			if err != nil {
 				return err
 			}
-			// Ignore heartbeats
-			if msg.Topic != bridge.HeartbeatTopic {
-				fs.msgQ = append(fs.msgQ, msg)
-				err = handleMsg(srv, msg)
-				if err != nil {
-					fs.t.Errorf("Error marshalling: %+v", err)
-					return err
+			if msg.Topic == string(cvmsgs.HeartbeatTopic) {
+				n := atomic.AddInt32(&fs.numHeartbeats, 1)
+				if fs.heartbeats != nil {
+					hb := &cvmsgspb.VizierHeartbeat{}
+					if err := types.UnmarshalAny(msg.Msg, hb); err != nil {
+						return err
+					}
+					select {
+					case fs.heartbeats <- hb:
+					default:
+					}
+				}
+				if fs.ackHeartbeat != nil && fs.ackHeartbeat(n) {
+					err = marshalAndSend(srv, string(cvmsgs.HeartbeatAckTopic), &cvmsgspb.VizierHeartbeatAck{
+						Status: cvmsgspb.HB_OK,
+					})
+					if err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if msg.Topic == string(cvmsgs.HeartbeatBatchTopic) {
+				if fs.heartbeatBatches != nil {
+					batch := &cvmsgspb.VizierHeartbeatBatch{}
+					if err := types.UnmarshalAny(msg.Msg, batch); err != nil {
+						return err
+					}
+					select {
+					case fs.heartbeatBatches <- batch:
+					default:
+					}
 				}
-				fs.wg.Done()
+				continue
+			}
+			if msg.Topic == string(cvmsgs.RegisterTopic) {
+				n := atomic.AddInt32(&fs.numRegistrations, 1)
+				if fs.dropRegistrations || (fs.dropRegistration != nil && fs.dropRegistration(n)) {
+					fs.msgQ = append(fs.msgQ, msg)
+					fs.wg.Done()
+					continue
+				}
+				if fs.registrationNotFound {
+					fs.msgQ = append(fs.msgQ, msg)
+					err = marshalAndSend(srv, string(cvmsgs.RegisterAckTopic), &cvmsgspb.RegisterVizierAck{Status: cvmsgspb.ST_FAILED_NOT_FOUND})
+					if err != nil {
+						return err
+					}
+					fs.wg.Done()
+					continue
+				}
+			}
+			fs.msgQ = append(fs.msgQ, msg)
+			err = handleMsg(srv, msg)
+			if err != nil {
+				fs.t.Errorf("Error marshalling: %+v", err)
+				return err
 			}
+			fs.wg.Done()
 		}
 	}
 }
@@ -144,14 +216,18 @@ func (f *FakeVZChecker) GetStatus() (time.Time, error) {
 }
 
 type FakeVZInfo struct {
-	externalAddr string
-	port         int32
+	externalAddr   string
+	port           int32
+	status         cvmsgspb.VizierStatus
+	statusErr      error
+	pluginStatuses []*cvmsgspb.PluginStatus
 }
 
 func makeFakeVZInfo(externalAddr string, port int32) bridge.VizierInfo {
 	return &FakeVZInfo{
 		externalAddr: externalAddr,
 		port:         port,
+		status:       cvmsgspb.VZ_ST_HEALTHY,
 	}
 }
 
@@ -178,6 +254,10 @@ func (f *FakeVZInfo) GetK8sState() (map[string]*cvmsgspb.PodStatus, int32, int32
 	return podStatus, 3, 2, lastUpdatedTime
 }
 
+func (f *FakeVZInfo) GetPluginStatuses() []*cvmsgspb.PluginStatus {
+	return f.pluginStatuses
+}
+
 func (f *FakeVZInfo) LaunchJob(j *batchv1.Job) (*batchv1.Job, error) {
 	return nil, nil
 }
@@ -199,7 +279,7 @@ func (f *FakeVZInfo) DeleteJob(name string) error {
 }
 
 func (f *FakeVZInfo) GetJob(name string) (*batchv1.Job, error) {
-	return nil, nil
+	return nil, k8sErrors.NewNotFound(batchv1.Resource("jobs"), name)
 }
 
 func (f *FakeVZInfo) GetClusterUID() (string, error) {
@@ -232,6 +312,10 @@ func (f *FakeVZInfo) GetVizierPods() ([]*vizierpb.VizierPodStatus, []*vizierpb.V
 	return fakeAgents, fakeControlPlane, nil
 }
 
+func (f *FakeVZInfo) GetStatus() (cvmsgspb.VizierStatus, error) {
+	return f.status, f.statusErr
+}
+
 type FakeVZUpdater struct{}
 
 func (f *FakeVZUpdater) UpdateCRDVizierVersion(string) error {
@@ -292,7 +376,7 @@ func makeTestState(t *testing.T) (*testState, func(t *testing.T)) {
 		vzServer: vs,
 		vzClient: vc,
 		nats:     nc,
-		jwt:      testingutils.GenerateTestJWTToken(t, "jwt-key"),
+		jwt:      testJWTSigningKey,
 		wg:       wg,
 		lis:      lis,
 	}, cleanupFunc
@@ -305,9 +389,10 @@ func TestNATSGRPCBridgeTest_CorrectRegistrationFlow(t *testing.T) {
 	ts.wg.Add(1)
 
 	sessionID := time.Now().UnixNano()
-	b := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
 	defer b.Stop()
-	go b.RunStream()
+	go b.RunStream(context.Background())
 
 	ts.wg.Wait()
 	assert.Equal(t, 1, len(ts.vzServer.msgQ))
@@ -315,12 +400,12 @@ func TestNATSGRPCBridgeTest_CorrectRegistrationFlow(t *testing.T) {
 	register := ts.vzServer.msgQ[0]
 
 	// Check the metadata
-	assert.Equal(t, "register", register.Topic)
+	assert.Equal(t, string(cvmsgs.RegisterTopic), register.Topic)
 	assert.Equal(t, sessionID, register.SessionId)
 
 	// Check the contents
 	registerMsg := &cvmsgspb.RegisterVizierRequest{}
-	err := types.UnmarshalAny(register.Msg, registerMsg)
+	err = types.UnmarshalAny(register.Msg, registerMsg)
 	if err != nil {
 		t.Fatalf("Could not unmarshal: %+v", err)
 	}
@@ -332,6 +417,82 @@ func TestNATSGRPCBridgeTest_CorrectRegistrationFlow(t *testing.T) {
 	assert.Equal(t, "084cb5f0-ff69-11e9-a63e-42010a8a0193", registerMsg.ClusterInfo.ClusterUID)
 }
 
+func TestNATSGRPCBridgeTest_StartStreamContextCancelled(t *testing.T) {
+	ts, cleanup := makeTestState(t)
+	defer cleanup(t)
+	ts.vzServer.dropRegistrations = true
+
+	ts.wg.Add(1)
+
+	sessionID := time.Now().UnixNano()
+	b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
+	defer b.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error)
+	streamErrCh := make(chan error, 1)
+	go func() {
+		streamErrCh <- b.StartStream(ctx, errCh)
+	}()
+
+	// Wait for the registration request, which is never acked.
+	ts.wg.Wait()
+	cancel()
+
+	select {
+	case err := <-streamErrCh:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("StartStream did not return after the context was cancelled")
+	}
+}
+
+func TestNATSGRPCBridgeTest_ConnectionStateCallbacks(t *testing.T) {
+	ts, cleanup := makeTestState(t)
+	defer cleanup(t)
+
+	ts.wg.Add(1)
+
+	sessionID := time.Now().UnixNano()
+	b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
+	defer b.Stop()
+
+	var numConnected, numDisconnected int32
+	connectedCh := make(chan bool, 1)
+	disconnectedCh := make(chan bool, 1)
+	b.OnConnected(func() {
+		atomic.AddInt32(&numConnected, 1)
+		connectedCh <- true
+	})
+	b.OnDisconnected(func() {
+		atomic.AddInt32(&numDisconnected, 1)
+		disconnectedCh <- true
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go b.RunStream(ctx)
+
+	select {
+	case <-connectedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for connected callback")
+	}
+	assert.Equal(t, int32(0), atomic.LoadInt32(&numDisconnected))
+
+	// Cancelling the context ends the stream.
+	cancel()
+	select {
+	case <-disconnectedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for disconnected callback")
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&numConnected))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&numDisconnected))
+}
+
 // Test a message that comes from our NATS queue (and should end up sent to the VZConn)
 func TestNATSGRPCBridgeTest_TestOutboundNATSMessage(t *testing.T) {
 	ts, cleanup := makeTestState(t)
@@ -341,11 +502,12 @@ func TestNATSGRPCBridgeTest_TestOutboundNATSMessage(t *testing.T) {
 	ts.wg.Add(1)
 
 	sessionID := time.Now().UnixNano()
-	b := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
 	defer func() {
 		b.Stop()
 	}()
-	go b.RunStream()
+	go b.RunStream(context.Background())
 
 	ts.wg.Wait()
 
@@ -399,10 +561,11 @@ func TestNATSGRPCBridgeTest_TestInboundNATSMessage(t *testing.T) {
 	ts.wg.Add(1)
 
 	sessionID := time.Now().UnixNano()
-	b := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
 	defer b.Stop()
 
-	go b.RunStream()
+	go b.RunStream(context.Background())
 	ts.wg.Wait()
 
 	// Subscribe to NATS
@@ -472,10 +635,11 @@ func TestNATSGRPCBridgeTest_TestRegisterDeployment(t *testing.T) {
 	vzID := uuid.FromStringOrNil("")
 
 	sessionID := time.Now().UnixNano()
-	b := bridge.New(vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	b, err := bridge.New(vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
 	defer b.Stop()
 
-	go b.RunStream()
+	go b.RunStream(context.Background())
 	ts.wg.Wait()
 
 	// Subscribe to NATS
@@ -491,3 +655,555 @@ func TestNATSGRPCBridgeTest_TestRegisterDeployment(t *testing.T) {
 		ts.wg.Done()
 	}()
 }
+
+func setHeartbeatAckConfig(interval time.Duration, ackTimeout time.Duration, maxTimeouts int) func() {
+	viper.Set("heartbeat_interval", interval)
+	viper.Set("heartbeat_ack_timeout", ackTimeout)
+	viper.Set("max_heartbeat_ack_timeouts", maxTimeouts)
+	return func() {
+		viper.Set("heartbeat_interval", 0)
+		viper.Set("heartbeat_ack_timeout", 0)
+		viper.Set("max_heartbeat_ack_timeouts", 0)
+	}
+}
+
+func TestNATSGRPCBridgeTest_HeartbeatAckTimeoutsBelowLimit(t *testing.T) {
+	resetConfig := setHeartbeatAckConfig(100*time.Millisecond, 50*time.Millisecond, 3)
+	defer resetConfig()
+
+	ts, cleanup := makeTestState(t)
+	defer cleanup(t)
+	// Drop the acks for the first two heartbeats, then ack everything.
+	ts.vzServer.ackHeartbeat = func(n int32) bool {
+		return n > 2
+	}
+
+	// Allow for an unexpected re-registration, so that it is caught by the assertion below.
+	ts.wg.Add(2)
+
+	sessionID := time.Now().UnixNano()
+	b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
+	defer b.Stop()
+	go b.RunStream(context.Background())
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&ts.vzServer.numHeartbeats) >= 6
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ts.vzServer.numRegistrations))
+}
+
+func TestNATSGRPCBridgeTest_HeartbeatAckTimeoutsRestartStream(t *testing.T) {
+	resetConfig := setHeartbeatAckConfig(100*time.Millisecond, 50*time.Millisecond, 3)
+	defer resetConfig()
+
+	ts, cleanup := makeTestState(t)
+	defer cleanup(t)
+	// Never ack heartbeats.
+	ts.vzServer.ackHeartbeat = nil
+
+	// Wait for the initial registration and the one after the stream restarts.
+	ts.wg.Add(2)
+
+	sessionID := time.Now().UnixNano()
+	b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
+	defer b.Stop()
+	go b.RunStream(context.Background())
+
+	ts.wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&ts.vzServer.numRegistrations))
+	// The stream should only restart after the third consecutive timeout.
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&ts.vzServer.numHeartbeats), int32(3))
+}
+
+func setRegistrationConfig(attempts int, timeout time.Duration) func() {
+	viper.Set("registration_attempts", attempts)
+	viper.Set("registration_timeout", timeout)
+	return func() {
+		viper.Set("registration_attempts", 0)
+		viper.Set("registration_timeout", 0)
+	}
+}
+
+func TestNATSGRPCBridgeTest_RegistrationAttempts(t *testing.T) {
+	resetConfig := setRegistrationConfig(3, 50*time.Millisecond)
+	defer resetConfig()
+
+	ts, cleanup := makeTestState(t)
+	defer cleanup(t)
+	ts.vzServer.dropRegistrations = true
+
+	// Wait for all of the registration attempts.
+	ts.wg.Add(3)
+
+	sessionID := time.Now().UnixNano()
+	b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
+	defer b.Stop()
+
+	err = b.StartStream(context.Background(), make(chan error))
+	assert.Equal(t, bridge.ErrRegistrationTimeout, err)
+
+	ts.wg.Wait()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&ts.vzServer.numRegistrations))
+}
+
+func TestNATSGRPCBridgeTest_RegistrationNotFound(t *testing.T) {
+	resetConfig := setRegistrationConfig(3, 5*time.Second)
+	defer resetConfig()
+
+	ts, cleanup := makeTestState(t)
+	defer cleanup(t)
+	ts.vzServer.registrationNotFound = true
+
+	ts.wg.Add(1)
+
+	sessionID := time.Now().UnixNano()
+	b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
+	defer b.Stop()
+
+	// A cluster that isn't found is a permanent error, so it should not be retried.
+	err = b.StartStream(context.Background(), make(chan error))
+	assert.Equal(t, bridge.ErrRegistrationNotFound, err)
+
+	ts.wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ts.vzServer.numRegistrations))
+}
+
+func TestNATSGRPCBridgeTest_RunStreamStopsOnRegistrationNotFound(t *testing.T) {
+	ts, cleanup := makeTestState(t)
+	defer cleanup(t)
+	ts.vzServer.registrationNotFound = true
+
+	ts.wg.Add(1)
+
+	sessionID := time.Now().UnixNano()
+	b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
+	defer b.Stop()
+	assert.Nil(t, b.GetStatus().FatalError)
+
+	streamDone := make(chan struct{})
+	go func() {
+		b.RunStream(context.Background())
+		close(streamDone)
+	}()
+
+	select {
+	case <-streamDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for RunStream to stop")
+	}
+
+	ts.wg.Wait()
+	// The stream should not have been restarted after the cluster wasn't found.
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ts.vzServer.numRegistrations))
+	status := b.GetStatus()
+	assert.Equal(t, bridge.ErrRegistrationNotFound, status.FatalError)
+	assert.False(t, status.Connected)
+}
+
+func TestNATSGRPCBridgeTest_HeartbeatsBufferedWhileDisconnected(t *testing.T) {
+	// Restart the stream as soon as a heartbeat isn't acked.
+	resetHeartbeatConfig := setHeartbeatAckConfig(20*time.Millisecond, 30*time.Millisecond, 1)
+	defer resetHeartbeatConfig()
+	resetRegistrationConfig := setRegistrationConfig(1, 200*time.Millisecond)
+	defer resetRegistrationConfig()
+	viper.Set("heartbeat_buffer_size", 3)
+	defer viper.Set("heartbeat_buffer_size", 0)
+
+	ts, cleanup := makeTestState(t)
+	defer cleanup(t)
+	// Drop the ack for the first heartbeat, so that the stream disconnects after connecting.
+	ts.vzServer.ackHeartbeat = func(n int32) bool {
+		return n > 1
+	}
+	// Keep the bridge disconnected for the next two registration attempts.
+	ts.vzServer.dropRegistration = func(n int32) bool {
+		return n == 2 || n == 3
+	}
+	ts.vzServer.heartbeats = make(chan *cvmsgspb.VizierHeartbeat, 1)
+	ts.vzServer.heartbeatBatches = make(chan *cvmsgspb.VizierHeartbeatBatch, 1)
+
+	ts.wg.Add(4)
+
+	sessionID := time.Now().UnixNano()
+	b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
+	defer b.Stop()
+	go b.RunStream(context.Background())
+
+	var firstHb *cvmsgspb.VizierHeartbeat
+	select {
+	case firstHb = <-ts.vzServer.heartbeats:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for heartbeat")
+	}
+
+	select {
+	case batch := <-ts.vzServer.heartbeatBatches:
+		// Many more heartbeats were recorded while disconnected, but only the most recent are kept.
+		require.Len(t, batch.Heartbeats, 3)
+		for i := 1; i < len(batch.Heartbeats); i++ {
+			assert.Less(t, batch.Heartbeats[i-1].Time, batch.Heartbeats[i].Time)
+			assert.Equal(t, batch.Heartbeats[i-1].SequenceNumber+1, batch.Heartbeats[i].SequenceNumber)
+		}
+		assert.Greater(t, batch.Heartbeats[0].SequenceNumber, firstHb.SequenceNumber)
+		assert.Equal(t, "foobar", batch.Heartbeats[0].Address)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for buffered heartbeats")
+	}
+
+	ts.wg.Wait()
+	assert.Equal(t, int32(4), atomic.LoadInt32(&ts.vzServer.numRegistrations))
+}
+
+func TestNATSGRPCBridgeTest_HeartbeatsNotBufferedBeforeConnecting(t *testing.T) {
+	resetHeartbeatConfig := setHeartbeatAckConfig(20*time.Millisecond, time.Second, 0)
+	defer resetHeartbeatConfig()
+	resetRegistrationConfig := setRegistrationConfig(1, 200*time.Millisecond)
+	defer resetRegistrationConfig()
+	viper.Set("heartbeat_buffer_size", 3)
+	defer viper.Set("heartbeat_buffer_size", 0)
+
+	ts, cleanup := makeTestState(t)
+	defer cleanup(t)
+	// The bridge doesn't connect until the third registration attempt.
+	ts.vzServer.dropRegistration = func(n int32) bool {
+		return n <= 2
+	}
+	ts.vzServer.heartbeatBatches = make(chan *cvmsgspb.VizierHeartbeatBatch, 1)
+
+	ts.wg.Add(3)
+
+	sessionID := time.Now().UnixNano()
+	b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
+	defer b.Stop()
+	go b.RunStream(context.Background())
+
+	ts.wg.Wait()
+	require.Eventually(t, func() bool {
+		return b.GetStatus().Connected
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// There was no gap in the heartbeats for the cloud to backfill.
+	select {
+	case <-ts.vzServer.heartbeatBatches:
+		t.Fatal("Unexpected heartbeat batch")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestNATSGRPCBridgeTest_GetStatus(t *testing.T) {
+	resetConfig := setHeartbeatAckConfig(50*time.Millisecond, time.Second, 0)
+	defer resetConfig()
+
+	ts, cleanup := makeTestState(t)
+	defer cleanup(t)
+	ts.vzServer.ackHeartbeat = func(n int32) bool {
+		return true
+	}
+
+	ts.wg.Add(1)
+
+	sessionID := time.Now().UnixNano()
+	b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
+	defer b.Stop()
+
+	status := b.GetStatus()
+	assert.Equal(t, int64(0), status.HeartbeatSeqNum)
+	assert.True(t, status.LastHeartbeatAck.IsZero())
+	assert.False(t, status.Connected)
+
+	start := time.Now()
+	go b.RunStream(context.Background())
+
+	// Once the cloud has received a few heartbeats, the sequence number matches the number of heartbeats sent.
+	require.Eventually(t, func() bool {
+		numHeartbeats := atomic.LoadInt32(&ts.vzServer.numHeartbeats)
+		return numHeartbeats >= 3 && b.GetStatus().HeartbeatSeqNum == int64(numHeartbeats)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.True(t, b.GetStatus().Connected)
+	assert.Eventually(t, func() bool {
+		return b.GetStatus().LastHeartbeatAck.After(start)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func setHeartbeatJitterConfig(interval time.Duration, jitter time.Duration) func() {
+	viper.Set("heartbeat_interval", interval)
+	viper.Set("heartbeat_initial_jitter", jitter)
+	return func() {
+		viper.Set("heartbeat_interval", 0)
+		viper.Set("heartbeat_initial_jitter", 0)
+	}
+}
+
+func TestNATSGRPCBridgeTest_HeartbeatInitialJitter(t *testing.T) {
+	jitter := 300 * time.Millisecond
+	// The interval is long enough that only the first heartbeat is sent during the test.
+	resetConfig := setHeartbeatJitterConfig(time.Minute, jitter)
+	defer resetConfig()
+
+	ts, cleanup := makeTestState(t)
+	defer cleanup(t)
+	ts.vzServer.heartbeats = make(chan *cvmsgspb.VizierHeartbeat, 1)
+
+	ts.wg.Add(1)
+
+	sessionID := time.Now().UnixNano()
+	b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
+	defer b.Stop()
+
+	connectedAt := make(chan time.Time, 1)
+	b.OnConnected(func() {
+		connectedAt <- time.Now()
+	})
+	go b.RunStream(context.Background())
+
+	select {
+	case <-ts.vzServer.heartbeats:
+		receivedAt := time.Now()
+		start := <-connectedAt
+		// Allow some slack for the heartbeat to be sent over the stream.
+		assert.Less(t, int64(receivedAt.Sub(start)), int64(jitter+200*time.Millisecond))
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for heartbeat")
+	}
+}
+
+func TestNATSGRPCBridgeTest_HeartbeatInitialJitterInterruptedByStop(t *testing.T) {
+	resetConfig := setHeartbeatJitterConfig(time.Minute, time.Hour)
+	defer resetConfig()
+
+	ts, cleanup := makeTestState(t)
+	defer cleanup(t)
+	ts.vzServer.heartbeats = make(chan *cvmsgspb.VizierHeartbeat, 1)
+
+	ts.wg.Add(1)
+
+	sessionID := time.Now().UnixNano()
+	b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
+
+	connectedCh := make(chan bool, 1)
+	b.OnConnected(func() {
+		connectedCh <- true
+	})
+	go b.RunStream(context.Background())
+
+	select {
+	case <-connectedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for connected callback")
+	}
+
+	// Stop waits for the heartbeat routine, so it only returns if the initial delay is interrupted.
+	stopped := make(chan bool)
+	go func() {
+		b.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not interrupt the initial heartbeat delay")
+	}
+	assert.Empty(t, ts.vzServer.heartbeats)
+}
+
+func TestNATSGRPCBridgeTest_HeartbeatStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		vzStatus       cvmsgspb.VizierStatus
+		vzStatusErr    error
+		expectedStatus cvmsgspb.VizierStatus
+	}{
+		{
+			name:           "healthy",
+			vzStatus:       cvmsgspb.VZ_ST_HEALTHY,
+			expectedStatus: cvmsgspb.VZ_ST_HEALTHY,
+		},
+		{
+			name:           "degraded",
+			vzStatus:       cvmsgspb.VZ_ST_UNHEALTHY,
+			expectedStatus: cvmsgspb.VZ_ST_UNHEALTHY,
+		},
+		{
+			name:           "state not collected",
+			vzStatus:       cvmsgspb.VZ_ST_UNKNOWN,
+			vzStatusErr:    bridge.ErrK8sStateNotCollected,
+			expectedStatus: cvmsgspb.VZ_ST_HEALTHY,
+		},
+		{
+			name:           "status error",
+			vzStatus:       cvmsgspb.VZ_ST_HEALTHY,
+			vzStatusErr:    errors.New("could not get status"),
+			expectedStatus: cvmsgspb.VZ_ST_UNKNOWN,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ts, cleanup := makeTestState(t)
+			defer cleanup(t)
+			ts.vzServer.heartbeats = make(chan *cvmsgspb.VizierHeartbeat, 1)
+
+			ts.wg.Add(1)
+
+			vzInfo := &FakeVZInfo{
+				externalAddr: "foobar",
+				port:         123,
+				status:       tc.vzStatus,
+				statusErr:    tc.vzStatusErr,
+			}
+			sessionID := time.Now().UnixNano()
+			b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, vzInfo, &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+			require.NoError(t, err)
+			defer b.Stop()
+			go b.RunStream(context.Background())
+
+			select {
+			case hb := <-ts.vzServer.heartbeats:
+				assert.Equal(t, tc.expectedStatus, hb.Status)
+			case <-time.After(5 * time.Second):
+				t.Fatal("Timed out waiting for heartbeat")
+			}
+		})
+	}
+}
+
+func TestNATSGRPCBridgeTest_HeartbeatPluginStatuses(t *testing.T) {
+	ts, cleanup := makeTestState(t)
+	defer cleanup(t)
+	ts.vzServer.heartbeats = make(chan *cvmsgspb.VizierHeartbeat, 1)
+
+	ts.wg.Add(1)
+
+	pluginStatuses := []*cvmsgspb.PluginStatus{
+		{
+			Name:    "otel-export",
+			Healthy: true,
+		},
+		{
+			Name:    "prometheus-export",
+			Healthy: false,
+			Message: "pod prometheus-export-abcd is FAILED",
+		},
+	}
+	vzInfo := &FakeVZInfo{
+		externalAddr:   "foobar",
+		port:           123,
+		status:         cvmsgspb.VZ_ST_HEALTHY,
+		pluginStatuses: pluginStatuses,
+	}
+	sessionID := time.Now().UnixNano()
+	b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, vzInfo, &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
+	defer b.Stop()
+	go b.RunStream(context.Background())
+
+	select {
+	case hb := <-ts.vzServer.heartbeats:
+		assert.Equal(t, pluginStatuses, hb.PluginStatuses)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for heartbeat")
+	}
+}
+
+func TestNATSGRPCBridgeTest_HeartbeatLastScriptRunAt(t *testing.T) {
+	resetConfig := setHeartbeatAckConfig(20*time.Millisecond, time.Second, 0)
+	defer resetConfig()
+
+	ts, cleanup := makeTestState(t)
+	defer cleanup(t)
+	ts.vzServer.heartbeats = make(chan *cvmsgspb.VizierHeartbeat, 1)
+
+	ts.wg.Add(1)
+
+	sessionID := time.Now().UnixNano()
+	b, err := bridge.New(ts.vzID, ts.jwt, "", sessionID, ts.vzClient, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, ts.nats, &FakeVZChecker{})
+	require.NoError(t, err)
+	defer b.Stop()
+	go b.RunStream(context.Background())
+
+	// No script has run yet.
+	select {
+	case hb := <-ts.vzServer.heartbeats:
+		assert.Nil(t, hb.LastScriptRunAt)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for heartbeat")
+	}
+
+	publishScriptRun := func(runAt *types.Timestamp) {
+		data, err := runAt.Marshal()
+		require.NoError(t, err)
+		require.NoError(t, ts.nats.Publish(messagebus.ScriptRunTopic, data))
+	}
+	publishScriptRun(&types.Timestamp{Seconds: 1561230620})
+	// Older script runs don't move the time back.
+	publishScriptRun(&types.Timestamp{Seconds: 1561230000})
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case hb := <-ts.vzServer.heartbeats:
+			if hb.LastScriptRunAt == nil {
+				continue
+			}
+			assert.Equal(t, &types.Timestamp{Seconds: 1561230620}, hb.LastScriptRunAt)
+			return
+		case <-timeout:
+			t.Fatal("Timed out waiting for heartbeat with the last script run")
+		}
+	}
+}
+
+func TestNew_ValidatesJWTSigningKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		key         string
+		expectError bool
+	}{
+		{
+			name:        "valid key",
+			key:         testJWTSigningKey,
+			expectError: false,
+		},
+		{
+			name:        "empty key",
+			key:         "",
+			expectError: true,
+		},
+		{
+			name:        "short key",
+			key:         strings.Repeat("0f", 32),
+			expectError: true,
+		},
+		{
+			name:        "not hex encoded",
+			key:         strings.Repeat("zz", 64),
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vzID := uuid.Must(uuid.NewV4())
+			b, err := bridge.New(vzID, test.key, "", 0, nil, makeFakeVZInfo("foobar", 123), &FakeVZUpdater{}, nil, &FakeVZChecker{})
+			if test.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, b)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, b)
+			}
+		})
+	}
+}
@@ -20,32 +20,128 @@ package bridge
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"strings"
 	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding/gzip"
 
 	"px.dev/pixie/src/cloud/vzconn/vzconnpb"
 	"px.dev/pixie/src/shared/services"
 )
 
+// defaultVZConnMaxMsgSize is the default max size of messages sent to and received from VZConn. It's larger
+// than gRPC's default of 4MB, since heartbeats include the status of every pod in the cluster.
+const defaultVZConnMaxMsgSize = 16 * 1024 * 1024
+
 func init() {
 	pflag.String("cloud_addr", "vzconn-service.plc.svc:51600", "The Pixie Cloud service url (load balancer/list is ok)")
+	pflag.String("cloud_client_tls_cert", "", "The client certificate presented to Pixie Cloud for mutual TLS. If unset, no client certificate is presented")
+	pflag.String("cloud_client_tls_key", "", "The key of the client certificate presented to Pixie Cloud for mutual TLS")
+	pflag.String("cloud_tls_ca_cert", "", "The CA bundle used to verify Pixie Cloud when using mutual TLS. If unset, the system CAs are used")
+	pflag.Int("cloud_max_msg_size", defaultVZConnMaxMsgSize, "The max size in bytes of messages sent to and received from Pixie Cloud")
+	pflag.Bool("cloud_use_gzip", false, "Whether to gzip the messages sent to Pixie Cloud. Older versions of Pixie Cloud don't support compression")
+}
+
+// VZConnTLSConfig configures mutual TLS for the connection to VZConn.
+type VZConnTLSConfig struct {
+	// The client certificate and key presented to VZConn.
+	ClientCertFile string
+	ClientKeyFile  string
+	// The CA bundle used to verify VZConn's certificate. If empty, the system CAs are used.
+	CACertFile string
+}
+
+// vzConnTLSConfigFromFlags returns the mutual TLS config set by the flags, or nil if it isn't configured.
+func vzConnTLSConfigFromFlags() *VZConnTLSConfig {
+	cfg := &VZConnTLSConfig{
+		ClientCertFile: viper.GetString("cloud_client_tls_cert"),
+		ClientKeyFile:  viper.GetString("cloud_client_tls_key"),
+		CACertFile:     viper.GetString("cloud_tls_ca_cert"),
+	}
+	if cfg.ClientCertFile == "" && cfg.ClientKeyFile == "" {
+		return nil
+	}
+	return cfg
+}
+
+func (c *VZConnTLSConfig) tlsConfig() (*tls.Config, error) {
+	if c.ClientCertFile == "" || c.ClientKeyFile == "" {
+		return nil, errors.New("both a client certificate and key are required for mutual TLS")
+	}
+	pair, err := tls.LoadX509KeyPair(c.ClientCertFile, c.ClientKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{pair},
+	}
+	if c.CACertFile == "" {
+		return tlsConfig, nil
+	}
+
+	ca, err := ioutil.ReadFile(c.CACertFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA cert: %w", err)
+	}
+	certPool := x509.NewCertPool()
+	if ok := certPool.AppendCertsFromPEM(ca); !ok {
+		return nil, fmt.Errorf("failed to append CA cert: %s", c.CACertFile)
+	}
+	tlsConfig.RootCAs = certPool
+	return tlsConfig, nil
+}
+
+// VZConnDialOpts gets the dial options for connecting to VZConn at cloudAddr. If tlsConfig is nil, the
+// connection uses server-side TLS only.
+func VZConnDialOpts(cloudAddr string, tlsConfig *VZConnTLSConfig) ([]grpc.DialOption, error) {
+	if tlsConfig == nil {
+		isInternal := strings.ContainsAny(cloudAddr, ".svc.cluster.local")
+		return services.GetGRPCClientDialOptsServerSideTLS(isInternal)
+	}
+
+	config, err := tlsConfig.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	return []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(config))}, nil
+}
+
+// VZConnMsgSizeDialOpt gets the dial option limiting the size of messages sent to and received from VZConn
+// to maxMsgSize bytes. If maxMsgSize isn't positive, the default of 16MB is used.
+func VZConnMsgSizeDialOpt(maxMsgSize int) grpc.DialOption {
+	if maxMsgSize <= 0 {
+		maxMsgSize = defaultVZConnMaxMsgSize
+	}
+	return grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxMsgSize), grpc.MaxCallSendMsgSize(maxMsgSize))
+}
+
+// VZConnGzipDialOpt gets the dial option which gzips the messages sent to VZConn. VZConn responds with gzipped
+// messages too.
+func VZConnGzipDialOpt() grpc.DialOption {
+	return grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name))
 }
 
 // NewVZConnClient creates a new vzconn RPC client stub.
 func NewVZConnClient() (vzconnpb.VZConnServiceClient, error) {
 	cloudAddr := viper.GetString("cloud_addr")
 
-	isInternal := strings.ContainsAny(cloudAddr, ".svc.cluster.local")
-
-	dialOpts, err := services.GetGRPCClientDialOptsServerSideTLS(isInternal)
+	dialOpts, err := VZConnDialOpts(cloudAddr, vzConnTLSConfigFromFlags())
 	if err != nil {
 		return nil, err
 	}
-	dialOpts = append(dialOpts, []grpc.DialOption{grpc.WithBlock()}...)
+	dialOpts = append(dialOpts, grpc.WithBlock(), VZConnMsgSizeDialOpt(viper.GetInt("cloud_max_msg_size")))
+	if viper.GetBool("cloud_use_gzip") {
+		dialOpts = append(dialOpts, VZConnGzipDialOpt())
+	}
 
 	ctxBg := context.Background()
 	ctx, cancel := context.WithTimeout(ctxBg, 10*time.Second)
@@ -0,0 +1,313 @@
+/*
+ * Copyright 2018- The Pixie Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package bridge_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"px.dev/pixie/src/cloud/vzconn/vzconnpb"
+	"px.dev/pixie/src/vizier/services/cloud_connector/bridge"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func mustWritePEM(t *testing.T, path string, blockType string, bytes []byte) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, pem.Encode(f, &pem.Block{Type: blockType, Bytes: bytes}))
+}
+
+func mustCreateTestCA(t *testing.T, dir string, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	mustWritePEM(t, filepath.Join(dir, name+".crt"), "CERTIFICATE", der)
+	return &testCA{cert: cert, key: key}
+}
+
+// mustCreateTestCert writes a certificate signed by the CA to <dir>/<name>.crt, and its key to <dir>/<name>.key.
+func mustCreateTestCert(t *testing.T, dir string, name string, ca *testCA, usage x509.ExtKeyUsage) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"bufnet"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	mustWritePEM(t, filepath.Join(dir, name+".crt"), "CERTIFICATE", der)
+	mustWritePEM(t, filepath.Join(dir, name+".key"), "EC PRIVATE KEY", keyDER)
+}
+
+func TestVZConnDialOpts_MutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vzconn_certs")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := mustCreateTestCA(t, dir, "ca")
+	otherCA := mustCreateTestCA(t, dir, "other_ca")
+	mustCreateTestCert(t, dir, "server", ca, x509.ExtKeyUsageServerAuth)
+	mustCreateTestCert(t, dir, "client", ca, x509.ExtKeyUsageClientAuth)
+	mustCreateTestCert(t, dir, "untrusted_client", otherCA, x509.ExtKeyUsageClientAuth)
+
+	// The server only accepts clients with a certificate signed by the CA.
+	serverPair, err := tls.LoadX509KeyPair(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+	serverCreds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverPair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	})
+
+	lis := bufconn.Listen(bufSize)
+	s := grpc.NewServer(grpc.Creds(serverCreds))
+	vzconnpb.RegisterVZConnServiceServer(s, newFakeVZConnServer(&sync.WaitGroup{}, t))
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	defer s.Stop()
+
+	tests := []struct {
+		name              string
+		tlsConfig         *bridge.VZConnTLSConfig
+		expectDialOptsErr bool
+		expectRPCErr      bool
+	}{
+		{
+			name: "trusted client certificate",
+			tlsConfig: &bridge.VZConnTLSConfig{
+				ClientCertFile: filepath.Join(dir, "client.crt"),
+				ClientKeyFile:  filepath.Join(dir, "client.key"),
+				CACertFile:     filepath.Join(dir, "ca.crt"),
+			},
+		},
+		{
+			name: "untrusted client certificate",
+			tlsConfig: &bridge.VZConnTLSConfig{
+				ClientCertFile: filepath.Join(dir, "untrusted_client.crt"),
+				ClientKeyFile:  filepath.Join(dir, "untrusted_client.key"),
+				CACertFile:     filepath.Join(dir, "ca.crt"),
+			},
+			expectRPCErr: true,
+		},
+		{
+			name: "untrusted server certificate",
+			tlsConfig: &bridge.VZConnTLSConfig{
+				ClientCertFile: filepath.Join(dir, "client.crt"),
+				ClientKeyFile:  filepath.Join(dir, "client.key"),
+				CACertFile:     filepath.Join(dir, "other_ca.crt"),
+			},
+			expectRPCErr: true,
+		},
+		{
+			name: "missing client key",
+			tlsConfig: &bridge.VZConnTLSConfig{
+				ClientCertFile: filepath.Join(dir, "client.crt"),
+				CACertFile:     filepath.Join(dir, "ca.crt"),
+			},
+			expectDialOptsErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dialOpts, err := bridge.VZConnDialOpts("bufnet", tc.tlsConfig)
+			if tc.expectDialOptsErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			dialOpts = append(dialOpts, grpc.WithContextDialer(createDialer(lis)))
+			conn, err := grpc.DialContext(ctx, "bufnet", dialOpts...)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			_, err = vzconnpb.NewVZConnServiceClient(conn).RegisterVizierDeployment(ctx, &vzconnpb.RegisterVizierDeploymentRequest{
+				K8sClusterUID:     "084cb5f0-ff69-11e9-a63e-42010a8a0193",
+				K8sClusterVersion: "v1.14.10-gke.27",
+			})
+			if tc.expectRPCErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestVZConnMsgSizeDialOpt(t *testing.T) {
+	lis := bufconn.Listen(bufSize)
+	s := grpc.NewServer()
+	vzconnpb.RegisterVZConnServiceServer(s, newFakeVZConnServer(&sync.WaitGroup{}, t))
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	defer s.Stop()
+
+	// The request is larger than 1KB, but well within gRPC's default limit.
+	req := &vzconnpb.RegisterVizierDeploymentRequest{
+		K8sClusterUID:     "084cb5f0-ff69-11e9-a63e-42010a8a0193",
+		K8sClusterName:    strings.Repeat("a", 2*1024),
+		K8sClusterVersion: "v1.14.10-gke.27",
+	}
+
+	tests := []struct {
+		name         string
+		maxMsgSize   int
+		expectedCode codes.Code
+	}{
+		{
+			name:         "custom size smaller than request",
+			maxMsgSize:   1024,
+			expectedCode: codes.ResourceExhausted,
+		},
+		{
+			name:         "custom size larger than request",
+			maxMsgSize:   4 * 1024,
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "default size",
+			maxMsgSize:   0,
+			expectedCode: codes.OK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(createDialer(lis)), grpc.WithInsecure(),
+				bridge.VZConnMsgSizeDialOpt(tc.maxMsgSize))
+			require.NoError(t, err)
+			defer conn.Close()
+
+			_, err = vzconnpb.NewVZConnServiceClient(conn).RegisterVizierDeployment(ctx, req)
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+		})
+	}
+}
+
+func TestVZConnGzipDialOpt(t *testing.T) {
+	lis := bufconn.Listen(bufSize)
+	s := grpc.NewServer()
+	vzconnpb.RegisterVZConnServiceServer(s, newFakeVZConnServer(&sync.WaitGroup{}, t))
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	defer s.Stop()
+
+	tests := []struct {
+		name               string
+		dialOpts           []grpc.DialOption
+		expectedCompressor string
+	}{
+		{
+			name:               "gzip enabled",
+			dialOpts:           []grpc.DialOption{bridge.VZConnGzipDialOpt()},
+			expectedCompressor: "gzip",
+		},
+		{
+			name:               "gzip disabled",
+			expectedCompressor: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Record the compressor that each call is made with.
+			var compressor string
+			recordCompressor := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				for _, opt := range opts {
+					if c, ok := opt.(grpc.CompressorCallOption); ok {
+						compressor = c.CompressorType
+					}
+				}
+				return invoker(ctx, method, req, reply, cc, opts...)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			dialOpts := append(tc.dialOpts, grpc.WithContextDialer(createDialer(lis)), grpc.WithInsecure(),
+				grpc.WithUnaryInterceptor(recordCompressor))
+			conn, err := grpc.DialContext(ctx, "bufnet", dialOpts...)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			// The call only succeeds if the server supports the compressor.
+			_, err = vzconnpb.NewVZConnServiceClient(conn).RegisterVizierDeployment(ctx, &vzconnpb.RegisterVizierDeploymentRequest{
+				K8sClusterUID:     "084cb5f0-ff69-11e9-a63e-42010a8a0193",
+				K8sClusterVersion: "v1.14.10-gke.27",
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedCompressor, compressor)
+		})
+	}
+}
@@ -21,6 +21,8 @@ package bridge
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -51,6 +53,13 @@ import (
 
 const k8sStateUpdatePeriod = 10 * time.Second
 
+// pluginLabel is the label on the pods of a plugin (such as the OTel or Prometheus exporters) enabled on
+// the vizier. Its value is the name of the plugin.
+const pluginLabel = "plugin"
+
+// ErrK8sStateNotCollected is returned when the state of the cluster has not been collected yet.
+var ErrK8sStateNotCollected = errors.New("k8s state has not been collected yet")
+
 const privateImageRepo = "gcr.io/pixie-oss/pixie-dev"
 const publicImageRepo = "gcr.io/pixie-oss/pixie-prod"
 
@@ -70,6 +79,7 @@ type K8sVizierInfo struct {
 	k8sStateLastUpdated  time.Time
 	numNodes             int32
 	numInstrumentedNodes int32
+	currentPluginStatus  []*cvmsgspb.PluginStatus
 	mu                   sync.Mutex
 }
 
@@ -352,6 +362,17 @@ func (v *K8sVizierInfo) UpdateK8sState() {
 	if err != nil {
 		return
 	}
+	// Get the pods of all plugins.
+	pluginPodsList, err := v.clientset.CoreV1().Pods(v.ns).List(context.Background(), metav1.ListOptions{
+		LabelSelector: pluginLabel,
+	})
+	if err != nil {
+		return
+	}
+	pluginStatuses, err := pluginStatusesFromPods(pluginPodsList.Items)
+	if err != nil {
+		return
+	}
 	// Get the count of healthy PEMs.
 	healthyPemCount := 0
 	for _, p := range pemPodsList.Items {
@@ -374,6 +395,12 @@ func (v *K8sVizierInfo) UpdateK8sState() {
 			return
 		}
 
+		// The restart counts aren't included in the proto, so get them from the pod itself.
+		restartCounts := make(map[string]int32)
+		for _, c := range p.Status.ContainerStatuses {
+			restartCounts[c.Name] = c.RestartCount
+		}
+
 		status := metadatapb.PHASE_UNKNOWN
 		msg := ""
 		containers := make([]*cvmsgspb.ContainerStatus, 0)
@@ -382,11 +409,12 @@ func (v *K8sVizierInfo) UpdateK8sState() {
 			msg = podPb.Status.Reason
 			for _, c := range podPb.Status.ContainerStatuses {
 				containers = append(containers, &cvmsgspb.ContainerStatus{
-					Name:      c.Name,
-					Message:   c.Message,
-					Reason:    c.Reason,
-					State:     c.ContainerState,
-					CreatedAt: nanosToTimestampProto(c.StartTimestampNS),
+					Name:         c.Name,
+					Message:      c.Message,
+					Reason:       c.Reason,
+					State:        c.ContainerState,
+					CreatedAt:    nanosToTimestampProto(c.StartTimestampNS),
+					RestartCount: restartCounts[c.Name],
 				})
 			}
 		}
@@ -413,6 +441,7 @@ func (v *K8sVizierInfo) UpdateK8sState() {
 					Message:   e.Message,
 					FirstTime: nanosToTimestampProto(e.FirstTimestamp.UnixNano()),
 					LastTime:  nanosToTimestampProto(e.LastTimestamp.UnixNano()),
+					Type:      e.Type,
 				})
 				start++
 			}
@@ -438,6 +467,50 @@ func (v *K8sVizierInfo) UpdateK8sState() {
 	v.k8sStateLastUpdated = now
 	v.numNodes = int32(len(nodesList.Items))
 	v.numInstrumentedNodes = int32(healthyPemCount)
+	v.currentPluginStatus = pluginStatuses
+}
+
+// pluginStatusesFromPods groups the given plugin pods by plugin, ordered by the plugin name. A plugin
+// is healthy if all of its pods are running.
+func pluginStatusesFromPods(pods []corev1.Pod) ([]*cvmsgspb.PluginStatus, error) {
+	statuses := make(map[string]*cvmsgspb.PluginStatus)
+	for _, p := range pods {
+		podPb, err := protoutils.PodToProto(&p)
+		if err != nil {
+			return nil, err
+		}
+
+		name := p.Labels[pluginLabel]
+		s, ok := statuses[name]
+		if !ok {
+			s = &cvmsgspb.PluginStatus{Name: name, Healthy: true}
+			statuses[name] = s
+		}
+
+		phase := metadatapb.PHASE_UNKNOWN
+		reason := ""
+		if podPb.Status != nil {
+			phase = podPb.Status.Phase
+			reason = podPb.Status.Reason
+		}
+		if phase == metadatapb.RUNNING || !s.Healthy {
+			continue
+		}
+		s.Healthy = false
+		s.Message = fmt.Sprintf("pod %s is %s", podPb.Metadata.Name, phase)
+		if reason != "" {
+			s.Message = fmt.Sprintf("%s: %s", s.Message, reason)
+		}
+	}
+
+	res := make([]*cvmsgspb.PluginStatus, 0, len(statuses))
+	for _, s := range statuses {
+		res = append(res, s)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res, nil
 }
 
 // GetPodStatuses gets the pod statuses and the last time they were updated.
@@ -448,6 +521,31 @@ func (v *K8sVizierInfo) GetK8sState() (map[string]*cvmsgspb.PodStatus, int32, in
 	return v.currentPodStatus, v.numNodes, v.numInstrumentedNodes, v.k8sStateLastUpdated
 }
 
+// GetPluginStatuses gets the health of each plugin enabled on the vizier, as of the last time the K8s state was updated.
+func (v *K8sVizierInfo) GetPluginStatuses() []*cvmsgspb.PluginStatus {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.currentPluginStatus
+}
+
+// GetStatus gets the status of vizier based on the last collected state of the control plane pods.
+func (v *K8sVizierInfo) GetStatus() (cvmsgspb.VizierStatus, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.k8sStateLastUpdated.IsZero() {
+		return cvmsgspb.VZ_ST_UNKNOWN, ErrK8sStateNotCollected
+	}
+	for _, p := range v.currentPodStatus {
+		// Pending pods are expected while pods are being rolled out, so they don't make vizier unhealthy.
+		if p.Status != metadatapb.RUNNING && p.Status != metadatapb.PENDING {
+			return cvmsgspb.VZ_ST_UNHEALTHY, nil
+		}
+	}
+	return cvmsgspb.VZ_ST_HEALTHY, nil
+}
+
 // ParseJobYAML parses the yaml string into a k8s job and applies the image tag and env subtitutions.
 func (v *K8sVizierInfo) ParseJobYAML(yamlStr string, imageTag map[string]string, envSubtitutions map[string]string) (*batchv1.Job, error) {
 	decode := scheme.Codecs.UniversalDeserializer().Decode
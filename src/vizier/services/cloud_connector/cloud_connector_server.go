@@ -53,6 +53,13 @@ func init() {
 	pflag.String("cluster_name", "", "The name of the user's K8s cluster")
 	pflag.String("deploy_key", "", "The deploy key for the cluster")
 	pflag.Bool("disable_auto_update", false, "Whether auto-update should be disabled")
+	pflag.Duration("heartbeat_interval", 5*time.Second, "Interval at which heartbeats are sent to Pixie Cloud")
+	pflag.Duration("heartbeat_ack_timeout", 30*time.Second, "Duration to wait for a heartbeat ack from Pixie Cloud")
+	pflag.Duration("heartbeat_initial_jitter", 5*time.Second, "Maximum random delay before the first heartbeat after connecting to Pixie Cloud, at most 20s. 0 disables the delay")
+	pflag.Int("max_heartbeat_ack_timeouts", 0, "Number of consecutive heartbeat ack timeouts tolerated before restarting the stream. 0 disables the check")
+	pflag.Int("registration_attempts", 1, "Number of registration requests sent to Pixie Cloud before restarting the stream")
+	pflag.Duration("registration_timeout", 30*time.Second, "Duration to wait for a registration ack from Pixie Cloud")
+	pflag.Int("heartbeat_buffer_size", 60, "Number of heartbeats buffered while disconnected from Pixie Cloud, which are sent once reconnected. At most 1000. 0 disables buffering")
 }
 func newVzServiceClient() (vizierpb.VizierServiceClient, error) {
 	dialOpts, err := services.GetGRPCClientDialOpts()
@@ -84,6 +91,19 @@ func (r *readinessCheck) Check() error {
 	return err
 }
 
+// Checks to see if the bridge has stopped on an error it can't recover from, so that the pod is restarted.
+type bridgeCheck struct {
+	bridge *controllers.Bridge
+}
+
+func (b *bridgeCheck) Name() string {
+	return "cloud-bridge"
+}
+
+func (b *bridgeCheck) Check() error {
+	return b.bridge.GetStatus().FatalError
+}
+
 func main() {
 	services.SetupService("cloud-connector", 50800)
 	services.SetupSSLClientFlags()
@@ -153,13 +173,19 @@ func main() {
 	// We just use the current time in nanoseconds to mark the session ID. This will let the cloud side know that
 	// the cloud connector restarted. Clock skew might make this incorrect, but we mostly want this for debugging.
 	sessionID := time.Now().UnixNano()
-	svr := controllers.New(vizierID, viper.GetString("jwt_signing_key"), deployKey, sessionID, nil, vzInfo, vzInfo, nil, checker)
-	go svr.RunStream()
+	svr, err := controllers.New(vizierID, viper.GetString("jwt_signing_key"), deployKey, sessionID, nil, vzInfo, vzInfo, nil, checker)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create cloud connector")
+	}
+	streamCtx, cancelStream := context.WithCancel(context.Background())
+	go svr.RunStream(streamCtx)
 	defer svr.Stop()
+	// Cancel any outstanding stream operations before stopping the bridge.
+	defer cancelStream()
 
 	mux := http.NewServeMux()
 	// Set up healthz endpoint.
-	healthz.RegisterDefaultChecks(mux)
+	healthz.RegisterDefaultChecks(mux, &bridgeCheck{svr})
 	// Set up readyz endpoint.
 	healthz.InstallPathHandler(mux, "/readyz", &readinessCheck{vzInfo})
 
@@ -846,7 +846,8 @@ func GetContainerUpdatesFromPod(pod *metadatapb.Pod) []*metadatapb.ContainerUpda
 
 func getResourceUpdateFromNamespace(ns *metadatapb.Namespace, uv int64) *metadatapb.ResourceUpdate {
 	return &metadatapb.ResourceUpdate{
-		UpdateVersion: uv,
+		UpdateVersion:   uv,
+		ResourceVersion: ns.Metadata.ResourceVersion,
 		Update: &metadatapb.ResourceUpdate_NamespaceUpdate{
 			NamespaceUpdate: &metadatapb.NamespaceUpdate{
 				UID:              ns.Metadata.UID,
@@ -860,7 +861,8 @@ func getResourceUpdateFromNamespace(ns *metadatapb.Namespace, uv int64) *metadat
 
 func getServiceResourceUpdateFromEndpoint(ep *metadatapb.Endpoints, uv int64, podIDs []string, podNames []string) *metadatapb.ResourceUpdate {
 	update := &metadatapb.ResourceUpdate{
-		UpdateVersion: uv,
+		UpdateVersion:   uv,
+		ResourceVersion: ep.Metadata.ResourceVersion,
 		Update: &metadatapb.ResourceUpdate_ServiceUpdate{
 			ServiceUpdate: &metadatapb.ServiceUpdate{
 				UID:              ep.Metadata.UID,
@@ -894,7 +896,8 @@ func getResourceUpdateFromPod(pod *metadatapb.Pod, uv int64) *metadatapb.Resourc
 	}
 
 	update := &metadatapb.ResourceUpdate{
-		UpdateVersion: uv,
+		UpdateVersion:   uv,
+		ResourceVersion: pod.Metadata.ResourceVersion,
 		Update: &metadatapb.ResourceUpdate_PodUpdate{
 			PodUpdate: &metadatapb.PodUpdate{
 				UID:              pod.Metadata.UID,
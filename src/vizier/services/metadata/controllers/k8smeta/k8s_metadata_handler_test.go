@@ -416,7 +416,8 @@ func TestHandler_ProcessUpdates(t *testing.T) {
 								StopTimestampNS:  6,
 							},
 						},
-						UpdateVersion: 5,
+						UpdateVersion:   5,
+						ResourceVersion: "1",
 					},
 				},
 			},
@@ -526,6 +527,7 @@ func TestHandler_ProcessUpdates(t *testing.T) {
 	assert.Equal(t, &storepb.K8SResourceUpdate{
 		Update: &metadatapb.ResourceUpdate{
 			UpdateVersion:     5,
+			ResourceVersion:   "1",
 			PrevUpdateVersion: 5,
 			Update: &metadatapb.ResourceUpdate_NamespaceUpdate{
 				NamespaceUpdate: &metadatapb.NamespaceUpdate{
@@ -631,7 +633,8 @@ func TestEndpointsUpdateProcessor_GetUpdatesToSend(t *testing.T) {
 
 	assert.Contains(t, updates, &k8smeta.OutgoingUpdate{
 		Update: &metadatapb.ResourceUpdate{
-			UpdateVersion: 2,
+			UpdateVersion:   2,
+			ResourceVersion: "1",
 			Update: &metadatapb.ResourceUpdate_ServiceUpdate{
 				ServiceUpdate: &metadatapb.ServiceUpdate{
 					UID:              "ijkl",
@@ -649,7 +652,8 @@ func TestEndpointsUpdateProcessor_GetUpdatesToSend(t *testing.T) {
 
 	assert.Contains(t, updates, &k8smeta.OutgoingUpdate{
 		Update: &metadatapb.ResourceUpdate{
-			UpdateVersion: 2,
+			UpdateVersion:   2,
+			ResourceVersion: "1",
 			Update: &metadatapb.ResourceUpdate_ServiceUpdate{
 				ServiceUpdate: &metadatapb.ServiceUpdate{
 					UID:              "ijkl",
@@ -667,7 +671,8 @@ func TestEndpointsUpdateProcessor_GetUpdatesToSend(t *testing.T) {
 
 	assert.Contains(t, updates, &k8smeta.OutgoingUpdate{
 		Update: &metadatapb.ResourceUpdate{
-			UpdateVersion: 2,
+			UpdateVersion:   2,
+			ResourceVersion: "1",
 			Update: &metadatapb.ResourceUpdate_ServiceUpdate{
 				ServiceUpdate: &metadatapb.ServiceUpdate{
 					UID:              "ijkl",
@@ -917,7 +922,8 @@ func TestPodUpdateProcessor_GetUpdatesToSend(t *testing.T) {
 
 	pu := &k8smeta.OutgoingUpdate{
 		Update: &metadatapb.ResourceUpdate{
-			UpdateVersion: 3,
+			UpdateVersion:   3,
+			ResourceVersion: "1",
 			Update: &metadatapb.ResourceUpdate_PodUpdate{
 				PodUpdate: &metadatapb.PodUpdate{
 					UID:              "ijkl",
@@ -1170,7 +1176,8 @@ func TestNamespaceUpdateProcessor_GetUpdatesToSend(t *testing.T) {
 
 	nsUpdate := &k8smeta.OutgoingUpdate{
 		Update: &metadatapb.ResourceUpdate{
-			UpdateVersion: 2,
+			UpdateVersion:   2,
+			ResourceVersion: "1",
 			Update: &metadatapb.ResourceUpdate_NamespaceUpdate{
 				NamespaceUpdate: &metadatapb.NamespaceUpdate{
 					UID:              "ijkl",
@@ -27,6 +27,7 @@ import (
 
 	"github.com/gofrs/uuid"
 	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
 	"github.com/nats-io/nats.go"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
@@ -47,6 +48,7 @@ import (
 	"px.dev/pixie/src/vizier/services/query_broker/querybrokerenv"
 	"px.dev/pixie/src/vizier/services/query_broker/querybrokerpb"
 	"px.dev/pixie/src/vizier/services/query_broker/tracker"
+	"px.dev/pixie/src/vizier/utils/messagebus"
 )
 
 const healthCheckInterval = 5 * time.Second
@@ -430,6 +432,8 @@ func (s *Server) ExecuteScript(req *vizierpb.ExecuteScriptRequest, srv vizierpb.
 	wg.Add(1)
 
 	var sendErr error
+	// Whether the script failed with an error status, such as a compilation error.
+	failed := false
 	go func() {
 		var err error
 		for {
@@ -438,6 +442,9 @@ func (s *Server) ExecuteScript(req *vizierpb.ExecuteScriptRequest, srv vizierpb.
 				wg.Done()
 				return
 			case result := <-resultStream:
+				if result.Status != nil && result.Status.Code != int32(codes.OK) {
+					failed = true
+				}
 				err = srv.Send(result)
 				if err != nil {
 					sendErr = err
@@ -456,9 +463,31 @@ func (s *Server) ExecuteScript(req *vizierpb.ExecuteScriptRequest, srv vizierpb.
 	if sendErr != nil {
 		return err
 	}
+	if !failed {
+		s.publishScriptRun(time.Now())
+	}
 	return nil
 }
 
+// publishScriptRun lets the rest of Vizier, such as the cloud connector, know that a script finished running
+// successfully at the given time.
+func (s *Server) publishScriptRun(t time.Time) {
+	ts, err := types.TimestampProto(t)
+	if err != nil {
+		log.WithError(err).Error("Failed to convert script run time")
+		return
+	}
+	b, err := ts.Marshal()
+	if err != nil {
+		log.WithError(err).Error("Failed to marshal script run time")
+		return
+	}
+	err = s.natsConn.Publish(messagebus.ScriptRunTopic, b)
+	if err != nil {
+		log.WithError(err).Error("Failed to publish script run")
+	}
+}
+
 // TransferResultChunk implements the API that allows the query broker receive streamed results
 // from Carnot instances.
 func (s *Server) TransferResultChunk(srv carnotpb.ResultSinkService_TransferResultChunkServer) error {
@@ -23,10 +23,13 @@ import (
 	"fmt"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
 	"github.com/golang/mock/gomock"
+	"github.com/nats-io/nats.go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -44,6 +47,7 @@ import (
 	mock_controllers "px.dev/pixie/src/vizier/services/query_broker/controllers/mock"
 	"px.dev/pixie/src/vizier/services/query_broker/querybrokerenv"
 	"px.dev/pixie/src/vizier/services/query_broker/tracker"
+	"px.dev/pixie/src/vizier/utils/messagebus"
 )
 
 const singleAgentDistributedState = `
@@ -566,6 +570,13 @@ func TestExecuteScript_Success(t *testing.T) {
 	s, err := controllers.NewServerWithForwarderAndPlanner(env, &at, rf, nil, nil, nc, planner)
 	require.NoError(t, err)
 
+	scriptRunCh := make(chan *nats.Msg, 1)
+	scriptRunSub, err := nc.ChanSubscribe(messagebus.ScriptRunTopic, scriptRunCh)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, scriptRunSub.Unsubscribe())
+	}()
+
 	srv := mock_vizierpb.NewMockVizierService_ExecuteScriptServer(ctrl)
 	auth := authcontext.New()
 	ctx := authcontext.NewContext(context.Background(), auth)
@@ -602,6 +613,16 @@ func TestExecuteScript_Success(t *testing.T) {
 
 	assert.Equal(t, fakeResult1, resps[2])
 	assert.Equal(t, fakeResult2, resps[3])
+
+	// The successful run should be published.
+	select {
+	case msg := <-scriptRunCh:
+		runAt := &types.Timestamp{}
+		require.NoError(t, runAt.Unmarshal(msg.Data))
+		assert.NotZero(t, runAt.Seconds)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for script run")
+	}
 }
 
 // TestExecuteScript_PlannerErrorResult makes sure that compiler error handling is done well.
@@ -645,6 +666,13 @@ func TestExecuteScript_PlannerErrorResult(t *testing.T) {
 	s, err := controllers.NewServerWithForwarderAndPlanner(env, &at, rf, nil, nil, nc, planner)
 	require.NoError(t, err)
 
+	scriptRunCh := make(chan *nats.Msg, 1)
+	scriptRunSub, err := nc.ChanSubscribe(messagebus.ScriptRunTopic, scriptRunCh)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, scriptRunSub.Unsubscribe())
+	}()
+
 	srv := mock_vizierpb.NewMockVizierService_ExecuteScriptServer(ctrl)
 	auth := authcontext.New()
 	ctx := authcontext.NewContext(context.Background(), auth)
@@ -685,6 +713,13 @@ func TestExecuteScript_PlannerErrorResult(t *testing.T) {
 			},
 		},
 	}, resp.Status.ErrorDetails[1])
+
+	// Scripts which failed to compile aren't published as script runs.
+	select {
+	case <-scriptRunCh:
+		t.Fatal("Got script run for a script which failed to compile")
+	case <-time.After(100 * time.Millisecond):
+	}
 }
 
 func TestExecuteScript_ErrorInStatusResult(t *testing.T) {
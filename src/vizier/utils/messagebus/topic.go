@@ -34,6 +34,10 @@ const (
 	v2cTopicPrefix = "v2c"
 )
 
+// ScriptRunTopic is the topic on which the query broker publishes the time at which each script finished
+// running successfully, as a google.protobuf.Timestamp.
+const ScriptRunTopic = "ScriptRun"
+
 // V2CTopic returns the topic used in the Vizier NATS domain to send messages from Vizier to Cloud.
 func V2CTopic(topic string) string {
 	return fmt.Sprintf("%s.%s", v2cTopicPrefix, topic)